@@ -9,6 +9,7 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	router "github.com/gorilla/mux"
 	"github.com/journeymidnight/yig/api"
+	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
@@ -46,6 +47,19 @@ type usageJson struct {
 	Usage int64
 }
 
+type keyJson struct {
+	Key common.Credential
+}
+
+type quotaJson struct {
+	Quota common.Quota
+}
+
+type throttleJson struct {
+	Throttle   helper.ThrottleLimit
+	Overridden bool
+}
+
 var adminServer *adminServerConfig
 
 type handlerFunc func(http.Handler) http.Handler
@@ -127,6 +141,269 @@ func getCacheHitRatio(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func startMigration(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	fsid, _ := claims["fsid"].(string)
+	pool, _ := claims["pool"].(string)
+	targetFsid, _ := claims["target_fsid"].(string)
+	targetPool, _ := claims["target_pool"].(string)
+	helper.Logger.Info("enter startMigration", fsid, pool, targetFsid, targetPool)
+
+	err := adminServer.Yig.StartMigration(fsid, pool, targetFsid, targetPool)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getMigration(w, r)
+}
+
+func getMigration(w http.ResponseWriter, r *http.Request) {
+	progress, err := adminServer.Yig.GetMigrationProgress()
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	b, _ := json.Marshal(progress)
+	w.Write(b)
+	return
+}
+
+func cancelMigration(w http.ResponseWriter, r *http.Request) {
+	helper.Logger.Info("enter cancelMigration")
+	err := adminServer.Yig.CancelMigration()
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getMigration(w, r)
+}
+
+func startStorageClassJob(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	bucket, _ := claims["bucket"].(string)
+	prefix, _ := claims["prefix"].(string)
+	storageClass, _ := claims["storage_class"].(string)
+	helper.Logger.Info("enter startStorageClassJob", bucket, prefix, storageClass)
+
+	err := adminServer.Yig.StartStorageClassJob(bucket, prefix, storageClass)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getStorageClassJob(w, r)
+}
+
+func getStorageClassJob(w http.ResponseWriter, r *http.Request) {
+	progress, err := adminServer.Yig.GetStorageClassJobProgress()
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	b, _ := json.Marshal(progress)
+	w.Write(b)
+	return
+}
+
+func cancelStorageClassJob(w http.ResponseWriter, r *http.Request) {
+	helper.Logger.Info("enter cancelStorageClassJob")
+	err := adminServer.Yig.CancelStorageClassJob()
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getStorageClassJob(w, r)
+}
+
+func writeKey(w http.ResponseWriter, credential common.Credential) {
+	b, _ := json.Marshal(keyJson{Key: credential})
+	w.Write(b)
+}
+
+func createUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	displayName, _ := claims["display_name"].(string)
+	helper.Logger.Info("enter createUser", uid)
+
+	err := iam.CreateUser(uid, displayName)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getUserInfo(w, r)
+}
+
+func deleteUser(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	helper.Logger.Info("enter deleteUser", uid)
+
+	err := iam.DeleteUser(uid)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+}
+
+func createKey(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	helper.Logger.Info("enter createKey", uid)
+
+	credential, err := iam.CreateKey(uid)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	writeKey(w, credential)
+}
+
+func setKeyStatus(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	accessKey, _ := claims["access_key"].(string)
+	status, _ := claims["status"].(string)
+	helper.Logger.Info("enter setKeyStatus", accessKey, status)
+
+	err := iam.SetKeyStatus(accessKey, status)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+}
+
+func rotateKey(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	accessKey, _ := claims["access_key"].(string)
+	helper.Logger.Info("enter rotateKey", accessKey)
+
+	credential, err := iam.RotateKey(accessKey)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	writeKey(w, credential)
+}
+
+func deleteKey(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	accessKey, _ := claims["access_key"].(string)
+	helper.Logger.Info("enter deleteKey", accessKey)
+
+	err := iam.DeleteKey(accessKey)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+}
+
+func getUserQuota(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+
+	quota, err := adminServer.Yig.GetUserQuota(uid)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	b, _ := json.Marshal(quotaJson{Quota: quota})
+	w.Write(b)
+}
+
+func setUserQuota(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	// numbers in JWT claims are decoded as float64
+	maxBytes, _ := claims["max_bytes"].(float64)
+	maxObjects, _ := claims["max_objects"].(float64)
+	helper.Logger.Info("enter setUserQuota", uid, maxBytes, maxObjects)
+
+	err := adminServer.Yig.SetUserQuota(uid, common.Quota{
+		MaxBytes:   int64(maxBytes),
+		MaxObjects: int64(maxObjects),
+	})
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getUserQuota(w, r)
+}
+
+func getBucketQuota(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	bucketName, _ := claims["bucket"].(string)
+
+	quota, err := adminServer.Yig.GetBucketQuota(bucketName)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	b, _ := json.Marshal(quotaJson{Quota: quota})
+	w.Write(b)
+}
+
+func setBucketQuota(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	bucketName, _ := claims["bucket"].(string)
+	maxBytes, _ := claims["max_bytes"].(float64)
+	maxObjects, _ := claims["max_objects"].(float64)
+	helper.Logger.Info("enter setBucketQuota", bucketName, maxBytes, maxObjects)
+
+	err := adminServer.Yig.SetBucketQuota(bucketName, common.Quota{
+		MaxBytes:   int64(maxBytes),
+		MaxObjects: int64(maxObjects),
+	})
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getBucketQuota(w, r)
+}
+
+func getUserThrottle(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+
+	limit, overridden := api.GetUserThrottle(uid)
+	b, _ := json.Marshal(throttleJson{Throttle: limit, Overridden: overridden})
+	w.Write(b)
+}
+
+// Overrides are saved in meta storage, other instances apply them in a minute
+func setUserThrottle(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	requests, _ := claims["requests_per_second"].(float64)
+	upload, _ := claims["upload_bytes_per_second"].(float64)
+	download, _ := claims["download_bytes_per_second"].(float64)
+	helper.Logger.Info("enter setUserThrottle", uid, requests, upload, download)
+
+	if uid == "" || requests < 0 || upload < 0 || download < 0 {
+		api.WriteErrorResponse(w, r, ErrInvalidThrottle)
+		return
+	}
+	err := api.SetUserThrottle(adminServer.Yig.MetaStorage, uid, helper.ThrottleLimit{
+		RequestsPerSecond:      requests,
+		UploadBytesPerSecond:   int64(upload),
+		DownloadBytesPerSecond: int64(download),
+	})
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+		return
+	}
+	getUserThrottle(w, r)
+}
+
+func deleteUserThrottle(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value("claims").(jwt.MapClaims)
+	uid, _ := claims["uid"].(string)
+	helper.Logger.Info("enter deleteUserThrottle", uid)
+
+	err := api.DeleteUserThrottle(adminServer.Yig.MetaStorage, uid)
+	if err != nil {
+		api.WriteErrorResponse(w, r, err)
+	}
+}
+
 var handlerFns = []handlerFunc{
 	//	SetJwtMiddlewareHandler,
 }
@@ -146,9 +423,28 @@ func configureAdminHandler() http.Handler {
 	admin := apiRouter.PathPrefix("/admin").Subrouter()
 	admin.Methods("GET").Path("/usage").HandlerFunc(SetJwtMiddlewareFunc(getUsage))
 	admin.Methods("GET").Path("/user").HandlerFunc(SetJwtMiddlewareFunc(getUserInfo))
+	admin.Methods("POST").Path("/user").HandlerFunc(SetJwtMiddlewareFunc(createUser))
+	admin.Methods("DELETE").Path("/user").HandlerFunc(SetJwtMiddlewareFunc(deleteUser))
+	admin.Methods("GET").Path("/user/quota").HandlerFunc(SetJwtMiddlewareFunc(getUserQuota))
+	admin.Methods("PUT").Path("/user/quota").HandlerFunc(SetJwtMiddlewareFunc(setUserQuota))
+	admin.Methods("GET").Path("/user/throttle").HandlerFunc(SetJwtMiddlewareFunc(getUserThrottle))
+	admin.Methods("PUT").Path("/user/throttle").HandlerFunc(SetJwtMiddlewareFunc(setUserThrottle))
+	admin.Methods("DELETE").Path("/user/throttle").HandlerFunc(SetJwtMiddlewareFunc(deleteUserThrottle))
+	admin.Methods("POST").Path("/key").HandlerFunc(SetJwtMiddlewareFunc(createKey))
+	admin.Methods("PUT").Path("/key").HandlerFunc(SetJwtMiddlewareFunc(setKeyStatus))
+	admin.Methods("DELETE").Path("/key").HandlerFunc(SetJwtMiddlewareFunc(deleteKey))
+	admin.Methods("POST").Path("/key/rotate").HandlerFunc(SetJwtMiddlewareFunc(rotateKey))
 	admin.Methods("GET").Path("/bucket").HandlerFunc(SetJwtMiddlewareFunc(getBucketInfo))
+	admin.Methods("GET").Path("/bucket/quota").HandlerFunc(SetJwtMiddlewareFunc(getBucketQuota))
+	admin.Methods("PUT").Path("/bucket/quota").HandlerFunc(SetJwtMiddlewareFunc(setBucketQuota))
 	admin.Methods("GET").Path("/object").HandlerFunc(SetJwtMiddlewareFunc(getObjectInfo))
 	admin.Methods("GET").Path("/cachehit").HandlerFunc(SetJwtMiddlewareFunc(getCacheHitRatio))
+	admin.Methods("POST").Path("/migration").HandlerFunc(SetJwtMiddlewareFunc(startMigration))
+	admin.Methods("GET").Path("/migration").HandlerFunc(SetJwtMiddlewareFunc(getMigration))
+	admin.Methods("DELETE").Path("/migration").HandlerFunc(SetJwtMiddlewareFunc(cancelMigration))
+	admin.Methods("POST").Path("/storageclass").HandlerFunc(SetJwtMiddlewareFunc(startStorageClassJob))
+	admin.Methods("GET").Path("/storageclass").HandlerFunc(SetJwtMiddlewareFunc(getStorageClassJob))
+	admin.Methods("DELETE").Path("/storageclass").HandlerFunc(SetJwtMiddlewareFunc(cancelStorageClassJob))
 
 	metrics := NewMetrics("yig")
 	registry := prometheus.NewRegistry()
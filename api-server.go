@@ -68,6 +68,8 @@ func configureServerHandler(c *ServerConfig) http.Handler {
 		// graceful shutdown
 		api.SetGracefulStopHandler,
 		// Add new handlers here.
+		// Per-tenant request rate and bandwidth limits.
+		api.SetThrottleHandler,
 
 		api.SetLogHandler,
 
@@ -24,6 +24,8 @@ type ResponseRecorder struct {
 	targetStorageClass string
 	bucketLogging      bool
 	cdn_request        bool
+
+	throttle *requestThrottle // slows down writes if download bandwidth is limited
 }
 
 const timeLayoutStr = "2006-01-02 15:04:05"
@@ -39,6 +41,13 @@ func (r *ResponseRecorder) Flush() {
 	return
 }
 
+func (r *ResponseRecorder) Write(p []byte) (int, error) {
+	if r.throttle != nil {
+		r.throttle.write(len(p))
+	}
+	return r.ResponseWriter.Write(p)
+}
+
 type AccessLogHandler struct {
 	handler          http.Handler
 	responseRecorder *ResponseRecorder
@@ -65,6 +74,12 @@ func (a AccessLogHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		elems["last_modified_time"] = objectLastModifiedTime
 	}
 	a.notify(elems)
+
+	// deliver to target bucket of bucket logging
+	if logDelivery != nil && loggingEnabled(ctx.BucketInfo) {
+		logReplacer := NewReplacer(r, a.responseRecorder, "-")
+		logDelivery.add(ctx.BucketInfo, logReplacer.Replace(ServerAccessLogFormat))
+	}
 }
 
 func (a AccessLogHandler) notify(elems map[string]string) {
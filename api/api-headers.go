@@ -21,6 +21,7 @@ import (
 	"encoding/xml"
 	"net/http"
 	"strconv"
+	"time"
 
 	. "github.com/journeymidnight/yig/api/datatype"
 	meta "github.com/journeymidnight/yig/meta/types"
@@ -61,6 +62,20 @@ func SetObjectHeaders(w http.ResponseWriter, object *meta.Object, contentRange *
 
 	w.Header().Set("X-Amz-Object-Type", object.ObjectTypeToString())
 	w.Header().Set("X-Amz-Storage-Class", object.StorageClass.ToString())
+	if len(object.Tags) != 0 {
+		w.Header().Set(TaggingCountHeader, strconv.Itoa(len(object.Tags)))
+	}
+	if object.ReplicationStatus != "" {
+		w.Header().Set(ReplicationStatusHeader, object.ReplicationStatus)
+	}
+	if object.LockMode != "" {
+		w.Header().Set("X-Amz-Object-Lock-Mode", object.LockMode)
+		w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date",
+			object.RetainUntilDate.UTC().Format(time.RFC3339))
+	}
+	if object.LegalHold {
+		w.Header().Set("X-Amz-Object-Lock-Legal-Hold", ObjectLockLegalHoldOn)
+	}
 	w.Header().Set("Content-Length", strconv.FormatInt(object.Size, 10))
 	if object.Type == meta.ObjectTypeAppendable {
 		w.Header().Set("X-Amz-Next-Append-Position", strconv.FormatInt(object.Size, 10))
@@ -76,3 +91,13 @@ func SetObjectHeaders(w http.ResponseWriter, object *meta.Object, contentRange *
 
 	w.WriteHeader(statusCode)
 }
+
+// Sets x-amz-restore header of a glacier object with restore requested
+func SetRestoreHeader(w http.ResponseWriter, freezer *meta.Freezer) {
+	if freezer.Status == meta.ObjectHasRestored {
+		w.Header().Set("x-amz-restore", `ongoing-request="false", expiry-date="`+
+			freezer.ExpireTime().Format(http.TimeFormat)+`"`)
+	} else {
+		w.Header().Set("x-amz-restore", `ongoing-request="true"`)
+	}
+}
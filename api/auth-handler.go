@@ -4,11 +4,15 @@ import (
 	"net"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/journeymidnight/yig/api/datatype/policy"
+	"github.com/journeymidnight/yig/api/datatype/policy/condition"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
 	meta "github.com/journeymidnight/yig/meta/types"
 	"github.com/journeymidnight/yig/signature"
@@ -36,8 +40,8 @@ func checkRequestAuth(r *http.Request, action policy.Action) (c common.Credentia
 			return c, err
 		} else {
 			helper.Logger.Info("Credential:", c)
-			// check bucket policy
-			isAllow, err := IsBucketPolicyAllowed(c.UserId, ctx.BucketInfo, r, action, ctx.ObjectName)
+			// check identity policies and bucket policy
+			isAllow, err := IsPolicyAllowed(c, ctx.BucketInfo, r, action, ctx.ObjectName)
 			c.AllowOtherUserAccess = isAllow
 			return c, err
 		}
@@ -49,6 +53,89 @@ func checkRequestAuth(r *http.Request, action policy.Action) (c common.Credentia
 	return c, ErrAccessDenied
 }
 
+// IsPolicyAllowed - checks identity policies of the user along with bucket policy,
+// an explicit deny in either of them denies the request, otherwise an allow in
+// either of them grants access to buckets and objects of other users.
+// For temporary credentials, the request should also be allowed by session policy.
+func IsPolicyAllowed(credential common.Credential, bucket *meta.Bucket, r *http.Request, action policy.Action, objectName string) (allow bool, err error) {
+	if bucket == nil {
+		return false, ErrAccessDenied
+	}
+	userId := credential.UserId
+	args := policy.Args{
+		AccountName:     userId,
+		Action:          action,
+		BucketName:      bucket.Name,
+		ConditionValues: getObjectConditionValues(r, objectName),
+		IsOwner:         false,
+		ObjectName:      objectName,
+	}
+	if !isSessionAllowed(credential, args) {
+		return false, ErrAccessDenied
+	}
+	policies, err := iam.GetIdentityPolicies(userId)
+	if err != nil {
+		return false, err
+	}
+	identityResult := policy.IsAllowedByPolicies(policies, args)
+	if identityResult == policy.PolicyDeny {
+		return false, ErrAccessDenied
+	}
+	allow, err = IsBucketPolicyAllowed(userId, bucket, r, action, objectName)
+	if err != nil {
+		return false, err
+	}
+	return allow || identityResult == policy.PolicyAllow, nil
+}
+
+// checkIdentityAuth - verifies signature of the request for APIs which are not
+// granted by bucket policy, e.g. bucket configurations and listing buckets.
+// Access is still checked by owner or ACL afterwards, but an explicit deny in
+// identity policies of the user denies the request, and temporary credentials
+// should be allowed by session policy for the action.
+func checkIdentityAuth(r *http.Request, action policy.Action) (c common.Credential, err error) {
+	c, err = signature.IsReqAuthenticated(r)
+	if err != nil {
+		return c, err
+	}
+	ctx := getRequestContext(r)
+	return c, checkIdentityPolicy(c, r, action, ctx.BucketName, ctx.ObjectName)
+}
+
+// checkIdentityPolicy - checks policies of the credential for the action on
+// bucket and object, used when objects are not known by request context,
+// e.g. keys of DeleteMultipleObjects
+func checkIdentityPolicy(credential common.Credential, r *http.Request, action policy.Action,
+	bucketName, objectName string) error {
+
+	args := policy.Args{
+		AccountName:     credential.UserId,
+		Action:          action,
+		BucketName:      bucketName,
+		ConditionValues: getObjectConditionValues(r, objectName),
+		IsOwner:         false,
+		ObjectName:      objectName,
+	}
+	if !isSessionAllowed(credential, args) {
+		return ErrAccessDenied
+	}
+	policies, err := iam.GetIdentityPolicies(credential.UserId)
+	if err != nil {
+		return err
+	}
+	if policy.IsAllowedByPolicies(policies, args) == policy.PolicyDeny {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// isSessionAllowed - temporary credentials with session policy are only
+// allowed to do what the session policy allows
+func isSessionAllowed(credential common.Credential, args policy.Args) bool {
+	return credential.SessionPolicy == nil ||
+		credential.SessionPolicy.IsAllowed(args) == policy.PolicyAllow
+}
+
 func IsBucketPolicyAllowed(userId string, bucket *meta.Bucket, r *http.Request, action policy.Action, objectName string) (allow bool, err error) {
 	if bucket == nil {
 		return false, ErrAccessDenied
@@ -61,7 +148,7 @@ func IsBucketPolicyAllowed(userId string, bucket *meta.Bucket, r *http.Request,
 		AccountName:     userId,
 		Action:          action,
 		BucketName:      bucket.Name,
-		ConditionValues: getConditionValues(r, ""),
+		ConditionValues: getObjectConditionValues(r, objectName),
 		IsOwner:         false,
 		ObjectName:      objectName,
 	})
@@ -75,6 +162,19 @@ func IsBucketPolicyAllowed(userId string, bucket *meta.Bucket, r *http.Request,
 
 }
 
+// getObjectConditionValues - returns condition values of request along with
+// tags of existing object for s3:ExistingObjectTag/<key>
+func getObjectConditionValues(r *http.Request, objectName string) map[string][]string {
+	values := getConditionValues(r, "")
+	if ctx, ok := r.Context().Value(RequestContextKey).(RequestContext); ok &&
+		ctx.ObjectInfo != nil && ctx.ObjectInfo.Name == objectName {
+		for k, v := range ctx.ObjectInfo.Tags {
+			values[condition.NewExistingObjectTagKey(k).Name()] = []string{v}
+		}
+	}
+	return values
+}
+
 func getConditionValues(request *http.Request, locationConstraint string) map[string][]string {
 	args := make(map[string][]string)
 
@@ -96,6 +196,29 @@ func getConditionValues(request *http.Request, locationConstraint string) map[st
 
 	args["SourceIp"] = []string{GetSourceIP(request)}
 
+	now := time.Now().UTC()
+	args["CurrentTime"] = []string{now.Format(time.RFC3339)}
+	args["EpochTime"] = []string{strconv.FormatInt(now.Unix(), 10)}
+	args["SecureTransport"] = []string{strconv.FormatBool(isSecureTransport(request))}
+	args["UserAgent"] = []string{request.UserAgent()}
+
+	switch signature.GetRequestAuthType(request) {
+	case signature.AuthTypeSignedV2:
+		args["signatureversion"] = []string{"AWS"}
+		args["authType"] = []string{"REST-HEADER"}
+	case signature.AuthTypePresignedV2:
+		args["signatureversion"] = []string{"AWS"}
+		args["authType"] = []string{"REST-QUERY-STRING"}
+	case signature.AuthTypeSignedV4, signature.AuthTypeStreamingSigned:
+		args["signatureversion"] = []string{"AWS4-HMAC-SHA256"}
+		args["authType"] = []string{"REST-HEADER"}
+	case signature.AuthTypePresignedV4:
+		args["signatureversion"] = []string{"AWS4-HMAC-SHA256"}
+		args["authType"] = []string{"REST-QUERY-STRING"}
+	case signature.AuthTypePostPolicy:
+		args["authType"] = []string{"POST"}
+	}
+
 	if locationConstraint != "" {
 		args["LocationConstraint"] = []string{locationConstraint}
 	}
@@ -120,6 +243,50 @@ var (
 
 )
 
+// isSecureTransport - whether request is sent through TLS, X-Forwarded-Proto
+// is only trusted from proxies in config since clients could set it as well
+func isSecureTransport(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	return strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") &&
+		isTrustedProxy(r.RemoteAddr)
+}
+
+func isTrustedProxy(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range helper.CONFIG.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if ip.Equal(net.ParseIP(proxy)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Address of the client, forwarding headers are only trusted from proxies in
+// config since clients could forge them
+func getTrustedSourceIP(r *http.Request) string {
+	if isTrustedProxy(r.RemoteAddr) {
+		return GetSourceIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // GetSourceIP retrieves the IP from the X-Forwarded-For, X-Real-IP and RFC7239
 // Forwarded headers (in that order), falls back to r.RemoteAddr when all
 // else fails.
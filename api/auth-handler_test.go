@@ -0,0 +1,43 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/journeymidnight/yig/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSecureTransport(t *testing.T) {
+	helper.CONFIG.TrustedProxies = []string{"10.0.0.1", "10.1.0.0/16"}
+	defer func() {
+		helper.CONFIG.TrustedProxies = nil
+	}()
+	var testcase = [...]struct {
+		remoteAddr string
+		proto      string
+		tls        bool
+		expected   bool
+	}{
+		{"192.168.0.1:1234", "", true, true},
+		{"192.168.0.1:1234", "", false, false},
+		// only proxies are trusted to tell the protocol
+		{"192.168.0.1:1234", "https", false, false},
+		{"10.0.0.1:1234", "https", false, true},
+		{"10.1.2.3:1234", "HTTPS", false, true},
+		{"10.2.0.1:1234", "https", false, false},
+		{"10.0.0.1:1234", "http", false, false},
+	}
+	for _, c := range testcase {
+		r := httptest.NewRequest("GET", "/tiger", nil)
+		r.RemoteAddr = c.remoteAddr
+		if c.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", c.proto)
+		}
+		if c.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+		assert.Equal(t, c.expected, isSecureTransport(r), c.remoteAddr, c.proto, c.tls)
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/api/datatype/policy"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/iam/common"
 	"github.com/journeymidnight/yig/signature"
@@ -21,7 +22,7 @@ func (api ObjectAPIHandlers) PutBucketEncryption(w http.ResponseWriter, r *http.
 		WriteErrorResponse(w, r, ErrAccessDenied)
 		return
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutEncryptionConfigurationAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -73,7 +74,7 @@ func (api ObjectAPIHandlers) GetBucketEncryption(w http.ResponseWriter, r *http.
 		WriteErrorResponse(w, r, ErrAccessDenied)
 		return
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetEncryptionConfigurationAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -120,7 +121,7 @@ func (api ObjectAPIHandlers) DeleteBucketEncryption(w http.ResponseWriter, r *ht
 		WriteErrorResponse(w, r, ErrAccessDenied)
 		return
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutEncryptionConfigurationAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/gorilla/mux"
 	. "github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/api/datatype/policy"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/iam/common"
@@ -51,7 +52,7 @@ func (api ObjectAPIHandlers) GetBucketLocationHandler(w http.ResponseWriter, r *
 		break
 	case signature.AuthTypeSignedV4, signature.AuthTypePresignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetBucketLocationAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -96,7 +97,7 @@ func (api ObjectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.ListBucketMultipartUploadsAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -148,7 +149,7 @@ func (api ObjectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 		break
 	case signature.AuthTypeSignedV4, signature.AuthTypePresignedV4,
 		signature.AuthTypeSignedV2, signature.AuthTypePresignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.ListBucketAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -193,7 +194,7 @@ func (api ObjectAPIHandlers) ListVersionedObjectsHandler(w http.ResponseWriter,
 		break
 	case signature.AuthTypeSignedV4, signature.AuthTypePresignedV4,
 		signature.AuthTypeSignedV2, signature.AuthTypePresignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.ListBucketVersionsAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -232,7 +233,7 @@ func (api ObjectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 	// List buckets does not support bucket policies.
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.ListAllMyBucketsAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -315,8 +316,14 @@ func (api ObjectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 	var deletedObjects []ObjectIdentifier
 	// Loop through all the objects and delete them sequentially.
 	for _, object := range deleteObjects.Objects {
-		result, err := api.ObjectAPI.DeleteObject(bucket, object.ObjectName,
-			object.VersionId, credential)
+		// keys are not known by request context, check policies for each of them
+		err := checkIdentityPolicy(credential, r, policy.DeleteObjectAction, bucket, object.ObjectName)
+		var result DeleteObjectResult
+		if err == nil {
+			bypassGovernance := isGovernanceBypassAllowed(r, credential, object.ObjectName)
+			result, err = api.ObjectAPI.DeleteObject(bucket, object.ObjectName,
+				object.VersionId, bypassGovernance, credential)
+		}
 		if err == nil {
 			deletedObjects = append(deletedObjects, ObjectIdentifier{
 				ObjectName:   object.ObjectName,
@@ -325,6 +332,10 @@ func (api ObjectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 				DeleteMarkerVersionId: helper.Ternary(result.DeleteMarker,
 					result.VersionId, "").(string),
 			})
+			sendEventNotification(r, deleteEventName(result.DeleteMarker), credential.UserId, EventObject{
+				Key:       object.ObjectName,
+				VersionId: result.VersionId,
+			})
 		} else {
 			logger.Error("Unable to delete object:", err)
 			apiErrorCode, ok := err.(ApiErrorCode)
@@ -367,7 +378,7 @@ func (api ObjectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 	}
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.CreateBucketAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -407,7 +418,7 @@ func (api ObjectAPIHandlers) PutBucketLoggingHandler(w http.ResponseWriter, r *h
 	ctx := getRequestContext(r)
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutBucketLoggingAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -463,7 +474,7 @@ func (api ObjectAPIHandlers) GetBucketLoggingHandler(w http.ResponseWriter, r *h
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetBucketLoggingAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -509,27 +520,19 @@ func (api ObjectAPIHandlers) PutBucketLifeCycleHandler(w http.ResponseWriter, r
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutLifecycleConfigurationAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
 
-	var lc Lifecycle
-	lcBuffer, err := ioutil.ReadAll(io.LimitReader(r.Body, 4096))
-	if err != nil {
-		logger.Error("Unable to read lifecycle body:", err)
-		WriteErrorResponse(w, r, ErrInvalidLc)
-		return
-	}
-	err = xml.Unmarshal(lcBuffer, &lc)
+	lc, err := ParseLifecycleConfig(io.LimitReader(r.Body, MaxLifecycleConfigurationSize+1))
 	if err != nil {
-		logger.Error("Unable to parse lifecycle XML body:", err)
-		WriteErrorResponse(w, r, ErrInternalError)
+		WriteErrorResponse(w, r, err)
 		return
 	}
 
 	logger.Info("Setting lifecycle:", lc)
-	err = api.ObjectAPI.SetBucketLifecycle(bucket, lc, credential)
+	err = api.ObjectAPI.SetBucketLifecycle(bucket, *lc, credential)
 	if err != nil {
 		logger.Error(err, "Unable to set lifecycle for bucket:", err)
 		WriteErrorResponse(w, r, err)
@@ -556,7 +559,7 @@ func (api ObjectAPIHandlers) GetBucketLifeCycleHandler(w http.ResponseWriter, r
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetLifecycleConfigurationAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -591,7 +594,7 @@ func (api ObjectAPIHandlers) DelBucketLifeCycleHandler(w http.ResponseWriter, r
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutLifecycleConfigurationAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -614,7 +617,7 @@ func (api ObjectAPIHandlers) PutBucketAclHandler(w http.ResponseWriter, r *http.
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutBucketAclAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -670,7 +673,7 @@ func (api ObjectAPIHandlers) GetBucketAclHandler(w http.ResponseWriter, r *http.
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetBucketAclAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -705,7 +708,7 @@ func (api ObjectAPIHandlers) PutBucketCorsHandler(w http.ResponseWriter, r *http
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutBucketCORSAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -751,7 +754,7 @@ func (api ObjectAPIHandlers) DeleteBucketCorsHandler(w http.ResponseWriter, r *h
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutBucketCORSAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -773,7 +776,7 @@ func (api ObjectAPIHandlers) GetBucketCorsHandler(w http.ResponseWriter, r *http
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.GetBucketCORSAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -798,6 +801,100 @@ func (api ObjectAPIHandlers) GetBucketCorsHandler(w http.ResponseWriter, r *http
 	WriteSuccessResponse(w, corsBuffer)
 }
 
+func (api ObjectAPIHandlers) PutBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	logger := ContextLogger(r)
+	vars := mux.Vars(r)
+	bucketName := vars["bucket"]
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkIdentityAuth(r, policy.PutBucketTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	if !contains(r.TransferEncoding, "chunked") {
+		if r.ContentLength == -1 || r.ContentLength == 0 {
+			WriteErrorResponse(w, r, ErrMissingContentLength)
+			return
+		}
+		// If Content-Length is greater than maximum allowed tagging size.
+		if r.ContentLength > MaxTaggingConfigurationSize {
+			WriteErrorResponse(w, r, ErrEntityTooLarge)
+			return
+		}
+	}
+
+	tags, err := ParseTagging(io.LimitReader(r.Body, MaxTaggingConfigurationSize+1), MaxBucketTagsCount)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	err = api.ObjectAPI.SetBucketTagging(bucketName, tags, credential)
+	if err != nil {
+		logger.Error("Unable to set tagging for bucket", bucketName, "error:", err)
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "PutBucketTagging"
+	WriteSuccessNoContent(w)
+}
+
+func (api ObjectAPIHandlers) DeleteBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucketName := vars["bucket"]
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkIdentityAuth(r, policy.PutBucketTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	err = api.ObjectAPI.DeleteBucketTagging(bucketName, credential)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "DeleteBucketTagging"
+	WriteSuccessNoContent(w)
+}
+
+func (api ObjectAPIHandlers) GetBucketTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	logger := ContextLogger(r)
+	vars := mux.Vars(r)
+	bucketName := vars["bucket"]
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkIdentityAuth(r, policy.GetBucketTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	tagging, err := api.ObjectAPI.GetBucketTagging(bucketName, credential)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	taggingBuffer, err := xmlFormat(tagging)
+	if err != nil {
+		logger.Error("Failed to marshal tagging XML for bucket", bucketName,
+			"error:", err)
+		WriteErrorResponse(w, r, ErrInternalError)
+		return
+	}
+
+	setXmlHeader(w)
+	//ResponseRecorder
+	w.(*ResponseRecorder).operationName = "GetBucketTagging"
+	WriteSuccessResponse(w, taggingBuffer)
+}
+
 func (api ObjectAPIHandlers) GetBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
 	logger := ContextLogger(r)
 	vars := mux.Vars(r)
@@ -805,7 +902,7 @@ func (api ObjectAPIHandlers) GetBucketVersioningHandler(w http.ResponseWriter, r
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.GetBucketVersioningAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -837,7 +934,7 @@ func (api ObjectAPIHandlers) PutBucketVersioningHandler(w http.ResponseWriter, r
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.PutBucketVersioningAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -947,7 +1044,7 @@ func (api ObjectAPIHandlers) HeadBucketHandler(w http.ResponseWriter, r *http.Re
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.HeadBucketAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -971,7 +1068,7 @@ func (api ObjectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 
 	var credential common.Credential
 	var err error
-	if credential, err = signature.IsReqAuthenticated(r); err != nil {
+	if credential, err = checkIdentityAuth(r, policy.DeleteBucketAction); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
@@ -29,7 +29,7 @@ func (api ObjectAPIHandlers) PutBucketWebsiteHandler(w http.ResponseWriter, r *h
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutBucketWebsiteAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -80,7 +80,7 @@ func (api ObjectAPIHandlers) GetBucketWebsiteHandler(w http.ResponseWriter, r *h
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetBucketWebsiteAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -129,7 +129,7 @@ func (api ObjectAPIHandlers) DeleteBucketWebsiteHandler(w http.ResponseWriter, r
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.DeleteBucketWebsiteAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
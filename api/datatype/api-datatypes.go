@@ -311,9 +311,11 @@ type DeleteObjectsRequest struct {
 }
 
 type PutObjectResult struct {
-	Md5          string
-	VersionId    string
-	LastModified time.Time
+	Md5            string
+	VersionId      string
+	LastModified   time.Time
+	SseType        string
+	SseAwsKmsKeyId string
 }
 
 type RenameObjectResult struct {
@@ -333,7 +335,7 @@ type DeleteObjectResult struct {
 type PutObjectPartResult struct {
 	ETag                    string
 	SseType                 string
-	SseAwsKmsKeyIdBase64    string
+	SseAwsKmsKeyId          string
 	SseCustomerAlgorithm    string
 	SseCustomerKeyMd5Base64 string
 }
@@ -342,17 +344,17 @@ type CompleteMultipartResult struct {
 	ETag                    string
 	VersionId               string
 	SseType                 string
-	SseAwsKmsKeyIdBase64    string
+	SseAwsKmsKeyId          string
 	SseCustomerAlgorithm    string
 	SseCustomerKeyMd5Base64 string
 }
 
 type SseRequest struct {
-	// type of Server Side Encryption, could be "SSE-KMS", "SSE-S3", "SSE-C"(custom), or ""(none),
-	// KMS is not implemented yet
+	// type of Server Side Encryption, could be "SSE-KMS", "SSE-S3", "SSE-C"(custom), or ""(none)
 	Type string
 
-	// AWS-managed specific(KMS and S3)
+	// AWS-managed specific(KMS and S3), the context is a JSON object of strings,
+	// the object path is added to it when the data key is generated
 	SseAwsKmsKeyId string
 	SseContext     string
 
@@ -3,6 +3,7 @@ package datatype
 import (
 	"encoding/xml"
 	"github.com/dustin/go-humanize"
+	"github.com/journeymidnight/yig/crypto"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"io"
@@ -45,10 +46,19 @@ func (e *EncryptionConfiguration) Validate() (error error) {
 			}
 			sseAlgorithm := r.ApplyServerSideEncryptionByDefault.SSEAlgorithm
 			masterKeyID := r.ApplyServerSideEncryptionByDefault.KMSMasterKeyID
-			if sseAlgorithm == "" {
+			switch sseAlgorithm {
+			case "":
 				if masterKeyID == "" {
 					return ErrMissingSSEAlgorithmOrKMSMasterKeyIDInEncryptionRule
 				}
+			case crypto.SSEAlgorithmAES256:
+				if masterKeyID != "" {
+					return ErrInvalidEncryptionAlgorithm
+				}
+			case crypto.SSEAlgorithmKMS:
+				break
+			default:
+				return ErrInvalidEncryptionAlgorithm
 			}
 		}
 	} else {
@@ -1,19 +1,293 @@
 package datatype
 
 import (
+	"encoding/json"
 	"encoding/xml"
-	//	. "github.com/journeymidnight/yig/error"
-	//	"github.com/journeymidnight/yig/helper"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
 )
 
-type LifecycleRule struct {
-	ID         string `xml:"ID"`
-	Prefix     string `xml:"Prefix"`
-	Status     string `xml:"Status"`
-	Expiration string `xml:"Expiration>Days"`
+const (
+	MaxLifecycleConfigurationSize = 64 * humanize.KiByte
+	MaxLifecycleRulesCount        = 1000
+	MaxLifecycleRuleIdLength      = 255
+	LifecycleRuleEnabled          = "Enabled"
+	LifecycleRuleDisabled         = "Disabled"
+)
+
+// Storage classes objects could transit to
+var lifecycleTransitionStorageClasses = map[string]bool{
+	"STANDARD_IA": true,
+	"GLACIER":     true,
 }
 
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTBucketPUTlifecycle.html
 type Lifecycle struct {
 	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Xmlns   string          `xml:"xmlns,attr,omitempty"`
 	Rule    []LifecycleRule `xml:"Rule"`
 }
+
+type LifecycleRule struct {
+	ID     string           `xml:"ID,omitempty"`
+	Prefix string           `xml:"Prefix,omitempty"` // deprecated by S3, use Filter instead
+	Filter *LifecycleFilter `xml:"Filter,omitempty"`
+	Status string           `xml:"Status"`
+
+	Expiration                     *LifecycleExpiration            `xml:"Expiration,omitempty"`
+	Transitions                    []LifecycleTransition           `xml:"Transition,omitempty"`
+	NoncurrentVersionExpiration    *NoncurrentVersionExpiration    `xml:"NoncurrentVersionExpiration,omitempty"`
+	NoncurrentVersionTransitions   []NoncurrentVersionTransition   `xml:"NoncurrentVersionTransition,omitempty"`
+	AbortIncompleteMultipartUpload *AbortIncompleteMultipartUpload `xml:"AbortIncompleteMultipartUpload,omitempty"`
+}
+
+// Only one of Prefix, Tag and And could be set
+type LifecycleFilter struct {
+	Prefix string        `xml:"Prefix,omitempty"`
+	Tag    *Tag          `xml:"Tag,omitempty"`
+	And    *LifecycleAnd `xml:"And,omitempty"`
+}
+
+type LifecycleAnd struct {
+	Prefix string `xml:"Prefix,omitempty"`
+	Tags   []Tag  `xml:"Tag"`
+}
+
+// Only one of Days, Date and ExpiredObjectDeleteMarker could be set
+type LifecycleExpiration struct {
+	Days                      int    `xml:"Days,omitempty"`
+	Date                      string `xml:"Date,omitempty"` // ISO 8601 format, at midnight UTC
+	ExpiredObjectDeleteMarker bool   `xml:"ExpiredObjectDeleteMarker,omitempty"`
+}
+
+type LifecycleTransition struct {
+	Days         int    `xml:"Days,omitempty"`
+	Date         string `xml:"Date,omitempty"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type NoncurrentVersionExpiration struct {
+	NoncurrentDays int `xml:"NoncurrentDays"`
+}
+
+type NoncurrentVersionTransition struct {
+	NoncurrentDays int    `xml:"NoncurrentDays"`
+	StorageClass   string `xml:"StorageClass"`
+}
+
+type AbortIncompleteMultipartUpload struct {
+	DaysAfterInitiation int `xml:"DaysAfterInitiation"`
+}
+
+// Rules saved by former versions keep expiration days as a string,
+// e.g. {"Expiration": "30"}
+func (e *LifecycleExpiration) UnmarshalJSON(data []byte) (err error) {
+	var days string
+	if json.Unmarshal(data, &days) == nil {
+		if days != "" {
+			e.Days, err = strconv.Atoi(days)
+		}
+		return err
+	}
+	type expiration LifecycleExpiration
+	return json.Unmarshal(data, (*expiration)(e))
+}
+
+func ParseLifecycleDate(date string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return t, ErrInvalidLc
+	}
+	return t, nil
+}
+
+func (rule LifecycleRule) prefix() string {
+	if rule.Filter == nil {
+		return rule.Prefix
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Prefix
+	}
+	return rule.Filter.Prefix
+}
+
+func (rule LifecycleRule) tags() []Tag {
+	if rule.Filter == nil {
+		return nil
+	}
+	if rule.Filter.And != nil {
+		return rule.Filter.And.Tags
+	}
+	if rule.Filter.Tag != nil {
+		return []Tag{*rule.Filter.Tag}
+	}
+	return nil
+}
+
+func (rule LifecycleRule) Enabled() bool {
+	return rule.Status == LifecycleRuleEnabled
+}
+
+// Whether the rule applies to an object with name and tags
+func (rule LifecycleRule) Match(objectName string, tags map[string]string) bool {
+	if !strings.HasPrefix(objectName, rule.prefix()) {
+		return false
+	}
+	for _, tag := range rule.tags() {
+		if value, ok := tags[tag.Key]; !ok || value != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Multipart uploads have no tags, so rules with tag filters never abort them
+func (rule LifecycleRule) MatchUpload(objectName string) bool {
+	return len(rule.tags()) == 0 && strings.HasPrefix(objectName, rule.prefix())
+}
+
+func validateDaysOrDate(days int, date string) error {
+	if date == "" {
+		if days < 0 {
+			return ErrInvalidLc
+		}
+		return nil
+	}
+	if days != 0 {
+		return ErrInvalidLc
+	}
+	t, err := ParseLifecycleDate(date)
+	if err != nil {
+		return err
+	}
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 || t.Nanosecond() != 0 {
+		return ErrInvalidLc
+	}
+	return nil
+}
+
+func (rule LifecycleRule) validate() error {
+	if len(rule.ID) > MaxLifecycleRuleIdLength {
+		return ErrInvalidLc
+	}
+	if rule.Status != LifecycleRuleEnabled && rule.Status != LifecycleRuleDisabled {
+		return ErrInvalidLc
+	}
+	if rule.Filter != nil {
+		if rule.Prefix != "" {
+			return ErrInvalidLc
+		}
+		count := 0
+		if rule.Filter.Prefix != "" {
+			count++
+		}
+		if rule.Filter.Tag != nil {
+			count++
+		}
+		if rule.Filter.And != nil {
+			count++
+		}
+		if count > 1 {
+			return ErrInvalidLc
+		}
+		for _, tag := range rule.tags() {
+			if tag.Key == "" {
+				return ErrInvalidLc
+			}
+		}
+	}
+	hasTags := len(rule.tags()) != 0
+
+	if rule.Expiration == nil && len(rule.Transitions) == 0 && rule.NoncurrentVersionExpiration == nil &&
+		len(rule.NoncurrentVersionTransitions) == 0 && rule.AbortIncompleteMultipartUpload == nil {
+		return ErrInvalidLc
+	}
+	if e := rule.Expiration; e != nil {
+		if e.ExpiredObjectDeleteMarker {
+			if e.Days != 0 || e.Date != "" || hasTags {
+				return ErrInvalidLc
+			}
+		} else {
+			if e.Days == 0 && e.Date == "" {
+				return ErrInvalidLc
+			}
+			if err := validateDaysOrDate(e.Days, e.Date); err != nil {
+				return err
+			}
+		}
+	}
+	classes := make(map[string]bool)
+	for _, t := range rule.Transitions {
+		if !lifecycleTransitionStorageClasses[t.StorageClass] || classes[t.StorageClass] {
+			return ErrInvalidLc
+		}
+		classes[t.StorageClass] = true
+		if err := validateDaysOrDate(t.Days, t.Date); err != nil {
+			return err
+		}
+	}
+	if e := rule.NoncurrentVersionExpiration; e != nil && e.NoncurrentDays <= 0 {
+		return ErrInvalidLc
+	}
+	classes = make(map[string]bool)
+	for _, t := range rule.NoncurrentVersionTransitions {
+		if !lifecycleTransitionStorageClasses[t.StorageClass] || classes[t.StorageClass] ||
+			t.NoncurrentDays < 0 {
+			return ErrInvalidLc
+		}
+		classes[t.StorageClass] = true
+	}
+	if a := rule.AbortIncompleteMultipartUpload; a != nil && (a.DaysAfterInitiation <= 0 || hasTags) {
+		return ErrInvalidLc
+	}
+	return nil
+}
+
+func (lc *Lifecycle) Validate() error {
+	if len(lc.Rule) == 0 || len(lc.Rule) > MaxLifecycleRulesCount {
+		return ErrInvalidLc
+	}
+	ids := make(map[string]bool)
+	for i := range lc.Rule {
+		rule := &lc.Rule[i]
+		if err := rule.validate(); err != nil {
+			return err
+		}
+		rule.ID = helper.Ternary(rule.ID == "", string(helper.GenerateRandomId()), rule.ID).(string)
+		if ids[rule.ID] {
+			return ErrInvalidLc
+		}
+		ids[rule.ID] = true
+	}
+	return nil
+}
+
+func ParseLifecycleConfig(reader io.Reader) (*Lifecycle, error) {
+	lc := new(Lifecycle)
+	lcBuffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		helper.Logger.Error("Unable to read lifecycle body:", err)
+		return nil, err
+	}
+	size := len(lcBuffer)
+	if size > MaxLifecycleConfigurationSize {
+		return nil, ErrEntityTooLarge
+	}
+	err = xml.Unmarshal(lcBuffer, lc)
+	if err != nil {
+		helper.Logger.Error("Unable to parse lifecycle XML body:", err)
+		return nil, ErrMalformedXML
+	}
+	err = lc.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return lc, nil
+}
@@ -0,0 +1,290 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+)
+
+const (
+	MaxNotificationConfigurationSize = 20 * humanize.KiByte
+	MaxNotificationRulesCount        = 100
+)
+
+// Supported S3 event types
+const (
+	EventObjectCreatedAll                     = "s3:ObjectCreated:*"
+	EventObjectCreatedPut                     = "s3:ObjectCreated:Put"
+	EventObjectCreatedPost                    = "s3:ObjectCreated:Post"
+	EventObjectCreatedCopy                    = "s3:ObjectCreated:Copy"
+	EventObjectCreatedCompleteMultipartUpload = "s3:ObjectCreated:CompleteMultipartUpload"
+	EventObjectRemovedAll                     = "s3:ObjectRemoved:*"
+	EventObjectRemovedDelete                  = "s3:ObjectRemoved:Delete"
+	EventObjectRemovedDeleteMarkerCreated     = "s3:ObjectRemoved:DeleteMarkerCreated"
+	EventObjectRestoreAll                     = "s3:ObjectRestore:*"
+	EventObjectRestorePost                    = "s3:ObjectRestore:Post"
+	EventObjectRestoreCompleted               = "s3:ObjectRestore:Completed"
+)
+
+var supportedEvents = map[string]bool{
+	EventObjectCreatedAll:                     true,
+	EventObjectCreatedPut:                     true,
+	EventObjectCreatedPost:                    true,
+	EventObjectCreatedCopy:                    true,
+	EventObjectCreatedCompleteMultipartUpload: true,
+	EventObjectRemovedAll:                     true,
+	EventObjectRemovedDelete:                  true,
+	EventObjectRemovedDeleteMarkerCreated:     true,
+	EventObjectRestoreAll:                     true,
+	EventObjectRestorePost:                    true,
+	EventObjectRestoreCompleted:               true,
+}
+
+// Every configuration is delivered through the message queue plugin,
+// Queue/Topic/CloudFunction ARNs are kept only for clients to recognize their configurations
+type NotificationConfiguration struct {
+	XMLName                     xml.Name                     `xml:"NotificationConfiguration"`
+	Xmlns                       string                       `xml:"xmlns,attr,omitempty"`
+	TopicConfigurations         []TopicConfiguration         `xml:"TopicConfiguration,omitempty"`
+	QueueConfigurations         []QueueConfiguration         `xml:"QueueConfiguration,omitempty"`
+	CloudFunctionConfigurations []CloudFunctionConfiguration `xml:"CloudFunctionConfiguration,omitempty"`
+}
+
+type NotificationRule struct {
+	Id     string              `xml:"Id,omitempty"`
+	Events []string            `xml:"Event"`
+	Filter *NotificationFilter `xml:"Filter,omitempty"`
+}
+
+type TopicConfiguration struct {
+	NotificationRule
+	Topic string `xml:"Topic"`
+}
+
+type QueueConfiguration struct {
+	NotificationRule
+	Queue string `xml:"Queue"`
+}
+
+type CloudFunctionConfiguration struct {
+	NotificationRule
+	CloudFunction string `xml:"CloudFunction"`
+}
+
+type NotificationFilter struct {
+	S3Key S3KeyFilter `xml:"S3Key"`
+}
+
+type S3KeyFilter struct {
+	FilterRules []FilterRule `xml:"FilterRule"`
+}
+
+type FilterRule struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+func (c NotificationConfiguration) rules() (rules []NotificationRule) {
+	for _, t := range c.TopicConfigurations {
+		rules = append(rules, t.NotificationRule)
+	}
+	for _, q := range c.QueueConfigurations {
+		rules = append(rules, q.NotificationRule)
+	}
+	for _, f := range c.CloudFunctionConfigurations {
+		rules = append(rules, f.NotificationRule)
+	}
+	return
+}
+
+func (c NotificationConfiguration) IsEmpty() bool {
+	return len(c.TopicConfigurations) == 0 && len(c.QueueConfigurations) == 0 &&
+		len(c.CloudFunctionConfigurations) == 0
+}
+
+// Returns Ids of all the rules matching event and object key
+func (c NotificationConfiguration) Match(event, objectName string) (ids []string) {
+	for _, rule := range c.rules() {
+		if rule.Match(event, objectName) {
+			ids = append(ids, rule.Id)
+		}
+	}
+	return
+}
+
+func (rule NotificationRule) Match(event, objectName string) bool {
+	eventMatched := false
+	for _, e := range rule.Events {
+		if e == event || (strings.HasSuffix(e, "*") && strings.HasPrefix(event, strings.TrimSuffix(e, "*"))) {
+			eventMatched = true
+			break
+		}
+	}
+	if !eventMatched {
+		return false
+	}
+	if rule.Filter == nil {
+		return true
+	}
+	for _, f := range rule.Filter.S3Key.FilterRules {
+		switch strings.ToLower(f.Name) {
+		case "prefix":
+			if !strings.HasPrefix(objectName, f.Value) {
+				return false
+			}
+		case "suffix":
+			if !strings.HasSuffix(objectName, f.Value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTBucketPUTnotification.html
+func (rule NotificationRule) validate(destination string) error {
+	if destination == "" {
+		return ErrInvalidNotificationDestination
+	}
+	if len(rule.Events) == 0 {
+		return ErrInvalidNotificationEvent
+	}
+	for _, e := range rule.Events {
+		if !supportedEvents[e] {
+			return ErrInvalidNotificationEvent
+		}
+	}
+	if rule.Filter == nil {
+		return nil
+	}
+	var hasPrefix, hasSuffix bool
+	for _, f := range rule.Filter.S3Key.FilterRules {
+		switch strings.ToLower(f.Name) {
+		case "prefix":
+			if hasPrefix {
+				return ErrInvalidNotificationFilter
+			}
+			hasPrefix = true
+		case "suffix":
+			if hasSuffix {
+				return ErrInvalidNotificationFilter
+			}
+			hasSuffix = true
+		default:
+			return ErrInvalidNotificationFilter
+		}
+	}
+	return nil
+}
+
+func (c *NotificationConfiguration) Validate() error {
+	if len(c.rules()) > MaxNotificationRulesCount {
+		return ErrInvalidNotificationConfiguration
+	}
+	ids := make(map[string]bool)
+	for i := range c.TopicConfigurations {
+		t := &c.TopicConfigurations[i]
+		if err := t.validate(t.Topic); err != nil {
+			return err
+		}
+		t.Id = helper.Ternary(t.Id == "", string(helper.GenerateRandomId()), t.Id).(string)
+		if ids[t.Id] {
+			return ErrInvalidNotificationConfiguration
+		}
+		ids[t.Id] = true
+	}
+	for i := range c.QueueConfigurations {
+		q := &c.QueueConfigurations[i]
+		if err := q.validate(q.Queue); err != nil {
+			return err
+		}
+		q.Id = helper.Ternary(q.Id == "", string(helper.GenerateRandomId()), q.Id).(string)
+		if ids[q.Id] {
+			return ErrInvalidNotificationConfiguration
+		}
+		ids[q.Id] = true
+	}
+	for i := range c.CloudFunctionConfigurations {
+		f := &c.CloudFunctionConfigurations[i]
+		if err := f.validate(f.CloudFunction); err != nil {
+			return err
+		}
+		f.Id = helper.Ternary(f.Id == "", string(helper.GenerateRandomId()), f.Id).(string)
+		if ids[f.Id] {
+			return ErrInvalidNotificationConfiguration
+		}
+		ids[f.Id] = true
+	}
+	return nil
+}
+
+func ParseNotificationConfig(reader io.Reader) (*NotificationConfiguration, error) {
+	config := new(NotificationConfiguration)
+	notificationBuffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		helper.Logger.Error("Unable to read notification config body:", err)
+		return nil, err
+	}
+	size := len(notificationBuffer)
+	if size > MaxNotificationConfigurationSize {
+		return nil, ErrEntityTooLarge
+	}
+	err = xml.Unmarshal(notificationBuffer, config)
+	if err != nil {
+		helper.Logger.Error("Unable to parse notification config XML body:", err)
+		return nil, ErrMalformedXML
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Event message published to the message queue, compatible with S3 event message structure
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/dev/notification-content-structure.html
+type EventMessage struct {
+	Records []EventRecord `json:"Records"`
+}
+
+type EventRecord struct {
+	EventVersion      string            `json:"eventVersion"`
+	EventSource       string            `json:"eventSource"`
+	AwsRegion         string            `json:"awsRegion"`
+	EventTime         string            `json:"eventTime"`
+	EventName         string            `json:"eventName"`
+	UserIdentity      EventIdentity     `json:"userIdentity"`
+	RequestParameters map[string]string `json:"requestParameters"`
+	ResponseElements  map[string]string `json:"responseElements"`
+	S3                EventS3           `json:"s3"`
+}
+
+type EventIdentity struct {
+	PrincipalId string `json:"principalId"`
+}
+
+type EventS3 struct {
+	SchemaVersion   string      `json:"s3SchemaVersion"`
+	ConfigurationId string      `json:"configurationId"`
+	Bucket          EventBucket `json:"bucket"`
+	Object          EventObject `json:"object"`
+}
+
+type EventBucket struct {
+	Name          string        `json:"name"`
+	OwnerIdentity EventIdentity `json:"ownerIdentity"`
+	Arn           string        `json:"arn"`
+}
+
+type EventObject struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size,omitempty"`
+	ETag      string `json:"eTag,omitempty"`
+	VersionId string `json:"versionId,omitempty"`
+	Sequencer string `json:"sequencer"`
+}
@@ -0,0 +1,183 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+)
+
+const (
+	MaxObjectLockConfigurationSize = 16 * humanize.KiByte
+
+	ObjectLockEnabled               = "Enabled"
+	ObjectLockModeGovernance        = "GOVERNANCE"
+	ObjectLockModeCompliance        = "COMPLIANCE"
+	ObjectLockLegalHoldOn           = "ON"
+	ObjectLockLegalHoldOff          = "OFF"
+	BypassGovernanceRetentionHeader = "X-Amz-Bypass-Governance-Retention"
+)
+
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObjectLockConfiguration.html
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration"`
+	Xmlns             string          `xml:"xmlns,attr,omitempty"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty"`
+}
+
+type ObjectLockRule struct {
+	DefaultRetention DefaultRetention `xml:"DefaultRetention"`
+}
+
+// Only one of Days and Years could be set
+type DefaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+type ObjectRetention struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Xmlns           string   `xml:"xmlns,attr,omitempty"`
+	Mode            string   `xml:"Mode,omitempty"`
+	RetainUntilDate string   `xml:"RetainUntilDate,omitempty"` // ISO 8601 format
+}
+
+type ObjectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status"`
+}
+
+func (c ObjectLockConfiguration) IsEnabled() bool {
+	return c.ObjectLockEnabled == ObjectLockEnabled
+}
+
+// Retention mode and retain-until date applied to new object versions
+// created at `now`, empty mode if the bucket has no default retention
+func (c ObjectLockConfiguration) DefaultRetention(now time.Time) (mode string, retainUntilDate time.Time) {
+	if !c.IsEnabled() || c.Rule == nil {
+		return "", time.Time{}
+	}
+	retention := c.Rule.DefaultRetention
+	return retention.Mode, now.AddDate(retention.Years, 0, retention.Days)
+}
+
+func isValidObjectLockMode(mode string) bool {
+	return mode == ObjectLockModeGovernance || mode == ObjectLockModeCompliance
+}
+
+func (c *ObjectLockConfiguration) Validate() error {
+	if !c.IsEnabled() {
+		return ErrMalformedXML
+	}
+	if c.Rule == nil {
+		return nil
+	}
+	retention := c.Rule.DefaultRetention
+	if !isValidObjectLockMode(retention.Mode) {
+		return ErrMalformedXML
+	}
+	if (retention.Days == 0) == (retention.Years == 0) {
+		return ErrMalformedXML
+	}
+	if retention.Days < 0 || retention.Years < 0 {
+		return ErrInvalidRetentionPeriod
+	}
+	return nil
+}
+
+// Parse retention mode and retain-until date, an empty retention removes
+// retention settings of the object version
+func (r ObjectRetention) Parse() (mode string, retainUntilDate time.Time, err error) {
+	if r.Mode == "" && r.RetainUntilDate == "" {
+		return "", time.Time{}, nil
+	}
+	if !isValidObjectLockMode(r.Mode) {
+		return "", time.Time{}, ErrMalformedXML
+	}
+	retainUntilDate, err = time.Parse(time.RFC3339, r.RetainUntilDate)
+	if err != nil {
+		return "", time.Time{}, ErrMalformedXML
+	}
+	if !retainUntilDate.After(time.Now()) {
+		return "", time.Time{}, ErrInvalidRetentionPeriod
+	}
+	return r.Mode, retainUntilDate.UTC(), nil
+}
+
+func ObjectRetentionFromMeta(mode string, retainUntilDate time.Time) ObjectRetention {
+	if mode == "" {
+		return ObjectRetention{}
+	}
+	return ObjectRetention{
+		Mode:            mode,
+		RetainUntilDate: retainUntilDate.UTC().Format(time.RFC3339),
+	}
+}
+
+func ObjectLegalHoldFromMeta(legalHold bool) ObjectLegalHold {
+	return ObjectLegalHold{
+		Status: helper.Ternary(legalHold, ObjectLockLegalHoldOn, ObjectLockLegalHoldOff).(string),
+	}
+}
+
+func readObjectLockBody(reader io.Reader, v interface{}) error {
+	buffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		helper.Logger.Error("Unable to read object lock body:", err)
+		return err
+	}
+	if len(buffer) > MaxObjectLockConfigurationSize {
+		return ErrEntityTooLarge
+	}
+	err = xml.Unmarshal(buffer, v)
+	if err != nil {
+		helper.Logger.Error("Unable to parse object lock XML body:", err)
+		return ErrMalformedXML
+	}
+	return nil
+}
+
+func ParseObjectLockConfig(reader io.Reader) (*ObjectLockConfiguration, error) {
+	config := new(ObjectLockConfiguration)
+	err := readObjectLockBody(reader, config)
+	if err != nil {
+		return nil, err
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func ParseObjectRetention(reader io.Reader) (*ObjectRetention, error) {
+	retention := new(ObjectRetention)
+	err := readObjectLockBody(reader, retention)
+	if err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
+
+func ParseObjectLegalHold(reader io.Reader) (legalHold bool, err error) {
+	hold := new(ObjectLegalHold)
+	err = readObjectLockBody(reader, hold)
+	if err != nil {
+		return false, err
+	}
+	switch hold.Status {
+	case ObjectLockLegalHoldOn:
+		return true, nil
+	case ObjectLockLegalHoldOff:
+		return false, nil
+	default:
+		return false, ErrMalformedXML
+	}
+}
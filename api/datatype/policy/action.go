@@ -32,6 +32,10 @@ const (
 	// AbortMultipartUploadAction - AbortMultipartUpload Rest API action.
 	AbortMultipartUploadAction Action = "s3:AbortMultipartUpload"
 
+	// BypassGovernanceRetentionAction - allows removing object versions or
+	// shortening retention protected by GOVERNANCE mode object lock.
+	BypassGovernanceRetentionAction = "s3:BypassGovernanceRetention"
+
 	// CreateBucketAction - CreateBucket Rest API action.
 	CreateBucketAction = "s3:CreateBucket"
 
@@ -44,6 +48,9 @@ const (
 	// DeleteObjectAction - DeleteObject Rest API action.
 	DeleteObjectAction = "s3:DeleteObject"
 
+	// DeleteObjectTaggingAction - DeleteObjectTagging Rest API action.
+	DeleteObjectTaggingAction = "s3:DeleteObjectTagging"
+
 	// GetBucketLocationAction - GetBucketLocation Rest API action.
 	GetBucketLocationAction = "s3:GetBucketLocation"
 
@@ -56,6 +63,15 @@ const (
 	// GetObjectAction - GetObject Rest API action.
 	GetObjectAction = "s3:GetObject"
 
+	// GetObjectTaggingAction - GetObjectTagging Rest API action.
+	GetObjectTaggingAction = "s3:GetObjectTagging"
+
+	// GetObjectLegalHoldAction - GetObjectLegalHold Rest API action.
+	GetObjectLegalHoldAction = "s3:GetObjectLegalHold"
+
+	// GetObjectRetentionAction - GetObjectRetention Rest API action.
+	GetObjectRetentionAction = "s3:GetObjectRetention"
+
 	// HeadBucketAction - HeadBucket Rest API action. This action is unused in minio.
 	HeadBucketAction = "s3:HeadBucket"
 
@@ -83,6 +99,87 @@ const (
 
 	// PutObjectAction - PutObject Rest API action.
 	PutObjectAction = "s3:PutObject"
+
+	// PutObjectTaggingAction - PutObjectTagging Rest API action.
+	PutObjectTaggingAction = "s3:PutObjectTagging"
+
+	// PutObjectLegalHoldAction - PutObjectLegalHold Rest API action.
+	PutObjectLegalHoldAction = "s3:PutObjectLegalHold"
+
+	// PutObjectRetentionAction - PutObjectRetention Rest API action.
+	PutObjectRetentionAction = "s3:PutObjectRetention"
+
+	// DeleteBucketWebsiteAction - DeleteBucketWebsite Rest API action.
+	DeleteBucketWebsiteAction = "s3:DeleteBucketWebsite"
+
+	// GetBucketAclAction - GetBucketAcl Rest API action.
+	GetBucketAclAction = "s3:GetBucketAcl"
+
+	// GetBucketCORSAction - GetBucketCors Rest API action.
+	GetBucketCORSAction = "s3:GetBucketCORS"
+
+	// GetBucketLoggingAction - GetBucketLogging Rest API action.
+	GetBucketLoggingAction = "s3:GetBucketLogging"
+
+	// GetBucketObjectLockConfigurationAction - GetObjectLockConfiguration Rest API action.
+	GetBucketObjectLockConfigurationAction = "s3:GetBucketObjectLockConfiguration"
+
+	// GetBucketTaggingAction - GetBucketTagging Rest API action.
+	GetBucketTaggingAction = "s3:GetBucketTagging"
+
+	// GetBucketVersioningAction - GetBucketVersioning Rest API action.
+	GetBucketVersioningAction = "s3:GetBucketVersioning"
+
+	// GetBucketWebsiteAction - GetBucketWebsite Rest API action.
+	GetBucketWebsiteAction = "s3:GetBucketWebsite"
+
+	// GetEncryptionConfigurationAction - GetBucketEncryption Rest API action.
+	GetEncryptionConfigurationAction = "s3:GetEncryptionConfiguration"
+
+	// GetLifecycleConfigurationAction - GetBucketLifecycle Rest API action.
+	GetLifecycleConfigurationAction = "s3:GetLifecycleConfiguration"
+
+	// GetObjectAclAction - GetObjectAcl Rest API action.
+	GetObjectAclAction = "s3:GetObjectAcl"
+
+	// GetReplicationConfigurationAction - GetBucketReplication Rest API action.
+	GetReplicationConfigurationAction = "s3:GetReplicationConfiguration"
+
+	// ListBucketVersionsAction - ListObjectVersions Rest API action.
+	ListBucketVersionsAction = "s3:ListBucketVersions"
+
+	// PutBucketAclAction - PutBucketAcl Rest API action.
+	PutBucketAclAction = "s3:PutBucketAcl"
+
+	// PutBucketCORSAction - PutBucketCors and DeleteBucketCors Rest API action.
+	PutBucketCORSAction = "s3:PutBucketCORS"
+
+	// PutBucketLoggingAction - PutBucketLogging Rest API action.
+	PutBucketLoggingAction = "s3:PutBucketLogging"
+
+	// PutBucketObjectLockConfigurationAction - PutObjectLockConfiguration Rest API action.
+	PutBucketObjectLockConfigurationAction = "s3:PutBucketObjectLockConfiguration"
+
+	// PutBucketTaggingAction - PutBucketTagging and DeleteBucketTagging Rest API action.
+	PutBucketTaggingAction = "s3:PutBucketTagging"
+
+	// PutBucketVersioningAction - PutBucketVersioning Rest API action.
+	PutBucketVersioningAction = "s3:PutBucketVersioning"
+
+	// PutBucketWebsiteAction - PutBucketWebsite Rest API action.
+	PutBucketWebsiteAction = "s3:PutBucketWebsite"
+
+	// PutEncryptionConfigurationAction - PutBucketEncryption and DeleteBucketEncryption Rest API action.
+	PutEncryptionConfigurationAction = "s3:PutEncryptionConfiguration"
+
+	// PutLifecycleConfigurationAction - PutBucketLifecycle and DeleteBucketLifecycle Rest API action.
+	PutLifecycleConfigurationAction = "s3:PutLifecycleConfiguration"
+
+	// PutObjectAclAction - PutObjectAcl Rest API action.
+	PutObjectAclAction = "s3:PutObjectAcl"
+
+	// PutReplicationConfigurationAction - PutBucketReplication and DeleteBucketReplication Rest API action.
+	PutReplicationConfigurationAction = "s3:PutReplicationConfiguration"
 )
 
 // isObjectAction - returns whether action is object type or not.
@@ -91,6 +188,29 @@ func (action Action) isObjectAction() bool {
 	case AbortMultipartUploadAction, DeleteObjectAction, GetObjectAction:
 		fallthrough
 	case ListMultipartUploadPartsAction, PutObjectAction:
+		fallthrough
+	case DeleteObjectTaggingAction, GetObjectTaggingAction, PutObjectTaggingAction:
+		fallthrough
+	case BypassGovernanceRetentionAction, GetObjectLegalHoldAction, GetObjectRetentionAction:
+		fallthrough
+	case PutObjectLegalHoldAction, PutObjectRetentionAction:
+		fallthrough
+	case GetObjectAclAction, PutObjectAclAction:
+		return true
+	}
+
+	return false
+}
+
+// isExistingObjectTagSupported - returns whether s3:ExistingObjectTag/<key>
+// condition keys are applicable to action or not.
+func (action Action) isExistingObjectTagSupported() bool {
+	switch action {
+	case GetObjectAction, GetObjectTaggingAction, PutObjectTaggingAction, DeleteObjectTaggingAction:
+		fallthrough
+	case GetObjectLegalHoldAction, GetObjectRetentionAction, PutObjectLegalHoldAction:
+		fallthrough
+	case PutObjectRetentionAction:
 		return true
 	}
 
@@ -113,6 +233,28 @@ func (action Action) IsValid() bool {
 	case ListMultipartUploadPartsAction, PutBucketNotificationAction:
 		fallthrough
 	case PutBucketPolicyAction, PutObjectAction:
+		fallthrough
+	case DeleteObjectTaggingAction, GetObjectTaggingAction, PutObjectTaggingAction:
+		fallthrough
+	case BypassGovernanceRetentionAction, GetObjectLegalHoldAction, GetObjectRetentionAction:
+		fallthrough
+	case PutObjectLegalHoldAction, PutObjectRetentionAction:
+		fallthrough
+	case DeleteBucketWebsiteAction, GetBucketAclAction, GetBucketCORSAction:
+		fallthrough
+	case GetBucketLoggingAction, GetBucketObjectLockConfigurationAction, GetBucketTaggingAction:
+		fallthrough
+	case GetBucketVersioningAction, GetBucketWebsiteAction, GetEncryptionConfigurationAction:
+		fallthrough
+	case GetLifecycleConfigurationAction, GetObjectAclAction, GetReplicationConfigurationAction:
+		fallthrough
+	case ListBucketVersionsAction, PutBucketAclAction, PutBucketCORSAction:
+		fallthrough
+	case PutBucketLoggingAction, PutBucketObjectLockConfigurationAction, PutBucketTaggingAction:
+		fallthrough
+	case PutBucketVersioningAction, PutBucketWebsiteAction, PutEncryptionConfigurationAction:
+		fallthrough
+	case PutLifecycleConfigurationAction, PutObjectAclAction, PutReplicationConfigurationAction:
 		return true
 	}
 
@@ -163,6 +305,11 @@ var actionConditionKeyMap = map[Action]condition.KeySet{
 		condition.AWSSourceIP,
 	),
 
+	BypassGovernanceRetentionAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
 	CreateBucketAction: condition.NewKeySet(
 		condition.AWSReferer,
 		condition.AWSSourceIP,
@@ -178,6 +325,11 @@ var actionConditionKeyMap = map[Action]condition.KeySet{
 		condition.AWSSourceIP,
 	),
 
+	DeleteObjectTaggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
 	GetBucketLocationAction: condition.NewKeySet(
 		condition.AWSReferer,
 		condition.AWSSourceIP,
@@ -201,6 +353,21 @@ var actionConditionKeyMap = map[Action]condition.KeySet{
 		condition.AWSSourceIP,
 	),
 
+	GetObjectTaggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetObjectLegalHoldAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetObjectRetentionAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
 	HeadBucketAction: condition.NewKeySet(
 		condition.AWSReferer,
 		condition.AWSSourceIP,
@@ -253,4 +420,138 @@ var actionConditionKeyMap = map[Action]condition.KeySet{
 		condition.AWSReferer,
 		condition.AWSSourceIP,
 	),
+
+	PutObjectTaggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutObjectLegalHoldAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutObjectRetentionAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+	DeleteBucketWebsiteAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketAclAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketCORSAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketLoggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketObjectLockConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketTaggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketVersioningAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetBucketWebsiteAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetEncryptionConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetLifecycleConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetObjectAclAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	GetReplicationConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	ListBucketVersionsAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketAclAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketCORSAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketLoggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketObjectLockConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketTaggingAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketVersioningAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutBucketWebsiteAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutEncryptionConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutLifecycleConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutObjectAclAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
+
+	PutReplicationConfigurationAction: condition.NewKeySet(
+		condition.AWSReferer,
+		condition.AWSSourceIP,
+	),
 }
@@ -0,0 +1,140 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/journeymidnight/yig/api/datatype/policy/utils"
+)
+
+// arnComponents - number of colon delimited components of ARN,
+// i.e. "arn:partition:service:region:account-id:resource"
+const arnComponents = 6
+
+// isARNMatched - checks whether ARN matches pattern, each component of ARN is
+// wildcard matched separately.
+func isARNMatched(pattern, arn string) bool {
+	patternComponents := strings.SplitN(pattern, ":", arnComponents)
+	arnComponentStrings := strings.SplitN(arn, ":", arnComponents)
+	if len(patternComponents) != arnComponents || len(arnComponentStrings) != arnComponents {
+		return false
+	}
+
+	for i := range patternComponents {
+		if !utils.Match(patternComponents[i], arnComponentStrings[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// arnFunc - ARN condition function. It checks whether ARN by Key in given values
+// map matches condition values. ArnEquals and ArnLike behave the same, both of
+// them support wildcards in each component of ARN.
+// For example,
+//   - if values = ["arn:aws:iam::*:root"], at evaluate() it returns whether ARN
+//     in value map for Key is root of any account.
+type arnFunc struct {
+	n      name
+	k      Key
+	values utils.StringSet
+}
+
+// evaluate() - evaluates to check whether ARN by Key in given values matches
+// condition values, or doesn't match for ArnNotEquals and ArnNotLike.
+func (f arnFunc) evaluate(values map[string][]string) bool {
+	matched := false
+	for _, v := range values[f.k.Name()] {
+		if !f.values.FuncMatch(isARNMatched, v).IsEmpty() {
+			matched = true
+			break
+		}
+	}
+
+	if f.n == arnNotEquals || f.n == arnNotLike {
+		return !matched
+	}
+	return matched
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f arnFunc) key() Key {
+	return f.k
+}
+
+// name() - returns condition name of this function, e.g. "ArnLike".
+func (f arnFunc) name() name {
+	return f.n
+}
+
+func (f arnFunc) String() string {
+	valueStrings := f.values.ToSlice()
+	sort.Strings(valueStrings)
+
+	return fmt.Sprintf("%v:%v:%v", f.n, f.k, valueStrings)
+}
+
+// toMap - returns map representation of this function.
+func (f arnFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	values := NewValueSet()
+	for _, value := range f.values.ToSlice() {
+		values.Add(NewStringValue(value))
+	}
+
+	return map[Key]ValueSet{
+		f.k: values,
+	}
+}
+
+// newARNFunc - returns new ARN function of given condition name.
+func newARNFunc(n name, key Key, values ValueSet) (Function, error) {
+	valueStrings, err := valuesToStringSlice(n, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewARNFunc(string(n), key, valueStrings...)
+}
+
+// NewARNFunc - returns new ARN function, name should be one of "ArnEquals",
+// "ArnNotEquals", "ArnLike" and "ArnNotLike".
+func NewARNFunc(conditionName string, key Key, values ...string) (Function, error) {
+	n := name(conditionName)
+	switch n {
+	case arnEquals, arnNotEquals, arnLike, arnNotLike:
+	default:
+		return nil, fmt.Errorf("invalid ARN condition name '%v'", conditionName)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value is required for %v condition", n)
+	}
+	for _, s := range values {
+		if len(strings.SplitN(s, ":", arnComponents)) != arnComponents {
+			return nil, fmt.Errorf("invalid ARN '%v' for %v condition", s, n)
+		}
+	}
+
+	return &arnFunc{n, key, utils.CreateStringSet(values...)}, nil
+}
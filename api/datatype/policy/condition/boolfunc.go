@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// boolFunc - Bool condition function. It checks whether boolean value by Key in
+// given values map equals to condition value.
+// For example,
+//   - if Key = AWSSecureTransport and value = false, at evaluate() it returns
+//     whether request is sent without TLS.
+type boolFunc struct {
+	k     Key
+	value bool
+}
+
+// evaluate() - evaluates to check whether value by Key in given values equals to
+// condition value.
+func (f boolFunc) evaluate(values map[string][]string) bool {
+	for _, s := range values[f.k.Name()] {
+		if v, err := strconv.ParseBool(s); err == nil && v == f.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f boolFunc) key() Key {
+	return f.k
+}
+
+// name() - returns "Bool" condition name.
+func (f boolFunc) name() name {
+	return boolean
+}
+
+func (f boolFunc) String() string {
+	return fmt.Sprintf("%v:%v:%v", boolean, f.k, f.value)
+}
+
+// toMap - returns map representation of this function.
+func (f boolFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	return map[Key]ValueSet{
+		f.k: NewValueSet(NewStringValue(strconv.FormatBool(f.value))),
+	}
+}
+
+func newBoolFunc(key Key, values ValueSet) (Function, error) {
+	if len(values) != 1 {
+		return nil, fmt.Errorf("only one value is allowed for Bool condition")
+	}
+
+	var value bool
+	for v := range values {
+		var err error
+		if value, err = strconv.ParseBool(v.String()); err != nil {
+			return nil, fmt.Errorf("value must be a boolean for Bool condition")
+		}
+	}
+
+	return &boolFunc{key, value}, nil
+}
+
+// NewBoolFunc - returns new Bool function.
+func NewBoolFunc(key Key, value bool) (Function, error) {
+	return &boolFunc{key, value}, nil
+}
@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseDate - parses date in ISO 8601 formats, e.g. "2019-01-02T15:04:05Z" or
+// "2019-01-02", or in epoch seconds, e.g. "1546441445".
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date '%v'", s)
+}
+
+func compareTime(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// dateFunc - Date comparison function. It checks whether date by Key in given
+// values map compares to condition dates as its condition name states.
+// For example,
+//   - if name = DateLessThan, Key = AWSCurrentTime and values = ["2019-01-01T00:00:00Z"],
+//     at evaluate() it returns whether current time of request is before 2019.
+type dateFunc struct {
+	n      name
+	k      Key
+	values []time.Time
+}
+
+// evaluate() - evaluates to check whether date by Key in given values satisfies
+// the comparison with any of condition dates.
+func (f dateFunc) evaluate(values map[string][]string) bool {
+	operator := strings.TrimPrefix(string(f.n), "Date")
+	negate := operator == "NotEquals"
+	if negate {
+		operator = "Equals"
+	}
+
+	matched := false
+	for _, s := range values[f.k.Name()] {
+		t, err := parseDate(s)
+		if err != nil {
+			continue
+		}
+		for _, value := range f.values {
+			if isComparisonMatched(operator, compareTime(t, value)) {
+				matched = true
+			}
+		}
+	}
+
+	return matched != negate
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f dateFunc) key() Key {
+	return f.k
+}
+
+// name() - returns condition name of this function, e.g. "DateLessThan".
+func (f dateFunc) name() name {
+	return f.n
+}
+
+func (f dateFunc) String() string {
+	valueStrings := []string{}
+	for _, value := range f.values {
+		valueStrings = append(valueStrings, value.UTC().Format(time.RFC3339))
+	}
+	sort.Strings(valueStrings)
+
+	return fmt.Sprintf("%v:%v:%v", f.n, f.k, valueStrings)
+}
+
+// toMap - returns map representation of this function.
+func (f dateFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	values := NewValueSet()
+	for _, value := range f.values {
+		values.Add(NewStringValue(value.UTC().Format(time.RFC3339)))
+	}
+
+	return map[Key]ValueSet{
+		f.k: values,
+	}
+}
+
+// newDateFunc - returns new Date function of given condition name.
+func newDateFunc(n name, key Key, values ValueSet) (Function, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value is required for %v condition", n)
+	}
+
+	dates := []time.Time{}
+	for value := range values {
+		date, err := parseDate(value.String())
+		if err != nil {
+			return nil, fmt.Errorf("value must be a date for %v condition", n)
+		}
+		dates = append(dates, date)
+	}
+
+	return &dateFunc{n, key, dates}, nil
+}
+
+// NewDateFunc - returns new Date function, name should be one of "DateEquals",
+// "DateNotEquals", "DateLessThan", "DateLessThanEquals", "DateGreaterThan" and
+// "DateGreaterThanEquals".
+func NewDateFunc(conditionName string, key Key, values ...time.Time) (Function, error) {
+	n := name(conditionName)
+	if !strings.HasPrefix(conditionName, "Date") || !isValidBaseName(n) {
+		return nil, fmt.Errorf("invalid date condition name '%v'", conditionName)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value is required for %v condition", n)
+	}
+
+	return &dateFunc{n, key, values}, nil
+}
@@ -71,7 +71,14 @@ func (functions Functions) MarshalJSON() ([]byte, error) {
 	nm := make(map[name]map[Key]ValueSet)
 
 	for _, f := range functions {
-		nm[f.name()] = f.toMap()
+		// functions of the same name are merged, e.g. StringLike of different keys
+		if m, found := nm[f.name()]; found {
+			for k, v := range f.toMap() {
+				m[k] = v
+			}
+		} else {
+			nm[f.name()] = f.toMap()
+		}
 	}
 
 	return json.Marshal(nm)
@@ -119,38 +126,9 @@ func (functions *Functions) UnmarshalJSON(data []byte) error {
 				return err
 			}
 
-			var f Function
-			switch n {
-			case stringEquals:
-				if f, err = newStringEqualsFunc(key, values); err != nil {
-					return err
-				}
-			case stringNotEquals:
-				if f, err = newStringNotEqualsFunc(key, values); err != nil {
-					return err
-				}
-			case stringLike:
-				if f, err = newStringLikeFunc(key, values); err != nil {
-					return err
-				}
-			case stringNotLike:
-				if f, err = newStringNotLikeFunc(key, values); err != nil {
-					return err
-				}
-			case ipAddress:
-				if f, err = newIPAddressFunc(key, values); err != nil {
-					return err
-				}
-			case notIPAddress:
-				if f, err = newNotIPAddressFunc(key, values); err != nil {
-					return err
-				}
-			case null:
-				if f, err = newNullFunc(key, values); err != nil {
-					return err
-				}
-			default:
-				return fmt.Errorf("%v is not handled", n)
+			f, err := newFunction(n, key, values)
+			if err != nil {
+				return err
 			}
 
 			funcs = append(funcs, f)
@@ -162,6 +140,49 @@ func (functions *Functions) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// newFunction - returns new function of given condition name, which could have
+// set qualifier and IfExists suffix.
+func newFunction(n name, key Key, values ValueSet) (f Function, err error) {
+	_, base, _ := splitName(n)
+	switch base {
+	case stringEquals:
+		f, err = newStringEqualsFunc(key, values)
+	case stringNotEquals:
+		f, err = newStringNotEqualsFunc(key, values)
+	case stringEqualsIgnoreCase:
+		f, err = newStringEqualsIgnoreCaseFunc(key, values)
+	case stringNotEqualsIgnoreCase:
+		f, err = newStringNotEqualsIgnoreCaseFunc(key, values)
+	case stringLike:
+		f, err = newStringLikeFunc(key, values)
+	case stringNotLike:
+		f, err = newStringNotLikeFunc(key, values)
+	case numericEquals, numericNotEquals, numericLessThan, numericLessThanEquals,
+		numericGreaterThan, numericGreaterThanEquals:
+		f, err = newNumericFunc(base, key, values)
+	case dateEquals, dateNotEquals, dateLessThan, dateLessThanEquals,
+		dateGreaterThan, dateGreaterThanEquals:
+		f, err = newDateFunc(base, key, values)
+	case boolean:
+		f, err = newBoolFunc(key, values)
+	case ipAddress:
+		f, err = newIPAddressFunc(key, values)
+	case notIPAddress:
+		f, err = newNotIPAddressFunc(key, values)
+	case arnEquals, arnNotEquals, arnLike, arnNotLike:
+		f, err = newARNFunc(base, key, values)
+	case null:
+		f, err = newNullFunc(key, values)
+	default:
+		return nil, fmt.Errorf("%v is not handled", n)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return newQualifiedFunc(n, f), nil
+}
+
 // GobEncode - encodes Functions to gob data.
 func (functions Functions) GobEncode() ([]byte, error) {
 	return functions.MarshalJSON()
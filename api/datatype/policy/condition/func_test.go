@@ -0,0 +1,167 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFunctionsEvaluate(t *testing.T) {
+	testCases := []struct {
+		condition      string
+		values         map[string][]string
+		expectedResult bool
+	}{
+		// dates, in ISO 8601 or epoch seconds
+		{`{"DateLessThan": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{"CurrentTime": {"2018-12-31T23:59:59Z"}}, true},
+		{`{"DateLessThan": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, false},
+		{`{"DateLessThanEquals": {"aws:CurrentTime": "2019-01-01"}}`,
+			map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, true},
+		{`{"DateGreaterThan": {"aws:EpochTime": "1546300800"}}`,
+			map[string][]string{"EpochTime": {"1546300801"}}, true},
+		{`{"DateGreaterThanEquals": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{"CurrentTime": {"2018-12-31T23:59:59Z"}}, false},
+		{`{"DateEquals": {"aws:CurrentTime": "2019-01-01T08:00:00+08:00"}}`,
+			map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, true},
+		{`{"DateNotEquals": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, false},
+		{`{"DateLessThan": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{"CurrentTime": {"not a date"}}, false},
+		{`{"DateLessThan": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`,
+			map[string][]string{}, false},
+
+		// numbers
+		{`{"NumericEquals": {"s3:max-keys": "10"}}`,
+			map[string][]string{"max-keys": {"10"}}, true},
+		{`{"NumericNotEquals": {"s3:max-keys": "10"}}`,
+			map[string][]string{"max-keys": {"10"}}, false},
+		{`{"NumericLessThan": {"s3:max-keys": 10}}`,
+			map[string][]string{"max-keys": {"9.5"}}, true},
+		{`{"NumericLessThanEquals": {"s3:max-keys": "10"}}`,
+			map[string][]string{"max-keys": {"11"}}, false},
+		{`{"NumericGreaterThan": {"s3:max-keys": "10"}}`,
+			map[string][]string{"max-keys": {"11"}}, true},
+		{`{"NumericGreaterThanEquals": {"s3:max-keys": "10"}}`,
+			map[string][]string{"max-keys": {"ten"}}, false},
+
+		// booleans
+		{`{"Bool": {"aws:SecureTransport": "true"}}`,
+			map[string][]string{"SecureTransport": {"true"}}, true},
+		{`{"Bool": {"aws:SecureTransport": false}}`,
+			map[string][]string{"SecureTransport": {"true"}}, false},
+		{`{"Bool": {"aws:SecureTransport": "false"}}`,
+			map[string][]string{}, false},
+
+		// case insensitive strings
+		{`{"StringEqualsIgnoreCase": {"aws:UserAgent": "AWS-CLI"}}`,
+			map[string][]string{"UserAgent": {"aws-cli"}}, true},
+		{`{"StringEqualsIgnoreCase": {"aws:UserAgent": "AWS-CLI"}}`,
+			map[string][]string{"UserAgent": {"aws-sdk"}}, false},
+		{`{"StringNotEqualsIgnoreCase": {"aws:UserAgent": ["AWS-CLI", "boto3"]}}`,
+			map[string][]string{"UserAgent": {"BOTO3"}}, false},
+		{`{"StringNotEqualsIgnoreCase": {"aws:UserAgent": ["AWS-CLI", "boto3"]}}`,
+			map[string][]string{"UserAgent": {"curl"}}, true},
+
+		// IfExists evaluates to true if key is missing
+		{`{"StringEqualsIfExists": {"s3:x-amz-storage-class": "STANDARD"}}`,
+			map[string][]string{}, true},
+		{`{"StringEqualsIfExists": {"s3:x-amz-storage-class": "STANDARD"}}`,
+			map[string][]string{"x-amz-storage-class": {"GLACIER"}}, false},
+		{`{"StringEquals": {"s3:x-amz-storage-class": "STANDARD"}}`,
+			map[string][]string{}, false},
+		{`{"NumericLessThanIfExists": {"s3:max-keys": "10"}}`,
+			map[string][]string{}, true},
+		{`{"BoolIfExists": {"aws:SecureTransport": "true"}}`,
+			map[string][]string{"SecureTransport": {"false"}}, false},
+
+		// set qualifiers
+		{`{"ForAnyValue:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{"prefix": {"private/a", "public/b"}}, true},
+		{`{"ForAnyValue:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{"prefix": {"private/a"}}, false},
+		{`{"ForAnyValue:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{}, false},
+		{`{"ForAllValues:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{"prefix": {"home/a", "public/b"}}, true},
+		{`{"ForAllValues:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{"prefix": {"home/a", "private/b"}}, false},
+		{`{"ForAllValues:StringLike": {"s3:prefix": ["home/*", "public/*"]}}`,
+			map[string][]string{}, true},
+		{`{"ForAnyValue:StringEqualsIfExists": {"s3:prefix": "home/"}}`,
+			map[string][]string{}, false},
+
+		// existing object tags
+		{`{"StringEquals": {"s3:ExistingObjectTag/project": "tiger"}}`,
+			map[string][]string{"ExistingObjectTag/project": {"tiger"}}, true},
+
+		// all functions should be satisfied
+		{`{"Bool": {"aws:SecureTransport": "true"}, "StringEquals": {"s3:prefix": "home/"}}`,
+			map[string][]string{"SecureTransport": {"true"}, "prefix": {"public/"}}, false},
+	}
+
+	for i, testCase := range testCases {
+		var functions Functions
+		if err := json.Unmarshal([]byte(testCase.condition), &functions); err != nil {
+			t.Fatalf("case %v: unexpected error %v", i+1, err)
+		}
+		result := functions.Evaluate(testCase.values)
+		assert.Equal(t, testCase.expectedResult, result, "case %v: %v", i+1, testCase.condition)
+	}
+}
+
+func TestFunctionsUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		condition string
+		expectErr bool
+	}{
+		{`{"DateLessThan": {"aws:CurrentTime": "2019-01-01T00:00:00Z"}}`, false},
+		{`{"DateLessThan": {"aws:CurrentTime": "yesterday"}}`, true},
+		{`{"NumericLessThan": {"s3:max-keys": "ten"}}`, true},
+		{`{"Bool": {"aws:SecureTransport": "yes"}}`, true},
+		{`{"Bool": {"aws:SecureTransport": ["true", "false"]}}`, true},
+		{`{"ArnLike": {"aws:Referer": "arn:aws:iam::*:root"}}`, false},
+		{`{"ArnLike": {"aws:Referer": "not an arn"}}`, true},
+		{`{"ForAnyValue:StringEqualsIfExists": {"s3:prefix": "home/"}}`, false},
+		{`{"ForSomeValues:StringEquals": {"s3:prefix": "home/"}}`, true},
+		{`{"StringEquals": {"s3:NoSuchKey": "home/"}}`, true},
+		{`{}`, true},
+	}
+
+	for i, testCase := range testCases {
+		var functions Functions
+		err := json.Unmarshal([]byte(testCase.condition), &functions)
+		assert.Equal(t, testCase.expectErr, err != nil, "case %v: %v %v", i+1,
+			testCase.condition, err)
+	}
+}
+
+func TestARNFunc(t *testing.T) {
+	key := Key("aws:SourceArn")
+	testCases := []struct {
+		conditionName  string
+		values         []string
+		arn            string
+		expectedResult bool
+	}{
+		{"ArnLike", []string{"arn:aws:iam::*:root"}, "arn:aws:iam::123456789012:root", true},
+		{"ArnLike", []string{"arn:aws:iam::*:root"}, "arn:aws:iam::123456789012:user/tiger", false},
+		// wildcards don't match across components
+		{"ArnLike", []string{"arn:aws:s3:::*"}, "arn:aws:iam::123456789012:root", false},
+		{"ArnEquals", []string{"arn:aws:s3:::tiger/*"}, "arn:aws:s3:::tiger/a", true},
+		{"ArnNotLike", []string{"arn:aws:s3:::tiger/*"}, "arn:aws:s3:::turtle/a", true},
+		{"ArnNotEquals", []string{"arn:aws:s3:::tiger/*"}, "arn:aws:s3:::tiger/a", false},
+		{"ArnLike", []string{"arn:aws:s3:::tiger/*"}, "tiger/a", false},
+	}
+
+	for i, testCase := range testCases {
+		f, err := NewARNFunc(testCase.conditionName, key, testCase.values...)
+		if err != nil {
+			t.Fatalf("case %v: unexpected error %v", i+1, err)
+		}
+		result := f.evaluate(map[string][]string{key.Name(): {testCase.arn}})
+		assert.Equal(t, testCase.expectedResult, result, "case %v", i+1)
+	}
+}
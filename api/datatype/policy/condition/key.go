@@ -68,8 +68,47 @@ const (
 
 	// AWSSourceIP - key representing client's IP address (not intermittent proxies) of any API.
 	AWSSourceIP = "aws:SourceIp"
+
+	// AWSCurrentTime - key representing date and time of request in ISO 8601 format of any API.
+	AWSCurrentTime = "aws:CurrentTime"
+
+	// AWSEpochTime - key representing date and time of request in epoch seconds of any API.
+	AWSEpochTime = "aws:EpochTime"
+
+	// AWSSecureTransport - key representing whether request is sent using TLS of any API.
+	AWSSecureTransport = "aws:SecureTransport"
+
+	// AWSUserAgent - key representing User-Agent header of any API.
+	AWSUserAgent = "aws:UserAgent"
+
+	// S3SignatureVersion - key representing signature version of request of any API,
+	// "AWS" for V2 and "AWS4-HMAC-SHA256" for V4.
+	S3SignatureVersion = "s3:signatureversion"
+
+	// S3AuthType - key representing how request is authenticated of any API,
+	// "REST-HEADER", "REST-QUERY-STRING" or "POST".
+	S3AuthType = "s3:authType"
+
+	// S3ExistingObjectTagPrefix - prefix of keys representing tags of existing object,
+	// e.g. "s3:ExistingObjectTag/project", applicable to object APIs only.
+	S3ExistingObjectTagPrefix = "s3:ExistingObjectTag/"
 )
 
+// CommonKeys - keys which are applicable to any API.
+var CommonKeys = NewKeySet(AWSReferer, AWSSourceIP, AWSCurrentTime, AWSEpochTime,
+	AWSSecureTransport, AWSUserAgent, S3SignatureVersion, S3AuthType)
+
+// NewExistingObjectTagKey - returns key representing tag of existing object.
+func NewExistingObjectTagKey(tagKey string) Key {
+	return Key(S3ExistingObjectTagPrefix + tagKey)
+}
+
+// IsExistingObjectTag - checks if key represents tag of existing object.
+func (key Key) IsExistingObjectTag() bool {
+	return strings.HasPrefix(string(key), S3ExistingObjectTagPrefix) &&
+		len(key) > len(S3ExistingObjectTagPrefix)
+}
+
 // IsValid - checks if key is valid or not.
 func (key Key) IsValid() bool {
 	switch key {
@@ -77,11 +116,13 @@ func (key Key) IsValid() bool {
 		fallthrough
 	case S3XAmzMetadataDirective, S3XAmzStorageClass, S3LocationConstraint, S3Prefix:
 		fallthrough
-	case S3Delimiter, S3MaxKeys, AWSReferer, AWSSourceIP:
+	case S3Delimiter, S3MaxKeys, AWSReferer, AWSSourceIP, AWSCurrentTime, AWSEpochTime:
+		fallthrough
+	case AWSSecureTransport, AWSUserAgent, S3SignatureVersion, S3AuthType:
 		return true
 	}
 
-	return false
+	return key.IsExistingObjectTag()
 }
 
 // MarshalJSON - encodes Key to JSON data.
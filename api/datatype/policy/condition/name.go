@@ -19,30 +19,99 @@ package condition
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 type name string
 
 const (
-	stringEquals    name = "StringEquals"
-	stringNotEquals      = "StringNotEquals"
-	stringLike           = "StringLike"
-	stringNotLike        = "StringNotLike"
-	ipAddress            = "IpAddress"
-	notIPAddress         = "NotIpAddress"
-	null                 = "Null"
+	stringEquals              name = "StringEquals"
+	stringNotEquals                = "StringNotEquals"
+	stringEqualsIgnoreCase         = "StringEqualsIgnoreCase"
+	stringNotEqualsIgnoreCase      = "StringNotEqualsIgnoreCase"
+	stringLike                     = "StringLike"
+	stringNotLike                  = "StringNotLike"
+	numericEquals                  = "NumericEquals"
+	numericNotEquals               = "NumericNotEquals"
+	numericLessThan                = "NumericLessThan"
+	numericLessThanEquals          = "NumericLessThanEquals"
+	numericGreaterThan             = "NumericGreaterThan"
+	numericGreaterThanEquals       = "NumericGreaterThanEquals"
+	dateEquals                     = "DateEquals"
+	dateNotEquals                  = "DateNotEquals"
+	dateLessThan                   = "DateLessThan"
+	dateLessThanEquals             = "DateLessThanEquals"
+	dateGreaterThan                = "DateGreaterThan"
+	dateGreaterThanEquals          = "DateGreaterThanEquals"
+	boolean                        = "Bool"
+	ipAddress                      = "IpAddress"
+	notIPAddress                   = "NotIpAddress"
+	arnEquals                      = "ArnEquals"
+	arnNotEquals                   = "ArnNotEquals"
+	arnLike                        = "ArnLike"
+	arnNotLike                     = "ArnNotLike"
+	null                           = "Null"
 )
 
-// IsValid - checks if name is valid or not.
-func (n name) IsValid() bool {
+// Set qualifiers and suffix which could be added to condition names, e.g.
+// "ForAnyValue:StringLike" or "StringEqualsIfExists"
+const (
+	forAnyValue    = "ForAnyValue:"
+	forAllValues   = "ForAllValues:"
+	ifExistsSuffix = "IfExists"
+)
+
+// splitName - splits condition name into set qualifier, base name and whether
+// it has IfExists suffix.
+func splitName(n name) (qualifier string, base name, ifExists bool) {
+	s := string(n)
+	for _, q := range []string{forAnyValue, forAllValues} {
+		if strings.HasPrefix(s, q) {
+			qualifier = q
+			s = strings.TrimPrefix(s, q)
+			break
+		}
+	}
+	if strings.HasSuffix(s, ifExistsSuffix) {
+		ifExists = true
+		s = strings.TrimSuffix(s, ifExistsSuffix)
+	}
+	return qualifier, name(s), ifExists
+}
+
+// isValidBaseName - checks if name without qualifier and suffix is valid or not.
+func isValidBaseName(n name) bool {
 	switch n {
-	case stringEquals, stringNotEquals, stringLike, stringNotLike, ipAddress, notIPAddress, null:
+	case stringEquals, stringNotEquals, stringEqualsIgnoreCase, stringNotEqualsIgnoreCase:
+		fallthrough
+	case stringLike, stringNotLike, boolean, ipAddress, notIPAddress, null:
+		fallthrough
+	case numericEquals, numericNotEquals, numericLessThan, numericLessThanEquals:
+		fallthrough
+	case numericGreaterThan, numericGreaterThanEquals:
+		fallthrough
+	case dateEquals, dateNotEquals, dateLessThan, dateLessThanEquals:
+		fallthrough
+	case dateGreaterThan, dateGreaterThanEquals:
+		fallthrough
+	case arnEquals, arnNotEquals, arnLike, arnNotLike:
 		return true
 	}
 
 	return false
 }
 
+// IsValid - checks if name is valid or not.
+func (n name) IsValid() bool {
+	qualifier, base, ifExists := splitName(n)
+	if !isValidBaseName(base) {
+		return false
+	}
+
+	// Null checks existence of key itself
+	return base != null || (qualifier == "" && !ifExists)
+}
+
 // MarshalJSON - encodes name to JSON data.
 func (n name) MarshalJSON() ([]byte, error) {
 	if !n.IsValid() {
@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isComparisonMatched - checks whether result of comparing request value with
+// condition value, which is -1, 0 or +1, satisfies the comparison operator of
+// condition name, e.g. "LessThan" of "NumericLessThan". "NotEquals" is handled
+// by negating "Equals".
+func isComparisonMatched(operator string, result int) bool {
+	switch operator {
+	case "Equals":
+		return result == 0
+	case "LessThan":
+		return result < 0
+	case "LessThanEquals":
+		return result <= 0
+	case "GreaterThan":
+		return result > 0
+	case "GreaterThanEquals":
+		return result >= 0
+	}
+
+	return false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// numericFunc - Numeric comparison function. It checks whether value by Key in
+// given values map compares to condition values as its condition name states.
+// For example,
+//   - if name = NumericLessThan and values = ["10"], at evaluate() it returns
+//     whether number in value map for Key is less than 10.
+type numericFunc struct {
+	n      name
+	k      Key
+	values []float64
+}
+
+// evaluate() - evaluates to check whether value by Key in given values satisfies
+// the comparison with any of condition values.
+func (f numericFunc) evaluate(values map[string][]string) bool {
+	operator := strings.TrimPrefix(string(f.n), "Numeric")
+	negate := operator == "NotEquals"
+	if negate {
+		operator = "Equals"
+	}
+
+	matched := false
+	for _, s := range values[f.k.Name()] {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			continue
+		}
+		for _, value := range f.values {
+			if isComparisonMatched(operator, compareFloat(v, value)) {
+				matched = true
+			}
+		}
+	}
+
+	return matched != negate
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f numericFunc) key() Key {
+	return f.k
+}
+
+// name() - returns condition name of this function, e.g. "NumericEquals".
+func (f numericFunc) name() name {
+	return f.n
+}
+
+func (f numericFunc) String() string {
+	valueStrings := []string{}
+	for _, value := range f.values {
+		valueStrings = append(valueStrings, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	sort.Strings(valueStrings)
+
+	return fmt.Sprintf("%v:%v:%v", f.n, f.k, valueStrings)
+}
+
+// toMap - returns map representation of this function.
+func (f numericFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	values := NewValueSet()
+	for _, value := range f.values {
+		values.Add(NewStringValue(strconv.FormatFloat(value, 'f', -1, 64)))
+	}
+
+	return map[Key]ValueSet{
+		f.k: values,
+	}
+}
+
+// newNumericFunc - returns new Numeric function of given condition name.
+func newNumericFunc(n name, key Key, values ValueSet) (Function, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value is required for %v condition", n)
+	}
+
+	numbers := []float64{}
+	for value := range values {
+		number, err := strconv.ParseFloat(value.String(), 64)
+		if err != nil {
+			return nil, fmt.Errorf("value must be a number for %v condition", n)
+		}
+		numbers = append(numbers, number)
+	}
+
+	return &numericFunc{n, key, numbers}, nil
+}
+
+// NewNumericFunc - returns new Numeric function, name should be one of
+// "NumericEquals", "NumericNotEquals", "NumericLessThan", "NumericLessThanEquals",
+// "NumericGreaterThan" and "NumericGreaterThanEquals".
+func NewNumericFunc(conditionName string, key Key, values ...float64) (Function, error) {
+	n := name(conditionName)
+	if !strings.HasPrefix(conditionName, "Numeric") || !isValidBaseName(n) {
+		return nil, fmt.Errorf("invalid numeric condition name '%v'", conditionName)
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value is required for %v condition", n)
+	}
+
+	return &numericFunc{n, key, values}, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"strings"
+)
+
+// qualifiedFunc - condition function with set qualifier "ForAnyValue:" or
+// "ForAllValues:", and/or "IfExists" suffix, e.g. "ForAnyValue:StringLike" or
+// "StringEqualsIfExists". It wraps the function of base condition name.
+//   - with IfExists, it evaluates to true if Key is not present in given values.
+//   - with ForAnyValue, it evaluates to true if any value by Key satisfies the
+//     base function, false if Key is not present.
+//   - with ForAllValues, it evaluates to true if every value by Key satisfies the
+//     base function, true if Key is not present.
+type qualifiedFunc struct {
+	Function
+	n         name
+	qualifier string
+	ifExists  bool
+}
+
+// evaluate() - evaluates base function according to set qualifier and suffix.
+func (f qualifiedFunc) evaluate(values map[string][]string) bool {
+	keyName := f.key().Name()
+	requestValues := values[keyName]
+	if len(requestValues) == 0 {
+		switch {
+		case f.qualifier == forAnyValue:
+			return false
+		case f.qualifier == forAllValues, f.ifExists:
+			return true
+		}
+		return f.Function.evaluate(values)
+	}
+
+	switch f.qualifier {
+	case forAnyValue:
+		for _, v := range requestValues {
+			if f.Function.evaluate(map[string][]string{keyName: {v}}) {
+				return true
+			}
+		}
+		return false
+	case forAllValues:
+		for _, v := range requestValues {
+			if !f.Function.evaluate(map[string][]string{keyName: {v}}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return f.Function.evaluate(values)
+}
+
+// name() - returns qualified condition name, e.g. "ForAnyValue:StringLike".
+func (f qualifiedFunc) name() name {
+	return f.n
+}
+
+func (f qualifiedFunc) String() string {
+	return string(f.n) + strings.TrimPrefix(f.Function.String(), string(f.Function.name()))
+}
+
+// newQualifiedFunc - returns base function wrapped with set qualifier and suffix
+// of condition name, or base function itself if there are none.
+func newQualifiedFunc(n name, function Function) Function {
+	qualifier, _, ifExists := splitName(n)
+	if qualifier == "" && !ifExists {
+		return function
+	}
+
+	return &qualifiedFunc{function, n, qualifier, ifExists}
+}
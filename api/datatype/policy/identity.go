@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ParseIdentityPolicy - parses identity policy which is attached to a user or role.
+// It shares the grammar of bucket policy, except that statements have no Principal
+// since they always apply to the identity which the policy is attached to.
+func ParseIdentityPolicy(data []byte) (*Policy, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	var statements []map[string]json.RawMessage
+	if err := json.Unmarshal(document["Statement"], &statements); err != nil {
+		return nil, fmt.Errorf("invalid Statement: %v", err)
+	}
+	for _, statement := range statements {
+		if _, ok := statement["Principal"]; ok {
+			return nil, fmt.Errorf("Principal is not allowed in identity policy")
+		}
+		statement["Principal"] = json.RawMessage(`"*"`)
+	}
+
+	var err error
+	if document["Statement"], err = json.Marshal(statements); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(document); err != nil {
+		return nil, err
+	}
+
+	var policy Policy
+	if err = json.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// IsAllowedByPolicies - checks given policy args against all identity policies,
+// any deny statement wins, otherwise any allow statement allows.
+func IsAllowedByPolicies(policies []Policy, args Args) IsPolicyAllowedResult {
+	result := NoPolicy
+	for _, policy := range policies {
+		switch policy.IsAllowed(args) {
+		case PolicyDeny:
+			return PolicyDeny
+		case PolicyAllow:
+			result = PolicyAllow
+		}
+	}
+
+	return result
+}
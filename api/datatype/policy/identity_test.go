@@ -0,0 +1,94 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/yig/api/datatype/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	allowGetObject = `{
+	"Version": "2012-10-17",
+	"Statement": [{
+		"Effect": "Allow",
+		"Action": ["s3:GetObject"],
+		"Resource": ["arn:aws:s3:::tiger/*"]
+	}]
+}`
+	denyDeleteBucket = `{
+	"Version": "2012-10-17",
+	"Statement": [{
+		"Effect": "Deny",
+		"Action": ["s3:DeleteBucket", "s3:GetObject"],
+		"Resource": ["arn:aws:s3:::tiger", "arn:aws:s3:::tiger/secret/*"]
+	}]
+}`
+)
+
+func parse(t *testing.T, document string) policy.Policy {
+	p, err := policy.ParseIdentityPolicy([]byte(document))
+	if err != nil {
+		t.Fatal("ParseIdentityPolicy error:", err)
+	}
+	return *p
+}
+
+func TestParseIdentityPolicy(t *testing.T) {
+	var testcase = [...]struct {
+		document string
+		valid    bool
+	}{
+		{allowGetObject, true},
+		{denyDeleteBucket, true},
+		// identity policies always apply to the user they are attached to
+		{`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Principal": "*",
+			"Action": ["s3:GetObject"], "Resource": ["arn:aws:s3:::tiger/*"]}]}`, false},
+		{`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow",
+			"Action": ["s3:NoSuchAction"], "Resource": ["arn:aws:s3:::tiger/*"]}]}`, false},
+		// object actions need object resources
+		{`{"Version": "2012-10-17", "Statement": [{"Effect": "Allow",
+			"Action": ["s3:GetObject"], "Resource": ["arn:aws:s3:::tiger"]}]}`, false},
+		{`{"Version": "2012-10-17", "Statement": {}}`, false},
+		{`not json`, false},
+	}
+	for _, c := range testcase {
+		p, err := policy.ParseIdentityPolicy([]byte(c.document))
+		if c.valid {
+			assert.Nil(t, err, c.document)
+			assert.Equal(t, 1, len(p.Statements))
+		} else {
+			assert.NotNil(t, err, c.document)
+		}
+	}
+}
+
+func TestIsAllowedByPolicies(t *testing.T) {
+	policies := []policy.Policy{parse(t, allowGetObject), parse(t, denyDeleteBucket)}
+	var testcase = [...]struct {
+		policies   []policy.Policy
+		action     policy.Action
+		bucketName string
+		objectName string
+		expected   policy.IsPolicyAllowedResult
+	}{
+		{policies, policy.GetObjectAction, "tiger", "public/a", policy.PolicyAllow},
+		// any deny wins
+		{policies, policy.GetObjectAction, "tiger", "secret/a", policy.PolicyDeny},
+		{policies, policy.DeleteBucketAction, "tiger", "", policy.PolicyDeny},
+		{policies, policy.PutObjectAction, "tiger", "public/a", policy.NoPolicy},
+		{policies, policy.GetObjectAction, "turtle", "public/a", policy.NoPolicy},
+		{policies, policy.DeleteBucketAction, "turtle", "", policy.NoPolicy},
+		{nil, policy.GetObjectAction, "tiger", "public/a", policy.NoPolicy},
+	}
+	for _, c := range testcase {
+		result := policy.IsAllowedByPolicies(c.policies, policy.Args{
+			AccountName:     "hehehehe",
+			Action:          c.action,
+			BucketName:      c.bucketName,
+			ConditionValues: map[string][]string{},
+			ObjectName:      c.objectName,
+		})
+		assert.Equal(t, c.expected, result, c.action, c.bucketName, c.objectName)
+	}
+}
@@ -94,7 +94,12 @@ func (statement Statement) isValid() error {
 		}
 
 		keys := statement.Conditions.Keys()
-		keyDiff := keys.Difference(actionConditionKeyMap[action])
+		keyDiff := keys.Difference(actionConditionKeyMap[action]).Difference(condition.CommonKeys)
+		for key := range keyDiff {
+			if key.IsExistingObjectTag() && action.isExistingObjectTagSupported() {
+				delete(keyDiff, key)
+			}
+		}
 		if !keyDiff.IsEmpty() {
 			return fmt.Errorf("unsupported condition keys '%v' used for action '%v'", keyDiff, action)
 		}
@@ -0,0 +1,129 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+)
+
+const (
+	MaxReplicationConfigurationSize = 128 * humanize.KiByte
+	MaxReplicationRulesCount        = 1000
+	ReplicationStatusHeader         = "X-Amz-Replication-Status"
+	ReplicationRuleEnabled          = "Enabled"
+	ReplicationRuleDisabled         = "Disabled"
+)
+
+type ReplicationConfiguration struct {
+	XMLName xml.Name          `xml:"ReplicationConfiguration"`
+	Xmlns   string            `xml:"xmlns,attr,omitempty"`
+	Role    string            `xml:"Role,omitempty"`
+	Rules   []ReplicationRule `xml:"Rule"`
+}
+
+type ReplicationRule struct {
+	ID          string                 `xml:"ID,omitempty"`
+	Status      string                 `xml:"Status"`
+	Prefix      string                 `xml:"Prefix,omitempty"`
+	Filter      *ReplicationFilter     `xml:"Filter,omitempty"`
+	Destination ReplicationDestination `xml:"Destination"`
+}
+
+type ReplicationFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type ReplicationDestination struct {
+	// bucket ARN, i.e. "arn:aws:s3:<region>::<bucket>", region of the destination bucket
+	// selects one of `replication_targets` in config, empty region means local region
+	Bucket       string `xml:"Bucket"`
+	StorageClass string `xml:"StorageClass,omitempty"`
+}
+
+func (c ReplicationConfiguration) IsEmpty() bool {
+	return len(c.Rules) == 0
+}
+
+func (rule ReplicationRule) prefix() string {
+	if rule.Filter != nil {
+		return rule.Filter.Prefix
+	}
+	return rule.Prefix
+}
+
+// Returns the first enabled rule matching object key, nil if none matches
+func (c ReplicationConfiguration) Match(objectName string) *ReplicationRule {
+	for i, rule := range c.Rules {
+		if rule.Status == ReplicationRuleEnabled && strings.HasPrefix(objectName, rule.prefix()) {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Parse destination bucket ARN "arn:aws:s3:<region>::<bucket>"
+func ParseReplicationDestination(arn string) (region, bucket string, err error) {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "s3" || parts[5] == "" {
+		return "", "", ErrInvalidReplicationConfiguration
+	}
+	region = parts[3]
+	if region == "" {
+		region = helper.CONFIG.Region
+	}
+	return region, parts[5], nil
+}
+
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTBucketPUTreplication.html
+func (c *ReplicationConfiguration) Validate() error {
+	if len(c.Rules) == 0 || len(c.Rules) > MaxReplicationRulesCount {
+		return ErrInvalidReplicationConfiguration
+	}
+	ids := make(map[string]bool)
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if rule.Status != ReplicationRuleEnabled && rule.Status != ReplicationRuleDisabled {
+			return ErrInvalidReplicationConfiguration
+		}
+		if rule.Filter != nil && rule.Prefix != "" {
+			return ErrInvalidReplicationConfiguration
+		}
+		if _, _, err := ParseReplicationDestination(rule.Destination.Bucket); err != nil {
+			return err
+		}
+		rule.ID = helper.Ternary(rule.ID == "", string(helper.GenerateRandomId()), rule.ID).(string)
+		if ids[rule.ID] {
+			return ErrInvalidReplicationConfiguration
+		}
+		ids[rule.ID] = true
+	}
+	return nil
+}
+
+func ParseReplicationConfig(reader io.Reader) (*ReplicationConfiguration, error) {
+	config := new(ReplicationConfiguration)
+	replicationBuffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		helper.Logger.Error("Unable to read replication config body:", err)
+		return nil, err
+	}
+	size := len(replicationBuffer)
+	if size > MaxReplicationConfigurationSize {
+		return nil, ErrEntityTooLarge
+	}
+	err = xml.Unmarshal(replicationBuffer, config)
+	if err != nil {
+		helper.Logger.Error("Unable to parse replication config XML body:", err)
+		return nil, ErrMalformedXML
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,194 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+)
+
+const (
+	MaxSelectRequestSize = 256 * humanize.KiByte
+
+	SelectCompressionNone  = "NONE"
+	SelectCompressionGzip  = "GZIP"
+	SelectCompressionBzip2 = "BZIP2"
+
+	CsvFileHeaderUse    = "USE"
+	CsvFileHeaderIgnore = "IGNORE"
+	CsvFileHeaderNone   = "NONE"
+
+	JsonTypeDocument = "DOCUMENT"
+	JsonTypeLines    = "LINES"
+
+	CsvQuoteFieldsAlways   = "ALWAYS"
+	CsvQuoteFieldsAsNeeded = "ASNEEDED"
+)
+
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/API_SelectObjectContent.html
+type SelectObjectContentRequest struct {
+	XMLName             xml.Name            `xml:"SelectObjectContentRequest"`
+	Expression          string              `xml:"Expression"`
+	ExpressionType      string              `xml:"ExpressionType"`
+	InputSerialization  InputSerialization  `xml:"InputSerialization"`
+	OutputSerialization OutputSerialization `xml:"OutputSerialization"`
+}
+
+type InputSerialization struct {
+	CompressionType string     `xml:"CompressionType"`
+	CSV             *CsvInput  `xml:"CSV"`
+	JSON            *JsonInput `xml:"JSON"`
+	Parquet         *struct{}  `xml:"Parquet"`
+}
+
+type CsvInput struct {
+	FileHeaderInfo       string `xml:"FileHeaderInfo"`
+	Comments             string `xml:"Comments"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+}
+
+type JsonInput struct {
+	Type string `xml:"Type"`
+}
+
+type OutputSerialization struct {
+	CSV  *CsvOutput  `xml:"CSV"`
+	JSON *JsonOutput `xml:"JSON"`
+}
+
+type CsvOutput struct {
+	QuoteFields          string `xml:"QuoteFields"`
+	QuoteEscapeCharacter string `xml:"QuoteEscapeCharacter"`
+	RecordDelimiter      string `xml:"RecordDelimiter"`
+	FieldDelimiter       string `xml:"FieldDelimiter"`
+	QuoteCharacter       string `xml:"QuoteCharacter"`
+}
+
+type JsonOutput struct {
+	RecordDelimiter string `xml:"RecordDelimiter"`
+}
+
+func defaultString(s *string, value string) {
+	if *s == "" {
+		*s = value
+	}
+}
+
+func isSingleCharacter(s string) bool {
+	return utf8.RuneCountInString(s) == 1
+}
+
+// Fill in defaults and check the request, only SQL over CSV or JSON is
+// supported
+func (s *SelectObjectContentRequest) Validate() error {
+	if !strings.EqualFold(s.ExpressionType, "SQL") {
+		return ErrInvalidExpressionType
+	}
+	if strings.TrimSpace(s.Expression) == "" {
+		return ErrInvalidSelectQuery
+	}
+
+	input := &s.InputSerialization
+	input.CompressionType = strings.ToUpper(input.CompressionType)
+	defaultString(&input.CompressionType, SelectCompressionNone)
+	switch input.CompressionType {
+	case SelectCompressionNone, SelectCompressionGzip, SelectCompressionBzip2:
+	default:
+		return ErrInvalidCompressionFormat
+	}
+	switch {
+	case input.Parquet != nil:
+		return ErrInvalidDataSource
+	case input.CSV != nil && input.JSON != nil:
+		return ErrInvalidSelectParameter
+	case input.CSV != nil:
+		csv := input.CSV
+		csv.FileHeaderInfo = strings.ToUpper(csv.FileHeaderInfo)
+		defaultString(&csv.FileHeaderInfo, CsvFileHeaderNone)
+		defaultString(&csv.RecordDelimiter, "\n")
+		defaultString(&csv.FieldDelimiter, ",")
+		defaultString(&csv.QuoteCharacter, "\"")
+		defaultString(&csv.QuoteEscapeCharacter, csv.QuoteCharacter)
+		switch csv.FileHeaderInfo {
+		case CsvFileHeaderUse, CsvFileHeaderIgnore, CsvFileHeaderNone:
+		default:
+			return ErrInvalidSelectParameter
+		}
+		if utf8.RuneCountInString(csv.RecordDelimiter) > 2 ||
+			!isSingleCharacter(csv.FieldDelimiter) ||
+			!isSingleCharacter(csv.QuoteCharacter) ||
+			!isSingleCharacter(csv.QuoteEscapeCharacter) ||
+			utf8.RuneCountInString(csv.Comments) > 1 {
+			return ErrInvalidSelectParameter
+		}
+	case input.JSON != nil:
+		input.JSON.Type = strings.ToUpper(input.JSON.Type)
+		defaultString(&input.JSON.Type, JsonTypeDocument)
+		if input.JSON.Type != JsonTypeDocument && input.JSON.Type != JsonTypeLines {
+			return ErrInvalidSelectParameter
+		}
+	default:
+		return ErrInvalidDataSource
+	}
+
+	output := &s.OutputSerialization
+	switch {
+	case output.CSV != nil && output.JSON != nil:
+		return ErrInvalidSelectParameter
+	case output.CSV != nil:
+		csv := output.CSV
+		csv.QuoteFields = strings.ToUpper(csv.QuoteFields)
+		defaultString(&csv.QuoteFields, CsvQuoteFieldsAsNeeded)
+		defaultString(&csv.RecordDelimiter, "\n")
+		defaultString(&csv.FieldDelimiter, ",")
+		defaultString(&csv.QuoteCharacter, "\"")
+		defaultString(&csv.QuoteEscapeCharacter, csv.QuoteCharacter)
+		if csv.QuoteFields != CsvQuoteFieldsAlways && csv.QuoteFields != CsvQuoteFieldsAsNeeded {
+			return ErrInvalidSelectParameter
+		}
+		if utf8.RuneCountInString(csv.RecordDelimiter) > 2 ||
+			!isSingleCharacter(csv.FieldDelimiter) ||
+			!isSingleCharacter(csv.QuoteCharacter) ||
+			!isSingleCharacter(csv.QuoteEscapeCharacter) {
+			return ErrInvalidSelectParameter
+		}
+	case output.JSON != nil:
+		defaultString(&output.JSON.RecordDelimiter, "\n")
+		if utf8.RuneCountInString(output.JSON.RecordDelimiter) > 2 {
+			return ErrInvalidSelectParameter
+		}
+	default:
+		return ErrInvalidSelectParameter
+	}
+	return nil
+}
+
+func ParseSelectRequest(reader io.Reader) (*SelectObjectContentRequest, error) {
+	request := new(SelectObjectContentRequest)
+	buffer, err := ioutil.ReadAll(io.LimitReader(reader, MaxSelectRequestSize+1))
+	if err != nil {
+		helper.Logger.Error("Unable to read select request body:", err)
+		return nil, err
+	}
+	if len(buffer) > MaxSelectRequestSize {
+		return nil, ErrEntityTooLarge
+	}
+	err = xml.Unmarshal(buffer, request)
+	if err != nil {
+		helper.Logger.Error("Unable to parse select request XML body:", err)
+		return nil, ErrMalformedXML
+	}
+	err = request.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
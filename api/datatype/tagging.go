@@ -0,0 +1,125 @@
+package datatype
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+)
+
+const (
+	MaxObjectTagsCount          = 10
+	MaxBucketTagsCount          = 50
+	MaxTagKeyLength             = 128
+	MaxTagValueLength           = 256
+	MaxTaggingConfigurationSize = 16 * humanize.KiByte
+	TaggingHeader               = "X-Amz-Tagging"
+	TaggingCountHeader          = "X-Amz-Tagging-Count"
+	TaggingDirectiveHeader      = "X-Amz-Tagging-Directive"
+)
+
+type Tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	Xmlns   string   `xml:"xmlns,attr,omitempty"`
+	TagSet  TagSet   `xml:"TagSet"`
+}
+
+type TagSet struct {
+	Tags []Tag `xml:"Tag"`
+}
+
+type Tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/dev/object-tagging.html
+func validateTags(tags map[string]string, maxTags int) error {
+	if len(tags) > maxTags {
+		if maxTags == MaxBucketTagsCount {
+			return ErrTooManyBucketTags
+		}
+		return ErrTooManyTags
+	}
+	for key, value := range tags {
+		if key == "" || utf8.RuneCountInString(key) > MaxTagKeyLength ||
+			utf8.RuneCountInString(value) > MaxTagValueLength {
+			return ErrInvalidTag
+		}
+	}
+	return nil
+}
+
+// Parse and validate tagging XML of at most MaxTaggingConfigurationSize bytes,
+// returns tags as a map
+func ParseTagging(reader io.Reader, maxTags int) (tags map[string]string, err error) {
+	taggingBuffer, err := ioutil.ReadAll(reader)
+	if err != nil {
+		helper.Logger.Error("Unable to read tagging body:", err)
+		return nil, err
+	}
+	if len(taggingBuffer) > MaxTaggingConfigurationSize {
+		return nil, ErrEntityTooLarge
+	}
+	var tagging Tagging
+	err = xml.Unmarshal(taggingBuffer, &tagging)
+	if err != nil {
+		helper.Logger.Error("Unable to parse tagging XML body:", err)
+		return nil, ErrMalformedXML
+	}
+	tags = make(map[string]string)
+	for _, tag := range tagging.TagSet.Tags {
+		if _, ok := tags[tag.Key]; ok {
+			return nil, ErrInvalidTag
+		}
+		tags[tag.Key] = tag.Value
+	}
+	err = validateTags(tags, maxTags)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Parse `x-amz-tagging` header, which is encoded as URL query parameters,
+// i.e. "Key1=Value1&Key2=Value2"
+func ParseTaggingHeader(header string) (tags map[string]string, err error) {
+	if header == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(header)
+	if err != nil {
+		return nil, ErrInvalidTag
+	}
+	tags = make(map[string]string)
+	for key, value := range values {
+		if len(value) != 1 {
+			return nil, ErrInvalidTag
+		}
+		tags[key] = value[0]
+	}
+	err = validateTags(tags, MaxObjectTagsCount)
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func TaggingFromMap(tags map[string]string) (tagging Tagging) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	tagging.TagSet.Tags = make([]Tag, 0, len(keys))
+	for _, key := range keys {
+		tagging.TagSet.Tags = append(tagging.TagSet.Tags, Tag{Key: key, Value: tags[key]})
+	}
+	return
+}
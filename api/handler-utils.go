@@ -19,6 +19,7 @@ package api
 import (
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
@@ -67,6 +68,9 @@ var supportedHeaders = []string{
 	"content-type",
 	"expires",
 	"website-redirect-location",
+	// set by the replication worker to mark replicas, removed from metadata in storage
+	// and ignored unless the request is signed by one of replication access keys
+	"x-amz-replication-status",
 	// Add more supported headers here
 }
 
@@ -91,15 +95,15 @@ func extractMetadataFromHeader(header http.Header) map[string]string {
 
 func parseSseHeader(header http.Header) (request SseRequest, err error) {
 	// sse three options are mutually exclusive
-	if crypto.S3.IsRequested(header) && crypto.SSEC.IsRequested(header) {
+	if (crypto.S3.IsRequested(header) || crypto.S3KMS.IsRequested(header)) &&
+		crypto.SSEC.IsRequested(header) {
 		return request, ErrIncompatibleEncryptionMethod
 	}
 
 	if sse := header.Get(crypto.SSEHeader); sse != "" {
 		switch sse {
 		case crypto.SSEAlgorithmKMS:
-			err = ErrNotImplemented
-			return request, err
+			request.Type = crypto.S3KMS.String()
 		case crypto.SSEAlgorithmAES256:
 			request.Type = crypto.S3.String()
 		default:
@@ -117,10 +121,24 @@ func parseSseHeader(header http.Header) (request SseRequest, err error) {
 		}
 	}
 
+	// key ID and context are only meaningful for SSE-KMS
+	if request.Type != crypto.S3KMS.String() &&
+		(header.Get(crypto.SSEKmsID) != "" || header.Get(crypto.SSEKmsContext) != "") {
+		return request, ErrInvalidSseHeader
+	}
+
 	switch request.Type {
 	case crypto.S3KMS.String():
-		// Not implemented yet
-		return request, ErrNotImplemented
+		keyID, context, err := crypto.S3KMS.ParseHTTP(header)
+		if err != nil {
+			return request, ErrInvalidEncryptionContext
+		}
+		request.SseAwsKmsKeyId = keyID
+		if len(context) != 0 {
+			encoded, _ := json.Marshal(context)
+			request.SseContext = string(encoded)
+		}
+		return request, nil
 	case crypto.S3.String():
 		// encrypt key will retrieve from kms now
 		return request, nil
@@ -173,6 +191,39 @@ func parseSseHeader(header http.Header) (request SseRequest, err error) {
 	return
 }
 
+// Applies default encryption of the bucket if the request specifies none
+func (api ObjectAPIHandlers) applyBucketEncryption(bucketName string, sseRequest *SseRequest) {
+	if sseRequest.Type != "" {
+		return
+	}
+	configuration, ok := api.ObjectAPI.CheckBucketEncryption(bucketName)
+	if !ok {
+		return
+	}
+	if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
+		sseRequest.Type = crypto.S3.String()
+	} else { // SSE-KMS, the default key of KMS is used if no KMSMasterKeyID
+		sseRequest.Type = crypto.S3KMS.String()
+		sseRequest.SseAwsKmsKeyId = configuration.KMSMasterKeyID
+	}
+}
+
+// Sets the server side encryption response headers of an object
+func setSseResponseHeaders(w http.ResponseWriter, sseType, kmsKeyId, customerKeyMd5 string) {
+	switch sseType {
+	case crypto.S3.String():
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmAES256)
+	case crypto.S3KMS.String():
+		w.Header().Set(crypto.SSEHeader, crypto.SSEAlgorithmKMS)
+		if kmsKeyId != "" {
+			w.Header().Set(crypto.SSEKmsID, kmsKeyId)
+		}
+	case crypto.SSEC.String():
+		w.Header().Set(crypto.SSECAlgorithm, crypto.SSEAlgorithmAES256)
+		w.Header().Set(crypto.SSECKeyMD5, customerKeyMd5)
+	}
+}
+
 // Suffix matcher string matches suffix in a platform specific way.
 // For example on windows since its case insensitive we are supposed
 // to do case insensitive checks.
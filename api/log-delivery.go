@@ -0,0 +1,152 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+const (
+	// Server access log format of S3, see
+	// https://docs.aws.amazon.com/AmazonS3/latest/dev/LogFormat.html
+	ServerAccessLogFormat = "{project_id} {bucket_name} {access_time} {remote_ip} {requester_id} " +
+		"{request_id} {operation_name} {object_name} \"{request_uri}\" {http_status} {error_code} " +
+		"{body_bytes_sent} {object_size} {request_time} {server_cost} {http_referer} " +
+		"{http_user_agent} {version_id}"
+
+	// logs of a bucket are delivered immediately once buffered more than this size
+	LOG_DELIVERY_MAX_BUFFER_SIZE = 4 << 20
+	// time layout in names of delivered log objects
+	LOG_DELIVERY_TIME_LAYOUT = "2006-01-02-15-04-05"
+)
+
+// Access logs of a source bucket waiting for delivery
+type logBuffer struct {
+	targetBucket string
+	targetPrefix string
+	ownerId      string // owner of both source and target bucket
+	buffer       bytes.Buffer
+}
+
+// LogDelivery writes server access logs of buckets with logging enabled into
+// their target buckets, as objects named TargetPrefix+YYYY-mm-DD-HH-MM-SS-<id>
+type LogDelivery struct {
+	objectLayer ObjectLayer
+	mutex       sync.Mutex
+	buffers     map[string]*logBuffer // source bucket name -> logs
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+var logDelivery *LogDelivery
+
+// Start delivering logs every CONFIG.LogDeliveryInterval seconds
+func StartLogDelivery(objectLayer ObjectLayer) {
+	logDelivery = &LogDelivery{
+		objectLayer: objectLayer,
+		buffers:     make(map[string]*logBuffer),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go logDelivery.run(time.Duration(helper.CONFIG.LogDeliveryInterval) * time.Second)
+}
+
+// Deliver buffered logs and stop, should be called after API server stopped
+func StopLogDelivery() {
+	if logDelivery == nil {
+		return
+	}
+	close(logDelivery.stop)
+	<-logDelivery.done
+}
+
+func (d *LogDelivery) run(interval time.Duration) {
+	defer close(d.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.flush()
+		case <-d.stop:
+			d.flush()
+			return
+		}
+	}
+}
+
+func loggingEnabled(bucket *meta.Bucket) bool {
+	return bucket != nil && bucket.BucketLogging.LoggingEnabled.TargetBucket != ""
+}
+
+// Buffer a log line of source bucket
+func (d *LogDelivery) add(bucket *meta.Bucket, line string) {
+	rule := bucket.BucketLogging.LoggingEnabled
+	var full *logBuffer
+	d.mutex.Lock()
+	b, ok := d.buffers[bucket.Name]
+	if ok && (b.targetBucket != rule.TargetBucket || b.targetPrefix != rule.TargetPrefix) {
+		// logging target changed, deliver logs to the old target first
+		full = b
+		ok = false
+	}
+	if !ok {
+		b = &logBuffer{
+			targetBucket: rule.TargetBucket,
+			targetPrefix: rule.TargetPrefix,
+			ownerId:      bucket.OwnerId,
+		}
+		d.buffers[bucket.Name] = b
+	}
+	b.buffer.WriteString(line)
+	b.buffer.WriteByte('\n')
+	if b.buffer.Len() >= LOG_DELIVERY_MAX_BUFFER_SIZE {
+		delete(d.buffers, bucket.Name)
+		if full != nil {
+			go d.deliver(full)
+		}
+		full = b
+	}
+	d.mutex.Unlock()
+	if full != nil {
+		go d.deliver(full)
+	}
+}
+
+func (d *LogDelivery) flush() {
+	d.mutex.Lock()
+	buffers := d.buffers
+	d.buffers = make(map[string]*logBuffer)
+	d.mutex.Unlock()
+	for _, b := range buffers {
+		d.deliver(b)
+	}
+}
+
+// Write logs into target bucket, logs are dropped if it fails,
+// e.g. the target bucket is deleted
+func (d *LogDelivery) deliver(b *logBuffer) {
+	if b.buffer.Len() == 0 {
+		return
+	}
+	objectName := b.targetPrefix + time.Now().UTC().Format(LOG_DELIVERY_TIME_LAYOUT) +
+		"-" + string(helper.GenerateRandomId())
+	credential := common.Credential{UserId: b.ownerId}
+	metadata := map[string]string{"Content-Type": "text/plain"}
+	_, err := d.objectLayer.PutObject(b.targetBucket, objectName, credential,
+		int64(b.buffer.Len()), ioutil.NopCloser(&b.buffer), metadata,
+		datatype.Acl{CannedAcl: "private"}, datatype.SseRequest{},
+		meta.ObjectStorageClassStandard, nil)
+	if err != nil {
+		helper.Logger.Error("Failed to deliver access logs to", b.targetBucket,
+			objectName, "err:", err)
+		return
+	}
+	helper.Logger.Info("Delivered access logs to", b.targetBucket, objectName)
+}
@@ -15,6 +15,7 @@
 package api
 
 import (
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -169,6 +170,8 @@ func (r *replacer) getSubstitution(key string) string {
 	case "{time_local}":
 		timeLocal := time.Now().Format("2006-01-02 15:04:05")
 		return "[" + timeLocal + "]"
+	case "{access_time}":
+		return time.Now().Format("[02/Jan/2006:15:04:05 -0700]")
 	case "{request_uri}":
 		return r.request.Method + " " + r.request.URL.String() + " " + r.request.Proto
 	case "{request_id}":
@@ -213,6 +216,11 @@ func (r *replacer) getSubstitution(key string) string {
 		return bucketInfo.OwnerId
 	case "{remote_addr}":
 		return r.request.RemoteAddr
+	case "{remote_ip}":
+		if host, _, err := net.SplitHostPort(r.request.RemoteAddr); err == nil {
+			return host
+		}
+		return r.request.RemoteAddr
 	case "{http_x_real_ip}":
 		if realIP := r.request.Header.Get("X-Real-Ip"); realIP != "" {
 			return realIP
@@ -249,6 +257,11 @@ func (r *replacer) getSubstitution(key string) string {
 			return "\"" + agent + "\""
 		}
 		return `"-"`
+	case "{version_id}":
+		if versionId := r.request.URL.Query().Get("versionId"); versionId != "" {
+			return versionId
+		}
+		return "-"
 	case "{retain}":
 		return "-"
 	case "{http_referer}":
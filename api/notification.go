@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	meta "github.com/journeymidnight/yig/meta/types"
+	bus "github.com/journeymidnight/yig/mq"
+)
+
+// Publish event of a succeeded object request to the message queue
+// if the bucket has notification configurations matching it
+func sendEventNotification(r *http.Request, event string, principalId string, object EventObject) {
+	ctx := getRequestContext(r)
+	publishEvent(ctx.BucketInfo, event, principalId, object, map[string]string{
+		"sourceIPAddress": GetSourceIP(r),
+	}, map[string]string{
+		"x-amz-request-id": ctx.RequestID,
+	}, ctx.Logger)
+}
+
+// Publish event not triggered by a request, e.g. ObjectRestore:Completed sent
+// by the restore daemon once the restored copy is ready
+func SendEventNotification(bucket *meta.Bucket, event string, principalId string, object EventObject) {
+	publishEvent(bucket, event, principalId, object, map[string]string{},
+		map[string]string{}, helper.Logger)
+}
+
+func publishEvent(bucket *meta.Bucket, event string, principalId string, object EventObject,
+	requestParameters, responseElements map[string]string, logger log.Logger) {
+
+	if bucket == nil || bucket.Notification.IsEmpty() {
+		return
+	}
+	ids := bucket.Notification.Match(event, object.Key)
+	if len(ids) == 0 {
+		return
+	}
+	if bus.MsgSender == nil {
+		logger.Warn("No message queue configured, drop event", event,
+			"for", bucket.Name, object.Key)
+		return
+	}
+
+	now := time.Now().UTC()
+	object.Sequencer = fmt.Sprintf("%016X", now.UnixNano())
+	for _, id := range ids {
+		record := EventRecord{
+			EventVersion:      "2.1",
+			EventSource:       "yig:s3",
+			AwsRegion:         helper.CONFIG.Region,
+			EventTime:         now.Format(timeFormatAMZ),
+			EventName:         strings.TrimPrefix(event, "s3:"),
+			UserIdentity:      EventIdentity{PrincipalId: principalId},
+			RequestParameters: requestParameters,
+			ResponseElements:  responseElements,
+			S3: EventS3{
+				SchemaVersion:   "1.0",
+				ConfigurationId: id,
+				Bucket: EventBucket{
+					Name:          bucket.Name,
+					OwnerIdentity: EventIdentity{PrincipalId: bucket.OwnerId},
+					Arn:           "arn:aws:s3:::" + bucket.Name,
+				},
+				Object: object,
+			},
+		}
+		message, err := json.Marshal(EventMessage{Records: []EventRecord{record}})
+		if err != nil {
+			logger.Error("Failed to marshal event", event, "for", bucket.Name, object.Key,
+				"err:", err)
+			continue
+		}
+		err = bus.MsgSender.AsyncSendEvent(bucket.Name+"/"+object.Key, message)
+		if err != nil {
+			logger.Error("Failed to send event", event, "for", bucket.Name, object.Key,
+				"err:", err)
+		}
+	}
+}
+
+func deleteEventName(deleteMarker bool) string {
+	if deleteMarker {
+		return EventObjectRemovedDeleteMarkerCreated
+	}
+	return EventObjectRemovedDelete
+}
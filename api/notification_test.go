@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/journeymidnight/yig/api/datatype"
+	meta "github.com/journeymidnight/yig/meta/types"
+	bus "github.com/journeymidnight/yig/mq"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	key   string
+	value []byte
+}
+
+type recordingSender struct {
+	logs   [][]byte
+	events []message
+}
+
+func (s *recordingSender) AsyncSend(value []byte) error {
+	s.logs = append(s.logs, value)
+	return nil
+}
+
+func (s *recordingSender) AsyncSendEvent(key string, value []byte) error {
+	s.events = append(s.events, message{key: key, value: value})
+	return nil
+}
+
+func (s *recordingSender) Flush(timeout int) error { return nil }
+
+func (s *recordingSender) Close() {}
+
+func TestSendEventNotification(t *testing.T) {
+	sender := new(recordingSender)
+	bus.MsgSender = sender
+	defer func() {
+		bus.MsgSender = nil
+	}()
+	bucket := &meta.Bucket{
+		Name:    "tiger",
+		OwnerId: "hehe",
+		Notification: NotificationConfiguration{
+			QueueConfigurations: []QueueConfiguration{{
+				NotificationRule: NotificationRule{Id: "created",
+					Events: []string{EventObjectCreatedAll}},
+				Queue: "arn:aws:sqs:::created",
+			}},
+		},
+	}
+
+	SendEventNotification(bucket, EventObjectRemovedDelete, "hehe", EventObject{Key: "a"})
+	assert.Empty(t, sender.events)
+
+	SendEventNotification(bucket, EventObjectCreatedPut, "hehe", EventObject{Key: "a/b", Size: 10})
+	// events are kept apart from access logs, keyed by object
+	assert.Empty(t, sender.logs)
+	assert.Equal(t, 1, len(sender.events))
+	assert.Equal(t, "tiger/a/b", sender.events[0].key)
+	var event EventMessage
+	err := json.Unmarshal(sender.events[0].value, &event)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(event.Records))
+	assert.Equal(t, "ObjectCreated:Put", event.Records[0].EventName)
+	assert.Equal(t, "created", event.Records[0].S3.ConfigurationId)
+	assert.Equal(t, "a/b", event.Records[0].S3.Object.Key)
+	assert.Equal(t, int64(10), event.Records[0].S3.Object.Size)
+}
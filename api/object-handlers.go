@@ -35,6 +35,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // supportedGetReqParams - supported request parameters for GET presigned request.
@@ -68,6 +69,10 @@ func getStorageClassFromHeader(r *http.Request) (meta.StorageClass, error) {
 	}
 }
 
+func getTagsFromHeader(r *http.Request) (map[string]string, error) {
+	return ParseTaggingHeader(r.Header.Get(TaggingHeader))
+}
+
 // errAllowableNotFound - For an anon user, return 404 if have ListBucket, 403 otherwise
 // this is in keeping with the permissions sections of the docs of both:
 //   HEAD Object: http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectHEAD.html
@@ -218,7 +223,7 @@ func (api ObjectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	if object.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := api.ObjectAPI.GetFreezer(ctx.BucketName, ctx.ObjectName, version)
+		freezer, err := api.ObjectAPI.GetFreezer(ctx.BucketName, ctx.ObjectName, meta.FreezerVersion(object))
 		if err != nil {
 			if err == ErrNoSuchKey {
 				logger.Error("Unable to get glacier object with no restore")
@@ -229,7 +234,10 @@ func (api ObjectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			WriteErrorResponse(w, r, ErrInvalidRestoreInfo)
 			return
 		}
-		if freezer.Status != meta.ObjectHasRestored {
+		// expired copies are removed by the restore daemon later, copies of other
+		// versions are never served for this one
+		if freezer.VersionId != meta.FreezerVersion(object) ||
+			freezer.Status != meta.ObjectHasRestored || freezer.Expired(time.Now()) {
 			logger.Error("Unable to get glacier object with no restore")
 			WriteErrorResponse(w, r, ErrInvalidGlacierObject)
 			return
@@ -240,6 +248,7 @@ func (api ObjectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		object.Pool = freezer.Pool
 		object.Location = freezer.Location
 		object.ObjectId = freezer.ObjectId
+		SetRestoreHeader(w, freezer)
 	}
 
 	// Get request range.
@@ -296,19 +305,8 @@ func (api ObjectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	// io.Writer type which keeps track if any data was written.
 	writer := newGetObjectResponseWriter(w, r, object, hrange, http.StatusOK, version)
 
-	switch object.SseType {
-	case "":
-		break
-	case crypto.S3KMS.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
-		// TODO: not implemented yet
-	case crypto.S3.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
-	case crypto.SSEC.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-			r.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"))
-	}
+	setSseResponseHeaders(w, object.SseType, object.SseKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "GetObject"
@@ -361,16 +359,15 @@ func (api ObjectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	if object.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := api.ObjectAPI.GetFreezerStatus(object.BucketName, object.Name, version)
+		freezer, err := api.ObjectAPI.GetFreezerStatus(object.BucketName, object.Name, meta.FreezerVersion(object))
 		if err != nil && err != ErrNoSuchKey {
 			logger.Error("Unable to get restore object status", object.BucketName, object.Name, version,
 				"error:", err)
 			WriteErrorResponse(w, r, err)
+			return
 		}
-		if freezer.Status == meta.ObjectHasRestored {
-			w.Header().Set("x-amz-restore", "ongoing-request='true'")
-		} else {
-			w.Header().Set("x-amz-restore", "ongoing-request='false'")
+		if err == nil && freezer.VersionId == meta.FreezerVersion(object) && !freezer.Expired(time.Now()) {
+			SetRestoreHeader(w, freezer)
 		}
 	}
 
@@ -418,19 +415,8 @@ func (api ObjectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	switch object.SseType {
-	case "":
-		break
-	case crypto.S3KMS.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
-		// TODO not implemented yet
-	case crypto.S3.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
-	case crypto.SSEC.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-			r.Header.Get("X-Amz-Server-Side-Encryption-Customer-Key-Md5"))
-	}
+	setSseResponseHeaders(w, object.SseType, object.SseKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "HeadObject"
@@ -518,16 +504,10 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		WriteErrorResponse(w, r, err)
 		return
 	}
-	if sseRequest.Type == "" {
-		if configuration, ok := api.ObjectAPI.CheckBucketEncryption(targetBucketName); ok {
-			if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
-				sseRequest.Type = crypto.S3.String()
-			}
-			//TODO:add kms
-		}
-	}
+	api.applyBucketEncryption(targetBucketName, &sseRequest)
 	if sseRequest.Type == "" {
 		sseRequest.Type = sourceObject.SseType
+		sseRequest.SseAwsKmsKeyId = sourceObject.SseKmsKeyId
 	}
 
 	// Verify before x-amz-copy-source preconditions before continuing with CopyObject.
@@ -536,9 +516,9 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// TODO: In a versioning-enabled bucket, you cannot change the storage class
-	//  of a specific version of an object. When you copy it, Amazon S3 gives it
-	//  a new version ID.
+	// Copying an object to itself with a different storage class moves its data to
+	// the pool of new class in place. In a versioning-enabled bucket, a new version
+	// is created instead like S3.
 	storageClassStr := r.Header.Get("X-Amz-Storage-Class")
 	var targetStorageClass meta.StorageClass
 	if storageClassStr != "" {
@@ -554,7 +534,7 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	truelySourceObject := sourceObject
 	if sourceObject.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := api.ObjectAPI.GetFreezer(sourceBucketName, sourceObjectName, sourceVersion)
+		freezer, err := api.ObjectAPI.GetFreezer(sourceBucketName, sourceObjectName, meta.FreezerVersion(sourceObject))
 		if err != nil {
 			if err == ErrNoSuchKey {
 				logger.Error("Unable to get glacier object with no restore")
@@ -637,13 +617,27 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	taggingDirective := r.Header.Get(TaggingDirectiveHeader)
+	if taggingDirective == "COPY" || taggingDirective == "" {
+		targetObject.Tags = sourceObject.Tags
+	} else if taggingDirective == "REPLACE" {
+		targetObject.Tags, err = getTagsFromHeader(r)
+		if err != nil {
+			WriteErrorResponse(w, r, err)
+			return
+		}
+	} else {
+		WriteErrorResponse(w, r, ErrInvalidCopyRequest)
+		return
+	}
+
 	var isMetadataOnly bool
 	isMetadataOnly = false
 	if sourceBucketName == targetBucketName && sourceObjectName == targetObjectName {
-		if sourceObject.StorageClass != meta.ObjectStorageClassGlacier && targetObject.StorageClass == meta.ObjectStorageClassGlacier {
-			isMetadataOnly = false
-		} else {
-			isMetadataOnly = true
+		isMetadataOnly = true
+		// ACL is kept if not specified, also for the new version
+		if r.Header.Get("x-amz-acl") == "" {
+			targetObject.ACL = sourceObject.ACL
 		}
 	}
 
@@ -654,6 +648,12 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	sendEventNotification(r, EventObjectCreatedCopy, credential.UserId, EventObject{
+		Key:       targetObjectName,
+		Size:      targetObject.Size,
+		ETag:      result.Md5,
+		VersionId: result.VersionId,
+	})
 
 	response := GenerateCopyObjectResponse(result.Md5, result.LastModified)
 	encodedSuccessResponse := EncodeResponse(response)
@@ -667,17 +667,8 @@ func (api ObjectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	if result.VersionId != "" {
 		w.Header().Set("x-amz-version-id", result.VersionId)
 	}
-	// Set SSE related headers
-	for _, headerName := range []string{
-		"X-Amz-Server-Side-Encryption",
-		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-		"X-Amz-Server-Side-Encryption-Customer-Algorithm",
-		"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-	} {
-		if header := r.Header.Get(headerName); header != "" {
-			w.Header().Set(headerName, header)
-		}
-	}
+	setSseResponseHeaders(w, result.SseType, result.SseAwsKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "CopyObject"
@@ -756,7 +747,8 @@ func (api ObjectAPIHandlers) RenameObjectHandler(w http.ResponseWriter, r *http.
 
 	targetObject := sourceObject
 	targetObject.Name = ctx.ObjectName
-	result, err := api.ObjectAPI.RenameObject(targetObject, sourceObjectName, credential)
+	result, err := api.ObjectAPI.RenameObject(targetObject, sourceObjectName,
+		isGovernanceBypassAllowed(r, credential, sourceObjectName), credential)
 	if err != nil {
 		logger.Error("Unable to update object meta for", targetObject.Name,
 			"error:", err)
@@ -826,6 +818,12 @@ func (api ObjectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	tags, err := getTagsFromHeader(r)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
 	// Save metadata.
 	metadata := extractMetadataFromHeader(r.Header)
 	// Get Content-Md5 sent by client and verify if valid
@@ -877,11 +875,8 @@ func (api ObjectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			WriteErrorResponse(w, r, err)
 			return
 		}
-	} else if configuration, ok := api.ObjectAPI.CheckBucketEncryption(bucketName); ok {
-		if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
-			sseRequest.Type = crypto.S3.String()
-		}
-		//TODO:add kms
+	} else {
+		api.applyBucketEncryption(bucketName, &sseRequest)
 	}
 
 	acl, err := getAclFromHeader(r.Header)
@@ -898,12 +893,18 @@ func (api ObjectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 
 	var result PutObjectResult
 	result, err = api.ObjectAPI.PutObject(bucketName, objectName, credential, size, dataReadCloser,
-		metadata, acl, sseRequest, storageClass)
+		metadata, acl, sseRequest, storageClass, tags)
 	if err != nil {
 		logger.Error("Unable to create object", objectName, "error:", err)
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	sendEventNotification(r, EventObjectCreatedPut, credential.UserId, EventObject{
+		Key:       objectName,
+		Size:      size,
+		ETag:      result.Md5,
+		VersionId: result.VersionId,
+	})
 
 	if result.Md5 != "" {
 		w.Header()["ETag"] = []string{"\"" + result.Md5 + "\""}
@@ -911,17 +912,8 @@ func (api ObjectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	if result.VersionId != "" {
 		w.Header().Set("x-amz-version-id", result.VersionId)
 	}
-	// Set SSE related headers
-	for _, headerName := range []string{
-		"X-Amz-Server-Side-Encryption",
-		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-		"X-Amz-Server-Side-Encryption-Customer-Algorithm",
-		"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-	} {
-		if header := r.Header.Get(headerName); header != "" {
-			w.Header().Set(headerName, header)
-		}
-	}
+	setSseResponseHeaders(w, result.SseType, result.SseAwsKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	// ResponseRecorder
 	w.(*ResponseRecorder).operationName = "PutObject"
@@ -1091,7 +1083,7 @@ func (api ObjectAPIHandlers) AppendObjectHandler(w http.ResponseWriter, r *http.
 
 	var result AppendObjectResult
 	result, err = api.ObjectAPI.AppendObject(bucketName, objectName, credential, position, size, dataReadCloser,
-		metadata, acl, sseRequest, storageClass, objInfo)
+		metadata, acl, sseRequest, storageClass, objInfo, isGovernanceBypassAllowed(r, credential, objectName))
 	if err != nil {
 		logger.Error("Unable to append object", objectName, "error:", err)
 		WriteErrorResponse(w, r, err)
@@ -1188,7 +1180,7 @@ func (api ObjectAPIHandlers) PutObjectAclHandler(w http.ResponseWriter, r *http.
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutObjectAclAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1277,49 +1269,62 @@ func (api ObjectAPIHandlers) RestoreObjectHandler(w http.ResponseWriter, r *http
 	if err != nil {
 		logger.Error("Unable to get freezer info:", err)
 		WriteErrorResponse(w, r, ErrInvalidRestoreInfo)
+		return
+	}
+	tier, err := meta.MatchRestoreTier(info.GlacierJobParameters.Tier)
+	if err != nil {
+		logger.Error("Invalid restore tier:", info.GlacierJobParameters.Tier)
+		WriteErrorResponse(w, r, err)
+		return
 	}
 
-	freezer, err := api.ObjectAPI.GetFreezerStatus(object.BucketName, object.Name, object.VersionId)
+	freezer, err := api.ObjectAPI.GetFreezerStatus(object.BucketName, object.Name, meta.FreezerVersion(object))
 	if err != nil && err != ErrNoSuchKey {
 		logger.Error("Unable to get restore object status", object.BucketName, object.Name,
 			"error:", err)
 		WriteErrorResponse(w, r, err)
+		return
 	}
 	if err == ErrNoSuchKey || freezer.Name == "" {
-		status, err := meta.MatchStatusIndex("READY")
-		if err != nil {
-			logger.Error("Unable to get freezer status:", err)
-			WriteErrorResponse(w, r, ErrInvalidRestoreInfo)
-		}
-
 		lifeTime := info.Days
 		if lifeTime < 1 || lifeTime > 30 {
 			lifeTime = 1
 		}
 
+		// picked up by the restore daemon, see tools/restore.go
 		targetFreezer := &meta.Freezer{}
 		targetFreezer.BucketName = object.BucketName
 		targetFreezer.Name = object.Name
-		targetFreezer.Status = status
+		targetFreezer.VersionId = meta.FreezerVersion(object)
+		targetFreezer.Status = meta.ObjectNeedRestore
 		targetFreezer.LifeTime = lifeTime
+		targetFreezer.Tier = tier
+		targetFreezer.LastModifiedTime = time.Now()
 		err = api.ObjectAPI.CreateFreezer(targetFreezer)
 		if err != nil {
 			logger.Error("Unable to create freezer:", err)
 			WriteErrorResponse(w, r, ErrCreateRestoreObject)
+			return
 		}
 		logger.Info("Submit thaw request successfully")
+		sendEventNotification(r, EventObjectRestorePost, credential.UserId, EventObject{
+			Key:       object.Name,
+			Size:      object.Size,
+			ETag:      object.Etag,
+			VersionId: object.VersionId,
+		})
 
 		// ResponseRecorder
-		w.WriteHeader(http.StatusAccepted)
 		w.(*ResponseRecorder).operationName = "RestoreObject"
-
 		WriteSuccessResponseWithStatus(w, nil, http.StatusAccepted)
+		return
 	}
 	if freezer.Status == meta.ObjectHasRestored {
 		err = api.ObjectAPI.UpdateFreezerDate(freezer, info.Days, true)
 		if err != nil {
 			logger.Error("Unable to Update freezer date:", err)
 			WriteErrorResponse(w, r, ErrInvalidRestoreInfo)
+			return
 		}
 
 		// ResponseRecorder
@@ -1331,6 +1336,7 @@ func (api ObjectAPIHandlers) RestoreObjectHandler(w http.ResponseWriter, r *http
 			if err != nil {
 				logger.Error("Unable to Update freezer date:", err)
 				WriteErrorResponse(w, r, ErrInvalidRestoreInfo)
+				return
 			}
 		}
 		// ResponseRecorder
@@ -1356,7 +1362,7 @@ func (api ObjectAPIHandlers) GetObjectAclHandler(w http.ResponseWriter, r *http.
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.GetObjectAclAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1389,6 +1395,116 @@ func (api ObjectAPIHandlers) GetObjectAclHandler(w http.ResponseWriter, r *http.
 	WriteSuccessResponse(w, aclBuffer)
 }
 
+func (api ObjectAPIHandlers) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := getRequestContext(r)
+	logger := ctx.Logger
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkRequestAuth(r, policy.PutObjectTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	if r.ContentLength <= 0 {
+		WriteErrorResponse(w, r, ErrMissingContentLength)
+		return
+	}
+
+	if r.ContentLength > MaxTaggingConfigurationSize {
+		WriteErrorResponse(w, r, ErrEntityTooLarge)
+		return
+	}
+
+	tags, err := ParseTagging(io.LimitReader(r.Body, r.ContentLength), MaxObjectTagsCount)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	version := r.URL.Query().Get("versionId")
+	err = api.ObjectAPI.SetObjectTagging(ctx.BucketName, ctx.ObjectName, version, tags, credential)
+	if err != nil {
+		logger.Error("Unable to set tagging for object", ctx.ObjectName, "error:", err)
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	if version != "" {
+		w.Header().Set("x-amz-version-id", version)
+	}
+
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "PutObjectTagging"
+
+	WriteSuccessResponse(w, nil)
+}
+
+func (api ObjectAPIHandlers) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := getRequestContext(r)
+	logger := ctx.Logger
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkRequestAuth(r, policy.GetObjectTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	version := r.URL.Query().Get("versionId")
+	tagging, err := api.ObjectAPI.GetObjectTagging(ctx.BucketName, ctx.ObjectName, version, credential)
+	if err != nil {
+		logger.Error("Unable to fetch object tagging:", err)
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	taggingBuffer, err := xmlFormat(tagging)
+	if err != nil {
+		logger.Error("Failed to marshal tagging XML for object", ctx.ObjectName,
+			"error:", err)
+		WriteErrorResponse(w, r, ErrInternalError)
+		return
+	}
+
+	if version != "" {
+		w.Header().Set("x-amz-version-id", version)
+	}
+
+	setXmlHeader(w)
+
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "GetObjectTagging"
+	WriteSuccessResponse(w, taggingBuffer)
+}
+
+func (api ObjectAPIHandlers) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := getRequestContext(r)
+	logger := ctx.Logger
+
+	var credential common.Credential
+	var err error
+	if credential, err = checkRequestAuth(r, policy.DeleteObjectTaggingAction); err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	version := r.URL.Query().Get("versionId")
+	err = api.ObjectAPI.DeleteObjectTagging(ctx.BucketName, ctx.ObjectName, version, credential)
+	if err != nil {
+		logger.Error("Unable to delete tagging for object", ctx.ObjectName, "error:", err)
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	if version != "" {
+		w.Header().Set("x-amz-version-id", version)
+	}
+
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "DeleteObjectTagging"
+
+	WriteSuccessNoContent(w)
+}
+
 // Multipart objectAPIHandlers
 
 // NewMultipartUploadHandler - New multipart upload
@@ -1414,7 +1530,7 @@ func (api ObjectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutObjectAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1436,11 +1552,8 @@ func (api ObjectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 			WriteErrorResponse(w, r, err)
 			return
 		}
-	} else if configuration, ok := api.ObjectAPI.CheckBucketEncryption(bucketName); ok {
-		if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
-			sseRequest.Type = crypto.S3.String()
-		}
-		//TODO:add kms
+	} else {
+		api.applyBucketEncryption(bucketName, &sseRequest)
 	}
 
 	storageClass, err := getStorageClassFromHeader(r)
@@ -1449,8 +1562,14 @@ func (api ObjectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		return
 	}
 
+	tags, err := getTagsFromHeader(r)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
 	uploadID, err := api.ObjectAPI.NewMultipartUpload(credential, bucketName, objectName,
-		metadata, acl, sseRequest, storageClass)
+		metadata, acl, sseRequest, storageClass, tags)
 	if err != nil {
 		logger.Error("Unable to initiate new multipart upload id:", err)
 		WriteErrorResponse(w, r, err)
@@ -1459,17 +1578,8 @@ func (api ObjectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 
 	response := GenerateInitiateMultipartUploadResponse(bucketName, objectName, uploadID)
 	encodedSuccessResponse := EncodeResponse(response)
-	// Set SSE related headers
-	for _, headerName := range []string{
-		"X-Amz-Server-Side-Encryption",
-		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-		"X-Amz-Server-Side-Encryption-Customer-Algorithm",
-		"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-	} {
-		if header := r.Header.Get(headerName); header != "" {
-			w.Header().Set(headerName, header)
-		}
-	}
+	setSseResponseHeaders(w, sseRequest.Type, sseRequest.SseAwsKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "NewMultipartUpload"
@@ -1563,20 +1673,8 @@ func (api ObjectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	if result.ETag != "" {
 		w.Header()["ETag"] = []string{"\"" + result.ETag + "\""}
 	}
-	switch result.SseType {
-	case "":
-		break
-	case crypto.S3KMS.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-			result.SseAwsKmsKeyIdBase64)
-	case crypto.S3.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
-	case crypto.SSEC.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-			result.SseCustomerKeyMd5Base64)
-	}
+	setSseResponseHeaders(w, result.SseType, result.SseAwsKmsKeyId,
+		result.SseCustomerKeyMd5Base64)
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "PutObjectPart"
@@ -1607,7 +1705,7 @@ func (api ObjectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutObjectAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1680,7 +1778,7 @@ func (api ObjectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 	}
 
 	if sourceObject.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := api.ObjectAPI.GetFreezer(sourceBucketName, sourceObjectName, sourceVersion)
+		freezer, err := api.ObjectAPI.GetFreezer(sourceBucketName, sourceObjectName, meta.FreezerVersion(sourceObject))
 		if err != nil {
 			if err == ErrNoSuchKey {
 				logger.Error("Unable to get glacier object with no restore")
@@ -1772,17 +1870,8 @@ func (api ObjectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 	if sourceVersion != "" {
 		w.Header().Set("x-amz-copy-source-version-id", sourceVersion)
 	}
-	// Set SSE related headers
-	for _, headerName := range []string{
-		"X-Amz-Server-Side-Encryption",
-		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-		"X-Amz-Server-Side-Encryption-Customer-Algorithm",
-		"X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-	} {
-		if header := r.Header.Get(headerName); header != "" {
-			w.Header().Set(headerName, header)
-		}
-	}
+	setSseResponseHeaders(w, result.SseType, result.SseAwsKmsKeyId,
+		r.Header.Get(crypto.SSECKeyMD5))
 
 	//ResponseRecorder
 	w.(*ResponseRecorder).operationName = "CopyObjectPart"
@@ -1809,7 +1898,7 @@ func (api ObjectAPIHandlers) AbortMultipartUploadHandler(w http.ResponseWriter,
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.AbortMultipartUploadAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1848,7 +1937,7 @@ func (api ObjectAPIHandlers) ListObjectPartsHandler(w http.ResponseWriter, r *ht
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.ListMultipartUploadPartsAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1896,7 +1985,7 @@ func (api ObjectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		break
 	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
 		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.PutObjectAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -1951,6 +2040,11 @@ func (api ObjectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		}
 		return
 	}
+	sendEventNotification(r, EventObjectCreatedCompleteMultipartUpload, credential.UserId, EventObject{
+		Key:       objectName,
+		ETag:      result.ETag,
+		VersionId: result.VersionId,
+	})
 
 	// Get object location.
 	location := GetLocation(r)
@@ -1966,20 +2060,8 @@ func (api ObjectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	if result.VersionId != "" {
 		w.Header().Set("x-amz-version-id", result.VersionId)
 	}
-	switch result.SseType {
-	case "":
-		break
-	case crypto.S3KMS.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "aws:kms")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id",
-			result.SseAwsKmsKeyIdBase64)
-	case crypto.S3.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption", "AES256")
-	case crypto.SSEC.String():
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Algorithm", "AES256")
-		w.Header().Set("X-Amz-Server-Side-Encryption-Customer-Key-Md5",
-			result.SseCustomerKeyMd5Base64)
-	}
+	setSseResponseHeaders(w, result.SseType, result.SseAwsKmsKeyId,
+		result.SseCustomerKeyMd5Base64)
 
 	setXmlHeader(w)
 
@@ -2008,7 +2090,7 @@ func (api ObjectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 		break
 	case signature.AuthTypeSignedV4, signature.AuthTypePresignedV4,
 		signature.AuthTypeSignedV2, signature.AuthTypePresignedV2:
-		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+		if credential, err = checkIdentityAuth(r, policy.DeleteObjectAction); err != nil {
 			WriteErrorResponse(w, r, err)
 			return
 		}
@@ -2016,11 +2098,16 @@ func (api ObjectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 	version := r.URL.Query().Get("versionId")
 	// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	// Ignore delete object errors, since we are supposed to reply only 204.
-	result, err := api.ObjectAPI.DeleteObject(bucketName, objectName, version, credential)
+	bypassGovernance := isGovernanceBypassAllowed(r, credential, objectName)
+	result, err := api.ObjectAPI.DeleteObject(bucketName, objectName, version, bypassGovernance, credential)
 	if err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	sendEventNotification(r, deleteEventName(result.DeleteMarker), credential.UserId, EventObject{
+		Key:       objectName,
+		VersionId: result.VersionId,
+	})
 	if result.DeleteMarker {
 		w.Header().Set("x-amz-delete-marker", "true")
 	} else {
@@ -2096,11 +2183,21 @@ func (api ObjectAPIHandlers) PostObjectHandler(w http.ResponseWriter, r *http.Re
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	if postPolicyType != signature.PostPolicyAnonymous {
+		signature.Authenticated(r, credential)
+	}
 
 	if err = signature.CheckPostPolicy(formValues, postPolicyType); err != nil {
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	if postPolicyType != signature.PostPolicyAnonymous {
+		err = checkIdentityPolicy(credential, r, policy.PutObjectAction, bucketName, objectName)
+		if err != nil {
+			WriteErrorResponse(w, r, err)
+			return
+		}
+	}
 
 	// Convert form values to header type so those values could be handled as in
 	// normal requests
@@ -2127,14 +2224,7 @@ func (api ObjectAPIHandlers) PostObjectHandler(w http.ResponseWriter, r *http.Re
 		WriteErrorResponse(w, r, err)
 		return
 	}
-	if sseRequest.Type == "" {
-		if configuration, ok := api.ObjectAPI.CheckBucketEncryption(bucketName); ok {
-			if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
-				sseRequest.Type = crypto.S3.String()
-			}
-			//TODO:add kms
-		}
-	}
+	api.applyBucketEncryption(bucketName, &sseRequest)
 
 	storageClass, err := getStorageClassFromHeader(r)
 	if err != nil {
@@ -2143,12 +2233,17 @@ func (api ObjectAPIHandlers) PostObjectHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	result, err := api.ObjectAPI.PutObject(bucketName, objectName, credential, -1, fileBody,
-		metadata, acl, sseRequest, storageClass)
+		metadata, acl, sseRequest, storageClass, nil)
 	if err != nil {
 		logger.Error("Unable to create object", objectName, "error:", err)
 		WriteErrorResponse(w, r, err)
 		return
 	}
+	sendEventNotification(r, EventObjectCreatedPost, credential.UserId, EventObject{
+		Key:       objectName,
+		ETag:      result.Md5,
+		VersionId: result.VersionId,
+	})
 	if result.Md5 != "" {
 		w.Header().Set("ETag", "\""+result.Md5+"\"")
 	}
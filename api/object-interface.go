@@ -43,6 +43,9 @@ type ObjectLayer interface {
 	DeleteBucketCors(bucket string, credential common.Credential) error
 	GetBucketVersioning(bucket string, credential common.Credential) (datatype.Versioning, error)
 	GetBucketCors(bucket string, credential common.Credential) (datatype.Cors, error)
+	SetBucketTagging(bucket string, tags map[string]string, credential common.Credential) error
+	GetBucketTagging(bucket string, credential common.Credential) (datatype.Tagging, error)
+	DeleteBucketTagging(bucket string, credential common.Credential) error
 	GetBucket(bucketName string) (bucket *meta.Bucket, err error) // For INTERNAL USE ONLY
 	GetBucketInfo(bucket string, credential common.Credential) (bucketInfo *meta.Bucket, err error)
 	GetBucketInfoByCtx(ctx RequestContext, credential common.Credential) (bucket *meta.Bucket, err error)
@@ -63,6 +66,19 @@ type ObjectLayer interface {
 	GetBucketWebsite(bucket string) (datatype.WebsiteConfiguration, error)
 	DeleteBucketWebsite(bucket *meta.Bucket) error
 
+	// Notification operations
+	SetBucketNotification(bucket *meta.Bucket, config datatype.NotificationConfiguration) error
+	GetBucketNotification(bucket string) (datatype.NotificationConfiguration, error)
+
+	// Replication operations
+	SetBucketReplication(bucket *meta.Bucket, config datatype.ReplicationConfiguration) error
+	GetBucketReplication(bucket string) (datatype.ReplicationConfiguration, error)
+	DeleteBucketReplication(bucket *meta.Bucket) error
+
+	// Object lock operations
+	SetBucketObjectLock(bucket *meta.Bucket, config datatype.ObjectLockConfiguration) error
+	GetBucketObjectLock(bucket string) (datatype.ObjectLockConfiguration, error)
+
 	// Encryption operations
 	SetBucketEncryption(bucket *meta.Bucket, config datatype.EncryptionConfiguration) error
 	GetBucketEncryption(bucket string) (datatype.EncryptionConfiguration, error)
@@ -76,28 +92,44 @@ type ObjectLayer interface {
 	GetObjectInfoByCtx(ctx RequestContext, version string, credential common.Credential) (objInfo *meta.Object, err error)
 	PutObject(bucket, object string, credential common.Credential, size int64, data io.ReadCloser,
 		metadata map[string]string, acl datatype.Acl,
-		sse datatype.SseRequest, storageClass meta.StorageClass) (result datatype.PutObjectResult, err error)
+		sse datatype.SseRequest, storageClass meta.StorageClass,
+		tags map[string]string) (result datatype.PutObjectResult, err error)
 	AppendObject(bucket, object string, credential common.Credential, offset uint64, size int64, data io.ReadCloser,
 		metadata map[string]string, acl datatype.Acl,
-		sse datatype.SseRequest, storageClass meta.StorageClass, objInfo *meta.Object) (result datatype.AppendObjectResult, err error)
+		sse datatype.SseRequest, storageClass meta.StorageClass, objInfo *meta.Object,
+		bypassGovernance bool) (result datatype.AppendObjectResult, err error)
 
 	CopyObject(targetObject *meta.Object, sourceObject *meta.Object, source io.Reader, credential common.Credential,
 		sseRequest datatype.SseRequest, isMetadataOnly bool) (result datatype.PutObjectResult, err error)
-	RenameObject(targetObject *meta.Object, sourceObject string, credential common.Credential) (result datatype.RenameObjectResult, err error)
+	RenameObject(targetObject *meta.Object, sourceObject string, bypassGovernance bool,
+		credential common.Credential) (result datatype.RenameObjectResult, err error)
 	PutObjectMeta(bucket *meta.Bucket, targetObject *meta.Object, credential common.Credential) (err error)
 	SetObjectAcl(bucket string, object string, version string, policy datatype.AccessControlPolicy,
 		acl datatype.Acl, credential common.Credential) error
 	GetObjectAcl(bucket string, object string, version string, credential common.Credential) (
 		policy datatype.AccessControlPolicyResponse, err error)
-	DeleteObject(bucket, object, version string, credential common.Credential) (datatype.DeleteObjectResult,
-		error)
+	DeleteObject(bucket, object, version string, bypassGovernance bool,
+		credential common.Credential) (datatype.DeleteObjectResult, error)
+
+	// Object tagging operations.
+	GetObjectTagging(bucket, object, version string, credential common.Credential) (datatype.Tagging, error)
+	SetObjectTagging(bucket, object, version string, tags map[string]string, credential common.Credential) error
+	DeleteObjectTagging(bucket, object, version string, credential common.Credential) error
+
+	// Object lock operations.
+	GetObjectRetention(bucket, object, version string, credential common.Credential) (datatype.ObjectRetention, error)
+	SetObjectRetention(bucket, object, version string, retention datatype.ObjectRetention,
+		bypassGovernance bool, credential common.Credential) error
+	GetObjectLegalHold(bucket, object, version string, credential common.Credential) (datatype.ObjectLegalHold, error)
+	SetObjectLegalHold(bucket, object, version string, legalHold bool, credential common.Credential) error
 
 	// Multipart operations.
 	ListMultipartUploads(credential common.Credential, bucket string,
 		request datatype.ListUploadsRequest) (result datatype.ListMultipartUploadsResponse, err error)
 	NewMultipartUpload(credential common.Credential, bucket, object string,
 		metadata map[string]string, acl datatype.Acl,
-		sse datatype.SseRequest, storageClass meta.StorageClass) (uploadID string, err error)
+		sse datatype.SseRequest, storageClass meta.StorageClass,
+		tags map[string]string) (uploadID string, err error)
 	PutObjectPart(bucket, object string, credential common.Credential, uploadID string, partID int,
 		size int64, data io.ReadCloser, md5Hex string,
 		sse datatype.SseRequest) (result datatype.PutObjectPartResult, err error)
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	. "github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/api/datatype/policy"
+	. "github.com/journeymidnight/yig/error"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/s3select"
+)
+
+// Counts bytes of event stream messages for access log
+type selectResponseWriter struct {
+	w http.ResponseWriter
+}
+
+func (s selectResponseWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if n > 0 {
+		s.w.(*ResponseRecorder).size += int64(n)
+	}
+	return n, err
+}
+
+// SelectObjectContentHandler - POST Object?select&select-type=2
+// ----------
+// Filters content of a CSV or JSON object by a SQL expression, results are
+// streamed back as event stream messages, so errors found after the first
+// message could only be sent as an error message.
+func (api ObjectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := getRequestContext(r)
+	logger := ctx.Logger
+
+	credential, err := checkRequestAuth(r, policy.GetObjectAction)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	request, err := ParseSelectRequest(r.Body)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	selector, err := s3select.NewSelector(request)
+	if err != nil {
+		logger.Error("Unable to parse select expression:", request.Expression, err)
+		WriteErrorResponse(w, r, err)
+		return
+	}
+
+	version := r.URL.Query().Get("versionId")
+	object, err := api.ObjectAPI.GetObjectInfoByCtx(ctx, version, credential)
+	if err != nil {
+		logger.Error("Unable to fetch object info:", err)
+		if err == ErrNoSuchKey {
+			api.errAllowableObjectNotFound(w, r, credential)
+			return
+		}
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	if object.DeleteMarker {
+		w.Header().Set("x-amz-delete-marker", "true")
+		WriteErrorResponse(w, r, ErrNoSuchKey)
+		return
+	}
+	if object.StorageClass == meta.ObjectStorageClassGlacier {
+		WriteErrorResponse(w, r, ErrInvalidGlacierObject)
+		return
+	}
+
+	sseRequest, err := parseSseHeader(r.Header)
+	if err != nil {
+		WriteErrorResponse(w, r, err)
+		return
+	}
+	if len(sseRequest.CopySourceSseCustomerKey) != 0 {
+		WriteErrorResponse(w, r, ErrInvalidSseHeader)
+		return
+	}
+
+	// ResponseRecorder
+	w.(*ResponseRecorder).operationName = "SelectObjectContent"
+
+	reader, writer := io.Pipe()
+	go func() {
+		err := api.ObjectAPI.GetObject(object, 0, object.Size, writer, sseRequest)
+		writer.CloseWithError(err)
+	}()
+	// stops reading the object if the query finishes early, e.g. by LIMIT
+	defer reader.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if version != "" {
+		w.Header().Set("x-amz-version-id", version)
+	}
+	err = selector.Run(reader, selectResponseWriter{w: w})
+	if err != nil {
+		logger.Error("SelectObjectContent error:", err)
+	}
+}
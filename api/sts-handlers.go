@@ -0,0 +1,140 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam"
+	"github.com/journeymidnight/yig/iam/common"
+	"github.com/journeymidnight/yig/signature"
+)
+
+const (
+	// As per AWS STS specification, inline session policy is at most 2048 characters.
+	maxSessionPolicySize = 2048
+)
+
+// SecurityTokenServiceHandler - AssumeRole and GetSessionToken of STS, which
+// mint temporary credentials with session token for the requester.
+// Since roles are not managed by IAM plugins, a user could only assume roles
+// of its own account, i.e "arn:aws:iam::<user id>:role/<role name>", and the
+// session acts as the user limited by inline session policy if any, which is
+// evaluated by all APIs, along with identity and bucket policies by object APIs.
+func (api ObjectAPIHandlers) SecurityTokenServiceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := getRequestContext(r)
+	logger := ctx.Logger
+	if helper.CONFIG.StsSecretKey == "" {
+		WriteErrorResponse(w, r, ErrNotImplemented)
+		return
+	}
+
+	var credential common.Credential
+	var err error
+	switch ctx.AuthType {
+	case signature.AuthTypePresignedV4, signature.AuthTypeSignedV4,
+		signature.AuthTypePresignedV2, signature.AuthTypeSignedV2:
+		if credential, err = signature.IsReqAuthenticated(r); err != nil {
+			WriteErrorResponse(w, r, err)
+			return
+		}
+	default:
+		WriteErrorResponse(w, r, ErrAccessDenied)
+		return
+	}
+	// otherwise a session could extend its expiration or drop its session policy
+	if credential.SessionToken != "" {
+		logger.Info("Temporary credentials could not call STS:", credential.AccessKeyID)
+		WriteErrorResponse(w, r, ErrAccessDenied)
+		return
+	}
+
+	if err = r.ParseForm(); err != nil {
+		WriteErrorResponse(w, r, ErrMalformedPOSTRequest)
+		return
+	}
+	duration := iam.SESSION_DEFAULT_DURATION
+	if s := r.Form.Get("DurationSeconds"); s != "" {
+		seconds, err := strconv.Atoi(s)
+		if err != nil {
+			WriteErrorResponse(w, r, ErrInvalidDurationSeconds)
+			return
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+
+	switch r.Form.Get("Action") {
+	case "AssumeRole":
+		roleArn := r.Form.Get("RoleArn")
+		sessionName := r.Form.Get("RoleSessionName")
+		sessionPolicy := r.Form.Get("Policy")
+		if roleArn == "" || sessionName == "" {
+			WriteErrorResponse(w, r, ErrMissingFields)
+			return
+		}
+		if len(sessionPolicy) > maxSessionPolicySize {
+			WriteErrorResponse(w, r, ErrEntityTooLarge)
+			return
+		}
+		roleName, ok := parseRoleArn(roleArn, credential.UserId)
+		if !ok {
+			logger.Info("User", credential.UserId, "could not assume role", roleArn)
+			WriteErrorResponse(w, r, ErrAccessDenied)
+			return
+		}
+		session, expiration, err := iam.NewSessionCredential(credential, duration, sessionPolicy)
+		if err != nil {
+			logger.Error("Unable to mint session credential:", err)
+			WriteErrorResponse(w, r, err)
+			return
+		}
+		var response AssumeRoleResponse
+		response.Result.Credentials = generateStsCredentials(session, expiration)
+		response.Result.AssumedRoleUser = AssumedRoleUser{
+			Arn: "arn:aws:sts::" + credential.UserId + ":assumed-role/" +
+				roleName + "/" + sessionName,
+			AssumedRoleId: session.AccessKeyID + ":" + sessionName,
+		}
+		response.ResponseMetadata.RequestId = ctx.RequestID
+		WriteSuccessResponse(w, EncodeResponse(response))
+	case "GetSessionToken":
+		session, expiration, err := iam.NewSessionCredential(credential, duration, "")
+		if err != nil {
+			logger.Error("Unable to mint session credential:", err)
+			WriteErrorResponse(w, r, err)
+			return
+		}
+		var response GetSessionTokenResponse
+		response.Result.Credentials = generateStsCredentials(session, expiration)
+		response.ResponseMetadata.RequestId = ctx.RequestID
+		WriteSuccessResponse(w, EncodeResponse(response))
+	default:
+		WriteErrorResponse(w, r, ErrInvalidStsAction)
+	}
+}
+
+// parseRoleArn - returns role name of ARN "arn:aws:iam::<account>:role/<name>",
+// which should be a role of userId
+func parseRoleArn(roleArn, userId string) (roleName string, ok bool) {
+	parts := strings.SplitN(roleArn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "iam" || parts[4] != userId {
+		return "", false
+	}
+	if !strings.HasPrefix(parts[5], "role/") || len(parts[5]) == len("role/") {
+		return "", false
+	}
+	return strings.TrimPrefix(parts[5], "role/"), true
+}
+
+func generateStsCredentials(session common.Credential, expiration time.Time) StsCredentials {
+	return StsCredentials{
+		AccessKeyId:     session.AccessKeyID,
+		SecretAccessKey: session.SecretAccessKey,
+		SessionToken:    session.SessionToken,
+		Expiration:      expiration.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,384 @@
+package api
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	"github.com/journeymidnight/yig/meta"
+	"github.com/journeymidnight/yig/signature"
+)
+
+const (
+	// token buckets idle for longer than this are dropped
+	throttleIdleTimeout     = 10 * time.Minute
+	throttleRefreshInterval = time.Minute
+)
+
+// A token bucket filled at rate tokens per second and holding one second of
+// tokens, at least one. Tokens could be borrowed, later takers wait for the debt.
+type tokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: math.Max(rate, 1), last: time.Now()}
+}
+
+// Lock should be held
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*b.rate, math.Max(b.rate, 1))
+	b.last = now
+}
+
+func (b *tokenBucket) setRate(rate float64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.rate != rate {
+		b.refill(time.Now())
+		b.rate = rate
+	}
+}
+
+// Take a token if there is one
+func (b *tokenBucket) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens -= 1
+	return true
+}
+
+// Whether there is a token, without taking it
+func (b *tokenBucket) available() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	return b.tokens >= 1
+}
+
+// Whether tokens are borrowed and not paid back yet
+func (b *tokenBucket) inDebt() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	return b.tokens < 0
+}
+
+// Take n tokens, returns how long to wait until they are paid back
+func (b *tokenBucket) take(n int) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.refill(time.Now())
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+type throttleKind int
+
+const (
+	throttleRequests throttleKind = iota
+	throttleUpload
+	throttleDownload
+)
+
+type throttleKey struct {
+	kind  throttleKind
+	scope string // "ak", "bucket" or "ip"
+	name  string
+}
+
+type throttleEntry struct {
+	bucket     *tokenBucket
+	lastAccess time.Time
+}
+
+var throttle = struct {
+	sync.Mutex
+	buckets       map[throttleKey]*throttleEntry
+	userOverrides map[string]helper.ThrottleLimit // cache of meta storage
+}{
+	buckets:       make(map[throttleKey]*throttleEntry),
+	userOverrides: make(map[string]helper.ThrottleLimit),
+}
+
+// Override limits of access keys of a user, other instances load it from meta
+// storage in throttleRefreshInterval
+func SetUserThrottle(m *meta.Meta, userId string, limit helper.ThrottleLimit) error {
+	err := m.PutUserThrottle(userId, limit)
+	if err != nil {
+		return err
+	}
+	throttle.Lock()
+	defer throttle.Unlock()
+	throttle.userOverrides[userId] = limit
+	return nil
+}
+
+func DeleteUserThrottle(m *meta.Meta, userId string) error {
+	err := m.DeleteUserThrottle(userId)
+	if err != nil {
+		return err
+	}
+	throttle.Lock()
+	defer throttle.Unlock()
+	delete(throttle.userOverrides, userId)
+	return nil
+}
+
+// Returns overridden limits of a user, or default limits of access keys
+func GetUserThrottle(userId string) (limit helper.ThrottleLimit, overridden bool) {
+	throttle.Lock()
+	defer throttle.Unlock()
+	limit, overridden = throttle.userOverrides[userId]
+	if !overridden {
+		limit = helper.CONFIG.Throttle.AccessKey
+	}
+	return
+}
+
+func loadUserThrottles(m *meta.Meta) {
+	limits, err := m.GetUserThrottles()
+	if err != nil {
+		helper.Logger.Error("Load user throttles error:", err)
+		return
+	}
+	throttle.Lock()
+	defer throttle.Unlock()
+	throttle.userOverrides = limits
+}
+
+func getTokenBucket(key throttleKey, rate float64) *tokenBucket {
+	throttle.Lock()
+	defer throttle.Unlock()
+	entry, ok := throttle.buckets[key]
+	if !ok {
+		entry = &throttleEntry{bucket: newTokenBucket(rate)}
+		throttle.buckets[key] = entry
+	} else {
+		entry.bucket.setRate(rate)
+	}
+	entry.lastAccess = time.Now()
+	return entry.bucket
+}
+
+// Returns the token bucket if it exists, without creating or touching it
+func peekTokenBucket(key throttleKey) *tokenBucket {
+	throttle.Lock()
+	defer throttle.Unlock()
+	if entry, ok := throttle.buckets[key]; ok {
+		return entry.bucket
+	}
+	return nil
+}
+
+func cleanTokenBuckets() {
+	now := time.Now()
+	throttle.Lock()
+	defer throttle.Unlock()
+	for key, entry := range throttle.buckets {
+		if now.Sub(entry.lastAccess) > throttleIdleTimeout {
+			delete(throttle.buckets, key)
+		}
+	}
+}
+
+func maintainThrottle(m *meta.Meta) {
+	for {
+		time.Sleep(throttleRefreshInterval)
+		cleanTokenBuckets()
+		loadUserThrottles(m)
+	}
+}
+
+// Token buckets of one kind that apply to a request
+type tokenBuckets []*tokenBucket
+
+func (bs tokenBuckets) allow() bool {
+	for _, b := range bs {
+		if !b.allow() {
+			return false
+		}
+	}
+	return true
+}
+
+func (bs tokenBuckets) inDebt() bool {
+	for _, b := range bs {
+		if b.inDebt() {
+			return true
+		}
+	}
+	return false
+}
+
+// Take n tokens from every bucket and wait for the slowest one
+func (bs tokenBuckets) wait(n int) {
+	var delay time.Duration
+	for _, b := range bs {
+		if d := b.take(n); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+func limitOf(limit helper.ThrottleLimit, kind throttleKind) float64 {
+	switch kind {
+	case throttleRequests:
+		return limit.RequestsPerSecond
+	case throttleUpload:
+		return float64(limit.UploadBytesPerSecond)
+	default:
+		return float64(limit.DownloadBytesPerSecond)
+	}
+}
+
+var throttleKinds = []throttleKind{throttleRequests, throttleUpload, throttleDownload}
+
+// Token buckets charged by a request. Buckets of access key are added only when
+// the signature is verified, so nobody could drain limits of others' access keys.
+type requestThrottle struct {
+	mutex    sync.Mutex
+	buckets  [3]tokenBuckets
+	uploaded int // bytes read before the access key is verified
+	once     sync.Once
+}
+
+func (t *requestThrottle) add(scope, name string, limit helper.ThrottleLimit) {
+	for _, kind := range throttleKinds {
+		rate := limitOf(limit, kind)
+		if rate <= 0 {
+			continue
+		}
+		b := getTokenBucket(throttleKey{kind: kind, scope: scope, name: name}, rate)
+		t.buckets[kind] = append(t.buckets[kind], b)
+	}
+}
+
+func (t *requestThrottle) get(kind throttleKind) tokenBuckets {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.buckets[kind]
+}
+
+func (t *requestThrottle) read(n int) {
+	t.mutex.Lock()
+	t.uploaded += n
+	buckets := t.buckets[throttleUpload]
+	t.mutex.Unlock()
+	buckets.wait(n)
+}
+
+func (t *requestThrottle) write(n int) {
+	t.get(throttleDownload).wait(n)
+}
+
+// Charge the request and bytes read so far to the verified access key, the
+// request is let in even if tokens of the access key are used up, since it's
+// checked before the request is served
+func (t *requestThrottle) authenticated(credential common.Credential) {
+	t.once.Do(func() {
+		limit, _ := GetUserThrottle(credential.UserId)
+		var ak requestThrottle
+		ak.add("ak", credential.AccessKeyID, limit)
+		t.mutex.Lock()
+		for _, kind := range throttleKinds {
+			t.buckets[kind] = append(t.buckets[kind], ak.buckets[kind]...)
+		}
+		uploaded := t.uploaded
+		t.mutex.Unlock()
+		for _, b := range ak.buckets[throttleRequests] {
+			b.take(1)
+		}
+		if uploaded > 0 {
+			ak.buckets[throttleUpload].wait(uploaded)
+		}
+	})
+}
+
+type throttledReader struct {
+	io.ReadCloser
+	throttle *requestThrottle
+}
+
+func (r throttledReader) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	if n > 0 {
+		r.throttle.read(n)
+	}
+	return
+}
+
+type throttleHandler struct {
+	handler http.Handler
+}
+
+// Whether former requests of the access key claimed by request used up its
+// tokens. Buckets are only looked up here, they are charged when the access
+// key is verified.
+func accessKeyThrottled(r *http.Request) bool {
+	accessKey, _ := signature.GetRequestAccessKey(r)
+	if accessKey == "" {
+		return false
+	}
+	for _, kind := range throttleKinds {
+		b := peekTokenBucket(throttleKey{kind: kind, scope: "ak", name: accessKey})
+		if b == nil {
+			continue
+		}
+		if kind == throttleRequests && !b.available() ||
+			kind != throttleRequests && b.inDebt() {
+			return true
+		}
+	}
+	return false
+}
+
+func (h throttleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	config := helper.CONFIG.Throttle
+	t := new(requestThrottle)
+	if bucketName := getRequestContext(r).BucketName; bucketName != "" {
+		t.add("bucket", bucketName, config.Bucket)
+	}
+	// clients could not dodge or exhaust limits of others by forged headers
+	t.add("ip", getTrustedSourceIP(r), config.SourceIP)
+	// new transfers are refused while former ones are still slowed down
+	if accessKeyThrottled(r) || !t.buckets[throttleRequests].allow() ||
+		t.buckets[throttleUpload].inDebt() || t.buckets[throttleDownload].inDebt() {
+		WriteErrorResponse(w, r, ErrSlowDown)
+		return
+	}
+	r = signature.WithAuthCallback(r, t.authenticated)
+	if r.Body != nil {
+		r.Body = throttledReader{ReadCloser: r.Body, throttle: t}
+	}
+	if recorder, ok := w.(*ResponseRecorder); ok {
+		recorder.throttle = t
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// Throttle requests by token buckets of access key, bucket and source IP,
+// it should be inside access log handler to slow down response
+func SetThrottleHandler(h http.Handler, m *meta.Meta) http.Handler {
+	loadUserThrottles(m)
+	go maintainThrottle(m)
+	return throttleHandler{handler: h}
+}
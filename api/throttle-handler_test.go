@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	"github.com/journeymidnight/yig/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	b := newTokenBucket(2)
+	assert.True(t, b.allow())
+	assert.True(t, b.available())
+	assert.True(t, b.allow())
+	assert.False(t, b.available())
+	assert.False(t, b.allow())
+
+	// refilled at rate, but never more than one second of tokens
+	b.last = b.last.Add(-10 * time.Second)
+	assert.True(t, b.allow())
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+}
+
+func TestTokenBucket_SlowRate(t *testing.T) {
+	// holds at least one token
+	b := newTokenBucket(0.1)
+	assert.True(t, b.allow())
+	assert.False(t, b.allow())
+}
+
+func TestTokenBucket_Take(t *testing.T) {
+	b := newTokenBucket(100)
+	assert.Equal(t, time.Duration(0), b.take(60))
+	assert.False(t, b.inDebt())
+	// borrow 100 tokens, paid back in one second
+	delay := b.take(140)
+	assert.True(t, delay > 900*time.Millisecond && delay <= time.Second, delay)
+	assert.True(t, b.inDebt())
+	assert.False(t, b.allow())
+
+	b.last = b.last.Add(-2 * time.Second)
+	assert.False(t, b.inDebt())
+	assert.True(t, b.allow())
+}
+
+func TestTokenBucket_SetRate(t *testing.T) {
+	b := newTokenBucket(1)
+	assert.True(t, b.allow())
+	b.setRate(10)
+	b.last = b.last.Add(-time.Second)
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.allow(), i)
+	}
+	assert.False(t, b.allow())
+}
+
+func TestTokenBuckets(t *testing.T) {
+	buckets := tokenBuckets{newTokenBucket(1), newTokenBucket(2)}
+	assert.True(t, buckets.allow())
+	// the first one is used up
+	assert.False(t, buckets.allow())
+
+	buckets = tokenBuckets{newTokenBucket(1000), newTokenBucket(1000)}
+	start := time.Now()
+	buckets.wait(1100)
+	// waits for the debt of 100 tokens
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+}
+
+func TestRequestThrottle_Authenticated(t *testing.T) {
+	helper.CONFIG.Throttle.AccessKey = helper.ThrottleLimit{
+		RequestsPerSecond:    1,
+		UploadBytesPerSecond: 1000,
+	}
+	defer func() {
+		helper.CONFIG.Throttle.AccessKey = helper.ThrottleLimit{}
+	}()
+	r := httptest.NewRequest("GET", "/tiger", nil)
+	r.Header.Set("Authorization", "AWS throttled:signature")
+	assert.False(t, accessKeyThrottled(r))
+
+	throttle := new(requestThrottle)
+	throttle.read(10)
+	// nothing is charged to access keys before signature is verified
+	assert.Equal(t, 0, len(throttle.get(throttleUpload)))
+	assert.Nil(t, peekTokenBucket(throttleKey{kind: throttleRequests, scope: "ak", name: "throttled"}))
+
+	throttle.authenticated(common.Credential{UserId: "tiger", AccessKeyID: "throttled"})
+	throttle.authenticated(common.Credential{UserId: "tiger", AccessKeyID: "throttled"})
+	assert.Equal(t, 1, len(throttle.get(throttleRequests)))
+	assert.Equal(t, 1, len(throttle.get(throttleUpload)))
+	assert.Equal(t, 0, len(throttle.get(throttleDownload)))
+	upload := throttle.get(throttleUpload)[0]
+	assert.Equal(t, time.Duration(0), upload.take(990))
+	assert.True(t, upload.take(1) > 0)
+	// the only request token is taken, later requests of the access key are refused
+	assert.True(t, accessKeyThrottled(r))
+}
+
+type discardLog struct{}
+
+func (discardLog) Write(p []byte) (int, error) { return len(p), nil }
+func (discardLog) Close() error                { return nil }
+
+func TestThrottleHandler_ForwardedSourceIP(t *testing.T) {
+	helper.CONFIG.Throttle.SourceIP = helper.ThrottleLimit{RequestsPerSecond: 1}
+	helper.CONFIG.TrustedProxies = []string{"10.0.0.0/8"}
+	defer func() {
+		helper.CONFIG.Throttle.SourceIP = helper.ThrottleLimit{}
+		helper.CONFIG.TrustedProxies = nil
+	}()
+	handler := throttleHandler{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	logger := log.NewLogger(discardLog{}, log.ErrorLevel)
+	serve := func(remoteAddr, forwardedFor string) int {
+		r := httptest.NewRequest("GET", "/", nil)
+		r = r.WithContext(context.WithValue(r.Context(), RequestContextKey,
+			RequestContext{Logger: logger, RequestID: "throttle"}))
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(NewResponseRecorder(w), r)
+		return w.Code
+	}
+
+	// forged headers of clients are ignored, requests are throttled by peer address
+	assert.Equal(t, http.StatusOK, serve("192.0.2.1:1234", "198.51.100.1"))
+	assert.NotEqual(t, http.StatusOK, serve("192.0.2.1:1234", "198.51.100.2"))
+	// and they could not use up tokens of the forged address
+	assert.Equal(t, http.StatusOK, serve("198.51.100.3:1234", ""))
+	assert.Equal(t, http.StatusOK, serve("192.0.2.2:1234", "198.51.100.4"))
+	assert.Equal(t, http.StatusOK, serve("198.51.100.4:1234", ""))
+
+	// clients behind trusted proxies are throttled by forwarded address
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "203.0.113.1"))
+	assert.NotEqual(t, http.StatusOK, serve("10.0.0.2:1234", "203.0.113.1"))
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1:1234", "203.0.113.2"))
+}
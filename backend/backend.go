@@ -3,6 +3,7 @@ package backend
 import (
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/mods"
 	"io"
 )
 
@@ -40,3 +41,35 @@ type Plugin interface {
 	// returns cluster ID -> Cluster, panic on errors
 	Initialize(logger *log.Logger, config helper.Config) map[string]Cluster
 }
+
+// Initialize clusters of all BACKEND_PLUGIN plugins, returns cluster ID -> Cluster
+// and cluster ID -> name of the backend plugin owning it, panic on errors
+func InitializePlugins(plugins map[string]*mods.YigPlugin) (clusters map[string]Cluster,
+	owners map[string]string) {
+
+	clusters = make(map[string]Cluster)
+	owners = make(map[string]string)
+	for name, p := range plugins {
+		if p.PluginType != mods.BACKEND_PLUGIN {
+			continue
+		}
+		c, err := p.Create(helper.CONFIG.Plugins[name].Args)
+		if err != nil {
+			helper.Logger.Error("failed to initial backend plugin:", name, "\nerr:", err)
+			panic("failed to initial backend plugin " + name + ": " + err.Error())
+		}
+		plugin, ok := c.(Plugin)
+		if !ok {
+			panic("backend plugin " + name + " does not implement backend.Plugin")
+		}
+		helper.Logger.Info("Backend plugin is", name)
+		for id, cluster := range plugin.Initialize(&helper.Logger, helper.CONFIG) {
+			if _, ok := clusters[id]; ok {
+				panic("duplicated backend cluster ID " + id)
+			}
+			clusters[id] = cluster
+			owners[id] = name
+		}
+	}
+	return clusters, owners
+}
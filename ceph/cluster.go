@@ -349,7 +349,7 @@ func (cluster *CephCluster) Append(poolname string, existName string, data io.Re
 	if len(oid) == 0 {
 		oid = cluster.getUniqUploadName()
 	}
-	if poolname != backend.BIG_FILE_POOLNAME {
+	if poolname == backend.SMALL_FILE_POOLNAME {
 		return oid, 0,
 			errors.New("specified pool must be used for storing big file.")
 	}
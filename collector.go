@@ -23,6 +23,7 @@ type UsageDataWithBucket struct {
 	value        int64
 	owner        string
 	storageClass string
+	tags         []string // values of bucket tags in `metric_bucket_tag_keys`, in config order
 }
 
 type UsageData struct {
@@ -35,9 +36,14 @@ func newGlobalMetric(namespace string, metricName string, docString string, labe
 }
 
 func NewMetrics(namespace string) *Metrics {
+	bucketLabels := []string{"bucket_name", "owner", "storage_class"}
+	// keys of same label name are rejected on loading config
+	for _, key := range helper.CONFIG.MetricBucketTagKeys {
+		bucketLabels = append(bucketLabels, helper.MetricTagLabelName(key))
+	}
 	return &Metrics{
 		metrics: map[string]*prometheus.Desc{
-			"bucket_usage_byte_metric": newGlobalMetric(namespace, "bucket_usage_byte_metric", "The description of bucket_usage_byte_metric", []string{"bucket_name", "owner", "storage_class"}),
+			"bucket_usage_byte_metric": newGlobalMetric(namespace, "bucket_usage_byte_metric", "The description of bucket_usage_byte_metric", bucketLabels),
 			"user_usage_byte_metric":   newGlobalMetric(namespace, "user_usage_byte_metric", "The description of User_usage_byte_metric", []string{"owner_id", "storage_class"}),
 		},
 	}
@@ -56,7 +62,8 @@ func (c *Metrics) Collect(ch chan<- prometheus.Metric) {
 	GaugeMetricDataForBucket := c.GenerateBucketUsageData()
 	for bucket, data := range GaugeMetricDataForBucket {
 		for _, v := range data {
-			ch <- prometheus.MustNewConstMetric(c.metrics["bucket_usage_byte_metric"], prometheus.GaugeValue, float64(v.value), bucket, v.owner, v.storageClass)
+			labelValues := append([]string{bucket, v.owner, v.storageClass}, v.tags...)
+			ch <- prometheus.MustNewConstMetric(c.metrics["bucket_usage_byte_metric"], prometheus.GaugeValue, float64(v.value), labelValues...)
 		}
 	}
 
@@ -91,8 +98,12 @@ func (c *Metrics) GenerateBucketUsageData() (GaugeMetricData map[string][]UsageD
 				err.Error())
 			return
 		}
+		tags := make([]string, 0, len(helper.CONFIG.MetricBucketTagKeys))
+		for _, key := range helper.CONFIG.MetricBucketTagKeys {
+			tags = append(tags, bucket.Tags[key])
+		}
 		for _, data := range datas {
-			GaugeMetricData[bucket.Name] = append(GaugeMetricData[bucket.Name], UsageDataWithBucket{data.value, bucket.OwnerId, data.storageClass})
+			GaugeMetricData[bucket.Name] = append(GaugeMetricData[bucket.Name], UsageDataWithBucket{data.value, bucket.OwnerId, data.storageClass, tags})
 		}
 	}
 	return
@@ -6,9 +6,15 @@ import (
 	"io"
 )
 
+// Compression is implemented by COMPRESS_PLUGIN plugins.
+// CompressReader returns a reader producing the compressed form of reader,
+// the compressed output of several readers appended one after another
+// must still be decompressible as a whole, since AppendObject relies on it.
+// CompressWriter returns a writer which decompresses everything written to it
+// into writer, Close MUST be called to flush the remaining data.
 type Compression interface {
 	CompressReader(reader io.Reader) io.Reader
-	CompressWriter(writer io.Writer) io.Writer
+	CompressWriter(writer io.Writer) io.WriteCloser
 	IsCompressible(objectName, mtype string) bool
 }
 
@@ -30,3 +36,12 @@ func InitCompression(plugins map[string]*mods.YigPlugin) (Compression, error) {
 	}
 	panic("Failed to initialize any Compression plugin, quiting...\n")
 }
+
+// ShouldCompress reports whether an object with given name and content type
+// should be stored compressed.
+func ShouldCompress(objectName, mtype string) bool {
+	if !helper.CONFIG.EnableCompression || Compress == nil {
+		return false
+	}
+	return Compress.IsCompressible(objectName, mtype)
+}
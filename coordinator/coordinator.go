@@ -0,0 +1,261 @@
+package coordinator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/meta/types"
+)
+
+const (
+	memberLeases    = "member"
+	partitionLeases = "partition"
+)
+
+// Leases are kept in meta storage, implemented by *meta.Meta
+type LeaseClient interface {
+	AcquireLease(name, owner string, expire time.Time) (acquired bool, err error)
+	ReleaseLease(name, owner string) error
+	ListLeases(prefix string) (leases []types.Lease, err error)
+}
+
+// Coordinator splits work of a service, e.g. gc, between its running instances.
+// Work items are hashed by their keys into a fixed number of partitions, and
+// every instance holds leases of its fair share of partitions, renewed every
+// third of TTL. Leases of a dead instance expire after TTL and are taken over by
+// others, while an instance stops working on a partition well before its lease
+// expires if it fails to renew. With one partition, it's leader election.
+// Partitions held by work in progress are not given back to newly joined
+// instances until the work is done.
+type Coordinator struct {
+	service    string
+	id         string // owner of leases, unique for each instance
+	partitions int
+	ttl        time.Duration
+	client     LeaseClient
+
+	mutex sync.RWMutex
+	owned map[int]time.Time // partition -> until when it's safe to work on
+	held  map[int]bool
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func New(service string, client LeaseClient, partitions int, ttl time.Duration) *Coordinator {
+	hostname, _ := os.Hostname()
+	return &Coordinator{
+		service:    service,
+		id:         fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		partitions: partitions,
+		ttl:        ttl,
+		client:     client,
+		owned:      make(map[int]time.Time),
+		held:       make(map[int]bool),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Partition of a work item
+func Partition(key string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+func (c *Coordinator) leaseName(kind, name string) string {
+	return c.service + "/" + kind + "/" + name
+}
+
+func (c *Coordinator) partitionLease(partition int) string {
+	return c.leaseName(partitionLeases, strconv.Itoa(partition))
+}
+
+// Start takes the first share of partitions, and keeps rebalancing in background
+func (c *Coordinator) Start() {
+	helper.Logger.Info("Coordinator of", c.service, "started as", c.id)
+	c.balance()
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+				c.balance()
+			}
+		}
+	}()
+}
+
+// Stop releases all leases, so other instances could take over immediately
+func (c *Coordinator) Stop() {
+	close(c.stop)
+	<-c.done
+	c.mutex.Lock()
+	owned := c.owned
+	c.owned = make(map[int]time.Time)
+	c.mutex.Unlock()
+	for partition := range owned {
+		c.client.ReleaseLease(c.partitionLease(partition), c.id)
+	}
+	c.client.ReleaseLease(c.leaseName(memberLeases, c.id), c.id)
+	helper.Logger.Info("Coordinator of", c.service, "stopped, released partitions:", len(owned))
+}
+
+// Owns returns whether the work item of key should be processed by this instance
+func (c *Coordinator) Owns(key string) bool {
+	return c.OwnsPartition(Partition(key, c.partitions))
+}
+
+func (c *Coordinator) OwnsPartition(partition int) bool {
+	c.mutex.RLock()
+	until, ok := c.owned[partition]
+	c.mutex.RUnlock()
+	return ok && time.Now().Before(until)
+}
+
+// Hold keeps an owned partition from being given back by rebalancing, returns
+// false if the partition is not owned
+func (c *Coordinator) Hold(partition int) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	until, ok := c.owned[partition]
+	if !ok || !time.Now().Before(until) {
+		return false
+	}
+	c.held[partition] = true
+	return true
+}
+
+func (c *Coordinator) Unhold(partition int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.held, partition)
+}
+
+// Partitions currently owned, in order
+func (c *Coordinator) Partitions() (partitions []int) {
+	now := time.Now()
+	c.mutex.RLock()
+	for partition, until := range c.owned {
+		if now.Before(until) {
+			partitions = append(partitions, partition)
+		}
+	}
+	c.mutex.RUnlock()
+	sort.Ints(partitions)
+	return
+}
+
+// Renew leases of owned partitions, give back ones beyond the fair share so
+// newly joined instances could take them, and take free ones if below the share
+func (c *Coordinator) balance() {
+	now := time.Now()
+	expire := now.Add(c.ttl)
+	// safe to work on a partition until a third of TTL before its lease expires,
+	// as a margin for clock skew and work in progress
+	until := expire.Add(-c.ttl / 3)
+
+	_, err := c.client.AcquireLease(c.leaseName(memberLeases, c.id), c.id, expire)
+	if err != nil {
+		helper.Logger.Warn("Renew member lease of", c.service, "error:", err)
+	}
+	leases, err := c.client.ListLeases(c.service + "/")
+	if err != nil {
+		helper.Logger.Warn("List leases of", c.service, "error:", err)
+		return
+	}
+	members := 0
+	holders := make(map[string]string) // lease name -> owner
+	for _, lease := range leases {
+		if lease.Expire.Before(now) {
+			continue
+		}
+		if strings.HasPrefix(lease.Name, c.leaseName(memberLeases, "")) {
+			members++
+			continue
+		}
+		holders[lease.Name] = lease.Owner
+	}
+	if members == 0 { // failed to register itself
+		members = 1
+	}
+	share := (c.partitions + members - 1) / members
+
+	var mine, free []int
+	for partition := 0; partition < c.partitions; partition++ {
+		owner, held := holders[c.partitionLease(partition)]
+		if !held {
+			free = append(free, partition)
+		} else if owner == c.id {
+			mine = append(mine, partition)
+		}
+	}
+	// held partitions are kept even beyond the share
+	var kept, extra []int
+	c.mutex.RLock()
+	for _, partition := range mine {
+		if c.held[partition] {
+			kept = append(kept, partition)
+		} else {
+			extra = append(extra, partition)
+		}
+	}
+	c.mutex.RUnlock()
+	for len(kept)+len(extra) > share && len(extra) > 0 {
+		partition := extra[len(extra)-1]
+		extra = extra[:len(extra)-1]
+		c.mutex.Lock()
+		delete(c.owned, partition)
+		c.mutex.Unlock()
+		err = c.client.ReleaseLease(c.partitionLease(partition), c.id)
+		if err != nil {
+			helper.Logger.Warn("Release lease of", c.service, "partition", partition, "error:", err)
+		}
+	}
+	mine = append(kept, extra...)
+	// instances starting together compete for different partitions
+	rand.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	for _, partition := range free {
+		if len(mine) >= share {
+			break
+		}
+		mine = append(mine, partition)
+	}
+
+	owned := make(map[int]time.Time)
+	for _, partition := range mine {
+		acquired, err := c.client.AcquireLease(c.partitionLease(partition), c.id, expire)
+		if err != nil {
+			helper.Logger.Warn("Acquire lease of", c.service, "partition", partition, "error:", err)
+			continue
+		}
+		if acquired {
+			owned[partition] = until
+		}
+	}
+	c.mutex.Lock()
+	changed := len(owned) != len(c.owned)
+	for partition := range owned {
+		if _, ok := c.owned[partition]; !ok {
+			changed = true
+		}
+	}
+	c.owned = owned
+	c.mutex.Unlock()
+	if changed {
+		helper.Logger.Info("Coordinator of", c.service, "owns", len(owned), "of", c.partitions,
+			"partitions with", members, "members:", c.Partitions())
+	}
+}
@@ -0,0 +1,149 @@
+package coordinator
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryLeases struct {
+	mutex  sync.Mutex
+	leases map[string]types.Lease
+}
+
+func newMemoryLeases() *memoryLeases {
+	return &memoryLeases{leases: make(map[string]types.Lease)}
+}
+
+func (m *memoryLeases) AcquireLease(name, owner string, expire time.Time) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if lease, ok := m.leases[name]; ok && !lease.Available(owner, time.Now()) {
+		return false, nil
+	}
+	m.leases[name] = types.Lease{Name: name, Owner: owner, Expire: expire}
+	return true, nil
+}
+
+func (m *memoryLeases) ReleaseLease(name, owner string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.leases[name].Owner == owner {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+func (m *memoryLeases) ListLeases(prefix string) (leases []types.Lease, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for name, lease := range m.leases {
+		if strings.HasPrefix(name, prefix) {
+			leases = append(leases, lease)
+		}
+	}
+	return
+}
+
+// every key is owned by exactly one of the coordinators
+func assertPartitioned(t *testing.T, coordinators ...*Coordinator) {
+	for i := 0; i < 100; i++ {
+		key := "bucket/object" + strconv.Itoa(i)
+		owners := 0
+		for _, c := range coordinators {
+			if c.Owns(key) {
+				owners++
+			}
+		}
+		assert.Equal(t, 1, owners, key)
+	}
+}
+
+func TestCoordinator_Rebalance(t *testing.T) {
+	client := newMemoryLeases()
+	c1 := New("gc", client, 8, time.Hour)
+	c2 := New("gc", client, 8, time.Hour)
+	other := New("lc", client, 8, time.Hour)
+
+	c1.balance()
+	other.balance()
+	assert.Equal(t, 8, len(c1.Partitions()))
+	assert.Equal(t, 8, len(other.Partitions()))
+	c2.balance()
+	assert.Equal(t, 0, len(c2.Partitions()))
+	assertPartitioned(t, c1, c2)
+
+	// c1 gives back the partitions beyond its share, then c2 takes them
+	c1.balance()
+	assert.Equal(t, 4, len(c1.Partitions()))
+	c2.balance()
+	assert.Equal(t, 4, len(c2.Partitions()))
+	assertPartitioned(t, c1, c2)
+	assert.Equal(t, 8, len(other.Partitions()))
+}
+
+func TestCoordinator_Failover(t *testing.T) {
+	client := newMemoryLeases()
+	ttl := 300 * time.Millisecond
+	c1 := New("gc", client, 4, ttl)
+	c2 := New("gc", client, 4, ttl)
+	c1.balance()
+	c2.balance()
+	c1.balance()
+	c2.balance()
+	assert.Equal(t, 2, len(c1.Partitions()))
+	assert.Equal(t, 2, len(c2.Partitions()))
+
+	// c1 dies, it stops working before its leases expire
+	time.Sleep(ttl * 2 / 3)
+	assert.Equal(t, 0, len(c1.Partitions()))
+	c2.balance()
+	assert.Equal(t, 2, len(c2.Partitions()))
+	time.Sleep(ttl / 2)
+	c2.balance()
+	assert.Equal(t, 4, len(c2.Partitions()))
+	assertPartitioned(t, c2)
+}
+
+func TestCoordinator_Stop(t *testing.T) {
+	client := newMemoryLeases()
+	c := New("lc", client, 1, time.Hour)
+	c.Start()
+	assert.True(t, c.Owns("bucket"))
+	c.Stop()
+	assert.False(t, c.Owns("bucket"))
+	leases, _ := client.ListLeases("")
+	assert.Equal(t, 0, len(leases))
+}
+
+func TestCoordinator_Hold(t *testing.T) {
+	client := newMemoryLeases()
+	c1 := New("lc", client, 4, time.Hour)
+	c2 := New("lc", client, 4, time.Hour)
+	c1.balance()
+	assert.Equal(t, []int{0, 1, 2, 3}, c1.Partitions())
+	assert.True(t, c1.Hold(0))
+	assert.True(t, c1.Hold(1))
+	assert.True(t, c1.Hold(2))
+	assert.False(t, c2.Hold(0))
+
+	// partitions in progress are not given back to c2
+	c2.balance()
+	c1.balance()
+	assert.Equal(t, []int{0, 1, 2}, c1.Partitions())
+	c2.balance()
+	assert.Equal(t, []int{3}, c2.Partitions())
+
+	c1.Unhold(1)
+	c1.Unhold(2)
+	c1.balance()
+	assert.Equal(t, []int{0, 1}, c1.Partitions())
+	c2.balance()
+	assert.Equal(t, 2, len(c2.Partitions()))
+	assertPartitioned(t, c1, c2)
+}
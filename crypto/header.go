@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"strings"
 )
@@ -125,6 +126,26 @@ func (s3KMS) IsRequested(h http.Header) bool {
 	return false
 }
 
+// ParseHTTP parses the SSE-KMS related HTTP headers and returns the
+// requested key ID, which may be empty for the default key, and the
+// optional encryption context, a base64-encoded JSON object of strings.
+func (s3KMS) ParseHTTP(h http.Header) (keyID string, context Context, err error) {
+	if h.Get(SSEHeader) != SSEAlgorithmKMS {
+		return "", nil, ErrInvalidEncryptionMethod
+	}
+	keyID = h.Get(SSEKmsID)
+	if encoded := h.Get(SSEKmsContext); encoded != "" {
+		b, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", nil, ErrInvalidEncryptionContext
+		}
+		if err = json.Unmarshal(b, &context); err != nil {
+			return "", nil, ErrInvalidEncryptionContext
+		}
+	}
+	return keyID, context, nil
+}
+
 var (
 	// SSEC represents AWS SSE-C. It provides functionality to handle
 	// SSE-C requests.
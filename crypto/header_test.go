@@ -54,6 +54,63 @@ func TestKMSIsRequested(t *testing.T) {
 	}
 }
 
+var kmsParseHTTPTests = []struct {
+	Header     http.Header
+	KeyID      string
+	Context    Context
+	ShouldFail bool
+}{
+	{Header: http.Header{"X-Amz-Server-Side-Encryption": []string{"aws:kms"}}, KeyID: "", ShouldFail: false}, // 0
+	{
+		Header: http.Header{
+			"X-Amz-Server-Side-Encryption":                []string{"aws:kms"},
+			"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": []string{"my-key"},
+			"X-Amz-Server-Side-Encryption-Context":        []string{"eyJwcm9qZWN0IjoieWlnIn0="}, // {"project":"yig"}
+		},
+		KeyID:      "my-key",
+		Context:    Context{"project": "yig"},
+		ShouldFail: false,
+	}, // 1
+	{Header: http.Header{"X-Amz-Server-Side-Encryption": []string{"AES256"}}, ShouldFail: true}, // 2
+	{
+		Header: http.Header{
+			"X-Amz-Server-Side-Encryption":         []string{"aws:kms"},
+			"X-Amz-Server-Side-Encryption-Context": []string{"not base64"},
+		},
+		ShouldFail: true,
+	}, // 3
+	{
+		Header: http.Header{
+			"X-Amz-Server-Side-Encryption":         []string{"aws:kms"},
+			"X-Amz-Server-Side-Encryption-Context": []string{"eyJudW1iZXIiOjF9"}, // {"number":1}
+		},
+		ShouldFail: true,
+	}, // 4
+}
+
+func TestKMSParseHTTP(t *testing.T) {
+	for i, test := range kmsParseHTTPTests {
+		keyID, context, err := S3KMS.ParseHTTP(test.Header)
+		if err == nil && test.ShouldFail {
+			t.Errorf("Test %d: should fail but succeeded", i)
+		}
+		if err != nil && !test.ShouldFail {
+			t.Errorf("Test %d: should pass but failed with: %v", i, err)
+		}
+		if err == nil && keyID != test.KeyID {
+			t.Errorf("Test %d: Wanted key ID %s but got %s", i, test.KeyID, keyID)
+		}
+		if err == nil && len(context) != len(test.Context) {
+			t.Errorf("Test %d: Wanted context %v but got %v", i, test.Context, context)
+		}
+		for k, v := range test.Context {
+			if context[k] != v {
+				t.Errorf("Test %d: Wanted context %v but got %v", i, test.Context, context)
+			}
+		}
+	}
+}
+
 var s3IsRequestedTests = []struct {
 	Header   http.Header
 	Expected bool
@@ -0,0 +1,327 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sealed keys are in the ciphertext format of Vault transit,
+// "vault:v<version>:<base64 of nonce and ciphertext>"
+const localKMSCiphertextPrefix = "vault:v"
+
+var (
+	// ErrKMSKeyNotFound indicates that the master key referenced by the key ID
+	// does not exist in the KMS.
+	ErrKMSKeyNotFound = errors.New("The KMS master key does not exist")
+
+	errInvalidSealedKey = Error{"The sealed key is malformed"}
+	errNoSuchKeyVersion = Error{"The master key version of the sealed key does not exist"}
+	errInvalidMasterKey = errors.New("The master key in the keyring file is invalid")
+)
+
+// Master keys of every key ID, in base64, version N of a key is at index N-1
+type localKeyring struct {
+	Keys map[string][]string `json:"keys"`
+}
+
+// LocalKMS is a built-in stand-in of the Vault transit KMS, for testing and
+// small deployments. Master keys are kept in a local keyring file, and every
+// key could have multiple versions: data keys are sealed with the latest
+// version but could be unsealed with any version, so master keys could be
+// rotated while objects sealed with older versions are still readable.
+// The keyring file is reloaded whenever it's replaced, e.g. by the rewrap tool.
+type LocalKMS struct {
+	mutex      sync.Mutex
+	path       string
+	keyID      string // default key ID
+	autoCreate bool   // create master keys of unknown key IDs on first use
+	fileInfo   os.FileInfo
+	keyring    localKeyring
+}
+
+// NewLocalKMS loads the keyring file at path, the file is created with a new
+// master key of keyID if it doesn't exist.
+func NewLocalKMS(path, keyID string, autoCreate bool) (*LocalKMS, error) {
+	if path == "" || keyID == "" {
+		return nil, errors.New("path and key ID of local KMS must be set")
+	}
+	k := &LocalKMS{
+		path:       path,
+		keyID:      keyID,
+		autoCreate: autoCreate,
+	}
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	err := k.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := k.keyring.Keys[keyID]; !ok {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			return nil, err
+		}
+		_, err = k.rotate(keyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+func (k *LocalKMS) load() error {
+	info, err := os.Stat(k.path)
+	if os.IsNotExist(err) {
+		k.fileInfo = nil
+		k.keyring = localKeyring{Keys: make(map[string][]string)}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if k.fileInfo != nil && os.SameFile(info, k.fileInfo) &&
+		info.ModTime().Equal(k.fileInfo.ModTime()) {
+		return nil
+	}
+	content, err := ioutil.ReadFile(k.path)
+	if err != nil {
+		return err
+	}
+	var keyring localKeyring
+	err = json.Unmarshal(content, &keyring)
+	if err != nil {
+		return errors.New("corrupted KMS keyring file " + k.path + ": " + err.Error())
+	}
+	if keyring.Keys == nil {
+		keyring.Keys = make(map[string][]string)
+	}
+	k.keyring = keyring
+	k.fileInfo = info
+	return nil
+}
+
+// Replace the file atomically, so readers never see a partial one
+func (k *LocalKMS) save() (err error) {
+	content, err := json.MarshalIndent(k.keyring, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := k.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, content, 0600)
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmpPath, k.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+	k.fileInfo, err = os.Stat(k.path)
+	return
+}
+
+func (k *LocalKMS) rotate(keyID string) (version int, err error) {
+	var masterKey [32]byte
+	_, err = io.ReadFull(rand.Reader, masterKey[:])
+	if err != nil {
+		return 0, errOutOfEntropy
+	}
+	versions := append(k.keyring.Keys[keyID], base64.StdEncoding.EncodeToString(masterKey[:]))
+	k.keyring.Keys[keyID] = versions
+	err = k.save()
+	if err != nil {
+		// force reload since the keyring is changed
+		k.fileInfo = nil
+		return 0, err
+	}
+	return len(versions), nil
+}
+
+// Returns the master key of keyID at version, or the latest version if
+// version is 0, along with the actual version
+func (k *LocalKMS) masterKey(keyID string, version int) (aead cipher.AEAD, actualVersion int, err error) {
+	if keyID == "" {
+		keyID = k.keyID
+	}
+	err = k.load()
+	if err != nil {
+		return
+	}
+	versions, ok := k.keyring.Keys[keyID]
+	if !ok && version == 0 && k.autoCreate {
+		if _, err = k.rotate(keyID); err != nil {
+			return
+		}
+		versions = k.keyring.Keys[keyID]
+	} else if !ok {
+		return nil, 0, ErrKMSKeyNotFound
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	if version < 1 || version > len(versions) {
+		return nil, 0, errNoSuchKeyVersion
+	}
+	masterKey, err := base64.StdEncoding.DecodeString(versions[version-1])
+	if err != nil || len(masterKey) != 32 {
+		return nil, 0, errInvalidMasterKey
+	}
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return
+	}
+	aead, err = cipher.NewGCM(block)
+	return aead, version, err
+}
+
+func (k *LocalKMS) seal(keyID string, key [32]byte, context Context) (sealedKey []byte, err error) {
+	aead, version, err := k.masterKey(keyID, 0)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, errOutOfEntropy
+	}
+	var associatedData strings.Builder
+	context.WriteTo(&associatedData)
+	ciphertext := aead.Seal(nonce, nonce, key[:], []byte(associatedData.String()))
+	sealed := localKMSCiphertextPrefix + strconv.Itoa(version) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext)
+	return []byte(sealed), nil
+}
+
+// Returns the master key version of a sealed key
+func sealedKeyVersion(sealedKey []byte) (version int, ciphertext []byte, err error) {
+	s := string(sealedKey)
+	if !strings.HasPrefix(s, localKMSCiphertextPrefix) {
+		return 0, nil, errInvalidSealedKey
+	}
+	parts := strings.SplitN(s[len(localKMSCiphertextPrefix):], ":", 2)
+	if len(parts) != 2 {
+		return 0, nil, errInvalidSealedKey
+	}
+	version, err = strconv.Atoi(parts[0])
+	if err != nil || version < 1 {
+		return 0, nil, errInvalidSealedKey
+	}
+	ciphertext, err = base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, errInvalidSealedKey
+	}
+	return version, ciphertext, nil
+}
+
+func (k *LocalKMS) unseal(keyID string, sealedKey []byte, context Context) (key [32]byte, version int, err error) {
+	version, ciphertext, err := sealedKeyVersion(sealedKey)
+	if err != nil {
+		return
+	}
+	aead, _, err := k.masterKey(keyID, version)
+	if err != nil {
+		return
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return key, 0, errInvalidSealedKey
+	}
+	var associatedData strings.Builder
+	context.WriteTo(&associatedData)
+	nonceSize := aead.NonceSize()
+	plaintext, err := aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:],
+		[]byte(associatedData.String()))
+	if err != nil || len(plaintext) != len(key) {
+		return key, 0, ErrSecretKeyMismatch
+	}
+	copy(key[:], plaintext)
+	return key, version, nil
+}
+
+// GenerateKey generates a new random data key, and seals it with the latest
+// version of the master key referenced by keyID, or the default key if empty
+func (k *LocalKMS) GenerateKey(keyID string, context Context) (key [32]byte, sealedKey []byte, err error) {
+	_, err = io.ReadFull(rand.Reader, key[:])
+	if err != nil {
+		return key, nil, errOutOfEntropy
+	}
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	sealedKey, err = k.seal(keyID, key, context)
+	return key, sealedKey, err
+}
+
+// UnsealKey unseals the sealedKey with the master key version it's sealed with
+func (k *LocalKMS) UnsealKey(keyID string, sealedKey []byte, context Context) (key [32]byte, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key, _, err = k.unseal(keyID, sealedKey, context)
+	return
+}
+
+func (k *LocalKMS) GetKeyID() string {
+	return k.keyID
+}
+
+// KeyIDs returns all key IDs in the keyring
+func (k *LocalKMS) KeyIDs() (keyIDs []string, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	err = k.load()
+	if err != nil {
+		return
+	}
+	for keyID := range k.keyring.Keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+	return
+}
+
+// RotateKey adds a new version to the master key referenced by keyID, which
+// is used to seal data keys from now on. Older versions are kept to unseal
+// existing keys, until they are rewrapped.
+func (k *LocalKMS) RotateKey(keyID string) (version int, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	err = k.load()
+	if err != nil {
+		return
+	}
+	return k.rotate(keyID)
+}
+
+// RewrapKey re-seals the sealedKey with the latest version of the master key,
+// without exposing the data key. rewrapped is false if the key is already
+// sealed with the latest version.
+func (k *LocalKMS) RewrapKey(keyID string, sealedKey []byte, context Context) (newSealedKey []byte, rewrapped bool, err error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key, version, err := k.unseal(keyID, sealedKey, context)
+	if err != nil {
+		return nil, false, err
+	}
+	_, latest, err := k.masterKey(keyID, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	if version == latest {
+		return sealedKey, false, nil
+	}
+	newSealedKey, err = k.seal(keyID, key, context)
+	if err != nil {
+		return nil, false, err
+	}
+	return newSealedKey, true, nil
+}
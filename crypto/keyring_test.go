@@ -0,0 +1,96 @@
+package crypto
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLocalKMS(t *testing.T) (kms *LocalKMS, path string) {
+	dir, err := ioutil.TempDir("", "yig-kms")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	path = filepath.Join(dir, "keyring", "kms.json")
+	kms, err = NewLocalKMS(path, "yig", false)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Failed to create local KMS: %v", err)
+	}
+	return kms, path
+}
+
+func TestLocalKMSGenerateAndUnseal(t *testing.T) {
+	kms, path := newTestLocalKMS(t)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+	context := Context{"bucket": "bucket/object"}
+	key, sealedKey, err := kms.GenerateKey("", context)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if !strings.HasPrefix(string(sealedKey), "vault:v1:") {
+		t.Errorf("Sealed key should be sealed by version 1, got: %s", sealedKey)
+	}
+	unsealedKey, err := kms.UnsealKey("yig", sealedKey, context)
+	if err != nil {
+		t.Fatalf("Failed to unseal key: %v", err)
+	}
+	if unsealedKey != key {
+		t.Error("Unsealed key differs from the generated one")
+	}
+	if _, err = kms.UnsealKey("yig", sealedKey, Context{"bucket": "bucket/other"}); err == nil {
+		t.Error("Unseal should fail with a different context")
+	}
+	if _, _, err = kms.GenerateKey("not-exist", context); err != ErrKMSKeyNotFound {
+		t.Errorf("Generate key of unknown key ID should fail with ErrKMSKeyNotFound, got: %v", err)
+	}
+}
+
+func TestLocalKMSRotateAndRewrap(t *testing.T) {
+	kms, path := newTestLocalKMS(t)
+	defer os.RemoveAll(filepath.Dir(filepath.Dir(path)))
+
+	context := Context{"bucket": "bucket/object"}
+	key, sealedKey, err := kms.GenerateKey("yig", context)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	// rotated by another instance, e.g. the rewrap tool
+	tool, err := NewLocalKMS(path, "yig", false)
+	if err != nil {
+		t.Fatalf("Failed to load local KMS: %v", err)
+	}
+	version, err := tool.RotateKey("yig")
+	if err != nil || version != 2 {
+		t.Fatalf("Rotate should create version 2, got: %d %v", version, err)
+	}
+	rewrapped, ok, err := tool.RewrapKey("yig", sealedKey, context)
+	if err != nil || !ok {
+		t.Fatalf("Failed to rewrap key: %v", err)
+	}
+	if !strings.HasPrefix(string(rewrapped), "vault:v2:") {
+		t.Errorf("Rewrapped key should be sealed by version 2, got: %s", rewrapped)
+	}
+	if _, ok, _ = tool.RewrapKey("yig", rewrapped, context); ok {
+		t.Error("Key sealed by the latest version should not be rewrapped")
+	}
+
+	// both versions are readable by the reloaded instance
+	for _, sealed := range [][]byte{sealedKey, rewrapped} {
+		unsealedKey, err := kms.UnsealKey("yig", sealed, context)
+		if err != nil {
+			t.Fatalf("Failed to unseal key: %v", err)
+		}
+		if unsealedKey != key {
+			t.Error("Unsealed key differs from the generated one")
+		}
+	}
+	_, sealedKey, err = kms.GenerateKey("yig", context)
+	if err != nil || !strings.HasPrefix(string(sealedKey), "vault:v2:") {
+		t.Errorf("New keys should be sealed by version 2, got: %s %v", sealedKey, err)
+	}
+}
@@ -178,6 +178,49 @@ const (
 	ErrInvalidRestoreInfo
 	ErrCreateRestoreObject
 	ErrInvalidGlacierObject
+	ErrInvalidTag
+	ErrTooManyTags
+	ErrTooManyBucketTags
+	ErrNoSuchTagSet
+	ErrInvalidNotificationConfiguration
+	ErrInvalidNotificationEvent
+	ErrInvalidNotificationFilter
+	ErrInvalidNotificationDestination
+	ErrNoSuchReplicationConfiguration
+	ErrInvalidReplicationConfiguration
+	ErrReplicationRequiresVersioning
+	ErrNoSuchObjectLockConfiguration
+	ErrObjectLockRequiresVersioning
+	ErrMissingObjectLockConfiguration
+	ErrInvalidRetentionPeriod
+	ErrObjectLocked
+	ErrAppendObjectLockEnabled
+	ErrInvalidMigration
+	ErrMigrationInProgress
+	ErrNoSuchMigration
+	ErrInvalidTargetBucketForLogging
+	ErrInvalidToken
+	ErrExpiredToken
+	ErrInvalidStsAction
+	ErrInvalidDurationSeconds
+	ErrQuotaExceeded
+	ErrInvalidQuota
+	ErrSlowDown
+	ErrInvalidThrottle
+	ErrInvalidExpressionType
+	ErrInvalidSelectQuery
+	ErrInvalidSelectParameter
+	ErrInvalidDataSource
+	ErrInvalidCompressionFormat
+	ErrCSVParsing
+	ErrJSONParsing
+	ErrIllegalSelectArgument
+	ErrSelectCastFailed
+	ErrInvalidEncryptionContext
+	ErrInvalidEncryptionAlgorithm
+	ErrKMSKeyNotFound
+	ErrStorageClassJobInProgress
+	ErrNoSuchStorageClassJob
 )
 
 // error code to APIError structure, these fields carry respective
@@ -829,6 +872,221 @@ var ErrorCodeResponse = map[ApiErrorCode]ApiErrorStruct{
 		Description:    "Create object thaw operation failed",
 		HttpStatusCode: http.StatusInternalServerError,
 	},
+	ErrInvalidTag: {
+		AwsErrorCode:   "InvalidTag",
+		Description:    "The TagKey or TagValue you have provided is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyTags: {
+		AwsErrorCode:   "BadRequest",
+		Description:    "Object tags cannot be greater than 10.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyBucketTags: {
+		AwsErrorCode:   "BadRequest",
+		Description:    "Bucket tags cannot be greater than 50.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchTagSet: {
+		AwsErrorCode:   "NoSuchTagSet",
+		Description:    "The TagSet does not exist.",
+		HttpStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidNotificationConfiguration: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The notification configuration is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidNotificationEvent: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The event is not supported for notifications.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidNotificationFilter: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "Filter rule name must be either prefix or suffix, and can not be specified more than once.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidNotificationDestination: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "Unable to validate the destination configuration.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchReplicationConfiguration: {
+		AwsErrorCode:   "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found.",
+		HttpStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidReplicationConfiguration: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The replication configuration is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrReplicationRequiresVersioning: {
+		AwsErrorCode:   "InvalidRequest",
+		Description:    "Versioning must be 'Enabled' on the bucket to apply a replication configuration.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchObjectLockConfiguration: {
+		AwsErrorCode:   "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket.",
+		HttpStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLockRequiresVersioning: {
+		AwsErrorCode:   "InvalidBucketState",
+		Description:    "Versioning must be 'Enabled' on the bucket to apply a Object Lock configuration.",
+		HttpStatusCode: http.StatusConflict,
+	},
+	ErrMissingObjectLockConfiguration: {
+		AwsErrorCode:   "InvalidRequest",
+		Description:    "Bucket is missing Object Lock Configuration.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidRetentionPeriod: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The retention period specified is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLocked: {
+		AwsErrorCode:   "AccessDenied",
+		Description:    "Access Denied because object protected by object lock.",
+		HttpStatusCode: http.StatusForbidden,
+	},
+	ErrAppendObjectLockEnabled: {
+		AwsErrorCode:   "InvalidRequest",
+		Description:    "Appendable objects are not supported in a bucket with Object Lock enabled.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMigration: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The source or target cluster and pool of migration is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrMigrationInProgress: {
+		AwsErrorCode:   "OperationAborted",
+		Description:    "A migration job is already in progress.",
+		HttpStatusCode: http.StatusConflict,
+	},
+	ErrNoSuchMigration: {
+		AwsErrorCode:   "NoSuchMigration",
+		Description:    "No migration job has been started.",
+		HttpStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidTargetBucketForLogging: {
+		AwsErrorCode:   "InvalidTargetBucketForLogging",
+		Description:    "The target bucket for logging does not exist or is not owned by you.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidToken: {
+		AwsErrorCode:   "InvalidToken",
+		Description:    "The provided token is malformed or otherwise invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrExpiredToken: {
+		AwsErrorCode:   "ExpiredToken",
+		Description:    "The provided token has expired.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidStsAction: {
+		AwsErrorCode:   "InvalidAction",
+		Description:    "The action or operation requested is invalid, only AssumeRole and GetSessionToken are supported.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidDurationSeconds: {
+		AwsErrorCode:   "ValidationError",
+		Description:    "DurationSeconds must be between 900 and 43200.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrQuotaExceeded: {
+		AwsErrorCode:   "QuotaExceeded",
+		Description:    "The storage quota of the bucket or its owner is exceeded.",
+		HttpStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidQuota: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "Quota should not be negative.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrSlowDown: {
+		AwsErrorCode:   "SlowDown",
+		Description:    "Please reduce your request rate.",
+		HttpStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrInvalidThrottle: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "Throttle limits should not be negative.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidExpressionType: {
+		AwsErrorCode:   "InvalidExpressionType",
+		Description:    "The ExpressionType is invalid. Only SQL expressions are supported.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSelectQuery: {
+		AwsErrorCode:   "ParseSelectFailure",
+		Description:    "Encountered an error parsing the SQL expression.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSelectParameter: {
+		AwsErrorCode:   "InvalidRequestParameter",
+		Description:    "The value of a parameter in SelectRequest element is invalid.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidDataSource: {
+		AwsErrorCode:   "InvalidDataSource",
+		Description:    "Invalid data source type. Only CSV and JSON are supported.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCompressionFormat: {
+		AwsErrorCode:   "InvalidCompressionFormat",
+		Description:    "The file is not in a supported compression format. Only GZIP and BZIP2 are supported.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrCSVParsing: {
+		AwsErrorCode:   "CSVParsingError",
+		Description:    "Encountered an error parsing the CSV file.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrJSONParsing: {
+		AwsErrorCode:   "JSONParsingError",
+		Description:    "Encountered an error parsing the JSON file.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrIllegalSelectArgument: {
+		AwsErrorCode:   "IllegalSqlFunctionArgument",
+		Description:    "Illegal argument was used in the SQL function.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrSelectCastFailed: {
+		AwsErrorCode:   "CastFailed",
+		Description:    "Attempt to convert from one data type to another using CAST failed in the SQL expression.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncryptionContext: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The encryption context must be a base64-encoded UTF-8 string holding a JSON object of string values.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidEncryptionAlgorithm: {
+		AwsErrorCode:   "InvalidArgument",
+		Description:    "The SSEAlgorithm must be AES256 or aws:kms, and KMSMasterKeyID is only allowed for aws:kms.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrKMSKeyNotFound: {
+		AwsErrorCode:   "KMS.NotFoundException",
+		Description:    "The KMS key ID specified does not exist.",
+		HttpStatusCode: http.StatusBadRequest,
+	},
+	ErrStorageClassJobInProgress: {
+		AwsErrorCode:   "OperationAborted",
+		Description:    "A job changing storage class is already in progress.",
+		HttpStatusCode: http.StatusConflict,
+	},
+	ErrNoSuchStorageClassJob: {
+		AwsErrorCode:   "NoSuchStorageClassJob",
+		Description:    "No job changing storage class has been started.",
+		HttpStatusCode: http.StatusNotFound,
+	},
 	ErrMaintenance: {
 		AwsErrorCode: "ServiceTemporaryMaintenance",
 		Description: "Temporary maintenance, please retry your request",
@@ -0,0 +1,192 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/helper"
+)
+
+// FsCluster stores objects as files on a local filesystem, for development
+// and small edge sites without RADOS. Every pool is a directory under root,
+// and objects are spread into 256 sub-directories of the pool by hash of oid.
+type FsCluster struct {
+	Name       string
+	Root       string
+	InstanceId string
+	counter    uint64
+}
+
+func NewFsCluster(name, root string) (*FsCluster, error) {
+	if name == "" || root == "" {
+		return nil, errors.New("cluster name and root directory must be set")
+	}
+	pools := []string{backend.SMALL_FILE_POOLNAME, backend.BIG_FILE_POOLNAME,
+		backend.GLACIER_FILE_POOLNAME}
+	// pools of storage classes in config
+	for _, policy := range helper.CONFIG.StorageClasses {
+		pools = append(pools, policy.SmallPool, policy.BigPool)
+	}
+	for _, pool := range pools {
+		if pool == "" {
+			continue
+		}
+		err := os.MkdirAll(filepath.Join(root, pool), 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &FsCluster{
+		Name:       name,
+		Root:       root,
+		InstanceId: string(helper.GenerateRandomId()),
+	}, nil
+}
+
+func (cluster *FsCluster) getUniqUploadName() string {
+	v := atomic.AddUint64(&cluster.counter, 1)
+	return fmt.Sprintf("%s:%d", cluster.InstanceId, v)
+}
+
+func (cluster *FsCluster) objectPath(poolName, oid string) (string, error) {
+	if poolName == "" || strings.ContainsRune(poolName, os.PathSeparator) ||
+		poolName == "." || poolName == ".." {
+		return "", fmt.Errorf("Bad poolname %s", poolName)
+	}
+	poolPath := filepath.Join(cluster.Root, poolName)
+	if _, err := os.Stat(poolPath); err != nil {
+		return "", fmt.Errorf("Bad poolname %s", poolName)
+	}
+	if oid == "" || strings.ContainsRune(oid, os.PathSeparator) ||
+		oid == "." || oid == ".." {
+		return "", fmt.Errorf("Bad object name %s", oid)
+	}
+	dir := fmt.Sprintf("%02x", crc32.ChecksumIEEE([]byte(oid))&0xff)
+	return filepath.Join(poolPath, dir, oid), nil
+}
+
+// Write data to file at offset, the file is created if it doesn't exist
+func writeFile(path string, flag int, data io.Reader, offset int64) (size uint64, err error) {
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|flag, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return
+	}
+	n, err := io.Copy(f, data)
+	if err != nil {
+		return uint64(n), err
+	}
+	return uint64(n), f.Sync()
+}
+
+func (cluster *FsCluster) Put(poolName string, data io.Reader) (oid string,
+	size uint64, err error) {
+
+	oid = cluster.getUniqUploadName()
+	path, err := cluster.objectPath(poolName, oid)
+	if err != nil {
+		return "", 0, err
+	}
+	size, err = writeFile(path, os.O_CREATE|os.O_EXCL, data, 0)
+	if err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+	return oid, size, nil
+}
+
+func (cluster *FsCluster) Append(poolName, existName string, data io.Reader,
+	offset int64) (oid string, size uint64, err error) {
+
+	oid = existName
+	if len(oid) == 0 {
+		oid = cluster.getUniqUploadName()
+	}
+	if poolName == backend.SMALL_FILE_POOLNAME {
+		return oid, 0,
+			errors.New("specified pool must be used for storing big file.")
+	}
+	path, err := cluster.objectPath(poolName, oid)
+	if err != nil {
+		return oid, 0, err
+	}
+	size, err = writeFile(path, os.O_CREATE, data, offset)
+	return oid, size, err
+}
+
+type fileReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (r *fileReader) Close() error {
+	return r.file.Close()
+}
+
+func (cluster *FsCluster) GetReader(poolName, oid string, startOffset int64,
+	length uint64) (reader io.ReadCloser, err error) {
+
+	path, err := cluster.objectPath(poolName, oid)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Seek(startOffset, io.SeekStart)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &fileReader{Reader: f, file: f}
+	if length > 0 {
+		r.Reader = io.LimitReader(f, int64(length))
+	}
+	return r, nil
+}
+
+func (cluster *FsCluster) Remove(poolName, oid string) error {
+	path, err := cluster.objectPath(poolName, oid)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (cluster *FsCluster) ID() string {
+	return cluster.Name
+}
+
+func (cluster *FsCluster) GetUsage() (usage backend.Usage, err error) {
+	var stat syscall.Statfs_t
+	err = syscall.Statfs(cluster.Root, &stat)
+	if err != nil {
+		return usage, err
+	}
+	if stat.Blocks == 0 {
+		return usage, nil
+	}
+	usage.UsedSpacePercent = int((stat.Blocks - stat.Bavail) * uint64(100) / stat.Blocks)
+	return
+}
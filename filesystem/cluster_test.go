@@ -0,0 +1,112 @@
+package filesystem_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/filesystem"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCluster(t *testing.T) (cluster *filesystem.FsCluster, dir string) {
+	dir, err := ioutil.TempDir("", "yig-fs")
+	if err != nil {
+		t.Fatal("Error creating temp dir:", err)
+	}
+	cluster, err = filesystem.NewFsCluster("fs", dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("Error creating cluster:", err)
+	}
+	return cluster, dir
+}
+
+func readAll(t *testing.T, cluster *filesystem.FsCluster, pool, oid string,
+	offset int64, length uint64) []byte {
+
+	reader, err := cluster.GetReader(pool, oid, offset, length)
+	if err != nil {
+		t.Fatal("GetReader error:", err)
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal("Read error:", err)
+	}
+	return data
+}
+
+func TestFsCluster_PutAndGet(t *testing.T) {
+	cluster, dir := newCluster(t)
+	defer os.RemoveAll(dir)
+
+	oid, size, err := cluster.Put(backend.SMALL_FILE_POOLNAME, bytes.NewBufferString("hello world"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(11), size)
+	oid2, _, err := cluster.Put(backend.SMALL_FILE_POOLNAME, bytes.NewBufferString("hehe"))
+	assert.Nil(t, err)
+	assert.NotEqual(t, oid, oid2)
+
+	assert.Equal(t, "hello world", string(readAll(t, cluster, backend.SMALL_FILE_POOLNAME, oid, 0, 0)))
+	assert.Equal(t, "world", string(readAll(t, cluster, backend.SMALL_FILE_POOLNAME, oid, 6, 0)))
+	assert.Equal(t, "lo w", string(readAll(t, cluster, backend.SMALL_FILE_POOLNAME, oid, 3, 4)))
+
+	err = cluster.Remove(backend.SMALL_FILE_POOLNAME, oid)
+	assert.Nil(t, err)
+	_, err = cluster.GetReader(backend.SMALL_FILE_POOLNAME, oid, 0, 0)
+	assert.NotNil(t, err)
+	// removing a missing object is not an error
+	err = cluster.Remove(backend.SMALL_FILE_POOLNAME, oid)
+	assert.Nil(t, err)
+
+	_, _, err = cluster.Put("no-such-pool", bytes.NewBufferString("hehe"))
+	assert.NotNil(t, err)
+	_, _, err = cluster.Put("../"+backend.SMALL_FILE_POOLNAME, bytes.NewBufferString("hehe"))
+	assert.NotNil(t, err)
+}
+
+func TestFsCluster_Append(t *testing.T) {
+	cluster, dir := newCluster(t)
+	defer os.RemoveAll(dir)
+
+	_, _, err := cluster.Append(backend.SMALL_FILE_POOLNAME, "", bytes.NewBufferString("hehe"), 0)
+	assert.NotNil(t, err)
+
+	oid, size, err := cluster.Append(backend.BIG_FILE_POOLNAME, "", bytes.NewBufferString("hello"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), size)
+	name, size, err := cluster.Append(backend.BIG_FILE_POOLNAME, oid, bytes.NewBufferString(" world"), 5)
+	assert.Nil(t, err)
+	assert.Equal(t, oid, name)
+	assert.Equal(t, uint64(6), size)
+	assert.Equal(t, "hello world", string(readAll(t, cluster, backend.BIG_FILE_POOLNAME, oid, 0, 0)))
+}
+
+func TestFsCluster_StorageClassPools(t *testing.T) {
+	helper.CONFIG.StorageClasses = map[string]helper.StorageClassPolicy{
+		"STANDARD_IA": {SmallPool: "rabbit-ia", BigPool: "tiger-ia"},
+	}
+	defer func() { helper.CONFIG.StorageClasses = nil }()
+	cluster, dir := newCluster(t)
+	defer os.RemoveAll(dir)
+
+	oid, _, err := cluster.Put("rabbit-ia", bytes.NewBufferString("hello"))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(readAll(t, cluster, "rabbit-ia", oid, 0, 0)))
+	oid, _, err = cluster.Append("tiger-ia", "", bytes.NewBufferString("hello"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(readAll(t, cluster, "tiger-ia", oid, 0, 0)))
+}
+
+func TestFsCluster_GetUsage(t *testing.T) {
+	cluster, dir := newCluster(t)
+	defer os.RemoveAll(dir)
+
+	usage, err := cluster.GetUsage()
+	assert.Nil(t, err)
+	assert.True(t, usage.UsedSpacePercent >= 0 && usage.UsedSpacePercent <= 100)
+}
@@ -29,7 +29,6 @@ type Config struct {
 	BindAdminAddress     string                  `toml:"admin_listener"`
 	SSLKeyPath           string                  `toml:"ssl_key_path"`
 	SSLCertPath          string                  `toml:"ssl_cert_path"`
-	ZookeeperAddress     string                  `toml:"zk_address"`
 
 	InstanceId             string // if empty, generated one at server startup
 	ConcurrentRequestLimit int
@@ -44,8 +43,38 @@ type Config struct {
 	ReservedOrigins        string `toml:"reserved_origins"` // www.ccc.com,www.bbb.com,127.0.0.1
 	MetaStore              string `toml:"meta_store"`
 	TidbInfo               string `toml:"tidb_info"`
-	KeepAlive              bool   `toml:"keepalive"`
-	EnableCompression      bool   `toml:"enable_compression"`
+	// data file of the embedded metadata store, used when meta_store is "embedded"
+	MetaStorePath     string `toml:"meta_store_path"`
+	KeepAlive         bool   `toml:"keepalive"`
+	EnableCompression bool   `toml:"enable_compression"`
+	// Bucket tag keys exported as extra labels of bucket usage metric, e.g ["project", "team"]
+	MetricBucketTagKeys []string `toml:"metric_bucket_tag_keys"`
+	// used for tools/replicate only, set worker numbers to do replication
+	ReplicationThread int `toml:"replication_thread"`
+	// used for tools/restore only, set worker numbers to restore glacier objects
+	RestoreThread int `toml:"restore_thread"`
+	// Endpoints of destination clusters, keyed by region in destination bucket ARN
+	ReplicationTargets map[string]ReplicationTarget `toml:"replication_targets"`
+	// Access keys used by replication workers of source clusters, only requests
+	// signed by them could write replicas with `X-Amz-Replication-Status: REPLICA`
+	ReplicationAccessKeys []string `toml:"replication_access_keys"`
+	// Addresses or CIDRs of reverse proxies terminating TLS, whose
+	// `X-Forwarded-Proto` header is trusted for aws:SecureTransport
+	TrustedProxies []string `toml:"trusted_proxies"`
+	// Seconds between deliveries of bucket server access logs to target buckets
+	LogDeliveryInterval int `toml:"log_delivery_interval"`
+	// Secret to seal session tokens of temporary credentials minted by STS endpoint,
+	// should be the same on all instances. STS is disabled if empty
+	StsSecretKey string `toml:"sts_secret_key"`
+	// Per-tenant limits of request rate and bandwidth
+	Throttle ThrottleConfig `toml:"throttle"`
+	// Work of gc and lc daemons is split into partitions, each instance holds leases
+	// of its share in meta storage, and takes over ones of dead instances after TTL seconds
+	CoordinatorPartitions int `toml:"coordinator_partitions"`
+	CoordinatorLeaseTTL   int `toml:"coordinator_lease_ttl"`
+	// Pools to write objects of each storage class, keyed by storage class name,
+	// e.g STANDARD_IA. Classes not configured are written as STANDARD
+	StorageClasses map[string]StorageClassPolicy `toml:"storage_class"`
 
 	//About cache
 	EnableUsagePush       bool   `toml:"enable_usage_push"`
@@ -83,6 +112,34 @@ type Config struct {
 	UploadMaxChunkSize  int64 `toml:"upload_max_chunk_size"`
 }
 
+type ReplicationTarget struct {
+	Endpoint  string `toml:"endpoint"` // e.g http://s3.cn-sh-1.test.com
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+}
+
+// Token bucket limits, 0 means unlimited
+type ThrottleLimit struct {
+	RequestsPerSecond      float64 `toml:"requests_per_second" json:"requestsPerSecond"`
+	UploadBytesPerSecond   int64   `toml:"upload_bytes_per_second" json:"uploadBytesPerSecond"`
+	DownloadBytesPerSecond int64   `toml:"download_bytes_per_second" json:"downloadBytesPerSecond"`
+}
+
+type ThrottleConfig struct {
+	AccessKey ThrottleLimit `toml:"access_key"` // for each access key
+	Bucket    ThrottleLimit `toml:"bucket"`     // for each bucket
+	SourceIP  ThrottleLimit `toml:"source_ip"`  // for each client IP
+}
+
+type StorageClassPolicy struct {
+	SmallPool string `toml:"small_pool"` // pool of objects smaller than threshold
+	// pool of other objects, including appendable objects and multipart uploads
+	BigPool   string `toml:"big_pool"`
+	Threshold int64  `toml:"threshold"` // in bytes
+	// IDs of clusters to write, any cluster having the pool if empty
+	Clusters []string `toml:"clusters"`
+}
+
 type PluginConfig struct {
 	Path   string                 `toml:"path"`
 	Enable bool                   `toml:"enable"`
@@ -115,13 +172,21 @@ func MarshalTOMLConfig() error {
 	CONFIG.LogPath = logFilePathWithPid(c.LogPath)
 	CONFIG.AccessLogPath = logFilePathWithPid(c.AccessLogPath)
 	CONFIG.AccessLogFormat = c.AccessLogFormat
+	CONFIG.LogDeliveryInterval = Ternary(c.LogDeliveryInterval <= 0,
+		300, c.LogDeliveryInterval).(int)
+	CONFIG.StsSecretKey = c.StsSecretKey
+	CONFIG.Throttle = c.Throttle
+	CONFIG.CoordinatorPartitions = Ternary(c.CoordinatorPartitions <= 0,
+		16, c.CoordinatorPartitions).(int)
+	CONFIG.CoordinatorLeaseTTL = Ternary(c.CoordinatorLeaseTTL <= 0,
+		30, c.CoordinatorLeaseTTL).(int)
+	CONFIG.StorageClasses = c.StorageClasses
 	CONFIG.PanicLogPath = c.PanicLogPath
 	CONFIG.PidFile = c.PidFile
 	CONFIG.BindApiAddress = c.BindApiAddress
 	CONFIG.BindAdminAddress = c.BindAdminAddress
 	CONFIG.SSLKeyPath = c.SSLKeyPath
 	CONFIG.SSLCertPath = c.SSLCertPath
-	CONFIG.ZookeeperAddress = c.ZookeeperAddress
 	CONFIG.DebugMode = c.DebugMode
 	CONFIG.EnablePProf = c.EnablePProf
 	CONFIG.BindPProfAddress = c.BindPProfAddress
@@ -131,6 +196,18 @@ func MarshalTOMLConfig() error {
 	CONFIG.TidbInfo = c.TidbInfo
 	CONFIG.KeepAlive = c.KeepAlive
 	CONFIG.EnableCompression = c.EnableCompression
+	err = checkMetricBucketTagKeys(c.MetricBucketTagKeys)
+	if err != nil {
+		panic("load yig.toml error: " + err.Error())
+	}
+	CONFIG.MetricBucketTagKeys = c.MetricBucketTagKeys
+	CONFIG.ReplicationThread = Ternary(c.ReplicationThread == 0,
+		1, c.ReplicationThread).(int)
+	CONFIG.ReplicationTargets = c.ReplicationTargets
+	CONFIG.ReplicationAccessKeys = c.ReplicationAccessKeys
+	CONFIG.TrustedProxies = c.TrustedProxies
+	CONFIG.RestoreThread = Ternary(c.RestoreThread == 0,
+		1, c.RestoreThread).(int)
 	CONFIG.InstanceId = Ternary(c.InstanceId == "",
 		string(GenerateRandomId()), c.InstanceId).(string)
 	CONFIG.ConcurrentRequestLimit = Ternary(c.ConcurrentRequestLimit == 0,
@@ -141,6 +218,8 @@ func MarshalTOMLConfig() error {
 		1, c.LcThread).(int)
 	CONFIG.LogLevel = Ternary(len(c.LogLevel) == 0, "info", c.LogLevel).(string)
 	CONFIG.MetaStore = Ternary(c.MetaStore == "", "tidb", c.MetaStore).(string)
+	CONFIG.MetaStorePath = Ternary(c.MetaStorePath == "",
+		"/var/lib/yig/meta.db", c.MetaStorePath).(string)
 
 	CONFIG.EnableUsagePush = c.EnableUsagePush
 	CONFIG.RedisAddress = c.RedisAddress
@@ -174,6 +253,31 @@ func MarshalTOMLConfig() error {
 	return nil
 }
 
+// Convert a bucket tag key to a valid prometheus label name, e.g "cost-center" to "tag_cost_center"
+func MetricTagLabelName(key string) string {
+	label := []byte("tag_" + key)
+	for i, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			label[i] = '_'
+		}
+	}
+	return string(label)
+}
+
+// Labels of bucket usage metric must be unique, or registering the metric panics
+func checkMetricBucketTagKeys(keys []string) error {
+	labels := make(map[string]string) // label name -> tag key
+	for _, key := range keys {
+		label := MetricTagLabelName(key)
+		if other, ok := labels[label]; ok {
+			return fmt.Errorf("metric_bucket_tag_keys %q and %q are both exported as label %s",
+				other, key, label)
+		}
+		labels[label] = key
+	}
+	return nil
+}
+
 // Convert from "/var/log/yig/yig.log" to something like "/var/log/yig/49106.yig.log"
 // Only support UNIX style path
 func logFilePathWithPid(rawPath string) string {
@@ -181,4 +285,4 @@ func logFilePathWithPid(rawPath string) string {
 	parts := strings.Split(rawPath, "/")
 	parts[len(parts)-1] = fmt.Sprintf("%d.%s", pid, parts[len(parts)-1])
 	return strings.Join(parts, "/")
-}
\ No newline at end of file
+}
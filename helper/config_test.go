@@ -0,0 +1,15 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMetricBucketTagKeys(t *testing.T) {
+	assert.Equal(t, "tag_cost_center", MetricTagLabelName("cost-center"))
+	assert.Nil(t, checkMetricBucketTagKeys(nil))
+	assert.Nil(t, checkMetricBucketTagKeys([]string{"project", "team", "cost-center"}))
+	assert.NotNil(t, checkMetricBucketTagKeys([]string{"cost-center", "cost_center"}))
+	assert.NotNil(t, checkMetricBucketTagKeys([]string{"team", "team"}))
+}
@@ -0,0 +1,126 @@
+package iam
+
+import (
+	"errors"
+
+	"github.com/journeymidnight/yig/iam/cache"
+	"github.com/journeymidnight/yig/iam/common"
+)
+
+const (
+	KEY_STATUS_ACTIVE   = "active"
+	KEY_STATUS_INACTIVE = "inactive"
+)
+
+var ErrIamAdminNotSupported = errors.New("IAM plugin does not support user and key management")
+
+// Optionally implemented by IAM plugins which keep users and keys by
+// themselves, e.g. local_iam, so they could be managed through admin server
+type IamAdminClient interface {
+	CreateUser(userId, displayName string) error
+	// Delete the user and all its access keys
+	DeleteUser(userId string) error
+	// Create an active access key for user, secret key is only returned here
+	CreateKey(userId string) (common.Credential, error)
+	SetKeyStatus(accessKey, status string) error
+	DeleteKey(accessKey string) error
+}
+
+func adminClient() (IamAdminClient, error) {
+	c, ok := iamClient.(IamAdminClient)
+	if !ok {
+		return nil, ErrIamAdminNotSupported
+	}
+	return c, nil
+}
+
+// Drop cached credential so a disabled or deleted key stops working
+// immediately on this instance, other instances wait for cache expiry
+func invalidateKey(accessKey string) {
+	if cache.IamCache != nil {
+		cache.IamCache.Remove(accessKey)
+	}
+}
+
+func CreateUser(userId, displayName string) error {
+	c, err := adminClient()
+	if err != nil {
+		return err
+	}
+	return c.CreateUser(userId, displayName)
+}
+
+func DeleteUser(userId string) error {
+	c, err := adminClient()
+	if err != nil {
+		return err
+	}
+	credentials, err := c.(IamClient).GetKeysByUid(userId)
+	if err != nil {
+		return err
+	}
+	err = c.DeleteUser(userId)
+	if err != nil {
+		return err
+	}
+	for _, credential := range credentials {
+		invalidateKey(credential.AccessKeyID)
+	}
+	return nil
+}
+
+func CreateKey(userId string) (credential common.Credential, err error) {
+	c, err := adminClient()
+	if err != nil {
+		return
+	}
+	return c.CreateKey(userId)
+}
+
+func SetKeyStatus(accessKey, status string) error {
+	if status != KEY_STATUS_ACTIVE && status != KEY_STATUS_INACTIVE {
+		return errors.New("invalid key status: " + status)
+	}
+	c, err := adminClient()
+	if err != nil {
+		return err
+	}
+	err = c.SetKeyStatus(accessKey, status)
+	if err != nil {
+		return err
+	}
+	invalidateKey(accessKey)
+	return nil
+}
+
+// Create a new key for owner of accessKey and disable the old one,
+// the old key could be deleted once clients switch to the new one
+func RotateKey(accessKey string) (credential common.Credential, err error) {
+	c, err := adminClient()
+	if err != nil {
+		return
+	}
+	old, err := c.(IamClient).GetCredential(accessKey)
+	if err != nil {
+		return
+	}
+	credential, err = c.CreateKey(old.UserId)
+	if err != nil {
+		return
+	}
+	err = SetKeyStatus(accessKey, KEY_STATUS_INACTIVE)
+	return
+}
+
+func DeleteKey(accessKey string) error {
+	c, err := adminClient()
+	if err != nil {
+		return err
+	}
+	err = c.DeleteKey(accessKey)
+	if err != nil {
+		return err
+	}
+	invalidateKey(accessKey)
+	return nil
+}
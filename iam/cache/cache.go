@@ -3,6 +3,8 @@ package cache
 import (
 	"sync"
 	"time"
+
+	"github.com/journeymidnight/yig/api/datatype/policy"
 	"github.com/journeymidnight/yig/iam/common"
 )
 
@@ -14,32 +16,39 @@ const (
 type cacheEntry struct {
 	createTime time.Time
 	credential common.Credential
+	policies   []policy.Policy
 }
 
-// maps access key to Credential object
+// maps access key to Credential object, and user id to identity policies
 type cache struct {
-	cache map[string]cacheEntry
-	lock  *sync.RWMutex
+	cache    map[string]cacheEntry
+	policies map[string]cacheEntry
+	lock     *sync.RWMutex
 }
 
 var IamCache *cache
 
+func expire(entries map[string]cacheEntry, now time.Time) {
+	keysToExpire := make([]string, 0)
+	for k, entry := range entries {
+		if entry.createTime.Add(CACHE_EXPIRE_TIME).Before(now) {
+			keysToExpire = append(keysToExpire, k)
+		}
+	}
+	for _, key := range keysToExpire {
+		delete(entries, key)
+	}
+}
+
 func cacheInvalidator() {
 	if IamCache == nil {
 		panic("IAM cache not initialized yet")
 	}
 	for {
-		keysToExpire := make([]string, 0)
 		now := time.Now()
 		IamCache.lock.Lock()
-		for k, entry := range IamCache.cache {
-			if entry.createTime.Add(CACHE_EXPIRE_TIME).Before(now) {
-				keysToExpire = append(keysToExpire, k)
-			}
-		}
-		for _, key := range keysToExpire {
-			delete(IamCache.cache, key)
-		}
+		expire(IamCache.cache, now)
+		expire(IamCache.policies, now)
 		IamCache.lock.Unlock()
 		time.Sleep(CACHE_CHECK_TIME)
 	}
@@ -50,8 +59,9 @@ func InitializeIamCache() {
 		return
 	}
 	IamCache = &cache{
-		cache: make(map[string]cacheEntry),
-		lock:  new(sync.RWMutex),
+		cache:    make(map[string]cacheEntry),
+		policies: make(map[string]cacheEntry),
+		lock:     new(sync.RWMutex),
 	}
 	go cacheInvalidator()
 }
@@ -75,3 +85,29 @@ func (c *cache) Set(key string, credential common.Credential) {
 	c.cache[key] = entry
 	c.lock.Unlock()
 }
+
+func (c *cache) GetPolicies(userId string) (policies []policy.Policy, hit bool) {
+	c.lock.RLock()
+	entry, hit := c.policies[userId]
+	c.lock.RUnlock()
+	if hit {
+		policies = entry.policies
+	}
+	return policies, hit
+}
+
+func (c *cache) SetPolicies(userId string, policies []policy.Policy) {
+	entry := cacheEntry{
+		createTime: time.Now(),
+		policies:   policies,
+	}
+	c.lock.Lock()
+	c.policies[userId] = entry
+	c.lock.Unlock()
+}
+
+func (c *cache) Remove(key string) {
+	c.lock.Lock()
+	delete(c.cache, key)
+	c.lock.Unlock()
+}
@@ -1,6 +1,11 @@
 package common
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/journeymidnight/yig/api/datatype/policy"
+)
+
 // credential container for access and secret keys.
 type Credential struct {
 	UserId               string
@@ -8,6 +13,16 @@ type Credential struct {
 	AccessKeyID          string
 	SecretAccessKey      string
 	AllowOtherUserAccess bool
+	// Set for temporary credentials minted by STS
+	SessionToken string
+	// Inline policy of the session which limits its permissions, could be nil
+	SessionPolicy *policy.Policy
+}
+
+// Storage limits of a user, zero means unlimited
+type Quota struct {
+	MaxBytes   int64 `json:"maxBytes"`
+	MaxObjects int64 `json:"maxObjects"`
 }
 
 func (a Credential) String() string {
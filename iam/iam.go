@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/journeymidnight/yig/api/datatype/policy"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/iam/cache"
 	"github.com/journeymidnight/yig/iam/common"
@@ -19,6 +20,9 @@ var IsValidAccessKey = regexp.MustCompile(`^[a-zA-Z0-9\\-\\.\\_\\~]{5,20}$`)
 type IamClient interface {
 	GetKeysByUid(string) ([]common.Credential, error)
 	GetCredential(string) (common.Credential, error)
+	// Returns JSON documents of identity policies attached to a user,
+	// which share the grammar of bucket policy but have no Principal
+	GetIdentityPolicies(string) ([]string, error)
 }
 
 var iamClient IamClient
@@ -41,7 +45,13 @@ func InitializeIamClient(plugins map[string]*mods.YigPlugin) {
 	panic("Failed to initialize any IAM plugin, quiting...\n")
 }
 
-func GetCredential(accessKey string) (credential common.Credential, err error) {
+// Get credential of access key, temporary credentials minted by STS are
+// validated with their session token without asking IAM backend
+func GetCredential(accessKey, sessionToken string) (credential common.Credential, err error) {
+	if sessionToken != "" {
+		return getSessionCredential(accessKey, sessionToken)
+	}
+
 	if cache.IamCache == nil {
 		cache.InitializeIamCache()
 	}
@@ -60,6 +70,32 @@ func GetCredential(accessKey string) (credential common.Credential, err error) {
 
 }
 
+func GetIdentityPolicies(userId string) (policies []policy.Policy, err error) {
+	if cache.IamCache == nil {
+		cache.InitializeIamCache()
+	}
+
+	policies, hit := cache.IamCache.GetPolicies(userId)
+	if hit {
+		return policies, nil
+	}
+
+	documents, err := iamClient.GetIdentityPolicies(userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range documents {
+		p, err := policy.ParseIdentityPolicy([]byte(document))
+		if err != nil {
+			helper.Logger.Error("Invalid identity policy of user", userId, "error:", err)
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	cache.IamCache.SetPolicies(userId, policies)
+	return policies, nil
+}
+
 func GetKeysByUid(uid string) (credentials []common.Credential, err error) {
 	credentials, err = iamClient.GetKeysByUid(uid)
 	return
@@ -0,0 +1,164 @@
+package iam
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/journeymidnight/yig/api/datatype/policy"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+)
+
+const (
+	SESSION_MIN_DURATION     = 900 * time.Second
+	SESSION_DEFAULT_DURATION = 3600 * time.Second
+	SESSION_MAX_DURATION     = 43200 * time.Second
+
+	SESSION_ACCESS_KEY_PREFIX = "ASIA"
+	sessionAccessKeyLength    = 20
+	sessionSecretKeyLength    = 40
+	sessionKeyCharacters      = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	sessionSecretCharacters   = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+var ErrStsDisabled = errors.New("STS is disabled since sts_secret_key is not configured")
+
+// Attributes of temporary credentials, sealed in session token so they could
+// be validated by any instance without asking IAM backend
+type session struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	UserId          string
+	DisplayName     string
+	Expiration      time.Time
+	Policy          string `json:",omitempty"`
+}
+
+func sessionCipher() (cipher.AEAD, error) {
+	if helper.CONFIG.StsSecretKey == "" {
+		return nil, ErrStsDisabled
+	}
+	key := sha256.Sum256([]byte(helper.CONFIG.StsSecretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomString(n int, characters string) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(characters)))
+	for i := range b {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = characters[r.Int64()]
+	}
+	return string(b), nil
+}
+
+// Mint temporary credentials for the user of parent credential, the session
+// acts as the user and its permissions are further limited by sessionPolicy
+// if it's not empty
+func NewSessionCredential(parent common.Credential, duration time.Duration,
+	sessionPolicy string) (credential common.Credential, expiration time.Time, err error) {
+
+	if duration < SESSION_MIN_DURATION || duration > SESSION_MAX_DURATION {
+		return credential, expiration, ErrInvalidDurationSeconds
+	}
+	if sessionPolicy != "" {
+		if _, err = policy.ParseIdentityPolicy([]byte(sessionPolicy)); err != nil {
+			return credential, expiration, ErrMalformedPolicy
+		}
+	}
+	aead, err := sessionCipher()
+	if err != nil {
+		return
+	}
+
+	s := session{
+		UserId:      parent.UserId,
+		DisplayName: parent.DisplayName,
+		Expiration:  time.Now().UTC().Add(duration).Truncate(time.Second),
+		Policy:      sessionPolicy,
+	}
+	s.AccessKeyID, err = randomString(sessionAccessKeyLength-len(SESSION_ACCESS_KEY_PREFIX),
+		sessionKeyCharacters)
+	if err != nil {
+		return
+	}
+	s.AccessKeyID = SESSION_ACCESS_KEY_PREFIX + s.AccessKeyID
+	s.SecretAccessKey, err = randomString(sessionSecretKeyLength, sessionSecretCharacters)
+	if err != nil {
+		return
+	}
+	plaintext, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+
+	credential = common.Credential{
+		UserId:          s.UserId,
+		DisplayName:     s.DisplayName,
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    base64.RawURLEncoding.EncodeToString(sealed),
+	}
+	return credential, s.Expiration, nil
+}
+
+// Validate session token of temporary credentials and return the credential
+func getSessionCredential(accessKey, sessionToken string) (credential common.Credential, err error) {
+	aead, err := sessionCipher()
+	if err != nil {
+		return credential, ErrInvalidToken
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(sessionToken)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return credential, ErrInvalidToken
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return credential, ErrInvalidToken
+	}
+	var s session
+	if err = json.Unmarshal(plaintext, &s); err != nil {
+		return credential, ErrInvalidToken
+	}
+	if s.AccessKeyID != accessKey {
+		return credential, ErrInvalidToken
+	}
+	if time.Now().After(s.Expiration) {
+		return credential, ErrExpiredToken
+	}
+
+	credential = common.Credential{
+		UserId:          s.UserId,
+		DisplayName:     s.DisplayName,
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    sessionToken,
+	}
+	if s.Policy != "" {
+		credential.SessionPolicy, err = policy.ParseIdentityPolicy([]byte(s.Policy))
+		if err != nil {
+			return credential, ErrInvalidToken
+		}
+	}
+	return credential, nil
+}
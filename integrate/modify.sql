@@ -50,4 +50,41 @@ CREATE TABLE `objects` (
    UNIQUE KEY `rowkey` (`bucketname`,`name`,`version`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 
-INSERT INTO `objects` SELECT * FROM `objects_bak`;
\ No newline at end of file
+INSERT INTO `objects` SELECT * FROM `objects_bak`;
+
+-- name of the backend plugin owning the cluster, NULL for any backend
+
+ALTER TABLE `cluster`
+	ADD COLUMN `backend` varchar(255) DEFAULT NULL;
+
+-- limits overriding default throttle of access keys, by user
+
+CREATE TABLE IF NOT EXISTS `userthrottles` (
+  `userid` varchar(255) NOT NULL,
+  `requests` double DEFAULT 0,
+  `upload` bigint(20) DEFAULT 0,
+  `download` bigint(20) DEFAULT 0,
+   UNIQUE KEY `rowkey` (`userid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+
+-- storage quotas of buckets and users, number of objects is counted along with
+-- usages, run the backfill before starting upgraded yig
+
+ALTER TABLE `buckets`
+	ADD COLUMN `quota` JSON DEFAULT NULL;
+ALTER TABLE `buckets`
+	ADD COLUMN `objects` bigint(20) DEFAULT 0;
+ALTER TABLE `buckets`
+	ADD INDEX `uid` (`uid`);
+
+UPDATE `buckets` SET `objects` = (
+	SELECT COUNT(*) FROM `objects`
+	WHERE `objects`.`bucketname` = `buckets`.`bucketname` AND IFNULL(`objects`.`deletemarker`, 0) = 0
+);
+
+CREATE TABLE IF NOT EXISTS `userquotas` (
+  `userid` varchar(255) NOT NULL,
+  `maxbytes` bigint(20) DEFAULT 0,
+  `maxobjects` bigint(20) DEFAULT 0,
+   UNIQUE KEY `rowkey` (`userid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
@@ -35,7 +35,14 @@ CREATE TABLE `buckets` (
   `createtime` datetime DEFAULT NULL,
   `usages` bigint(20) DEFAULT NULL,
   `versioning` varchar(255) DEFAULT NULL,
-  PRIMARY KEY (`bucketname`)
+  `tags` JSON DEFAULT NULL,
+  `notification` JSON DEFAULT NULL,
+  `replication` JSON DEFAULT NULL,
+  `objectlock` JSON DEFAULT NULL,
+  `quota` JSON DEFAULT NULL,
+  `objects` bigint(20) DEFAULT 0,
+  PRIMARY KEY (`bucketname`),
+  KEY `uid` (`uid`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
@@ -50,10 +57,26 @@ CREATE TABLE `cluster` (
   `fsid` varchar(255) DEFAULT NULL,
   `pool` varchar(255) DEFAULT NULL,
   `weight` int(11) DEFAULT NULL,
+  `backend` varchar(255) DEFAULT NULL,
    UNIQUE KEY `rowkey` (`fsid`,`pool`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
 
+--
+-- Table structure for table `leases`
+--
+
+DROP TABLE IF EXISTS `leases`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `leases` (
+  `name` varchar(255) NOT NULL,
+  `owner` varchar(255) DEFAULT NULL,
+  `expire` bigint(20) DEFAULT NULL,
+   UNIQUE KEY `rowkey` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
 --
 -- Table structure for table `gc`
 --
@@ -113,6 +136,7 @@ CREATE TABLE `multipartpart` (
   `etag` varchar(255) DEFAULT NULL,
   `lastmodified` datetime DEFAULT NULL,
   `initializationvector` blob DEFAULT NULL,
+  `compressedsize` bigint(20) DEFAULT 0,
   `bucketname` varchar(255) DEFAULT NULL,
   `objectname` varchar(255) DEFAULT NULL,
   `uploadtime` bigint(20) UNSIGNED DEFAULT NULL,
@@ -142,6 +166,8 @@ CREATE TABLE `multiparts` (
   `cipher` blob DEFAULT NULL,
   `attrs` JSON DEFAULT NULL,
   `storageclass` tinyint(1) DEFAULT 0,
+  `compressed` tinyint(1) DEFAULT 0,
+  `tags` JSON DEFAULT NULL,
   UNIQUE KEY `rowkey` (`bucketname`,`objectname`,`uploadtime`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
@@ -161,6 +187,7 @@ CREATE TABLE `objectpart` (
   `etag` varchar(255) DEFAULT NULL,
   `lastmodified` datetime DEFAULT NULL,
   `initializationvector` blob DEFAULT NULL,
+  `compressedsize` bigint(20) DEFAULT 0,
   `bucketname` varchar(255) DEFAULT NULL,
   `objectname` varchar(255) DEFAULT NULL,
   `version` varchar(255) DEFAULT NULL,
@@ -197,6 +224,15 @@ CREATE TABLE `objects` (
   `initializationvector` blob DEFAULT NULL,
   `type` tinyint(1) DEFAULT 0,
   `storageclass` tinyint(1) DEFAULT 0,
+  `compressed` tinyint(1) DEFAULT 0,
+  `compressedsize` bigint(20) DEFAULT 0,
+  `tags` JSON DEFAULT NULL,
+  `replicationstatus` varchar(20) DEFAULT NULL,
+  `lockmode` varchar(20) DEFAULT NULL,
+  `retainuntildate` datetime DEFAULT NULL,
+  `legalhold` tinyint(1) DEFAULT 0,
+  `ssekmskeyid` varchar(255) DEFAULT NULL,
+  `ssecontext` text DEFAULT NULL,
    UNIQUE KEY `rowkey` (`bucketname`,`name`,`version`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
@@ -216,6 +252,7 @@ CREATE TABLE `restoreobjectpart` (
   `etag` varchar(255) DEFAULT NULL,
   `lastmodified` datetime DEFAULT NULL,
   `initializationvector` blob DEFAULT NULL,
+  `compressedsize` bigint(20) DEFAULT 0,
   `bucketname` varchar(255) DEFAULT NULL,
   `objectname` varchar(255) DEFAULT NULL,
   `version` bigint(20) unsigned DEFAULT NULL,
@@ -243,6 +280,7 @@ CREATE TABLE `restoreobjects` (
   `size` bigint(20) DEFAULT NULL,
   `objectid` varchar(255) DEFAULT NULL,
   `etag` varchar(255) DEFAULT NULL,
+  `tier` tinyint(1) DEFAULT '0',
   UNIQUE KEY `rowkey` (`bucketname`,`objectname`,`version`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
@@ -274,6 +312,37 @@ CREATE TABLE `users` (
   `bucketname` varchar(255) DEFAULT NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
 /*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `userquotas`
+--
+
+DROP TABLE IF EXISTS `userquotas`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `userquotas` (
+  `userid` varchar(255) NOT NULL,
+  `maxbytes` bigint(20) DEFAULT 0,
+  `maxobjects` bigint(20) DEFAULT 0,
+   UNIQUE KEY `rowkey` (`userid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+/*!40101 SET character_set_client = @saved_cs_client */;
+
+--
+-- Table structure for table `userthrottles`
+--
+
+DROP TABLE IF EXISTS `userthrottles`;
+/*!40101 SET @saved_cs_client     = @@character_set_client */;
+/*!40101 SET character_set_client = utf8 */;
+CREATE TABLE `userthrottles` (
+  `userid` varchar(255) NOT NULL,
+  `requests` double DEFAULT 0,
+  `upload` bigint(20) DEFAULT 0,
+  `download` bigint(20) DEFAULT 0,
+   UNIQUE KEY `rowkey` (`userid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+/*!40101 SET character_set_client = @saved_cs_client */;
 /*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
 
 /*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
@@ -291,4 +360,14 @@ DROP TABLE IF EXISTS `lifecycle`;
 CREATE TABLE `lifecycle` (
                        `bucketname` varchar(255) DEFAULT NULL,
                        `status` varchar(255) DEFAULT NULL
-) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
+DROP TABLE IF EXISTS `replication`;
+CREATE TABLE `replication` (
+                       `bucketname` varchar(255) DEFAULT NULL,
+                       `objectname` varchar(255) DEFAULT NULL,
+                       `version` bigint(20) UNSIGNED DEFAULT NULL,
+                       `triedtimes` int(11) DEFAULT 0,
+                       `nexttime` datetime DEFAULT NULL,
+                       UNIQUE KEY `rowkey` (`bucketname`,`objectname`,`version`),
+                       KEY `nexttime` (`nexttime`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_bin;
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/journeymidnight/yig/api"
 	"github.com/journeymidnight/yig/compression"
 	"github.com/journeymidnight/yig/crypto"
 	"math/rand"
@@ -47,7 +48,7 @@ func main() {
 
 	kms := crypto.NewKMS(allPluginMap)
 
-	yig := storage.New(helper.CONFIG.MetaCacheType, helper.CONFIG.EnableDataCache, kms)
+	yig := storage.New(helper.CONFIG.MetaCacheType, helper.CONFIG.EnableDataCache, kms, allPluginMap)
 	adminServerConfig := &adminServerConfig{
 		Address: helper.CONFIG.BindAdminAddress,
 		Logger:  helper.Logger,
@@ -96,6 +97,9 @@ func main() {
 
 	startAdminServer(adminServerConfig)
 
+	// deliver bucket access logs to target buckets
+	api.StartLogDelivery(yig)
+
 	apiServerConfig := &ServerConfig{
 		Address:      helper.CONFIG.BindApiAddress,
 		KeyFilePath:  helper.CONFIG.SSLKeyPath,
@@ -123,6 +127,7 @@ func main() {
 			// stop YIG server, order matters
 			stopAdminServer()
 			stopApiServer()
+			api.StopLogDelivery()
 			yig.Stop()
 			return
 		}
@@ -39,7 +39,7 @@ func (m *Meta) GetBuckets() (buckets []Bucket, err error) {
 }
 
 func (m *Meta) UpdateUsage(bucketName string, size int64) {
-	m.Client.UpdateUsage(bucketName, size, nil)
+	m.Client.UpdateUsage(bucketName, size, 0, nil)
 }
 
 func (m *Meta) GetUsage(bucketName string) (int64, error) {
@@ -1,29 +1,40 @@
 package client
 
 import (
-	"database/sql"
+	"time"
+
 	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
 	. "github.com/journeymidnight/yig/meta/types"
 )
 
 //DB Client Interface
 type Client interface {
 	//Transaction
-	NewTrans() (tx *sql.Tx, err error)
-	AbortTrans(tx *sql.Tx) error
-	CommitTrans(tx *sql.Tx) error
+	NewTrans() (tx Tx, err error)
+	AbortTrans(tx Tx) error
+	CommitTrans(tx Tx) error
 	//object
 	GetObject(bucketName, objectName, version string) (object *Object, err error)
 	GetAllObject(bucketName, objectName, version string) (object []*Object, err error)
-	PutObject(object *Object, tx DB) error
-	UpdateAppendObject(object *Object, tx DB) error
-	RenameObjectPart(object *Object, sourceObject string, tx DB) (err error)
-	RenameObject(object *Object, sourceObject string, tx DB) (err error)
-	ReplaceObjectMetas(object *Object, tx DB) (err error)
-	DeleteObject(object *Object, tx DB) error
-	UpdateObject(object *Object, tx DB) (err error)
+	ScanObjectVersions(bucketName, keyMarker string, versionMarker uint64, limit int) (objects []*Object, nextKeyMarker string, nextVersionMarker uint64, truncated bool, err error)
+	PutObject(object *Object, tx Tx) error
+	UpdateAppendObject(object *Object, tx Tx) error
+	RenameObjectPart(object *Object, sourceObject string, tx Tx) (err error)
+	RenameObject(object *Object, sourceObject string, tx Tx) (err error)
+	ReplaceObjectMetas(object *Object, tx Tx) (err error)
+	DeleteObject(object *Object, tx Tx) error
+	UpdateObject(object *Object, tx Tx) (err error)
+	// Fails tx with ErrNoSuchKey if the object version is deleted or its data
+	// is no longer the same as object, the version is locked until tx ends
+	CheckObjectData(object *Object, tx Tx) (err error)
 	UpdateObjectAcl(object *Object) error
 	UpdateObjectAttrs(object *Object) error
+	UpdateObjectTags(object *Object) error
+	UpdateObjectReplicationStatus(object *Object) error
+	UpdateObjectLock(object *Object) error
+	UpdateObjectEncryptionKey(object *Object) error
 	//bucket
 	GetBucket(bucketName string) (bucket *Bucket, err error)
 	GetBuckets() (buckets []Bucket, err error)
@@ -31,18 +42,20 @@ type Client interface {
 	CheckAndPutBucket(bucket Bucket) (bool, error)
 	DeleteBucket(bucket Bucket) error
 	ListObjects(bucketName, marker, verIdMarker, prefix, delimiter string, versioned bool, maxKeys int) (retObjects []*Object, prefixes []string, truncated bool, nextMarker, nextVerIdMarker string, err error)
-	UpdateUsage(bucketName string, size int64, tx DB) error
+	// Add size bytes and number of objects to usage of bucket
+	UpdateUsage(bucketName string, size, objects int64, tx Tx) error
 
 	//multipart
 	GetMultipart(bucketName, objectName, uploadId string) (multipart Multipart, err error)
 	CreateMultipart(multipart Multipart) (err error)
-	PutObjectPart(multipart *Multipart, part *Part, tx DB) (err error)
-	DeleteMultipart(multipart *Multipart, tx DB) (err error)
+	PutObjectPart(multipart *Multipart, part *Part, tx Tx) (err error)
+	DeleteMultipart(multipart *Multipart, tx Tx) (err error)
+	UpdateMultipartCipherKey(multipart *Multipart) (err error)
 	ListMultipartUploads(bucketName, keyMarker, uploadIdMarker, prefix, delimiter, encodingType string, maxUploads int) (uploads []datatype.Upload, prefixs []string, isTruncated bool, nextKeyMarker, nextUploadIdMarker string, err error)
 	//objmap
 	GetObjectMap(bucketName, objectName string) (objMap *ObjMap, err error)
-	PutObjectMap(objMap *ObjMap, tx DB) error
-	DeleteObjectMap(objMap *ObjMap, tx DB) error
+	PutObjectMap(objMap *ObjMap, tx Tx) error
+	DeleteObjectMap(objMap *ObjMap, tx Tx) error
 	//cluster
 	GetClusters() (cluster []Cluster, err error)
 	//lc
@@ -53,15 +66,34 @@ type Client interface {
 	GetUserBuckets(userId string) (buckets []string, err error)
 	AddBucketForUser(bucketName, userId string) (err error)
 	RemoveBucketForUser(bucketName string, userId string) (err error)
+	// Total usage and number of objects of buckets owned by user
+	GetUserUsage(userId string) (usage, objects int64, err error)
+	GetUserQuota(userId string) (quota common.Quota, err error)
+	PutUserQuota(userId string, quota common.Quota) error
+	//throttle
+	GetUserThrottles() (limits map[string]helper.ThrottleLimit, err error)
+	PutUserThrottle(userId string, limit helper.ThrottleLimit) error
+	DeleteUserThrottle(userId string) error
 	//gc
-	PutObjectToGarbageCollection(object *Object, tx DB) error
-	PutFreezerToGarbageCollection(object *Freezer, tx DB) (err error)
+	PutObjectToGarbageCollection(object *Object, tx Tx) error
+	PutFreezerToGarbageCollection(object *Freezer, tx Tx) (err error)
 	ScanGarbageCollection(limit int, startRowKey string) ([]GarbageCollection, error)
 	RemoveGarbageCollection(garbage GarbageCollection) error
+	//replication
+	PutObjectToReplication(object *Object, tx Tx) error
+	ScanReplication(limit int, now time.Time) ([]ReplicationTask, error)
+	UpdateReplicationTask(task ReplicationTask) error
+	RemoveReplicationTask(task ReplicationTask) error
 	//freezer
 	CreateFreezer(freezer *Freezer) (err error)
 	GetFreezer(bucketName, objectName, version string) (freezer *Freezer, err error)
 	GetFreezerStatus(bucketName, objectName, version string) (freezer *Freezer, err error)
-	UploadFreezerDate(bucketName, objectName string, lifetime int) (err error)
-	DeleteFreezer(bucketName, objectName string, tx DB) (err error)
+	UploadFreezerDate(bucketName, objectName, version string, lifetime int) (err error)
+	DeleteFreezer(bucketName, objectName, version string, tx Tx) (err error)
+	ScanFreezers(status Status, bucketMarker, objectMarker, versionMarker string, limit int) (freezers []Freezer, err error)
+	UpdateFreezer(freezer *Freezer, status Status) (err error)
+	//lease
+	AcquireLease(name, owner string, expire time.Time) (acquired bool, err error)
+	ReleaseLease(name, owner string) error
+	ListLeases(prefix string) (leases []Lease, err error)
 }
@@ -0,0 +1,177 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"strings"
+
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (c *EmbeddedClient) GetBucket(bucketName string) (bucket *Bucket, err error) {
+	err = c.store.read(func() error {
+		value, ok := c.store.get(bucketsTable, bucketName)
+		if !ok {
+			return ErrNoSuchBucket
+		}
+		bucket = new(Bucket)
+		return json.Unmarshal(value, bucket)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (c *EmbeddedClient) GetBuckets() (buckets []Bucket, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scan(bucketsTable, "", func(k string, v []byte) bool {
+			var bucket Bucket
+			err = json.Unmarshal(v, &bucket)
+			if err != nil {
+				return false
+			}
+			buckets = append(buckets, bucket)
+			return true
+		})
+		return
+	})
+	return
+}
+
+//Actually this method is used to update bucket
+func (c *EmbeddedClient) PutBucket(bucket Bucket) error {
+	return c.write(nil, func(w *writer) error {
+		value, ok := w.get(bucketsTable, bucket.Name)
+		if !ok {
+			return nil
+		}
+		var old Bucket
+		err := json.Unmarshal(value, &old)
+		if err != nil {
+			return err
+		}
+		// same as tidb, create time and usage are not updated
+		bucket.CreateTime = old.CreateTime
+		bucket.Usage = old.Usage
+		bucket.ObjectCount = old.ObjectCount
+		return w.put(bucketsTable, bucket.Name, bucket)
+	})
+}
+
+func (c *EmbeddedClient) CheckAndPutBucket(bucket Bucket) (processed bool, err error) {
+	err = c.write(nil, func(w *writer) error {
+		if _, ok := w.get(bucketsTable, bucket.Name); ok {
+			return nil
+		}
+		processed = true
+		return w.put(bucketsTable, bucket.Name, bucket)
+	})
+	if err != nil {
+		return false, err
+	}
+	return
+}
+
+// Same as tidb, list latest versions of objects only, version listing is not supported
+func (c *EmbeddedClient) ListObjects(bucketName, marker, verIdMarker, prefix, delimiter string, versioned bool, maxKeys int) (retObjects []*Object, prefixes []string, truncated bool, nextMarker, nextVerIdMarker string, err error) {
+	if versioned {
+		return
+	}
+	var count int
+	commonPrefixes := make(map[string]struct{})
+	start := helper.Ternary(marker > prefix, marker, prefix).(string)
+	bucketPrefix := bucketName + keySeparator
+	err = c.store.read(func() (err error) {
+		var lastName string
+		c.store.scan(objectsTable, bucketPrefix+start, func(k string, v []byte) bool {
+			if !strings.HasPrefix(k, bucketPrefix) {
+				return false
+			}
+			name := objectNameOfKey(bucketName, k)
+			if !strings.HasPrefix(name, prefix) {
+				return false
+			}
+			// only the latest version of each object is listed
+			if name == lastName {
+				return true
+			}
+			lastName = name
+			if name == marker {
+				return true
+			}
+			var o *Object
+			o, err = decodeObject(v)
+			if err != nil {
+				return false
+			}
+			if o.DeleteMarker {
+				return true
+			}
+			if len(delimiter) != 0 {
+				subStr := strings.TrimPrefix(name, prefix)
+				n := strings.Index(subStr, delimiter)
+				if n != -1 {
+					prefixKey := prefix + subStr[0:(n+1)]
+					if prefixKey == marker {
+						return true
+					}
+					if _, ok := commonPrefixes[prefixKey]; !ok {
+						if count == maxKeys {
+							truncated = true
+							return false
+						}
+						commonPrefixes[prefixKey] = struct{}{}
+						nextMarker = prefixKey
+						count += 1
+					}
+					return true
+				}
+			}
+			count += 1
+			if count == maxKeys {
+				nextMarker = name
+			}
+			if count > maxKeys {
+				truncated = true
+				return false
+			}
+			retObjects = append(retObjects, o)
+			return true
+		})
+		return
+	})
+	if err != nil {
+		return nil, nil, false, "", "", err
+	}
+	prefixes = helper.Keys(commonPrefixes)
+	return
+}
+
+func (c *EmbeddedClient) DeleteBucket(bucket Bucket) error {
+	return c.write(nil, func(w *writer) error {
+		return w.remove(bucketsTable, bucket.Name)
+	})
+}
+
+func (c *EmbeddedClient) UpdateUsage(bucketName string, size, objects int64, tx Tx) (err error) {
+	if !helper.CONFIG.PiggybackUpdateUsage {
+		return nil
+	}
+
+	return c.write(tx, func(w *writer) error {
+		value, ok := w.get(bucketsTable, bucketName)
+		if !ok {
+			return nil
+		}
+		var bucket Bucket
+		err := json.Unmarshal(value, &bucket)
+		if err != nil {
+			return err
+		}
+		bucket.Usage += size
+		bucket.ObjectCount += objects
+		return w.put(bucketsTable, bucketName, bucket)
+	})
+}
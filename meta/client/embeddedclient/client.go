@@ -0,0 +1,59 @@
+package embeddedclient
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/journeymidnight/yig/helper"
+)
+
+// Tables of the embedded store, named after tables of tidb
+const (
+	bucketsTable     = "buckets"
+	objectsTable     = "objects"
+	multipartsTable  = "multiparts"
+	objMapTable      = "objmap"
+	lifeCycleTable   = "lifecycle"
+	usersTable       = "users"
+	throttlesTable   = "userthrottles"
+	quotasTable      = "userquotas"
+	gcTable          = "gc"
+	replicationTable = "replication"
+	freezerTable     = "restoreobjects"
+	leasesTable      = "leases"
+)
+
+const (
+	keySeparator = "\x00"
+	// versions are zero padded in keys, so they are ordered as numbers
+	versionKeyLength = 20
+)
+
+// Metadata store embedded in yig process, for single node deployments and tests
+// without a tidb cluster
+type EmbeddedClient struct {
+	store *store
+}
+
+func NewEmbeddedClient() *EmbeddedClient {
+	s, err := openStore(helper.CONFIG.MetaStorePath)
+	if err != nil {
+		panic("open embedded metastore " + helper.CONFIG.MetaStorePath + " error: " + err.Error())
+	}
+	return &EmbeddedClient{store: s}
+}
+
+func key(fields ...string) string {
+	return strings.Join(fields, keySeparator)
+}
+
+func versionKey(version uint64) string {
+	return fmt.Sprintf("%0*d", versionKeyLength, version)
+}
+
+// Same as `version` column of tidb, newer versions are smaller
+func timeVersion(t time.Time) uint64 {
+	return math.MaxUint64 - uint64(t.UnixNano())
+}
@@ -0,0 +1,439 @@
+package embeddedclient_test
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	"github.com/journeymidnight/yig/meta/client/embeddedclient"
+	. "github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(t *testing.T) (client *embeddedclient.EmbeddedClient, dir string) {
+	dir, err := ioutil.TempDir("", "yig-meta")
+	if err != nil {
+		t.Fatal("Error creating temp dir:", err)
+	}
+	helper.CONFIG.MetaStorePath = filepath.Join(dir, "meta.db")
+	helper.CONFIG.PiggybackUpdateUsage = true
+	return embeddedclient.NewEmbeddedClient(), dir
+}
+
+func newObject(bucketName, objectName string, t time.Time) *Object {
+	return &Object{
+		BucketName:       bucketName,
+		Name:             objectName,
+		LastModifiedTime: t,
+		Size:             10,
+		ObjectId:         objectName + t.String(),
+		CustomAttributes: map[string]string{},
+	}
+}
+
+func TestEmbeddedClient_Bucket(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	_, err := client.GetBucket("hehe")
+	assert.Equal(t, ErrNoSuchBucket, err)
+
+	bucket := Bucket{Name: "hehe", OwnerId: "haha", CreateTime: time.Now().UTC(),
+		Versioning: VersionDisabled}
+	processed, err := client.CheckAndPutBucket(bucket)
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	processed, err = client.CheckAndPutBucket(bucket)
+	assert.Nil(t, err)
+	assert.False(t, processed)
+
+	err = client.UpdateUsage("hehe", 100, 1, nil)
+	assert.Nil(t, err)
+	bucket.Versioning = VersionEnabled
+	err = client.PutBucket(bucket)
+	assert.Nil(t, err)
+
+	// reopen the store and read it back
+	client = embeddedclient.NewEmbeddedClient()
+	b, err := client.GetBucket("hehe")
+	assert.Nil(t, err)
+	assert.Equal(t, "haha", b.OwnerId)
+	assert.Equal(t, VersionEnabled, b.Versioning)
+	assert.Equal(t, int64(100), b.Usage)
+	assert.Equal(t, int64(1), b.ObjectCount)
+
+	err = client.DeleteBucket(bucket)
+	assert.Nil(t, err)
+	buckets, err := client.GetBuckets()
+	assert.Nil(t, err)
+	assert.Empty(t, buckets)
+}
+
+func TestEmbeddedClient_Transaction(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	object := newObject("hehe", "obj", now)
+	tx, err := client.NewTrans()
+	assert.Nil(t, err)
+	err = client.PutObject(object, tx)
+	assert.Nil(t, err)
+	err = client.AbortTrans(tx)
+	assert.Nil(t, err)
+	_, err = client.GetObject("hehe", "obj", "")
+	assert.Equal(t, ErrNoSuchKey, err)
+
+	tx, err = client.NewTrans()
+	assert.Nil(t, err)
+	err = client.PutObject(object, tx)
+	assert.Nil(t, err)
+	err = client.PutObjectToGarbageCollection(object, tx)
+	assert.Nil(t, err)
+	// others wait for the transaction
+	read := make(chan error)
+	go func() {
+		_, err := client.GetObject("hehe", "obj", "")
+		read <- err
+	}()
+	select {
+	case <-read:
+		t.Fatal("Read during transaction")
+	case <-time.After(100 * time.Millisecond):
+	}
+	err = client.CommitTrans(tx)
+	assert.Nil(t, err)
+	assert.Nil(t, <-read)
+	o, err := client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+	assert.Equal(t, object.ObjectId, o.ObjectId)
+	gcs, err := client.ScanGarbageCollection(10, "")
+	assert.Nil(t, err)
+	assert.Len(t, gcs, 1)
+
+	// a failed transaction leaves nothing
+	tx, err = client.NewTrans()
+	assert.Nil(t, err)
+	err = client.DeleteObject(object, tx)
+	assert.Nil(t, err)
+	err = client.PutObject(object, tx)
+	assert.Nil(t, err)
+	err = client.PutObject(object, tx)
+	assert.NotNil(t, err)
+	err = client.AbortTrans(tx)
+	assert.Nil(t, err)
+	_, err = client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+}
+
+func TestEmbeddedClient_CheckObjectData(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	object := newObject("hehe", "obj", time.Now())
+	err := client.PutObject(object, nil)
+	assert.Nil(t, err)
+	moved := *object
+	moved.Pool = "turtle"
+
+	// object is overwritten while its data is copied
+	stale := *object
+	stale.ObjectId = "former"
+	tx, err := client.NewTrans()
+	assert.Nil(t, err)
+	err = client.CheckObjectData(&stale, tx)
+	assert.Equal(t, ErrNoSuchKey, err)
+	err = client.AbortTrans(tx)
+	assert.Nil(t, err)
+	o, err := client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "", o.Pool)
+
+	tx, err = client.NewTrans()
+	assert.Nil(t, err)
+	err = client.CheckObjectData(object, tx)
+	assert.Nil(t, err)
+	err = client.UpdateObject(&moved, tx)
+	assert.Nil(t, err)
+	err = client.CommitTrans(tx)
+	assert.Nil(t, err)
+	o, err = client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "turtle", o.Pool)
+}
+
+func TestEmbeddedClient_TransactionReadsOwnWrites(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	object := newObject("hehe", "obj", time.Now())
+	tx, err := client.NewTrans()
+	assert.Nil(t, err)
+	assert.Nil(t, client.PutObject(object, tx))
+	// statements of a transaction see former writes of it
+	assert.Nil(t, client.CheckObjectData(object, tx))
+	assert.Nil(t, tx.Commit())
+	_, err = client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+
+	tx, err = client.NewTrans()
+	assert.Nil(t, err)
+	assert.Nil(t, client.DeleteObject(object, tx))
+	assert.Equal(t, ErrNoSuchKey, client.CheckObjectData(object, tx))
+	assert.Nil(t, tx.Rollback())
+	// the transaction is ended by rollback
+	assert.NotNil(t, tx.Commit())
+	assert.Nil(t, tx.Rollback())
+	_, err = client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+}
+
+func TestEmbeddedClient_ReplaceObjectMetas(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	former := newObject("hehe", "obj", now.Add(-time.Hour))
+	latest := newObject("hehe", "obj", now)
+	assert.Nil(t, client.PutObject(former, nil))
+	assert.Nil(t, client.PutObject(latest, nil))
+
+	replaced := *former
+	replaced.StorageClass = ObjectStorageClassStandardIa
+	replaced.ContentType = "text/plain"
+	err := client.ReplaceObjectMetas(&replaced, nil)
+	assert.Nil(t, err)
+	version := math.MaxUint64 - uint64(former.LastModifiedTime.UnixNano())
+	o, err := client.GetObject("hehe", "obj", strconv.FormatUint(version, 10))
+	assert.Nil(t, err)
+	assert.Equal(t, ObjectStorageClassStandardIa, o.StorageClass)
+	assert.Equal(t, "text/plain", o.ContentType)
+	o, err = client.GetObject("hehe", "obj", "")
+	assert.Nil(t, err)
+	assert.Equal(t, ObjectStorageClassStandard, o.StorageClass)
+	assert.Equal(t, "", o.ContentType)
+}
+
+func TestEmbeddedClient_ObjectVersions(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	for i, name := range []string{"a", "b/1", "b/2", "c"} {
+		for j := 0; j < 2; j++ {
+			err := client.PutObject(newObject("hehe", name,
+				now.Add(time.Duration(i*10+j)*time.Second)), nil)
+			assert.Nil(t, err)
+		}
+	}
+	err := client.PutObject(newObject("hehe2", "a", now), nil)
+	assert.Nil(t, err)
+
+	// latest version comes first
+	o, err := client.GetObject("hehe", "a", "")
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(time.Second).UnixNano(), o.LastModifiedTime.UnixNano())
+	objects, err := client.GetAllObject("hehe", "a", "")
+	assert.Nil(t, err)
+	assert.Len(t, objects, 2)
+
+	objects, nextKey, nextVersion, truncated, err := client.ScanObjectVersions("hehe", "", 0, 5)
+	assert.Nil(t, err)
+	assert.Len(t, objects, 5)
+	assert.True(t, truncated)
+	assert.Equal(t, "b/2", nextKey)
+	objects, _, _, truncated, err = client.ScanObjectVersions("hehe", nextKey, nextVersion, 5)
+	assert.Nil(t, err)
+	assert.Len(t, objects, 3)
+	assert.False(t, truncated)
+
+	objects, prefixes, truncated, nextMarker, _, err := client.ListObjects("hehe", "", "", "", "/",
+		false, 2)
+	assert.Nil(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, []string{"b/"}, prefixes)
+	assert.True(t, truncated)
+	assert.Equal(t, "b/", nextMarker)
+	objects, prefixes, truncated, _, _, err = client.ListObjects("hehe", nextMarker, "", "", "/",
+		false, 2)
+	assert.Nil(t, err)
+	assert.Len(t, objects, 1)
+	assert.Equal(t, "c", objects[0].Name)
+	assert.Empty(t, prefixes)
+	assert.False(t, truncated)
+
+	// rename moves the version
+	o.Name = "d"
+	err = client.RenameObject(o, "a", nil)
+	assert.Nil(t, err)
+	o, err = client.GetObject("hehe", "d", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "d", o.Name)
+	objects, err = client.GetAllObject("hehe", "a", "")
+	assert.Nil(t, err)
+	assert.Len(t, objects, 1)
+}
+
+func TestEmbeddedClient_SharedStore(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+	// another process on the same host
+	other := embeddedclient.NewEmbeddedClient()
+
+	err := client.AddBucketForUser("hehe", "haha")
+	assert.Nil(t, err)
+	buckets, err := other.GetUserBuckets("haha")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"hehe"}, buckets)
+
+	err = other.RemoveBucketForUser("hehe", "haha")
+	assert.Nil(t, err)
+	buckets, err = client.GetUserBuckets("haha")
+	assert.Nil(t, err)
+	assert.Empty(t, buckets)
+}
+
+func TestEmbeddedClient_Lease(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+	other := embeddedclient.NewEmbeddedClient()
+
+	expire := time.Now().Add(time.Hour)
+	acquired, err := client.AcquireLease("gc/partition/0", "a", expire)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	acquired, err = other.AcquireLease("gc/partition/0", "b", expire)
+	assert.Nil(t, err)
+	assert.False(t, acquired)
+	// renew
+	acquired, err = client.AcquireLease("gc/partition/0", "a", expire.Add(time.Hour))
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	// expired leases could be taken
+	acquired, err = other.AcquireLease("gc/partition/1", "b", time.Now().Add(-time.Second))
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	acquired, err = client.AcquireLease("gc/partition/1", "a", expire)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+
+	leases, err := other.ListLeases("gc/")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(leases))
+	err = other.ReleaseLease("gc/partition/0", "b")
+	assert.Nil(t, err)
+	err = client.ReleaseLease("gc/partition/1", "a")
+	assert.Nil(t, err)
+	leases, err = other.ListLeases("gc/")
+	assert.Nil(t, err)
+	if assert.Equal(t, 1, len(leases)) {
+		assert.Equal(t, "gc/partition/0", leases[0].Name)
+		assert.Equal(t, "a", leases[0].Owner)
+	}
+}
+
+func TestEmbeddedClient_UserThrottle(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	limit := helper.ThrottleLimit{RequestsPerSecond: 0.5, UploadBytesPerSecond: 1 << 20}
+	assert.Nil(t, client.PutUserThrottle("hehe", limit))
+	assert.Nil(t, client.PutUserThrottle("haha", helper.ThrottleLimit{}))
+	limit.DownloadBytesPerSecond = 1 << 20
+	assert.Nil(t, client.PutUserThrottle("hehe", limit))
+	limits, err := client.GetUserThrottles()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]helper.ThrottleLimit{"hehe": limit, "haha": {}}, limits)
+
+	assert.Nil(t, client.DeleteUserThrottle("haha"))
+	limits, err = client.GetUserThrottles()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]helper.ThrottleLimit{"hehe": limit}, limits)
+}
+
+func TestEmbeddedClient_UserQuota(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	quota, err := client.GetUserQuota("haha")
+	assert.Nil(t, err)
+	assert.Equal(t, common.Quota{}, quota)
+	assert.Nil(t, client.PutUserQuota("haha", common.Quota{MaxBytes: 1000}))
+	quota, err = client.GetUserQuota("haha")
+	assert.Nil(t, err)
+	assert.Equal(t, common.Quota{MaxBytes: 1000}, quota)
+
+	for _, name := range []string{"hehe", "hoho"} {
+		_, err = client.CheckAndPutBucket(Bucket{Name: name, OwnerId: "haha"})
+		assert.Nil(t, err)
+		assert.Nil(t, client.AddBucketForUser(name, "haha"))
+		assert.Nil(t, client.UpdateUsage(name, 100, 2, nil))
+	}
+	usage, objects, err := client.GetUserUsage("haha")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(200), usage)
+	assert.Equal(t, int64(4), objects)
+	usage, objects, err = client.GetUserUsage("hihi")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), usage+objects)
+}
+
+func TestEmbeddedClient_FreezerVersions(t *testing.T) {
+	client, dir := newClient(t)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	v1 := newObject("hehe", "obj", now.Add(-time.Hour))
+	v2 := newObject("hehe", "obj", now)
+	for _, o := range []*Object{v1, v2} {
+		err := client.CreateFreezer(&Freezer{BucketName: o.BucketName, Name: o.Name,
+			VersionId: FreezerVersion(o), Status: ObjectNeedRestore, LifeTime: 1,
+			LastModifiedTime: now})
+		assert.Nil(t, err)
+	}
+
+	restored := &Freezer{BucketName: "hehe", Name: "obj", VersionId: FreezerVersion(v1),
+		Status: ObjectNeedRestore, LastModifiedTime: now, ObjectId: "restored-v1"}
+	err := client.UpdateFreezer(restored, ObjectHasRestored)
+	assert.Nil(t, err)
+
+	// status is changed by others
+	err = client.UpdateFreezer(restored, ObjectHasRestored)
+	assert.Equal(t, ErrNoSuchKey, err)
+
+	f, err := client.GetFreezer("hehe", "obj", FreezerVersion(v1))
+	assert.Nil(t, err)
+	assert.Equal(t, ObjectHasRestored, f.Status)
+	assert.Equal(t, "restored-v1", f.ObjectId)
+	f, err = client.GetFreezer("hehe", "obj", FreezerVersion(v2))
+	assert.Nil(t, err)
+	assert.Equal(t, ObjectNeedRestore, f.Status)
+	assert.Equal(t, "", f.ObjectId)
+
+	freezers, err := client.ScanFreezers(ObjectHasRestored, "", "", "", 10)
+	assert.Nil(t, err)
+	assert.Len(t, freezers, 1)
+	assert.Equal(t, FreezerVersion(v1), freezers[0].VersionId)
+	// next version of the same object is found after the marker
+	freezers, err = client.ScanFreezers(ObjectNeedRestore, "hehe", "obj", FreezerVersion(v2), 10)
+	assert.Nil(t, err)
+	assert.Empty(t, freezers)
+	freezers, err = client.ScanFreezers(ObjectNeedRestore, "hehe", "obj", "", 10)
+	assert.Nil(t, err)
+	assert.Len(t, freezers, 1)
+	assert.Equal(t, FreezerVersion(v2), freezers[0].VersionId)
+
+	err = client.DeleteFreezer("hehe", "obj", FreezerVersion(v2), nil)
+	assert.Nil(t, err)
+	_, err = client.GetFreezer("hehe", "obj", FreezerVersion(v2))
+	assert.Equal(t, ErrNoSuchKey, err)
+	_, err = client.GetFreezer("hehe", "obj", FreezerVersion(v1))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,159 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"strconv"
+
+	. "github.com/journeymidnight/yig/error"
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+// Same as tidb, restored objects are keyed by bucket name, object name and version,
+// freezers created before versions were recorded have empty version
+func freezerKey(bucketName, objectName, version string) string {
+	v, err := strconv.ParseUint(version, 10, 64)
+	if err != nil {
+		return key(bucketName, objectName)
+	}
+	return key(bucketName, objectName, versionKey(v))
+}
+
+func (c *EmbeddedClient) getFreezer(bucketName, objectName, version string) (freezer *Freezer, err error) {
+	err = c.store.read(func() error {
+		value, ok := c.store.get(freezerTable, freezerKey(bucketName, objectName, version))
+		if !ok {
+			return ErrNoSuchKey
+		}
+		freezer = &Freezer{}
+		return json.Unmarshal(value, freezer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (c *EmbeddedClient) CreateFreezer(freezer *Freezer) (err error) {
+	f := Freezer{
+		Name:             freezer.Name,
+		BucketName:       freezer.BucketName,
+		VersionId:        freezer.VersionId,
+		Status:           freezer.Status,
+		LifeTime:         freezer.LifeTime,
+		LastModifiedTime: freezer.LastModifiedTime,
+		Tier:             freezer.Tier,
+	}
+	return c.write(nil, func(w *writer) error {
+		return w.insert(freezerTable, freezerKey(f.BucketName, f.Name, f.VersionId), f)
+	})
+}
+
+func (c *EmbeddedClient) GetFreezer(bucketName, objectName, version string) (freezer *Freezer, err error) {
+	freezer, err = c.getFreezer(bucketName, objectName, version)
+	if err != nil {
+		return
+	}
+	//build simple index for multipart
+	if len(freezer.Parts) != 0 {
+		var sortedPartNum = make([]int64, len(freezer.Parts))
+		for k, v := range freezer.Parts {
+			sortedPartNum[k-1] = v.Offset
+		}
+		freezer.PartsIndex = &SimpleIndex{Index: sortedPartNum}
+	}
+	return
+}
+
+func (c *EmbeddedClient) GetFreezerStatus(bucketName, objectName, version string) (freezer *Freezer, err error) {
+	f, err := c.getFreezer(bucketName, objectName, version)
+	if err != nil {
+		return
+	}
+	return &Freezer{
+		Name:             f.Name,
+		BucketName:       f.BucketName,
+		VersionId:        f.VersionId,
+		Status:           f.Status,
+		LifeTime:         f.LifeTime,
+		LastModifiedTime: f.LastModifiedTime,
+		Tier:             f.Tier,
+	}, nil
+}
+
+func (c *EmbeddedClient) UploadFreezerDate(bucketName, objectName, version string, lifetime int) (err error) {
+	k := freezerKey(bucketName, objectName, version)
+	return c.write(nil, func(w *writer) error {
+		value, ok := w.get(freezerTable, k)
+		if !ok {
+			return nil
+		}
+		var f Freezer
+		err := json.Unmarshal(value, &f)
+		if err != nil {
+			return err
+		}
+		f.LifeTime = lifetime
+		return w.put(freezerTable, k, f)
+	})
+}
+
+func (c *EmbeddedClient) DeleteFreezer(bucketName, objectName, version string, tx Tx) (err error) {
+	k := freezerKey(bucketName, objectName, version)
+	return c.write(tx, func(w *writer) error {
+		return w.remove(freezerTable, k)
+	})
+}
+
+func (c *EmbeddedClient) ScanFreezers(status Status, bucketMarker, objectMarker, versionMarker string, limit int) (freezers []Freezer, err error) {
+	marker := freezerKey(bucketMarker, objectMarker, versionMarker)
+	err = c.store.read(func() (err error) {
+		c.store.scan(freezerTable, marker, func(k string, v []byte) bool {
+			if k == marker {
+				return true
+			}
+			if len(freezers) == limit {
+				return false
+			}
+			var f Freezer
+			err = json.Unmarshal(v, &f)
+			if err != nil {
+				return false
+			}
+			if f.Status == status {
+				f.Parts = nil
+				freezers = append(freezers, f)
+			}
+			return true
+		})
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) UpdateFreezer(freezer *Freezer, status Status) (err error) {
+	k := freezerKey(freezer.BucketName, freezer.Name, freezer.VersionId)
+	return c.write(nil, func(w *writer) error {
+		value, ok := w.get(freezerTable, k)
+		if !ok {
+			return ErrNoSuchKey
+		}
+		var f Freezer
+		err := json.Unmarshal(value, &f)
+		if err != nil {
+			return err
+		}
+		if f.Status != freezer.Status {
+			return ErrNoSuchKey
+		}
+		f.Status = status
+		f.LastModifiedTime = freezer.LastModifiedTime
+		f.Location = freezer.Location
+		f.Pool = freezer.Pool
+		f.OwnerId = freezer.OwnerId
+		f.Size = freezer.Size
+		f.ObjectId = freezer.ObjectId
+		f.Etag = freezer.Etag
+		f.Parts = freezer.Parts
+		return w.put(freezerTable, k, f)
+	})
+}
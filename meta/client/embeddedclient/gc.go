@@ -0,0 +1,79 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+// Garbage collections are keyed by bucket name, object name and version,
+// Rowkey of them is the same as tidb, i.e. joined by ObjectNameSeparator
+func gcKey(bucketName, objectName string, version uint64) string {
+	return key(bucketName, objectName, versionKey(version))
+}
+
+func (c *EmbeddedClient) putGarbageCollection(gc GarbageCollection, version uint64, tx Tx) error {
+	k := gcKey(gc.BucketName, gc.ObjectName, version)
+	return c.write(tx, func(w *writer) error {
+		// same as "insert ignore"
+		if _, ok := w.get(gcTable, k); ok {
+			return nil
+		}
+		return w.put(gcTable, k, gc)
+	})
+}
+
+//gc
+func (c *EmbeddedClient) PutObjectToGarbageCollection(object *Object, tx Tx) (err error) {
+	return c.putGarbageCollection(GarbageCollectionFromObject(object),
+		timeVersion(object.LastModifiedTime), tx)
+}
+
+func (c *EmbeddedClient) PutFreezerToGarbageCollection(object *Freezer, tx Tx) (err error) {
+	return c.putGarbageCollection(GarbageCollectionFromFreeze(object),
+		timeVersion(object.LastModifiedTime), tx)
+}
+
+func (c *EmbeddedClient) ScanGarbageCollection(limit int, startRowKey string) (gcs []GarbageCollection, err error) {
+	var start string
+	if startRowKey != "" {
+		s := strings.Split(startRowKey, ObjectNameSeparator)
+		var version uint64
+		version, err = strconv.ParseUint(s[2], 10, 64)
+		if err != nil {
+			return
+		}
+		start = gcKey(s[0], s[1], version)
+	}
+	err = c.store.read(func() (err error) {
+		c.store.scan(gcTable, start, func(k string, v []byte) bool {
+			if len(gcs) >= limit {
+				return false
+			}
+			var gc GarbageCollection
+			err = json.Unmarshal(v, &gc)
+			if err != nil {
+				return false
+			}
+			version := strings.TrimLeft(k[len(k)-versionKeyLength:], "0")
+			gc.Rowkey = gc.BucketName + ObjectNameSeparator + gc.ObjectName + ObjectNameSeparator + version
+			gcs = append(gcs, gc)
+			return true
+		})
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) RemoveGarbageCollection(garbage GarbageCollection) (err error) {
+	version, err := strconv.ParseUint(strings.Split(garbage.Rowkey, ObjectNameSeparator)[2], 10, 64)
+	if err != nil {
+		return
+	}
+	k := gcKey(garbage.BucketName, garbage.ObjectName, version)
+	return c.write(nil, func(w *writer) error {
+		return w.remove(gcTable, k)
+	})
+}
@@ -0,0 +1,51 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (c *EmbeddedClient) PutBucketToLifeCycle(lifeCycle LifeCycle) error {
+	return c.write(nil, func(w *writer) error {
+		return w.put(lifeCycleTable, lifeCycle.BucketName, lifeCycle)
+	})
+}
+
+func (c *EmbeddedClient) RemoveBucketFromLifeCycle(bucket Bucket) error {
+	return c.write(nil, func(w *writer) error {
+		return w.remove(lifeCycleTable, bucket.Name)
+	})
+}
+
+func (c *EmbeddedClient) ScanLifeCycle(limit int, marker string) (result ScanLifeCycleResult, err error) {
+	result.Lcs = make([]LifeCycle, 0, limit)
+	err = c.store.read(func() (err error) {
+		c.store.scan(lifeCycleTable, marker, func(k string, v []byte) bool {
+			if k == marker {
+				return true
+			}
+			if len(result.Lcs) == limit {
+				return false
+			}
+			var lc LifeCycle
+			err = json.Unmarshal(v, &lc)
+			if err != nil {
+				return false
+			}
+			result.Lcs = append(result.Lcs, lc)
+			return true
+		})
+		return
+	})
+	if err != nil {
+		return
+	}
+	if len(result.Lcs) > 0 {
+		result.NextMarker = result.Lcs[len(result.Lcs)-1].BucketName
+	}
+	if len(result.Lcs) == limit {
+		result.Truncated = true
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+// Leases are shared by processes on the same host through the store file
+func (c *EmbeddedClient) AcquireLease(name, owner string, expire time.Time) (acquired bool, err error) {
+	err = c.write(nil, func(w *writer) error {
+		if value, ok := w.get(leasesTable, name); ok {
+			var lease Lease
+			err := json.Unmarshal(value, &lease)
+			if err != nil {
+				return err
+			}
+			if !lease.Available(owner, time.Now()) {
+				return nil
+			}
+		}
+		acquired = true
+		return w.put(leasesTable, name, Lease{Name: name, Owner: owner, Expire: expire})
+	})
+	if err != nil {
+		return false, err
+	}
+	return acquired, nil
+}
+
+func (c *EmbeddedClient) ReleaseLease(name, owner string) error {
+	return c.write(nil, func(w *writer) error {
+		value, ok := w.get(leasesTable, name)
+		if !ok {
+			return nil
+		}
+		var lease Lease
+		err := json.Unmarshal(value, &lease)
+		if err != nil {
+			return err
+		}
+		if lease.Owner != owner {
+			return nil
+		}
+		return w.remove(leasesTable, name)
+	})
+}
+
+func (c *EmbeddedClient) ListLeases(prefix string) (leases []Lease, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scanPrefix(leasesTable, prefix, func(k string, v []byte) bool {
+			var lease Lease
+			err = json.Unmarshal(v, &lease)
+			if err != nil {
+				return false
+			}
+			leases = append(leases, lease)
+			return true
+		})
+		return
+	})
+	return
+}
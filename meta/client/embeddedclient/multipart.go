@@ -0,0 +1,203 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam"
+	"github.com/journeymidnight/yig/iam/common"
+	. "github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/meta/util"
+)
+
+// Multiparts are keyed by bucket name, object name and upload time,
+// parts are kept in the multipart entry
+func multipartKey(bucketName, objectName string, initialTime time.Time) string {
+	return key(bucketName, objectName, versionKey(timeVersion(initialTime)))
+}
+
+func decodeMultipart(value []byte) (multipart Multipart, err error) {
+	err = json.Unmarshal(value, &multipart)
+	if err != nil {
+		return
+	}
+	if multipart.Parts == nil {
+		multipart.Parts = make(map[int]*Part)
+	}
+	return
+}
+
+func uploadTimeOfId(uploadId string) (initialTime time.Time, err error) {
+	timestampString, err := util.Decrypt(uploadId)
+	if err != nil {
+		return
+	}
+	timestamp, err := strconv.ParseInt(timestampString, 10, 64)
+	if err != nil {
+		return
+	}
+	return time.Unix(0, timestamp), nil
+}
+
+func (c *EmbeddedClient) GetMultipart(bucketName, objectName, uploadId string) (multipart Multipart, err error) {
+	initialTime, err := uploadTimeOfId(uploadId)
+	if err != nil {
+		return
+	}
+	err = c.store.read(func() (err error) {
+		value, ok := c.store.get(multipartsTable, multipartKey(bucketName, objectName, initialTime))
+		if !ok {
+			return ErrNoSuchUpload
+		}
+		multipart, err = decodeMultipart(value)
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) CreateMultipart(multipart Multipart) (err error) {
+	multipart.UploadId = ""
+	k := multipartKey(multipart.BucketName, multipart.ObjectName, multipart.InitialTime)
+	return c.write(nil, func(w *writer) error {
+		return w.insert(multipartsTable, k, multipart)
+	})
+}
+
+func (c *EmbeddedClient) PutObjectPart(multipart *Multipart, part *Part, tx Tx) (err error) {
+	_, err = time.Parse(CREATE_TIME_LAYOUT, part.LastModified)
+	if err != nil {
+		return
+	}
+	k := multipartKey(multipart.BucketName, multipart.ObjectName, multipart.InitialTime)
+	p := *part
+	return c.write(tx, func(w *writer) error {
+		value, ok := w.get(multipartsTable, k)
+		if !ok {
+			return ErrNoSuchUpload
+		}
+		m, err := decodeMultipart(value)
+		if err != nil {
+			return err
+		}
+		m.Parts[p.PartNumber] = &p
+		return w.put(multipartsTable, k, m)
+	})
+}
+
+func (c *EmbeddedClient) DeleteMultipart(multipart *Multipart, tx Tx) (err error) {
+	k := multipartKey(multipart.BucketName, multipart.ObjectName, multipart.InitialTime)
+	return c.write(tx, func(w *writer) error {
+		return w.remove(multipartsTable, k)
+	})
+}
+
+func (c *EmbeddedClient) UpdateMultipartCipherKey(multipart *Multipart) (err error) {
+	k := multipartKey(multipart.BucketName, multipart.ObjectName, multipart.InitialTime)
+	cipherKey := multipart.Metadata.CipherKey
+	return c.write(nil, func(w *writer) error {
+		value, ok := w.get(multipartsTable, k)
+		if !ok {
+			return ErrNoSuchUpload
+		}
+		m, err := decodeMultipart(value)
+		if err != nil {
+			return err
+		}
+		m.Metadata.CipherKey = cipherKey
+		return w.put(multipartsTable, k, m)
+	})
+}
+
+func (c *EmbeddedClient) ListMultipartUploads(bucketName, keyMarker, uploadIdMarker, prefix, delimiter, encodingType string, maxUploads int) (uploads []datatype.Upload, prefixs []string, isTruncated bool, nextKeyMarker, nextUploadIdMarker string, err error) {
+	// uploads of keyMarker are skipped, unless they are after uploadIdMarker
+	start := key(bucketName, keyMarker, versionKey(math.MaxUint64))
+	if uploadIdMarker != "" {
+		var initialTime time.Time
+		initialTime, err = uploadTimeOfId(uploadIdMarker)
+		if err != nil {
+			return
+		}
+		start = multipartKey(bucketName, keyMarker, initialTime)
+	}
+	if prefix > keyMarker {
+		start = key(bucketName, prefix)
+	}
+	bucketPrefix := bucketName + keySeparator
+	commonPrefixes := make(map[string]struct{})
+	var multiparts []Multipart
+	err = c.store.read(func() (err error) {
+		c.store.scan(multipartsTable, start, func(k string, v []byte) bool {
+			if !strings.HasPrefix(k, bucketPrefix) {
+				return false
+			}
+			if k == start {
+				return true
+			}
+			var m Multipart
+			m, err = decodeMultipart(v)
+			if err != nil {
+				return false
+			}
+			name := m.ObjectName
+			if !strings.HasPrefix(name, prefix) {
+				return false
+			}
+			//filte by delimiter
+			if len(delimiter) != 0 {
+				subStr := strings.TrimPrefix(name, prefix)
+				n := strings.Index(subStr, delimiter)
+				if n != -1 {
+					commonPrefixes[prefix+subStr[0:(n+1)]] = struct{}{}
+					return true
+				}
+			}
+			if len(multiparts) >= maxUploads {
+				isTruncated = true
+				return false
+			}
+			multiparts = append(multiparts, m)
+			return true
+		})
+		return
+	})
+	if err != nil {
+		return
+	}
+	for _, m := range multiparts {
+		upload := datatype.Upload{StorageClass: m.Metadata.StorageClass.ToString()}
+		upload.UploadId, _ = m.GetUploadId()
+		upload.Key = m.ObjectName
+		if encodingType != "" {
+			upload.Key = url.QueryEscape(upload.Key)
+		}
+		var user common.Credential
+		user, err = iam.GetCredentialByUserId(m.Metadata.OwnerId)
+		if err != nil {
+			return
+		}
+		upload.Owner.ID = user.UserId
+		upload.Owner.DisplayName = user.DisplayName
+		user, err = iam.GetCredentialByUserId(m.Metadata.InitiatorId)
+		if err != nil {
+			return
+		}
+		upload.Initiator.ID = user.UserId
+		upload.Initiator.DisplayName = user.DisplayName
+		upload.Initiated = m.InitialTime.UTC().Format(CREATE_TIME_LAYOUT)
+		uploads = append(uploads, upload)
+	}
+	if isTruncated && len(multiparts) > 0 {
+		last := multiparts[len(multiparts)-1]
+		nextKeyMarker = last.ObjectName
+		nextUploadIdMarker, _ = last.GetUploadId()
+	}
+	prefixs = helper.Keys(commonPrefixes)
+	return
+}
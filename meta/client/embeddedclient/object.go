@@ -0,0 +1,321 @@
+package embeddedclient
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	. "github.com/journeymidnight/yig/error"
+	. "github.com/journeymidnight/yig/meta/types"
+	"github.com/xxtea/xxtea-go/xxtea"
+)
+
+// Object versions are keyed by bucket name, object name and version, so
+// versions of an object are ordered newest first like in tidb.
+// Parts are kept in the object entry.
+func objectKey(bucketName, objectName string, version uint64) string {
+	return key(bucketName, objectName, versionKey(version))
+}
+
+func objectPrefix(bucketName, objectName string) string {
+	return key(bucketName, objectName, "")
+}
+
+func objectNameOfKey(bucketName, k string) string {
+	return k[len(bucketName)+1 : len(k)-versionKeyLength-1]
+}
+
+func encodeObject(object *Object) ([]byte, error) {
+	o := *object
+	o.Rowkey = nil
+	o.PartsIndex = nil
+	o.VersionId = ""
+	return json.Marshal(o)
+}
+
+func decodeObject(value []byte) (object *Object, err error) {
+	object = new(Object)
+	err = json.Unmarshal(value, object)
+	if err != nil {
+		return nil, err
+	}
+	//build simple index for multipart
+	if len(object.Parts) != 0 {
+		var sortedPartNum = make([]int64, len(object.Parts))
+		for k, v := range object.Parts {
+			sortedPartNum[k-1] = v.Offset
+		}
+		object.PartsIndex = &SimpleIndex{Index: sortedPartNum}
+	}
+	timeData := []byte(strconv.FormatUint(uint64(object.LastModifiedTime.UnixNano()), 10))
+	object.VersionId = hex.EncodeToString(xxtea.Encrypt(timeData, XXTEA_KEY))
+	return object, nil
+}
+
+// Store lock should be held
+func (c *EmbeddedClient) getObject(bucketName, objectName, version string) (object *Object, err error) {
+	var value []byte
+	if version == "" {
+		c.store.scanPrefix(objectsTable, objectPrefix(bucketName, objectName),
+			func(k string, v []byte) bool {
+				value = v
+				return false
+			})
+	} else {
+		v, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return nil, ErrNoSuchKey
+		}
+		value, _ = c.store.get(objectsTable, objectKey(bucketName, objectName, v))
+	}
+	if value == nil {
+		return nil, ErrNoSuchKey
+	}
+	return decodeObject(value)
+}
+
+func (c *EmbeddedClient) GetObject(bucketName, objectName, version string) (object *Object, err error) {
+	err = c.store.read(func() (err error) {
+		object, err = c.getObject(bucketName, objectName, version)
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) GetAllObject(bucketName, objectName, version string) (objects []*Object, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scanPrefix(objectsTable, objectPrefix(bucketName, objectName),
+			func(k string, v []byte) bool {
+				var o *Object
+				o, err = decodeObject(v)
+				if err != nil {
+					return false
+				}
+				objects = append(objects, o)
+				return true
+			})
+		return
+	})
+	return
+}
+
+// Scan all versions of objects in a bucket, ordered by name and then newest version first
+func (c *EmbeddedClient) ScanObjectVersions(bucketName, keyMarker string, versionMarker uint64, limit int) (
+	objects []*Object, nextKeyMarker string, nextVersionMarker uint64, truncated bool, err error) {
+
+	start := objectKey(bucketName, keyMarker, versionMarker)
+	err = c.store.read(func() (err error) {
+		c.store.scan(objectsTable, start, func(k string, v []byte) bool {
+			if !strings.HasPrefix(k, bucketName+keySeparator) {
+				return false
+			}
+			if k == start {
+				return true
+			}
+			var o *Object
+			o, err = decodeObject(v)
+			if err != nil {
+				return false
+			}
+			objects = append(objects, o)
+			return len(objects) < limit
+		})
+		return
+	})
+	if err != nil {
+		return nil, "", 0, false, err
+	}
+	if len(objects) == limit {
+		last := objects[len(objects)-1]
+		truncated = true
+		nextKeyMarker = last.Name
+		nextVersionMarker = timeVersion(last.LastModifiedTime)
+	}
+	return
+}
+
+// Apply fn to an object version and save it, the version is moved if fn
+// changes its name or last modified time. Do nothing if it doesn't exist.
+func updateObjectEntry(w *writer, k string, fn func(o *Object)) error {
+	value, ok := w.get(objectsTable, k)
+	if !ok {
+		return nil
+	}
+	o, err := decodeObject(value)
+	if err != nil {
+		return err
+	}
+	fn(o)
+	value, err = encodeObject(o)
+	if err != nil {
+		return err
+	}
+	newKey := objectKey(o.BucketName, o.Name, timeVersion(o.LastModifiedTime))
+	if newKey != k {
+		err = w.remove(objectsTable, k)
+		if err != nil {
+			return err
+		}
+	}
+	return w.set(objectsTable, newKey, value)
+}
+
+// Apply fn to all versions of an object
+func updateObjectVersions(w *writer, bucketName, objectName string, fn func(o *Object)) error {
+	for _, k := range w.keys(objectsTable, objectPrefix(bucketName, objectName)) {
+		err := updateObjectEntry(w, k, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *EmbeddedClient) updateObject(object *Object, tx Tx, fn func(o *Object)) error {
+	k := objectKey(object.BucketName, object.Name, timeVersion(object.LastModifiedTime))
+	return c.write(tx, func(w *writer) error {
+		return updateObjectEntry(w, k, fn)
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectAttrs(object *Object) error {
+	attrs := object.CustomAttributes
+	return c.write(nil, func(w *writer) error {
+		return updateObjectVersions(w, object.BucketName, object.Name, func(o *Object) {
+			o.CustomAttributes = attrs
+		})
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectAcl(object *Object) error {
+	acl := object.ACL
+	return c.updateObject(object, nil, func(o *Object) {
+		o.ACL = acl
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectTags(object *Object) error {
+	tags := object.Tags
+	return c.updateObject(object, nil, func(o *Object) {
+		o.Tags = tags
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectReplicationStatus(object *Object) error {
+	status := object.ReplicationStatus
+	return c.updateObject(object, nil, func(o *Object) {
+		o.ReplicationStatus = status
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectLock(object *Object) error {
+	mode, retainUntilDate, legalHold := object.LockMode, object.RetainUntilDate, object.LegalHold
+	return c.updateObject(object, nil, func(o *Object) {
+		o.LockMode = mode
+		o.RetainUntilDate = retainUntilDate
+		o.LegalHold = legalHold
+	})
+}
+
+func (c *EmbeddedClient) UpdateObjectEncryptionKey(object *Object) error {
+	encryptionKey := object.EncryptionKey
+	return c.updateObject(object, nil, func(o *Object) {
+		o.EncryptionKey = encryptionKey
+	})
+}
+
+func (c *EmbeddedClient) RenameObject(object *Object, sourceObject string, tx Tx) (err error) {
+	k := objectKey(object.BucketName, sourceObject, timeVersion(object.LastModifiedTime))
+	name := object.Name
+	return c.write(tx, func(w *writer) error {
+		return updateObjectEntry(w, k, func(o *Object) {
+			o.Name = name
+		})
+	})
+}
+
+// TODO : with Version, same as tidb
+func (c *EmbeddedClient) ReplaceObjectMetas(object *Object, tx Tx) (err error) {
+	source := *object
+	return c.updateObject(object, tx, func(o *Object) {
+		o.ContentType = source.ContentType
+		o.CustomAttributes = source.CustomAttributes
+		o.StorageClass = source.StorageClass
+		o.Tags = source.Tags
+	})
+}
+
+func (c *EmbeddedClient) UpdateAppendObject(object *Object, tx Tx) (err error) {
+	source := *object
+	return c.write(tx, func(w *writer) error {
+		return updateObjectVersions(w, source.BucketName, source.Name, func(o *Object) {
+			o.LastModifiedTime = source.LastModifiedTime
+			o.Size = source.Size
+			o.CompressedSize = source.CompressedSize
+		})
+	})
+}
+
+func (c *EmbeddedClient) PutObject(object *Object, tx Tx) (err error) {
+	value, err := encodeObject(object)
+	if err != nil {
+		return err
+	}
+	k := objectKey(object.BucketName, object.Name, timeVersion(object.LastModifiedTime))
+	return c.write(tx, func(w *writer) error {
+		if _, ok := w.get(objectsTable, k); ok {
+			return errKeyExists
+		}
+		return w.set(objectsTable, k, value)
+	})
+}
+
+func (c *EmbeddedClient) UpdateObject(object *Object, tx Tx) (err error) {
+	source := *object
+	return c.updateObject(object, tx, func(o *Object) {
+		o.Location = source.Location
+		o.Pool = source.Pool
+		o.Size = source.Size
+		o.ObjectId = source.ObjectId
+		o.Etag = source.Etag
+		o.InitializationVector = source.InitializationVector
+		o.StorageClass = source.StorageClass
+		o.Compressed = source.Compressed
+		o.CompressedSize = source.CompressedSize
+		o.Parts = source.Parts
+	})
+}
+
+// Checked in tx, so the object is not changed until tx is committed
+func (c *EmbeddedClient) CheckObjectData(object *Object, tx Tx) (err error) {
+	k := objectKey(object.BucketName, object.Name, timeVersion(object.LastModifiedTime))
+	source := *object
+	return c.write(tx, func(w *writer) error {
+		value, ok := w.get(objectsTable, k)
+		if !ok {
+			return ErrNoSuchKey
+		}
+		o, err := decodeObject(value)
+		if err != nil {
+			return err
+		}
+		if o.Location != source.Location || o.Pool != source.Pool ||
+			o.ObjectId != source.ObjectId || o.Size != source.Size {
+			return ErrNoSuchKey
+		}
+		return nil
+	})
+}
+
+func (c *EmbeddedClient) DeleteObject(object *Object, tx Tx) (err error) {
+	k := objectKey(object.BucketName, object.Name, timeVersion(object.LastModifiedTime))
+	return c.write(tx, func(w *writer) error {
+		return w.remove(objectsTable, k)
+	})
+}
+
+// Parts are kept in object entries and renamed along with them by RenameObject
+func (c *EmbeddedClient) RenameObjectPart(object *Object, sourceObject string, tx Tx) (err error) {
+	return nil
+}
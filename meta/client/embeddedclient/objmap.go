@@ -0,0 +1,44 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"strconv"
+
+	. "github.com/journeymidnight/yig/error"
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+//objmap
+func (c *EmbeddedClient) GetObjectMap(bucketName, objectName string) (objMap *ObjMap, err error) {
+	err = c.store.read(func() error {
+		value, ok := c.store.get(objMapTable, key(bucketName, objectName))
+		if !ok {
+			return ErrNoSuchKey
+		}
+		objMap = &ObjMap{}
+		return json.Unmarshal(value, objMap)
+	})
+	if err != nil {
+		return nil, err
+	}
+	objMap.NullVerId = strconv.FormatUint(objMap.NullVerNum, 10)
+	return
+}
+
+func (c *EmbeddedClient) PutObjectMap(objMap *ObjMap, tx Tx) (err error) {
+	m := ObjMap{
+		Name:       objMap.Name,
+		BucketName: objMap.BucketName,
+		NullVerNum: objMap.NullVerNum,
+	}
+	return c.write(tx, func(w *writer) error {
+		return w.insert(objMapTable, key(m.BucketName, m.Name), m)
+	})
+}
+
+func (c *EmbeddedClient) DeleteObjectMap(objMap *ObjMap, tx Tx) (err error) {
+	k := key(objMap.BucketName, objMap.Name)
+	return c.write(tx, func(w *writer) error {
+		return w.remove(objMapTable, k)
+	})
+}
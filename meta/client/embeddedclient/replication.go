@@ -0,0 +1,77 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func replicationKey(bucketName, objectName string, version uint64) string {
+	return key(bucketName, objectName, versionKey(version))
+}
+
+//replication
+func (c *EmbeddedClient) PutObjectToReplication(object *Object, tx Tx) (err error) {
+	task := ReplicationTask{
+		BucketName: object.BucketName,
+		ObjectName: object.Name,
+		Version:    timeVersion(object.LastModifiedTime),
+		NextTime:   time.Now().UTC(),
+	}
+	k := replicationKey(task.BucketName, task.ObjectName, task.Version)
+	return c.write(tx, func(w *writer) error {
+		// same as "insert ignore"
+		if _, ok := w.get(replicationTable, k); ok {
+			return nil
+		}
+		return w.put(replicationTable, k, task)
+	})
+}
+
+// Tasks are not indexed by next time, so the whole table is scanned,
+// which is fine for the size of a single node
+func (c *EmbeddedClient) ScanReplication(limit int, now time.Time) (tasks []ReplicationTask, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scan(replicationTable, "", func(k string, v []byte) bool {
+			var task ReplicationTask
+			err = json.Unmarshal(v, &task)
+			if err != nil {
+				return false
+			}
+			if !task.NextTime.After(now) {
+				tasks = append(tasks, task)
+			}
+			return true
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].NextTime.Before(tasks[j].NextTime)
+	})
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+func (c *EmbeddedClient) UpdateReplicationTask(task ReplicationTask) error {
+	k := replicationKey(task.BucketName, task.ObjectName, task.Version)
+	return c.write(nil, func(w *writer) error {
+		if _, ok := w.get(replicationTable, k); !ok {
+			return nil
+		}
+		return w.put(replicationTable, k, task)
+	})
+}
+
+func (c *EmbeddedClient) RemoveReplicationTask(task ReplicationTask) error {
+	k := replicationKey(task.BucketName, task.ObjectName, task.Version)
+	return c.write(nil, func(w *writer) error {
+		return w.remove(replicationTable, k)
+	})
+}
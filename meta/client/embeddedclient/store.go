@@ -0,0 +1,207 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// how long to wait for other processes to release the store file
+const openTimeout = 30 * time.Second
+
+var errKeyExists = errors.New("embedded meta store: key already exists")
+
+// An ordered key-value store in a bbolt file, with a bbolt bucket per table.
+// The file could be shared by processes on the same host, e.g. yig and
+// tools/lc: it's opened for each transaction, and bbolt holds flock of it until
+// the transaction ends, shared by readers and exclusive for the writer.
+// Transactions of a process are serialized since flock is per open file.
+type store struct {
+	mutex sync.Mutex
+	path  string
+	db    *bolt.DB
+	tx    *bolt.Tx // transaction in progress, mutex should be held
+}
+
+func openStore(path string) (s *store, err error) {
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return
+	}
+	s = &store{path: path}
+	// creates the store file, so readers could open it
+	err = s.update(func(w *writer) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Open the store file and begin a transaction, mutex should be held
+func (s *store) begin(writable bool) (err error) {
+	s.db, err = bolt.Open(s.path, 0644, &bolt.Options{Timeout: openTimeout, ReadOnly: !writable})
+	if err != nil {
+		return
+	}
+	s.tx, err = s.db.Begin(writable)
+	if err != nil {
+		s.db.Close()
+		s.db = nil
+	}
+	return
+}
+
+// End the transaction in progress and close the store file, changes are
+// discarded unless commit is true
+func (s *store) end(commit bool) (err error) {
+	if commit {
+		err = s.tx.Commit()
+	} else {
+		err = s.tx.Rollback()
+	}
+	s.tx = nil
+	if e := s.db.Close(); err == nil {
+		err = e
+	}
+	s.db = nil
+	return
+}
+
+// Run fn with a consistent view of the store
+func (s *store) read(fn func() error) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	err = s.begin(false)
+	if err != nil {
+		return
+	}
+	defer s.end(false)
+	return fn()
+}
+
+// Begin a transaction for writes, the store is held until the writer ends
+func (s *store) beginUpdate() (*writer, error) {
+	s.mutex.Lock()
+	err := s.begin(true)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, err
+	}
+	return &writer{s: s}, nil
+}
+
+// Run fn as a transaction, changes made by fn are discarded if it returns error,
+// otherwise they are persisted before update returns
+func (s *store) update(fn func(w *writer) error) error {
+	w, err := s.beginUpdate()
+	if err != nil {
+		return err
+	}
+	err = fn(w)
+	if err != nil {
+		w.end(false)
+		return err
+	}
+	return w.end(true)
+}
+
+// Returns a copy of the value, since values of bbolt are only valid in the transaction
+func (s *store) get(tableName, key string) ([]byte, bool) {
+	b := s.tx.Bucket([]byte(tableName))
+	if b == nil {
+		return nil, false
+	}
+	value := b.Get([]byte(key))
+	if value == nil {
+		return nil, false
+	}
+	return append([]byte{}, value...), true
+}
+
+// Call fn for keys not less than start in order, until fn returns false.
+// value is only valid in fn, and fn should not modify the table, collect keys
+// first instead.
+func (s *store) scan(tableName, start string, fn func(key string, value []byte) bool) {
+	b := s.tx.Bucket([]byte(tableName))
+	if b == nil {
+		return
+	}
+	cursor := b.Cursor()
+	for k, v := cursor.Seek([]byte(start)); k != nil; k, v = cursor.Next() {
+		if !fn(string(k), v) {
+			return
+		}
+	}
+}
+
+// Call fn for keys with prefix in order, until fn returns false
+func (s *store) scanPrefix(tableName, prefix string, fn func(key string, value []byte) bool) {
+	s.scan(tableName, prefix, func(key string, value []byte) bool {
+		if !strings.HasPrefix(key, prefix) {
+			return false
+		}
+		return fn(key, value)
+	})
+}
+
+// Writes of a transaction, visible to later reads in the same transaction
+type writer struct {
+	s *store
+}
+
+// End the transaction and release the store
+func (w *writer) end(commit bool) error {
+	defer w.s.mutex.Unlock()
+	return w.s.end(commit)
+}
+
+func (w *writer) set(tableName, key string, value []byte) error {
+	b, err := w.s.tx.CreateBucketIfNotExists([]byte(tableName))
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+func (w *writer) put(tableName, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return w.set(tableName, key, data)
+}
+
+// Like put, but fails with errKeyExists if the key exists
+func (w *writer) insert(tableName, key string, value interface{}) error {
+	if _, ok := w.s.get(tableName, key); ok {
+		return errKeyExists
+	}
+	return w.put(tableName, key, value)
+}
+
+func (w *writer) remove(tableName, key string) error {
+	b := w.s.tx.Bucket([]byte(tableName))
+	if b == nil {
+		return nil
+	}
+	return b.Delete([]byte(key))
+}
+
+func (w *writer) get(tableName, key string) ([]byte, bool) {
+	return w.s.get(tableName, key)
+}
+
+// Keys with prefix, collected so the caller could modify them
+func (w *writer) keys(tableName, prefix string) (keys []string) {
+	w.s.scanPrefix(tableName, prefix, func(key string, value []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return
+}
@@ -0,0 +1,36 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+
+	"github.com/journeymidnight/yig/helper"
+)
+
+func (c *EmbeddedClient) GetUserThrottles() (limits map[string]helper.ThrottleLimit, err error) {
+	limits = make(map[string]helper.ThrottleLimit)
+	err = c.store.read(func() (err error) {
+		c.store.scan(throttlesTable, "", func(k string, v []byte) bool {
+			var limit helper.ThrottleLimit
+			err = json.Unmarshal(v, &limit)
+			if err != nil {
+				return false
+			}
+			limits[k] = limit
+			return true
+		})
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) PutUserThrottle(userId string, limit helper.ThrottleLimit) error {
+	return c.write(nil, func(w *writer) error {
+		return w.put(throttlesTable, userId, limit)
+	})
+}
+
+func (c *EmbeddedClient) DeleteUserThrottle(userId string) error {
+	return c.write(nil, func(w *writer) error {
+		return w.remove(throttlesTable, userId)
+	})
+}
@@ -0,0 +1,63 @@
+package embeddedclient
+
+import (
+	"errors"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+var errTransactionEnded = errors.New("embedded meta store: transaction is already ended")
+
+// Writes in a transaction are run in a bbolt transaction, which holds the store
+// until it's committed or rolled back
+type transaction struct {
+	writer *writer
+}
+
+func (t *transaction) Commit() error {
+	if t.writer == nil {
+		return errTransactionEnded
+	}
+	w := t.writer
+	t.writer = nil
+	return w.end(true)
+}
+
+// Rollback after Commit or Rollback does nothing, like sql.Tx
+func (t *transaction) Rollback() error {
+	if t.writer == nil {
+		return nil
+	}
+	w := t.writer
+	t.writer = nil
+	return w.end(false)
+}
+
+func (c *EmbeddedClient) NewTrans() (tx Tx, err error) {
+	w, err := c.store.beginUpdate()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{writer: w}, nil
+}
+
+func (c *EmbeddedClient) AbortTrans(tx Tx) error {
+	return tx.Rollback()
+}
+
+func (c *EmbeddedClient) CommitTrans(tx Tx) error {
+	return tx.Commit()
+}
+
+// Run op in transaction tx if it's created by NewTrans,
+// otherwise run it in a transaction of its own
+func (c *EmbeddedClient) write(tx Tx, op func(w *writer) error) error {
+	if tx == nil {
+		return c.store.update(op)
+	}
+	t := tx.(*transaction)
+	if t.writer == nil {
+		return errTransactionEnded
+	}
+	return op(t.writer)
+}
@@ -0,0 +1,82 @@
+package embeddedclient
+
+import (
+	"encoding/json"
+
+	"github.com/journeymidnight/yig/iam/common"
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (c *EmbeddedClient) GetUserBuckets(userId string) (buckets []string, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scanPrefix(usersTable, key(userId, ""), func(k string, v []byte) bool {
+			var bucketName string
+			err = json.Unmarshal(v, &bucketName)
+			if err != nil {
+				return false
+			}
+			buckets = append(buckets, bucketName)
+			return true
+		})
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) AddBucketForUser(bucketName, userId string) (err error) {
+	return c.write(nil, func(w *writer) error {
+		return w.put(usersTable, key(userId, bucketName), bucketName)
+	})
+}
+
+func (c *EmbeddedClient) RemoveBucketForUser(bucketName string, userId string) (err error) {
+	return c.write(nil, func(w *writer) error {
+		return w.remove(usersTable, key(userId, bucketName))
+	})
+}
+
+func (c *EmbeddedClient) GetUserUsage(userId string) (usage, objects int64, err error) {
+	err = c.store.read(func() (err error) {
+		c.store.scanPrefix(usersTable, key(userId, ""), func(k string, v []byte) bool {
+			var bucketName string
+			err = json.Unmarshal(v, &bucketName)
+			if err != nil {
+				return false
+			}
+			value, ok := c.store.get(bucketsTable, bucketName)
+			if !ok {
+				return true
+			}
+			var bucket Bucket
+			err = json.Unmarshal(value, &bucket)
+			if err != nil {
+				return false
+			}
+			usage += bucket.Usage
+			objects += bucket.ObjectCount
+			return true
+		})
+		return
+	})
+	return
+}
+
+func (c *EmbeddedClient) GetUserQuota(userId string) (quota common.Quota, err error) {
+	err = c.store.read(func() error {
+		value, ok := c.store.get(quotasTable, userId)
+		if !ok {
+			return nil
+		}
+		return json.Unmarshal(value, &quota)
+	})
+	return
+}
+
+func (c *EmbeddedClient) PutUserQuota(userId string, quota common.Quota) error {
+	return c.write(nil, func(w *writer) error {
+		if quota.MaxBytes == 0 && quota.MaxObjects == 0 {
+			return w.remove(quotasTable, userId)
+		}
+		return w.put(quotasTable, userId, quota)
+	})
+}
@@ -14,8 +14,8 @@ import (
 )
 
 func (t *TidbClient) GetBucket(bucketName string) (bucket *Bucket, err error) {
-	var acl, cors, logging, lc, policy, website, encryption, createTime string
-	sqltext := "select bucketname,acl,cors,COALESCE(logging,\"\"),lc,uid,policy,website,COALESCE(encryption,\"\"),createtime,usages,versioning from buckets where bucketname=?;"
+	var acl, cors, logging, lc, policy, website, encryption, createTime, tags, notification, replication, objectLock, quota string
+	sqltext := "select bucketname,acl,cors,COALESCE(logging,\"\"),lc,uid,policy,website,COALESCE(encryption,\"\"),createtime,usages,versioning,COALESCE(tags,\"{}\"),COALESCE(notification,\"{}\"),COALESCE(replication,\"{}\"),COALESCE(objectlock,\"{}\"),COALESCE(quota,\"{}\"),COALESCE(objects,0) from buckets where bucketname=?;"
 	bucket = new(Bucket)
 	err = t.Client.QueryRow(sqltext, bucketName).Scan(
 		&bucket.Name,
@@ -30,6 +30,12 @@ func (t *TidbClient) GetBucket(bucketName string) (bucket *Bucket, err error) {
 		&createTime,
 		&bucket.Usage,
 		&bucket.Versioning,
+		&tags,
+		&notification,
+		&replication,
+		&objectLock,
+		&quota,
+		&bucket.ObjectCount,
 	)
 	if err != nil && err == sql.ErrNoRows {
 		err = ErrNoSuchBucket
@@ -69,11 +75,31 @@ func (t *TidbClient) GetBucket(bucketName string) (bucket *Bucket, err error) {
 	if err != nil {
 		return
 	}
+	err = json.Unmarshal([]byte(tags), &bucket.Tags)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(notification), &bucket.Notification)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(replication), &bucket.Replication)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(objectLock), &bucket.ObjectLock)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal([]byte(quota), &bucket.Quota)
+	if err != nil {
+		return
+	}
 	return
 }
 
 func (t *TidbClient) GetBuckets() (buckets []Bucket, err error) {
-	sqltext := "select bucketname,acl,cors,COALESCE(logging,\"\"),lc,uid,policy,website,COALESCE(encryption,\"\"),createtime,usages,versioning from buckets;"
+	sqltext := "select bucketname,acl,cors,COALESCE(logging,\"\"),lc,uid,policy,website,COALESCE(encryption,\"\"),createtime,usages,versioning,COALESCE(tags,\"{}\"),COALESCE(notification,\"{}\"),COALESCE(replication,\"{}\"),COALESCE(objectlock,\"{}\"),COALESCE(quota,\"{}\"),COALESCE(objects,0) from buckets;"
 	rows, err := t.Client.Query(sqltext)
 	if err == sql.ErrNoRows {
 		err = nil
@@ -85,7 +111,7 @@ func (t *TidbClient) GetBuckets() (buckets []Bucket, err error) {
 
 	for rows.Next() {
 		var tmp Bucket
-		var acl, cors, logging, lc, policy, website,encryption, createTime string
+		var acl, cors, logging, lc, policy, website,encryption, createTime, tags, notification, replication, objectLock, quota string
 		err = rows.Scan(
 			&tmp.Name,
 			&acl,
@@ -98,7 +124,13 @@ func (t *TidbClient) GetBuckets() (buckets []Bucket, err error) {
 			&encryption,
 			&createTime,
 			&tmp.Usage,
-			&tmp.Versioning)
+			&tmp.Versioning,
+			&tags,
+			&notification,
+			&replication,
+			&objectLock,
+			&quota,
+			&tmp.ObjectCount)
 		if err != nil {
 			return
 		}
@@ -134,6 +166,26 @@ func (t *TidbClient) GetBuckets() (buckets []Bucket, err error) {
 		if err != nil {
 			return
 		}
+		err = json.Unmarshal([]byte(tags), &tmp.Tags)
+		if err != nil {
+			return
+		}
+		err = json.Unmarshal([]byte(notification), &tmp.Notification)
+		if err != nil {
+			return
+		}
+		err = json.Unmarshal([]byte(replication), &tmp.Replication)
+		if err != nil {
+			return
+		}
+		err = json.Unmarshal([]byte(objectLock), &tmp.ObjectLock)
+		if err != nil {
+			return
+		}
+		err = json.Unmarshal([]byte(quota), &tmp.Quota)
+		if err != nil {
+			return
+		}
 		buckets = append(buckets, tmp)
 	}
 	return
@@ -330,15 +382,12 @@ func (t *TidbClient) DeleteBucket(bucket Bucket) error {
 	return nil
 }
 
-func (t *TidbClient) UpdateUsage(bucketName string, size int64, tx DB) (err error) {
+func (t *TidbClient) UpdateUsage(bucketName string, size, objects int64, tx Tx) (err error) {
 	if !helper.CONFIG.PiggybackUpdateUsage {
 		return nil
 	}
 
-	if tx == nil {
-		tx = t.Client
-	}
-	sql := "update buckets set usages= usages + ? where bucketname=?;"
-	_, err = tx.Exec(sql, size, bucketName)
+	sql := "update buckets set usages= usages + ?, objects= objects + ? where bucketname=?;"
+	_, err = t.db(tx).Exec(sql, size, objects, bucketName)
 	return
 }
@@ -15,8 +15,8 @@ func (t *TidbClient) CreateFreezer(freezer *Freezer) (err error) {
 
 func (t *TidbClient) GetFreezer(bucketName, objectName, version string) (freezer *Freezer, err error) {
 	var lastmodifiedtime string
-	sqltext := "select bucketname,objectname,IFNULL(version,''),status,lifetime,lastmodifiedtime,IFNULL(location,''),IFNULL(pool,''),IFNULL(ownerid,''),IFNULL(size,'0'),IFNULL(objectid,''),IFNULL(etag,'') from restoreobjects where bucketname=? and objectname=?;"
-	row := t.Client.QueryRow(sqltext, bucketName, objectName)
+	sqltext := "select bucketname,objectname,IFNULL(version,''),status,lifetime,lastmodifiedtime,IFNULL(location,''),IFNULL(pool,''),IFNULL(ownerid,''),IFNULL(size,'0'),IFNULL(objectid,''),IFNULL(etag,''),IFNULL(tier,0) from restoreobjects where bucketname=? and objectname=? and version<=>?;"
+	row := t.Client.QueryRow(sqltext, bucketName, objectName, freezerVersion(version))
 	freezer = &Freezer{}
 	err = row.Scan(
 		&freezer.BucketName,
@@ -31,6 +31,7 @@ func (t *TidbClient) GetFreezer(bucketName, objectName, version string) (freezer
 		&freezer.Size,
 		&freezer.ObjectId,
 		&freezer.Etag,
+		&freezer.Tier,
 	)
 	if err == sql.ErrNoRows {
 		err = ErrNoSuchKey
@@ -40,7 +41,7 @@ func (t *TidbClient) GetFreezer(bucketName, objectName, version string) (freezer
 	}
 	local, _ := time.LoadLocation("Local")
 	freezer.LastModifiedTime, _ = time.ParseInLocation(TIME_LAYOUT_TIDB, lastmodifiedtime, local)
-	freezer.Parts, err = getFreezerParts(freezer.BucketName, freezer.Name, t.Client)
+	freezer.Parts, err = getFreezerParts(freezer.BucketName, freezer.Name, version, t.Client)
 	//build simple index for multipart
 	if len(freezer.Parts) != 0 {
 		var sortedPartNum = make([]int64, len(freezer.Parts))
@@ -53,32 +54,40 @@ func (t *TidbClient) GetFreezer(bucketName, objectName, version string) (freezer
 }
 
 func (t *TidbClient) GetFreezerStatus(bucketName, objectName, version string) (freezer *Freezer, err error) {
-	sqltext := "select bucketname,objectname,IFNULL(version,''),status from restoreobjects where bucketname=? and objectname=?;"
-	row := t.Client.QueryRow(sqltext, bucketName, objectName)
+	var lastmodifiedtime string
+	sqltext := "select bucketname,objectname,IFNULL(version,''),status,lifetime,lastmodifiedtime,IFNULL(tier,0) from restoreobjects where bucketname=? and objectname=? and version<=>?;"
+	row := t.Client.QueryRow(sqltext, bucketName, objectName, freezerVersion(version))
 	freezer = &Freezer{}
 	err = row.Scan(
 		&freezer.BucketName,
 		&freezer.Name,
 		&freezer.VersionId,
 		&freezer.Status,
+		&freezer.LifeTime,
+		&lastmodifiedtime,
+		&freezer.Tier,
 	)
 	if err == sql.ErrNoRows || freezer.Name != objectName {
 		err = ErrNoSuchKey
 		return
+	} else if err != nil {
+		return
 	}
+	local, _ := time.LoadLocation("Local")
+	freezer.LastModifiedTime, _ = time.ParseInLocation(TIME_LAYOUT_TIDB, lastmodifiedtime, local)
 	return
 }
 
-func (t *TidbClient) UploadFreezerDate(bucketName, objectName string, lifetime int) (err error) {
-	sqltext := "update restoreobjects set lifetime=? where bucketname=? and objectname=?;"
-	_, err = t.Client.Exec(sqltext, lifetime, bucketName, objectName)
+func (t *TidbClient) UploadFreezerDate(bucketName, objectName, version string, lifetime int) (err error) {
+	sqltext := "update restoreobjects set lifetime=? where bucketname=? and objectname=? and version<=>?;"
+	_, err = t.Client.Exec(sqltext, lifetime, bucketName, objectName, freezerVersion(version))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *TidbClient) DeleteFreezer(bucketName, objectName string, tx DB) (err error) {
+func (t *TidbClient) DeleteFreezer(bucketName, objectName, version string, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -86,31 +95,118 @@ func (t *TidbClient) DeleteFreezer(bucketName, objectName string, tx DB) (err er
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
-	sqltext := "delete from restoreobjects where bucketname=? and objectname=?;"
-	_, err = tx.Exec(sqltext, bucketName, objectName)
+	sqltext := "delete from restoreobjects where bucketname=? and objectname=? and version<=>?;"
+	_, err = t.db(tx).Exec(sqltext, bucketName, objectName, freezerVersion(version))
 	if err != nil {
 		return err
 	}
-	sqltext = "delete from restoreobjectpart where objectname=? and bucketname=?;"
-	_, err = tx.Exec(sqltext, bucketName, objectName)
+	sqltext = "delete from restoreobjectpart where bucketname=? and objectname=? and version<=>?;"
+	_, err = t.db(tx).Exec(sqltext, bucketName, objectName, freezerVersion(version))
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// Freezers of status after the marker, ordered by bucket name, object name and version
+func (t *TidbClient) ScanFreezers(status Status, bucketMarker, objectMarker, versionMarker string, limit int) (freezers []Freezer, err error) {
+	if versionMarker == "" {
+		versionMarker = "0"
+	}
+	sqltext := "select bucketname,objectname,IFNULL(version,''),status,lifetime,lastmodifiedtime,IFNULL(tier,0) from restoreobjects " +
+		"where status=? and (bucketname>? or (bucketname=? and objectname>?) or " +
+		"(bucketname=? and objectname=? and IFNULL(version,0)>?)) " +
+		"order by bucketname,objectname,version limit ?;"
+	rows, err := t.Client.Query(sqltext, status, bucketMarker, bucketMarker, objectMarker,
+		bucketMarker, objectMarker, versionMarker, limit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	local, _ := time.LoadLocation("Local")
+	for rows.Next() {
+		var f Freezer
+		var lastmodifiedtime string
+		err = rows.Scan(
+			&f.BucketName,
+			&f.Name,
+			&f.VersionId,
+			&f.Status,
+			&f.LifeTime,
+			&lastmodifiedtime,
+			&f.Tier,
+		)
+		if err != nil {
+			return
+		}
+		f.LastModifiedTime, _ = time.ParseInLocation(TIME_LAYOUT_TIDB, lastmodifiedtime, local)
+		freezers = append(freezers, f)
+	}
+	return freezers, rows.Err()
+}
+
+// Change status of the freezer from freezer.Status, along with location of the
+// restored copy, fails with ErrNoSuchKey if the status is changed by others
+func (t *TidbClient) UpdateFreezer(freezer *Freezer, status Status) (err error) {
+	tx, err := t.Client.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	sqltext, args := freezer.GetUpdateSql(status)
+	result, err := tx.Exec(sqltext, args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNoSuchKey
+	}
+	sqltext = "delete from restoreobjectpart where bucketname=? and objectname=? and version<=>?;"
+	_, err = tx.Exec(sqltext, freezer.BucketName, freezer.Name, freezer.GetVersion())
+	if err != nil {
+		return err
+	}
+	for _, p := range freezer.Parts {
+		sqltext, args = p.GetCreateFreezerSql(freezer.BucketName, freezer.Name, freezer.GetVersion())
+		_, err = tx.Exec(sqltext, args...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NULL `version` of freezers created before versions were recorded is matched by empty version
+func freezerVersion(version string) interface{} {
+	if version == "" {
+		return nil
+	}
+	return version
+}
+
 //util function
-func getFreezerParts(bucketName, objectName string, cli *sql.DB) (parts map[int]*Part, err error) {
+func getFreezerParts(bucketName, objectName, version string, cli *sql.DB) (parts map[int]*Part, err error) {
 	parts = make(map[int]*Part)
-	sqltext := "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector from restoreobjectpart where bucketname=? and objectname=?;"
-	rows, err := cli.Query(sqltext, bucketName, objectName)
+	sqltext := "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector,IFNULL(compressedsize,0) from restoreobjectpart where bucketname=? and objectname=? and version<=>?;"
+	rows, err := cli.Query(sqltext, bucketName, objectName, freezerVersion(version))
 	if err != nil {
 		return
 	}
@@ -125,7 +221,11 @@ func getFreezerParts(bucketName, objectName string, cli *sql.DB) (parts map[int]
 			&p.Etag,
 			&p.LastModified,
 			&p.InitializationVector,
+			&p.CompressedSize,
 		)
+		if err != nil {
+			return
+		}
 		parts[p.PartNumber] = p
 	}
 	return
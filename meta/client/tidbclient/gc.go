@@ -9,7 +9,7 @@ import (
 )
 
 //gc
-func (t *TidbClient) PutObjectToGarbageCollection(object *Object, tx DB) (err error) {
+func (t *TidbClient) PutObjectToGarbageCollection(object *Object, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -17,10 +17,10 @@ func (t *TidbClient) PutObjectToGarbageCollection(object *Object, tx DB) (err er
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
@@ -33,13 +33,13 @@ func (t *TidbClient) PutObjectToGarbageCollection(object *Object, tx DB) (err er
 	mtime := o.MTime.Format(TIME_LAYOUT_TIDB)
 	version := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
 	sqltext := "insert ignore into gc(bucketname,objectname,version,location,pool,objectid,status,mtime,part,triedtimes) values(?,?,?,?,?,?,?,?,?,?);"
-	_, err = tx.Exec(sqltext, o.BucketName, o.ObjectName, version, o.Location, o.Pool, o.ObjectId, o.Status, mtime, hasPart, o.TriedTimes)
+	_, err = t.db(tx).Exec(sqltext, o.BucketName, o.ObjectName, version, o.Location, o.Pool, o.ObjectId, o.Status, mtime, hasPart, o.TriedTimes)
 	if err != nil {
 		return err
 	}
 	for _, p := range object.Parts {
 		psql, args := p.GetCreateGcSql(o.BucketName, o.ObjectName, version)
-		_, err = tx.Exec(psql, args...)
+		_, err = t.db(tx).Exec(psql, args...)
 		if err != nil {
 			return err
 		}
@@ -119,7 +119,7 @@ func (t *TidbClient) RemoveGarbageCollection(garbage GarbageCollection) (err err
 	return nil
 }
 
-func (t *TidbClient) PutFreezerToGarbageCollection(object *Freezer, tx DB) (err error) {
+func (t *TidbClient) PutFreezerToGarbageCollection(object *Freezer, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -127,10 +127,10 @@ func (t *TidbClient) PutFreezerToGarbageCollection(object *Freezer, tx DB) (err
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
@@ -142,13 +142,13 @@ func (t *TidbClient) PutFreezerToGarbageCollection(object *Freezer, tx DB) (err
 	mtime := o.MTime.Format(TIME_LAYOUT_TIDB)
 	version := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
 	sqltext := "insert ignore into gc(bucketname,objectname,version,location,pool,objectid,status,mtime,part,triedtimes) values(?,?,?,?,?,?,?,?,?,?);"
-	_, err = tx.Exec(sqltext, o.BucketName, o.ObjectName, version, o.Location, o.Pool, o.ObjectId, o.Status, mtime, hasPart, o.TriedTimes)
+	_, err = t.db(tx).Exec(sqltext, o.BucketName, o.ObjectName, version, o.Location, o.Pool, o.ObjectId, o.Status, mtime, hasPart, o.TriedTimes)
 	if err != nil {
 		return err
 	}
 	for _, p := range object.Parts {
 		psql, args := p.GetCreateGcSql(o.BucketName, o.ObjectName, version)
-		_, err = tx.Exec(psql, args...)
+		_, err = t.db(tx).Exec(psql, args...)
 		if err != nil {
 			return err
 		}
@@ -213,29 +213,3 @@ func getGcParts(bucketname, objectname, version string, cli *sql.DB) (parts map[
 	}
 	return
 }
-
-func GarbageCollectionFromObject(o *Object) (gc GarbageCollection) {
-	gc.BucketName = o.BucketName
-	gc.ObjectName = o.Name
-	gc.Location = o.Location
-	gc.Pool = o.Pool
-	gc.ObjectId = o.ObjectId
-	gc.Status = "Pending"
-	gc.MTime = time.Now().UTC()
-	gc.Parts = o.Parts
-	gc.TriedTimes = 0
-	return
-}
-
-func GarbageCollectionFromFreeze(f *Freezer) (gc GarbageCollection) {
-	gc.BucketName = f.BucketName
-	gc.ObjectName = f.Name
-	gc.Location = f.Location
-	gc.Pool = f.Pool
-	gc.ObjectId = f.ObjectId
-	gc.Status = "Pending"
-	gc.MTime = time.Now().UTC()
-	gc.Parts = f.Parts
-	gc.TriedTimes = 0
-	return
-}
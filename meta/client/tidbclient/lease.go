@@ -0,0 +1,70 @@
+package tidbclient
+
+import (
+	"database/sql"
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (t *TidbClient) AcquireLease(name, owner string, expire time.Time) (acquired bool, err error) {
+	tx, err := t.Client.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil || !acquired {
+			tx.Rollback()
+		}
+	}()
+	var lease Lease
+	var expireTime int64
+	sqltext := "select owner,expire from leases where name=? for update;"
+	err = tx.QueryRow(sqltext, name).Scan(&lease.Owner, &expireTime)
+	if err == sql.ErrNoRows {
+		sqltext = "insert into leases(name,owner,expire) values(?,?,?);"
+		_, err = tx.Exec(sqltext, name, owner, expire.UnixNano())
+	} else if err == nil {
+		lease.Expire = time.Unix(0, expireTime)
+		if !lease.Available(owner, time.Now()) {
+			return false, nil
+		}
+		sqltext = "update leases set owner=?,expire=? where name=?;"
+		_, err = tx.Exec(sqltext, owner, expire.UnixNano(), name)
+	}
+	if err != nil {
+		return false, err
+	}
+	// conflicts with other owners fail the commit
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (t *TidbClient) ReleaseLease(name, owner string) error {
+	sqltext := "delete from leases where name=? and owner=?;"
+	_, err := t.Client.Exec(sqltext, name, owner)
+	return err
+}
+
+func (t *TidbClient) ListLeases(prefix string) (leases []Lease, err error) {
+	sqltext := "select name,owner,expire from leases where name like ?;"
+	rows, err := t.Client.Query(sqltext, prefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lease Lease
+		var expire int64
+		err = rows.Scan(&lease.Name, &lease.Owner, &expire)
+		if err != nil {
+			return nil, err
+		}
+		lease.Expire = time.Unix(0, expire)
+		leases = append(leases, lease)
+	}
+	return leases, rows.Err()
+}
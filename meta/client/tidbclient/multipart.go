@@ -30,9 +30,9 @@ func (t *TidbClient) GetMultipart(bucketName, objectName, uploadId string) (mult
 	}
 	uploadTime = math.MaxUint64 - uploadTime
 	sqltext := "select bucketname,objectname,uploadtime,initiatorid,ownerid,contenttype,location,pool,acl,sserequest," +
-		"encryption,COALESCE(cipher,\"\"),attrs,storageclass from multiparts where bucketname=? and objectname=? and uploadtime=?;"
+		"encryption,COALESCE(cipher,\"\"),attrs,storageclass,compressed,COALESCE(tags,\"{}\") from multiparts where bucketname=? and objectname=? and uploadtime=?;"
 	var initialTime uint64
-	var acl, sseRequest, attrs string
+	var acl, sseRequest, attrs, tags string
 	err = t.Client.QueryRow(sqltext, bucketName, objectName, uploadTime).Scan(
 		&multipart.BucketName,
 		&multipart.ObjectName,
@@ -48,6 +48,8 @@ func (t *TidbClient) GetMultipart(bucketName, objectName, uploadId string) (mult
 		&multipart.Metadata.CipherKey,
 		&attrs,
 		&multipart.Metadata.StorageClass,
+		&multipart.Metadata.Compressed,
+		&tags,
 	)
 	if err != nil && err == sql.ErrNoRows {
 		err = ErrNoSuchUpload
@@ -71,8 +73,12 @@ func (t *TidbClient) GetMultipart(bucketName, objectName, uploadId string) (mult
 	if err != nil {
 		return
 	}
+	err = json.Unmarshal([]byte(tags), &multipart.Metadata.Tags)
+	if err != nil {
+		return
+	}
 
-	sqltext = "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector from multipartpart where bucketname=? and objectname=? and uploadtime=?;"
+	sqltext = "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector,compressedsize from multipartpart where bucketname=? and objectname=? and uploadtime=?;"
 	rows, err := t.Client.Query(sqltext, bucketName, objectName, uploadTime)
 	if err != nil {
 		return
@@ -88,6 +94,7 @@ func (t *TidbClient) GetMultipart(bucketName, objectName, uploadId string) (mult
 			&p.Etag,
 			&p.LastModified,
 			&p.InitializationVector,
+			&p.CompressedSize,
 		)
 		ts, e := time.Parse(TIME_LAYOUT_TIDB, p.LastModified)
 		if e != nil {
@@ -108,30 +115,34 @@ func (t *TidbClient) CreateMultipart(multipart Multipart) (err error) {
 	acl, _ := json.Marshal(m.Acl)
 	sseRequest, _ := json.Marshal(m.SseRequest)
 	attrs, _ := json.Marshal(m.Attrs)
-	sqltext := "insert into multiparts(bucketname,objectname,uploadtime,initiatorid,ownerid,contenttype,location,pool,acl,sserequest,encryption,cipher,attrs,storageclass) " +
-		"values(?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
-	_, err = t.Client.Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime, m.InitiatorId, m.OwnerId, m.ContentType, m.Location, m.Pool, acl, sseRequest, m.EncryptionKey,m.CipherKey, attrs, m.StorageClass)
+	tags, _ := json.Marshal(m.Tags)
+	sqltext := "insert into multiparts(bucketname,objectname,uploadtime,initiatorid,ownerid,contenttype,location,pool,acl,sserequest,encryption,cipher,attrs,storageclass,compressed,tags) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+	_, err = t.Client.Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime, m.InitiatorId, m.OwnerId, m.ContentType, m.Location, m.Pool, acl, sseRequest, m.EncryptionKey, m.CipherKey, attrs, m.StorageClass, m.Compressed, tags)
 	return
 }
 
-func (t *TidbClient) PutObjectPart(multipart *Multipart, part *Part, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) UpdateMultipartCipherKey(multipart *Multipart) (err error) {
+	uploadtime := math.MaxUint64 - uint64(multipart.InitialTime.UnixNano())
+	sqltext := "update multiparts set cipher=? where bucketname=? and objectname=? and uploadtime=?"
+	_, err = t.Client.Exec(sqltext, multipart.Metadata.CipherKey, multipart.BucketName, multipart.ObjectName, uploadtime)
+	return
+}
 
+func (t *TidbClient) PutObjectPart(multipart *Multipart, part *Part, tx Tx) (err error) {
 	uploadtime := math.MaxUint64 - uint64(multipart.InitialTime.UnixNano())
 	lastt, err := time.Parse(CREATE_TIME_LAYOUT, part.LastModified)
 	if err != nil {
 		return
 	}
 	lastModified := lastt.Format(TIME_LAYOUT_TIDB)
-	sqltext := "insert into multipartpart(partnumber,size,objectid,offset,etag,lastmodified,initializationvector,bucketname,objectname,uploadtime) " +
-		"values(?,?,?,?,?,?,?,?,?,?)"
-	_, err = tx.Exec(sqltext, part.PartNumber, part.Size, part.ObjectId, part.Offset, part.Etag, lastModified, part.InitializationVector, multipart.BucketName, multipart.ObjectName, uploadtime)
+	sqltext := "insert into multipartpart(partnumber,size,objectid,offset,etag,lastmodified,initializationvector,compressedsize,bucketname,objectname,uploadtime) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?)"
+	_, err = t.db(tx).Exec(sqltext, part.PartNumber, part.Size, part.ObjectId, part.Offset, part.Etag, lastModified, part.InitializationVector, part.CompressedSize, multipart.BucketName, multipart.ObjectName, uploadtime)
 	return
 }
 
-func (t *TidbClient) DeleteMultipart(multipart *Multipart, tx DB) (err error) {
+func (t *TidbClient) DeleteMultipart(multipart *Multipart, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -139,21 +150,21 @@ func (t *TidbClient) DeleteMultipart(multipart *Multipart, tx DB) (err error) {
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
 	uploadtime := math.MaxUint64 - uint64(multipart.InitialTime.UnixNano())
 	sqltext := "delete from multiparts where bucketname=? and objectname=? and uploadtime=?;"
-	_, err = tx.Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime)
+	_, err = t.db(tx).Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime)
 	if err != nil {
 		return
 	}
 	sqltext = "delete from multipartpart where bucketname=? and objectname=? and uploadtime=?;"
-	_, err = tx.Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime)
+	_, err = t.db(tx).Exec(sqltext, multipart.BucketName, multipart.ObjectName, uploadtime)
 	return err
 }
 
@@ -281,11 +292,8 @@ func (t *TidbClient) ListMultipartUploads(bucketName, keyMarker, uploadIdMarker,
 	return
 }
 
-func (t *TidbClient) RenameObjectPart(object *Object, sourceObject string, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) RenameObjectPart(object *Object, sourceObject string, tx Tx) (err error) {
 	sql, args := object.GetUpdateObjectPartNameSql(sourceObject)
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	return err
 }
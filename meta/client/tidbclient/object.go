@@ -14,12 +14,12 @@ import (
 )
 
 func (t *TidbClient) GetObject(bucketName, objectName, version string) (object *Object, err error) {
-	var ibucketname, iname, customattributes, acl, lastModifiedTime string
+	var ibucketname, iname, customattributes, acl, tags, lastModifiedTime, retainUntilDate string
 	var iversion uint64
 
 	var row *sql.Row
 	sqltext := "select bucketname,name,version,location,pool,ownerid,size,objectid,lastmodifiedtime,etag,contenttype," +
-		"customattributes,acl,nullversion,deletemarker,ssetype,encryptionkey,initializationvector,type,storageclass from objects where bucketname=? and name=? "
+		"customattributes,acl,nullversion,deletemarker,ssetype,encryptionkey,initializationvector,type,storageclass,compressed,compressedsize,COALESCE(tags,\"{}\"),COALESCE(replicationstatus,\"\"),COALESCE(lockmode,\"\"),COALESCE(retainuntildate,\"\"),COALESCE(legalhold,0),COALESCE(ssekmskeyid,\"\"),COALESCE(ssecontext,\"\") from objects where bucketname=? and name=? "
 	if version == "" {
 		sqltext += "order by bucketname,name,version limit 1;"
 		row = t.Client.QueryRow(sqltext, bucketName, objectName)
@@ -49,6 +49,15 @@ func (t *TidbClient) GetObject(bucketName, objectName, version string) (object *
 		&object.InitializationVector,
 		&object.Type,
 		&object.StorageClass,
+		&object.Compressed,
+		&object.CompressedSize,
+		&tags,
+		&object.ReplicationStatus,
+		&object.LockMode,
+		&retainUntilDate,
+		&object.LegalHold,
+		&object.SseKmsKeyId,
+		&object.SseContext,
 	)
 	if err == sql.ErrNoRows {
 		err = ErrNoSuchKey
@@ -70,6 +79,16 @@ func (t *TidbClient) GetObject(bucketName, objectName, version string) (object *
 	if err != nil {
 		return
 	}
+	err = json.Unmarshal([]byte(tags), &object.Tags)
+	if err != nil {
+		return
+	}
+	if retainUntilDate != "" {
+		object.RetainUntilDate, err = time.Parse(TIME_LAYOUT_TIDB, retainUntilDate)
+		if err != nil {
+			return
+		}
+	}
 	object.Parts, err = getParts(object.BucketName, object.Name, iversion, t.Client)
 	//build simple index for multipart
 	if len(object.Parts) != 0 {
@@ -79,8 +98,7 @@ func (t *TidbClient) GetObject(bucketName, objectName, version string) (object *
 		}
 		object.PartsIndex = &SimpleIndex{Index: sortedPartNum}
 	}
-	var reversedTime uint64
-	timestamp := math.MaxUint64 - reversedTime
+	timestamp := math.MaxUint64 - iversion
 	timeData := []byte(strconv.FormatUint(timestamp, 10))
 	object.VersionId = hex.EncodeToString(xxtea.Encrypt(timeData, XXTEA_KEY))
 	return
@@ -113,6 +131,51 @@ func (t *TidbClient) GetAllObject(bucketName, objectName, version string) (objec
 	return
 }
 
+// Scan all versions of objects in a bucket, ordered by name and then newest version first
+func (t *TidbClient) ScanObjectVersions(bucketName, keyMarker string, versionMarker uint64, limit int) (
+	objects []*Object, nextKeyMarker string, nextVersionMarker uint64, truncated bool, err error) {
+
+	sqltext := "select name,version from objects where bucketname=? and (name>? or (name=? and version>?)) " +
+		"order by bucketname,name,version limit ?;"
+	rows, err := t.Client.Query(sqltext, bucketName, keyMarker, keyMarker, versionMarker, limit)
+	if err != nil {
+		return
+	}
+	var names []string
+	var versions []uint64
+	for rows.Next() {
+		var name string
+		var version uint64
+		err = rows.Scan(&name, &version)
+		if err != nil {
+			rows.Close()
+			return
+		}
+		names = append(names, name)
+		versions = append(versions, version)
+	}
+	rows.Close()
+	for i := range names {
+		var o *Object
+		o, err = t.GetObject(bucketName, names[i], strconv.FormatUint(versions[i], 10))
+		if err == ErrNoSuchKey {
+			// it's possible the object is already deleted
+			continue
+		}
+		if err != nil {
+			return
+		}
+		objects = append(objects, o)
+	}
+	err = nil
+	if len(names) == limit {
+		truncated = true
+		nextKeyMarker = names[len(names)-1]
+		nextVersionMarker = versions[len(versions)-1]
+	}
+	return
+}
+
 func (t *TidbClient) UpdateObjectAttrs(object *Object) error {
 	sql, args := object.GetUpdateAttrsSql()
 	_, err := t.Client.Exec(sql, args...)
@@ -125,34 +188,49 @@ func (t *TidbClient) UpdateObjectAcl(object *Object) error {
 	return err
 }
 
-func (t *TidbClient) RenameObject(object *Object, sourceObject string, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) UpdateObjectTags(object *Object) error {
+	sql, args := object.GetUpdateTagsSql()
+	_, err := t.Client.Exec(sql, args...)
+	return err
+}
+
+func (t *TidbClient) UpdateObjectReplicationStatus(object *Object) error {
+	sql, args := object.GetUpdateReplicationStatusSql()
+	_, err := t.Client.Exec(sql, args...)
+	return err
+}
+
+func (t *TidbClient) UpdateObjectLock(object *Object) error {
+	sql, args := object.GetUpdateObjectLockSql()
+	_, err := t.Client.Exec(sql, args...)
+	return err
+}
+
+func (t *TidbClient) UpdateObjectEncryptionKey(object *Object) error {
+	sql, args := object.GetUpdateEncryptionKeySql()
+	_, err := t.Client.Exec(sql, args...)
+	return err
+}
+
+func (t *TidbClient) RenameObject(object *Object, sourceObject string, tx Tx) (err error) {
 	sql, args := object.GetUpdateNameSql(sourceObject)
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	return
 }
 
-func (t *TidbClient) ReplaceObjectMetas(object *Object, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) ReplaceObjectMetas(object *Object, tx Tx) (err error) {
 	sql, args := object.GetReplaceObjectMetasSql()
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	return
 }
 
-func (t *TidbClient) UpdateAppendObject(object *Object, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) UpdateAppendObject(object *Object, tx Tx) (err error) {
 	sql, args := object.GetAppendSql()
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	return err
 }
 
-func (t *TidbClient) PutObject(object *Object, tx DB) (err error) {
+func (t *TidbClient) PutObject(object *Object, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -160,21 +238,21 @@ func (t *TidbClient) PutObject(object *Object, tx DB) (err error) {
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
 	sql, args := object.GetCreateSql()
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	if object.Parts != nil {
 		v := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
 		version := strconv.FormatUint(v, 10)
 		for _, p := range object.Parts {
 			psql, args := p.GetCreateSql(object.BucketName, object.Name, version)
-			_, err = tx.Exec(psql, args...)
+			_, err = t.db(tx).Exec(psql, args...)
 			if err != nil {
 				return err
 			}
@@ -183,7 +261,27 @@ func (t *TidbClient) PutObject(object *Object, tx DB) (err error) {
 	return err
 }
 
-func (t *TidbClient) UpdateObject(object *Object, tx DB) (err error) {
+func (t *TidbClient) CheckObjectData(object *Object, tx Tx) (err error) {
+	version := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
+	var location, pool, objectId string
+	var size int64
+	sqltext := "select location,pool,objectid,size from objects where bucketname=? and name=? and version=? for update;"
+	err = t.db(tx).QueryRow(sqltext, object.BucketName, object.Name, version).Scan(
+		&location, &pool, &objectId, &size)
+	if err == sql.ErrNoRows {
+		return ErrNoSuchKey
+	}
+	if err != nil {
+		return err
+	}
+	if location != object.Location || pool != object.Pool || objectId != object.ObjectId ||
+		size != object.Size {
+		return ErrNoSuchKey
+	}
+	return nil
+}
+
+func (t *TidbClient) UpdateObject(object *Object, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -191,10 +289,10 @@ func (t *TidbClient) UpdateObject(object *Object, tx DB) (err error) {
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
@@ -202,17 +300,17 @@ func (t *TidbClient) UpdateObject(object *Object, tx DB) (err error) {
 	v := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
 	version := strconv.FormatUint(v, 10)
 	sqltext := "delete from objectpart where objectname=? and bucketname=? and version=?;"
-	_, err = tx.Exec(sqltext, object.Name, object.BucketName, version)
+	_, err = t.db(tx).Exec(sqltext, object.Name, object.BucketName, version)
 	if err != nil {
 		return err
 	}
 
 	sql, args := object.GetUpdateSql()
-	_, err = tx.Exec(sql, args...)
+	_, err = t.db(tx).Exec(sql, args...)
 	if object.Parts != nil {
 		for _, p := range object.Parts {
 			psql, args := p.GetCreateSql(object.BucketName, object.Name, version)
-			_, err = tx.Exec(psql, args...)
+			_, err = t.db(tx).Exec(psql, args...)
 			if err != nil {
 				return err
 			}
@@ -221,7 +319,7 @@ func (t *TidbClient) UpdateObject(object *Object, tx DB) (err error) {
 	return nil
 }
 
-func (t *TidbClient) DeleteObject(object *Object, tx DB) (err error) {
+func (t *TidbClient) DeleteObject(object *Object, tx Tx) (err error) {
 	if tx == nil {
 		tx, err = t.Client.Begin()
 		if err != nil {
@@ -229,10 +327,10 @@ func (t *TidbClient) DeleteObject(object *Object, tx DB) (err error) {
 		}
 		defer func() {
 			if err == nil {
-				err = tx.(*sql.Tx).Commit()
+				err = tx.Commit()
 			}
 			if err != nil {
-				tx.(*sql.Tx).Rollback()
+				tx.Rollback()
 			}
 		}()
 	}
@@ -240,12 +338,12 @@ func (t *TidbClient) DeleteObject(object *Object, tx DB) (err error) {
 	v := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
 	version := strconv.FormatUint(v, 10)
 	sqltext := "delete from objects where name=? and bucketname=? and version=?;"
-	_, err = tx.Exec(sqltext, object.Name, object.BucketName, version)
+	_, err = t.db(tx).Exec(sqltext, object.Name, object.BucketName, version)
 	if err != nil {
 		return err
 	}
 	sqltext = "delete from objectpart where objectname=? and bucketname=? and version=?;"
-	_, err = tx.Exec(sqltext, object.Name, object.BucketName, version)
+	_, err = t.db(tx).Exec(sqltext, object.Name, object.BucketName, version)
 	if err != nil {
 		return err
 	}
@@ -255,7 +353,7 @@ func (t *TidbClient) DeleteObject(object *Object, tx DB) (err error) {
 //util function
 func getParts(bucketName, objectName string, version uint64, cli *sql.DB) (parts map[int]*Part, err error) {
 	parts = make(map[int]*Part)
-	sqltext := "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector from objectpart where bucketname=? and objectname=? and version=?;"
+	sqltext := "select partnumber,size,objectid,offset,etag,lastmodified,initializationvector,compressedsize from objectpart where bucketname=? and objectname=? and version=?;"
 	rows, err := cli.Query(sqltext, bucketName, objectName, version)
 	if err != nil {
 		return
@@ -271,6 +369,7 @@ func getParts(bucketName, objectName string, version uint64, cli *sql.DB) (parts
 			&p.Etag,
 			&p.LastModified,
 			&p.InitializationVector,
+			&p.CompressedSize,
 		)
 		parts[p.PartNumber] = p
 	}
@@ -1,8 +1,11 @@
 package tidbclient
 
 import (
-	. "github.com/journeymidnight/yig/meta/types"
+	"database/sql"
 	"strconv"
+
+	. "github.com/journeymidnight/yig/error"
+	. "github.com/journeymidnight/yig/meta/types"
 )
 
 //objmap
@@ -14,27 +17,24 @@ func (t *TidbClient) GetObjectMap(bucketName, objectName string) (objMap *ObjMap
 		&objMap.Name,
 		&objMap.NullVerNum,
 	)
-	if err != nil {
+	if err == sql.ErrNoRows {
+		err = ErrNoSuchKey
+		return
+	} else if err != nil {
 		return
 	}
 	objMap.NullVerId = strconv.FormatUint(objMap.NullVerNum, 10)
 	return
 }
 
-func (t *TidbClient) PutObjectMap(objMap *ObjMap, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) PutObjectMap(objMap *ObjMap, tx Tx) (err error) {
 	sqltext := "insert into objmap(bucketname,objectname,nullvernum) values(?,?,?);"
-	_, err = tx.Exec(sqltext, objMap.BucketName, objMap.Name, objMap.NullVerNum)
+	_, err = t.db(tx).Exec(sqltext, objMap.BucketName, objMap.Name, objMap.NullVerNum)
 	return err
 }
 
-func (t *TidbClient) DeleteObjectMap(objMap *ObjMap, tx DB) (err error) {
-	if tx == nil {
-		tx = t.Client
-	}
+func (t *TidbClient) DeleteObjectMap(objMap *ObjMap, tx Tx) (err error) {
 	sqltext := "delete from objmap where bucketname=? and objectname=?;"
-	_, err = tx.Exec(sqltext, objMap.BucketName, objMap.Name)
+	_, err = t.db(tx).Exec(sqltext, objMap.BucketName, objMap.Name)
 	return err
 }
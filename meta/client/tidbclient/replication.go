@@ -0,0 +1,73 @@
+package tidbclient
+
+import (
+	"math"
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+//replication
+func (t *TidbClient) PutObjectToReplication(object *Object, tx Tx) (err error) {
+	if tx == nil {
+		tx, err = t.Client.Begin()
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil {
+				err = tx.Commit()
+			}
+			if err != nil {
+				tx.Rollback()
+			}
+		}()
+	}
+	version := math.MaxUint64 - uint64(object.LastModifiedTime.UnixNano())
+	nextTime := time.Now().UTC().Format(TIME_LAYOUT_TIDB)
+	sqltext := "insert ignore into replication(bucketname,objectname,version,triedtimes,nexttime) values(?,?,?,?,?);"
+	_, err = t.db(tx).Exec(sqltext, object.BucketName, object.Name, version, 0, nextTime)
+	return err
+}
+
+func (t *TidbClient) ScanReplication(limit int, now time.Time) (tasks []ReplicationTask, err error) {
+	sqltext := "select bucketname,objectname,version,triedtimes,nexttime from replication where nexttime<=? order by nexttime limit ?;"
+	rows, err := t.Client.Query(sqltext, now.UTC().Format(TIME_LAYOUT_TIDB), limit)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var task ReplicationTask
+		var nextTime string
+		err = rows.Scan(
+			&task.BucketName,
+			&task.ObjectName,
+			&task.Version,
+			&task.TriedTimes,
+			&nextTime,
+		)
+		if err != nil {
+			return
+		}
+		task.NextTime, err = time.Parse(TIME_LAYOUT_TIDB, nextTime)
+		if err != nil {
+			return
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (t *TidbClient) UpdateReplicationTask(task ReplicationTask) error {
+	sqltext := "update replication set triedtimes=?,nexttime=? where bucketname=? and objectname=? and version=?;"
+	_, err := t.Client.Exec(sqltext, task.TriedTimes, task.NextTime.UTC().Format(TIME_LAYOUT_TIDB),
+		task.BucketName, task.ObjectName, task.Version)
+	return err
+}
+
+func (t *TidbClient) RemoveReplicationTask(task ReplicationTask) error {
+	sqltext := "delete from replication where bucketname=? and objectname=? and version=?;"
+	_, err := t.Client.Exec(sqltext, task.BucketName, task.ObjectName, task.Version)
+	return err
+}
@@ -0,0 +1,39 @@
+package tidbclient
+
+import (
+	"github.com/journeymidnight/yig/helper"
+)
+
+func (t *TidbClient) GetUserThrottles() (limits map[string]helper.ThrottleLimit, err error) {
+	sqltext := "select userid,requests,upload,download from userthrottles;"
+	rows, err := t.Client.Query(sqltext)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	limits = make(map[string]helper.ThrottleLimit)
+	for rows.Next() {
+		var userId string
+		var limit helper.ThrottleLimit
+		err = rows.Scan(&userId, &limit.RequestsPerSecond, &limit.UploadBytesPerSecond,
+			&limit.DownloadBytesPerSecond)
+		if err != nil {
+			return nil, err
+		}
+		limits[userId] = limit
+	}
+	return limits, rows.Err()
+}
+
+func (t *TidbClient) PutUserThrottle(userId string, limit helper.ThrottleLimit) error {
+	sqltext := "replace into userthrottles(userid,requests,upload,download) values(?,?,?,?);"
+	_, err := t.Client.Exec(sqltext, userId, limit.RequestsPerSecond, limit.UploadBytesPerSecond,
+		limit.DownloadBytesPerSecond)
+	return err
+}
+
+func (t *TidbClient) DeleteUserThrottle(userId string) error {
+	sqltext := "delete from userthrottles where userid=?;"
+	_, err := t.Client.Exec(sqltext, userId)
+	return err
+}
@@ -1,18 +1,31 @@
 package tidbclient
 
-import "database/sql"
+import (
+	"database/sql"
 
-func (t *TidbClient) NewTrans() (tx *sql.Tx, err error) {
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (t *TidbClient) NewTrans() (tx Tx, err error) {
 	tx, err = t.Client.Begin()
 	return
 }
 
-func (t *TidbClient) AbortTrans(tx *sql.Tx) (err error) {
+func (t *TidbClient) AbortTrans(tx Tx) (err error) {
 	err = tx.Rollback()
 	return
 }
 
-func (t *TidbClient) CommitTrans(tx *sql.Tx) (err error) {
+func (t *TidbClient) CommitTrans(tx Tx) (err error) {
 	err = tx.Commit()
 	return
-}
\ No newline at end of file
+}
+
+// Statements run in transaction tx if it's created by NewTrans,
+// otherwise they are auto-committed
+func (t *TidbClient) db(tx Tx) DB {
+	if tx == nil {
+		return t.Client
+	}
+	return tx.(*sql.Tx)
+}
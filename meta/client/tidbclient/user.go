@@ -2,6 +2,8 @@ package tidbclient
 
 import (
 	"database/sql"
+
+	"github.com/journeymidnight/yig/iam/common"
 )
 
 func (t *TidbClient) GetUserBuckets(userId string) (buckets []string, err error) {
@@ -37,3 +39,29 @@ func (t *TidbClient) RemoveBucketForUser(bucketName string, userId string) (err
 	_, err = t.Client.Exec(sql, userId, bucketName)
 	return
 }
+
+func (t *TidbClient) GetUserUsage(userId string) (usage, objects int64, err error) {
+	sqltext := "select COALESCE(sum(usages),0),COALESCE(sum(objects),0) from buckets where uid=?;"
+	err = t.Client.QueryRow(sqltext, userId).Scan(&usage, &objects)
+	return
+}
+
+func (t *TidbClient) GetUserQuota(userId string) (quota common.Quota, err error) {
+	sqltext := "select maxbytes,maxobjects from userquotas where userid=?;"
+	err = t.Client.QueryRow(sqltext, userId).Scan(&quota.MaxBytes, &quota.MaxObjects)
+	if err == sql.ErrNoRows {
+		err = nil
+	}
+	return
+}
+
+func (t *TidbClient) PutUserQuota(userId string, quota common.Quota) (err error) {
+	if quota.MaxBytes == 0 && quota.MaxObjects == 0 {
+		sqltext := "delete from userquotas where userid=?;"
+		_, err = t.Client.Exec(sqltext, userId)
+		return
+	}
+	sqltext := "replace into userquotas(userid,maxbytes,maxobjects) values(?,?,?);"
+	_, err = t.Client.Exec(sqltext, userId, quota.MaxBytes, quota.MaxObjects)
+	return
+}
@@ -1,7 +1,6 @@
 package meta
 
 import (
-	"database/sql"
 	"github.com/journeymidnight/yig/meta/types"
 )
 
@@ -10,19 +9,19 @@ func (m *Meta) CreateFreezer(freezer *types.Freezer) error {
 }
 
 func (m *Meta) GetFreezer(bucketName string, objectName string, version string) (freezer *types.Freezer, err error) {
-	return m.Client.GetFreezer(bucketName, objectName, "")
+	return m.Client.GetFreezer(bucketName, objectName, version)
 }
 
 func (m *Meta) GetFreezerStatus(bucketName string, objectName string, version string) (freezer *types.Freezer, err error) {
-	return m.Client.GetFreezerStatus(bucketName, objectName, "")
+	return m.Client.GetFreezerStatus(bucketName, objectName, version)
 }
 
 func (m *Meta) UpdateFreezerDate(freezer *types.Freezer) error {
-	return m.Client.UploadFreezerDate(freezer.BucketName, freezer.Name, freezer.LifeTime)
+	return m.Client.UploadFreezerDate(freezer.BucketName, freezer.Name, freezer.VersionId, freezer.LifeTime)
 }
 
 func (m *Meta) DeleteFreezer(freezer *types.Freezer) (err error) {
-	var tx *sql.Tx
+	var tx types.Tx
 	tx, err = m.Client.NewTrans()
 	if err != nil {
 		return err
@@ -36,11 +35,15 @@ func (m *Meta) DeleteFreezer(freezer *types.Freezer) (err error) {
 		}
 	}()
 
-	err = m.Client.DeleteFreezer(freezer.BucketName, freezer.Name, tx)
+	err = m.Client.DeleteFreezer(freezer.BucketName, freezer.Name, freezer.VersionId, tx)
 	if err != nil {
 		return err
 	}
 
+	// nothing to recycle if not restored yet
+	if freezer.ObjectId == "" && len(freezer.Parts) == 0 {
+		return nil
+	}
 	err = m.Client.PutFreezerToGarbageCollection(freezer, tx)
 	if err != nil {
 		return err
@@ -48,3 +51,13 @@ func (m *Meta) DeleteFreezer(freezer *types.Freezer) (err error) {
 
 	return err
 }
+
+func (m *Meta) ScanFreezers(status types.Status, bucketMarker, objectMarker, versionMarker string,
+	limit int) (freezers []types.Freezer, err error) {
+	return m.Client.ScanFreezers(status, bucketMarker, objectMarker, versionMarker, limit)
+}
+
+// Change status of the freezer, fails with ErrNoSuchKey if its status is not freezer.Status
+func (m *Meta) UpdateFreezer(freezer *types.Freezer, status types.Status) error {
+	return m.Client.UpdateFreezer(freezer, status)
+}
@@ -0,0 +1,20 @@
+package meta
+
+import (
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+// Take or renew the lease until expire, fails if it's held by another owner
+func (m *Meta) AcquireLease(name, owner string, expire time.Time) (acquired bool, err error) {
+	return m.Client.AcquireLease(name, owner, expire)
+}
+
+func (m *Meta) ReleaseLease(name, owner string) error {
+	return m.Client.ReleaseLease(name, owner)
+}
+
+func (m *Meta) ListLeases(prefix string) (leases []Lease, err error) {
+	return m.Client.ListLeases(prefix)
+}
@@ -3,6 +3,7 @@ package meta
 import (
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/meta/client"
+	"github.com/journeymidnight/yig/meta/client/embeddedclient"
 	"github.com/journeymidnight/yig/meta/client/tidbclient"
 )
 
@@ -19,9 +20,12 @@ func New(myCacheType CacheType) *Meta {
 	meta := Meta{
 		Cache:  newMetaCache(myCacheType),
 	}
-	if helper.CONFIG.MetaStore == "tidb" {
+	switch helper.CONFIG.MetaStore {
+	case "tidb":
 		meta.Client = tidbclient.NewTidbClient()
-	} else {
+	case "embedded":
+		meta.Client = embeddedclient.NewEmbeddedClient()
+	default:
 		panic("unsupport metastore")
 	}
 	return &meta
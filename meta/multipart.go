@@ -1,7 +1,6 @@
 package meta
 
 import (
-	"database/sql"
 	. "github.com/journeymidnight/yig/meta/types"
 )
 
@@ -27,7 +26,7 @@ func (m *Meta) DeleteMultipart(multipart Multipart) (err error) {
 	for _, p := range multipart.Parts {
 		removedSize += p.Size
 	}
-	err = m.Client.UpdateUsage(multipart.BucketName, -removedSize, tx)
+	err = m.Client.UpdateUsage(multipart.BucketName, -removedSize, 0, tx)
 	if err != nil {
 		return
 	}
@@ -35,6 +34,10 @@ func (m *Meta) DeleteMultipart(multipart Multipart) (err error) {
 	return
 }
 
+func (m *Meta) UpdateMultipartCipherKey(multipart Multipart) (err error) {
+	return m.Client.UpdateMultipartCipherKey(&multipart)
+}
+
 func (m *Meta) PutObjectPart(multipart Multipart, part Part) (err error) {
 	tx, err := m.Client.NewTrans()
 	if err != nil {
@@ -53,7 +56,7 @@ func (m *Meta) PutObjectPart(multipart Multipart, part Part) (err error) {
 	if part, ok := multipart.Parts[part.PartNumber]; ok {
 		removedSize += part.Size
 	}
-	err = m.Client.UpdateUsage(multipart.BucketName, part.Size-removedSize, tx)
+	err = m.Client.UpdateUsage(multipart.BucketName, part.Size-removedSize, 0, tx)
 	if err != nil {
 		return
 	}
@@ -62,7 +65,7 @@ func (m *Meta) PutObjectPart(multipart Multipart, part Part) (err error) {
 }
 
 func (m *Meta) RenameObjectPart(object *Object, sourceObject string) (err error) {
-	var tx *sql.Tx
+	var tx Tx
 	tx, err = m.Client.NewTrans()
 	if err != nil {
 		return err
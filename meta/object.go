@@ -1,7 +1,6 @@
 package meta
 
 import (
-	"database/sql"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	. "github.com/journeymidnight/yig/meta/types"
@@ -47,8 +46,7 @@ func (m *Meta) GetAllObject(bucketName string, objectName string) (object []*Obj
 }
 
 func (m *Meta) GetObjectMap(bucketName, objectName string) (objMap *ObjMap, err error) {
-	m.Client.GetObjectMap(bucketName, objectName)
-	return
+	return m.Client.GetObjectMap(bucketName, objectName)
 }
 
 func (m *Meta) GetObjectVersion(bucketName, objectName, version string, willNeed bool) (object *Object, err error) {
@@ -111,8 +109,24 @@ func (m *Meta) PutObject(object *Object, multipart *Multipart, objMap *ObjMap, u
 		}
 	}
 
+	// enqueue replication task in the same transaction, so no new version would be missed
+	if object.ReplicationStatus == ReplicationStatusPending {
+		err = m.Client.PutObjectToReplication(object, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// size of completed multipart object is counted when its parts are uploaded
+	var size, objects int64
 	if updateUsage {
-		err = m.Client.UpdateUsage(object.BucketName, object.Size, tx)
+		size = object.Size
+	}
+	if !object.DeleteMarker {
+		objects = 1
+	}
+	if size != 0 || objects != 0 {
+		err = m.Client.UpdateUsage(object.BucketName, size, objects, tx)
 		if err != nil {
 			return err
 		}
@@ -135,6 +149,26 @@ func (m *Meta) UpdateObjectAttrs(object *Object) error {
 	return err
 }
 
+func (m *Meta) UpdateObjectTags(object *Object) error {
+	err := m.Client.UpdateObjectTags(object)
+	return err
+}
+
+func (m *Meta) UpdateObjectReplicationStatus(object *Object) error {
+	err := m.Client.UpdateObjectReplicationStatus(object)
+	return err
+}
+
+func (m *Meta) UpdateObjectLock(object *Object) error {
+	err := m.Client.UpdateObjectLock(object)
+	return err
+}
+
+func (m *Meta) UpdateObjectEncryptionKey(object *Object) error {
+	err := m.Client.UpdateObjectEncryptionKey(object)
+	return err
+}
+
 func (m *Meta) RenameObject(object *Object, sourceObject string) error {
 	err := m.Client.RenameObject(object, sourceObject, nil)
 	return err
@@ -151,7 +185,7 @@ func (m *Meta) PutObjMapEntry(objMap *ObjMap) error {
 }
 
 func (m *Meta) DeleteObject(object *Object, DeleteMarker bool, objMap *ObjMap) (err error) {
-	var tx *sql.Tx
+	var tx Tx
 	tx, err = m.Client.NewTrans()
 	if err != nil {
 		return err
@@ -186,11 +220,11 @@ func (m *Meta) DeleteObject(object *Object, DeleteMarker bool, objMap *ObjMap) (
 		return err
 	}
 
-	return m.Client.UpdateUsage(object.BucketName, -object.Size, tx)
+	return m.Client.UpdateUsage(object.BucketName, -object.Size, -1, tx)
 }
 
 func (m *Meta) UpdateGlacierObject(targetObject, sourceObject *Object, isFreezer bool) (err error) {
-	var tx *sql.Tx
+	var tx Tx
 	tx, err = m.Client.NewTrans()
 	if err != nil {
 		return err
@@ -210,7 +244,8 @@ func (m *Meta) UpdateGlacierObject(targetObject, sourceObject *Object, isFreezer
 			return err
 		}
 
-		err = m.Client.DeleteFreezer(sourceObject.BucketName, sourceObject.Name, tx)
+		err = m.Client.DeleteFreezer(sourceObject.BucketName, sourceObject.Name,
+			FreezerVersion(sourceObject), tx)
 		if err != nil {
 			return err
 		}
@@ -229,6 +264,40 @@ func (m *Meta) UpdateGlacierObject(targetObject, sourceObject *Object, isFreezer
 	return err
 }
 
+// Move object data to another storage class in place, former data is removed by gc.
+// Returns ErrNoSuchKey if data of sourceObject is changed meanwhile
+func (m *Meta) TransitObject(targetObject, sourceObject *Object) (err error) {
+	var tx Tx
+	tx, err = m.Client.NewTrans()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			err = m.Client.CommitTrans(tx)
+		}
+		if err != nil {
+			m.Client.AbortTrans(tx)
+		}
+	}()
+
+	// the object could be overwritten or deleted while its data is copied
+	err = m.Client.CheckObjectData(sourceObject, tx)
+	if err != nil {
+		return err
+	}
+	err = m.Client.UpdateObject(targetObject, tx)
+	if err != nil {
+		return err
+	}
+	return m.Client.PutObjectToGarbageCollection(sourceObject, tx)
+}
+
+func (m *Meta) ScanObjectVersions(bucketName, keyMarker string, versionMarker uint64, limit int) (
+	objects []*Object, nextKeyMarker string, nextVersionMarker uint64, truncated bool, err error) {
+	return m.Client.ScanObjectVersions(bucketName, keyMarker, versionMarker, limit)
+}
+
 func (m *Meta) AppendObject(object *Object, isExist bool) error {
 	tx, err := m.Client.NewTrans()
 	if err != nil {
@@ -247,7 +316,8 @@ func (m *Meta) AppendObject(object *Object, isExist bool) error {
 	if err != nil {
 		return err
 	}
-	err = m.Client.UpdateUsage(object.BucketName, object.Size, tx)
+	err = m.Client.UpdateUsage(object.BucketName, object.Size,
+		helper.Ternary(isExist, int64(0), int64(1)).(int64), tx)
 	if err != nil {
 		return err
 	}
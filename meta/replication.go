@@ -0,0 +1,19 @@
+package meta
+
+import (
+	"time"
+
+	. "github.com/journeymidnight/yig/meta/types"
+)
+
+func (m *Meta) ScanReplication(limit int, now time.Time) ([]ReplicationTask, error) {
+	return m.Client.ScanReplication(limit, now)
+}
+
+func (m *Meta) UpdateReplicationTask(task ReplicationTask) error {
+	return m.Client.UpdateReplicationTask(task)
+}
+
+func (m *Meta) RemoveReplicationTask(task ReplicationTask) error {
+	return m.Client.RemoveReplicationTask(task)
+}
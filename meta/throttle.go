@@ -0,0 +1,18 @@
+package meta
+
+import (
+	"github.com/journeymidnight/yig/helper"
+)
+
+// Limits overriding default limits of access keys, by user
+func (m *Meta) GetUserThrottles() (limits map[string]helper.ThrottleLimit, err error) {
+	return m.Client.GetUserThrottles()
+}
+
+func (m *Meta) PutUserThrottle(userId string, limit helper.ThrottleLimit) error {
+	return m.Client.PutUserThrottle(userId, limit)
+}
+
+func (m *Meta) DeleteUserThrottle(userId string) error {
+	return m.Client.DeleteUserThrottle(userId)
+}
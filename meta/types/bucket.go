@@ -6,6 +6,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/journeymidnight/yig/api/datatype"
 	"github.com/journeymidnight/yig/api/datatype/policy"
+	"github.com/journeymidnight/yig/iam/common"
 	"time"
 )
 
@@ -29,7 +30,13 @@ type Bucket struct {
 	Website    datatype.WebsiteConfiguration
 	Encryption datatype.EncryptionConfiguration
 	Versioning string // actually enum: Disabled/Enabled/Suspended
+	Tags       map[string]string // bucket tagging
+	Notification datatype.NotificationConfiguration
+	Replication datatype.ReplicationConfiguration
+	ObjectLock datatype.ObjectLockConfiguration
+	Quota      common.Quota
 	Usage      int64
+	ObjectCount int64 // number of objects, maintained along with Usage
 }
 
 func (b *Bucket) String() (s string) {
@@ -45,6 +52,12 @@ func (b *Bucket) String() (s string) {
 	s += "Encryption" + fmt.Sprintf("%+v", b.Encryption) + "\t"
 	s += "Version: " + b.Versioning + "\t"
 	s += "Usage: " + humanize.Bytes(uint64(b.Usage)) + "\t"
+	s += "ObjectCount: " + fmt.Sprintf("%d", b.ObjectCount) + "\t"
+	s += "Quota: " + fmt.Sprintf("%+v", b.Quota) + "\t"
+	s += "Tags: " + fmt.Sprintf("%+v", b.Tags) + "\t"
+	s += "Notification: " + fmt.Sprintf("%+v", b.Notification) + "\t"
+	s += "Replication: " + fmt.Sprintf("%+v", b.Replication) + "\t"
+	s += "ObjectLock: " + fmt.Sprintf("%+v", b.ObjectLock) + "\t"
 	return
 }
 
@@ -57,8 +70,13 @@ func (b Bucket) GetUpdateSql() (string, []interface{}) {
 	bucket_policy, _ := json.Marshal(b.Policy)
 	website, _ := json.Marshal(b.Website)
 	encryption,_ := json.Marshal(b.Encryption)
-	sql := "update buckets set bucketname=?,acl=?,policy=?,cors=?,logging=?,lc=?,website=?,encryption=?,uid=?,versioning=? where bucketname=?"
-	args := []interface{}{b.Name, acl, bucket_policy, cors, logging, lc, website, encryption, b.OwnerId, b.Versioning, b.Name}
+	tags, _ := json.Marshal(b.Tags)
+	notification, _ := json.Marshal(b.Notification)
+	replication, _ := json.Marshal(b.Replication)
+	objectLock, _ := json.Marshal(b.ObjectLock)
+	quota, _ := json.Marshal(b.Quota)
+	sql := "update buckets set bucketname=?,acl=?,policy=?,cors=?,logging=?,lc=?,website=?,encryption=?,uid=?,versioning=?,tags=?,notification=?,replication=?,objectlock=?,quota=? where bucketname=?"
+	args := []interface{}{b.Name, acl, bucket_policy, cors, logging, lc, website, encryption, b.OwnerId, b.Versioning, tags, notification, replication, objectLock, quota, b.Name}
 	return sql, args
 }
 
@@ -70,9 +88,14 @@ func (b Bucket) GetCreateSql() (string, []interface{}) {
 	bucket_policy, _ := json.Marshal(b.Policy)
 	website, _ := json.Marshal(b.Website)
 	encryption,_ := json.Marshal(b.Encryption)
+	tags, _ := json.Marshal(b.Tags)
+	notification, _ := json.Marshal(b.Notification)
+	replication, _ := json.Marshal(b.Replication)
+	objectLock, _ := json.Marshal(b.ObjectLock)
+	quota, _ := json.Marshal(b.Quota)
 	createTime := b.CreateTime.Format(TIME_LAYOUT_TIDB)
-	sql := "insert into buckets(bucketname,acl,cors,logging,lc,uid,policy,website,encryption,createtime,usages,versioning) " +
-		"values(?,?,?,?,?,?,?,?,?,?,?,?);"
-	args := []interface{}{b.Name, acl, cors, logging, lc, b.OwnerId, bucket_policy, website, encryption, createTime, b.Usage, b.Versioning}
+	sql := "insert into buckets(bucketname,acl,cors,logging,lc,uid,policy,website,encryption,createtime,usages,versioning,tags,notification,replication,objectlock,quota,objects) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?);"
+	args := []interface{}{b.Name, acl, cors, logging, lc, b.OwnerId, bucket_policy, website, encryption, createTime, b.Usage, b.Versioning, tags, notification, replication, objectLock, quota, b.ObjectCount}
 	return sql, args
 }
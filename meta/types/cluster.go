@@ -1,8 +1,9 @@
 package types
 
 type Cluster struct {
-	Fsid   string
-	Pool   string
-	Weight int
+	Fsid    string
+	Pool    string
+	Weight  int
+	Backend string // name of the backend plugin owning the cluster, empty for any
 }
 
@@ -9,3 +9,14 @@ type DB interface {
 	Query(string, ...interface{}) (*sql.Rows, error)
 	QueryRow(string, ...interface{}) *sql.Row
 }
+
+// Transaction of a metadata store, created by client.Client.NewTrans and
+// passed back to methods of the same client. nil means no transaction.
+// Only methods taking the transaction see its uncommitted writes, other
+// reads return committed data. The embedded store runs statements of a
+// transaction when it's committed, so their errors, e.g. ErrNoSuchKey of
+// CheckObjectData, could be returned by Commit instead.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
@@ -1,6 +1,50 @@
 package types
 
-import "time"
+import (
+	"math"
+	"strconv"
+	"time"
+
+	. "github.com/journeymidnight/yig/error"
+)
+
+// Restore requests of higher priority tiers are processed first
+type RestoreTier uint8
+
+const (
+	RestoreTierStandard RestoreTier = iota
+	RestoreTierExpedited
+	RestoreTierBulk
+)
+
+var (
+	RestoreTierIndexMap = map[RestoreTier]string{
+		RestoreTierStandard:  "Standard",
+		RestoreTierExpedited: "Expedited",
+		RestoreTierBulk:      "Bulk",
+	}
+
+	RestoreTierStringMap = map[string]RestoreTier{
+		"Standard":  RestoreTierStandard,
+		"Expedited": RestoreTierExpedited,
+		"Bulk":      RestoreTierBulk,
+	}
+)
+
+func (t RestoreTier) ToString() string {
+	return RestoreTierIndexMap[t]
+}
+
+// Tier is Standard if not specified in restore request
+func MatchRestoreTier(tier string) (RestoreTier, error) {
+	if tier == "" {
+		return RestoreTierStandard, nil
+	}
+	if index, ok := RestoreTierStringMap[tier]; ok {
+		return index, nil
+	}
+	return 0, ErrInvalidRestoreInfo
+}
 
 type Freezer struct {
 	Rowkey           []byte // Rowkey cache
@@ -15,26 +59,54 @@ type Freezer struct {
 	Etag             string
 	Parts            map[int]*Part
 	PartsIndex       *SimpleIndex
-	VersionId        string // version cache
+	VersionId        string // `version` of the object version to restore, see FreezerVersion
 	Status           Status
-	LifeTime         int
+	LifeTime         int // days to keep the restored copy
+	Tier             RestoreTier
+}
+
+// Restored copy expires LifeTime days after restored, i.e. LastModifiedTime of
+// a finished freezer, rounded up to the next midnight UTC like AWS
+func (o *Freezer) ExpireTime() time.Time {
+	expire := o.LastModifiedTime.UTC().AddDate(0, 0, o.LifeTime)
+	midnight := expire.Truncate(24 * time.Hour)
+	if midnight.Before(expire) {
+		midnight = midnight.Add(24 * time.Hour)
+	}
+	return midnight
+}
+
+func (o *Freezer) Expired(now time.Time) bool {
+	return o.Status == ObjectHasRestored && !now.Before(o.ExpireTime())
+}
+
+// Freezers are keyed by bucket name, object name and `version` of the object version
+func FreezerVersion(object *Object) string {
+	return strconv.FormatUint(math.MaxUint64-uint64(object.LastModifiedTime.UnixNano()), 10)
+}
+
+// Freezers created before versions were recorded have NULL `version`
+func (o *Freezer) GetVersion() interface{} {
+	if o.VersionId == "" {
+		return nil
+	}
+	return o.VersionId
 }
 
 func (o *Freezer) GetCreateSql() (string, []interface{}) {
-	// TODO Multi-version control
 	lastModifiedTime := o.LastModifiedTime.Format(TIME_LAYOUT_TIDB)
-	sql := "insert into restoreobjects(bucketname,objectname,status,lifetime,lastmodifiedtime) values(?,?,?,?,?)"
-	args := []interface{}{o.BucketName, o.Name, o.Status, o.LifeTime, lastModifiedTime}
+	version := o.GetVersion()
+	sql := "insert into restoreobjects(bucketname,objectname,version,status,lifetime,lastmodifiedtime,tier) values(?,?,?,?,?,?,?)"
+	args := []interface{}{o.BucketName, o.Name, version, o.Status, o.LifeTime, lastModifiedTime, o.Tier}
 	return sql, args
 }
 
 func (o *Freezer) GetUpdateSql(status Status) (string, []interface{}) {
-	// TODO Multi-version control
-	// version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
 	lastModifiedTime := o.LastModifiedTime.Format(TIME_LAYOUT_TIDB)
 	sql := "update restoreobjects set status=?,lastmodifiedtime=?,location=?,pool=?," +
-		"ownerid=?,size=?,etag=? where bucketname=? and objectname=? and status=?"
-	args := []interface{}{status, lastModifiedTime, o.Location, o.Pool, o.OwnerId, o.Size, o.Etag, o.BucketName, o.Name, o.Status}
+		"ownerid=?,size=?,objectid=?,etag=? where bucketname=? and objectname=? and version<=>? and status=?"
+	args := []interface{}{status, lastModifiedTime, o.Location, o.Pool, o.OwnerId, o.Size, o.ObjectId, o.Etag,
+		o.BucketName, o.Name, o.GetVersion(), o.Status}
 
 	return sql, args
 }
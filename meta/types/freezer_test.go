@@ -0,0 +1,54 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreezer_ExpireTime(t *testing.T) {
+	var testcase = [...]struct {
+		restored string
+		lifeTime int
+		expire   string
+	}{
+		{"2019-08-01T10:30:00Z", 1, "2019-08-03T00:00:00Z"},
+		{"2019-08-01T00:00:00Z", 1, "2019-08-02T00:00:00Z"},
+		{"2019-08-31T23:59:59Z", 2, "2019-09-03T00:00:00Z"},
+	}
+	for _, c := range testcase {
+		restored, _ := time.Parse(time.RFC3339, c.restored)
+		expire, _ := time.Parse(time.RFC3339, c.expire)
+		freezer := Freezer{Status: ObjectHasRestored, LastModifiedTime: restored, LifeTime: c.lifeTime}
+		if !freezer.ExpireTime().Equal(expire) {
+			t.Errorf("restored at %s for %d days, expect expiry %s, got %s",
+				c.restored, c.lifeTime, c.expire, freezer.ExpireTime())
+		}
+		if freezer.Expired(expire.Add(-time.Second)) || !freezer.Expired(expire) {
+			t.Errorf("restored at %s for %d days, wrong expiry", c.restored, c.lifeTime)
+		}
+		freezer.Status = ObjectRestoring
+		if freezer.Expired(expire) {
+			t.Errorf("restoring object should not expire")
+		}
+	}
+}
+
+func TestMatchRestoreTier(t *testing.T) {
+	var testcase = [...]struct {
+		tier     string
+		expected RestoreTier
+		valid    bool
+	}{
+		{"", RestoreTierStandard, true},
+		{"Standard", RestoreTierStandard, true},
+		{"Expedited", RestoreTierExpedited, true},
+		{"Bulk", RestoreTierBulk, true},
+		{"bulk", 0, false},
+	}
+	for _, c := range testcase {
+		tier, err := MatchRestoreTier(c.tier)
+		if (err == nil) != c.valid || (c.valid && tier != c.expected) {
+			t.Errorf("tier %q: got %v, %v", c.tier, tier, err)
+		}
+	}
+}
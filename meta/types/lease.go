@@ -0,0 +1,15 @@
+package types
+
+import "time"
+
+// Lease of a named resource, e.g. a partition of gc work, held by Owner until Expire
+type Lease struct {
+	Name   string
+	Owner  string
+	Expire time.Time
+}
+
+// A lease could be taken if it's not held, or held by the same owner or expired
+func (l Lease) Available(owner string, now time.Time) bool {
+	return l.Owner == "" || l.Owner == owner || l.Expire.Before(now)
+}
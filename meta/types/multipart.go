@@ -23,6 +23,9 @@ type Part struct {
 	Etag                 string
 	LastModified         string // time string of format "2006-01-02T15:04:05.000Z"
 	InitializationVector []byte
+	// size of this part stored in Ceph, only set when the object is compressed.
+	// Offset and Size are always calculated before compression
+	CompressedSize int64
 }
 
 type MultipartMetadata struct {
@@ -36,7 +39,9 @@ type MultipartMetadata struct {
 	EncryptionKey []byte
 	CipherKey     []byte
 	Attrs         map[string]string
+	Tags          map[string]string
 	StorageClass  StorageClass
+	Compressed    bool
 }
 
 type Multipart struct {
@@ -86,9 +91,16 @@ func valuesForParts(parts map[int]*Part) (values map[string][]byte, err error) {
 }
 
 func (p *Part) GetCreateSql(bucketname, objectname, version string) (string, []interface{}) {
-	sql := "insert into objectpart(partnumber,size,objectid,offset,etag,lastmodified,initializationvector,bucketname,objectname,version) " +
-		"values(?,?,?,?,?,?,?,?,?,?)"
-	args := []interface{}{p.PartNumber, p.Size, p.ObjectId, p.Offset, p.Etag, p.LastModified, p.InitializationVector, bucketname, objectname, version}
+	sql := "insert into objectpart(partnumber,size,objectid,offset,etag,lastmodified,initializationvector,compressedsize,bucketname,objectname,version) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?)"
+	args := []interface{}{p.PartNumber, p.Size, p.ObjectId, p.Offset, p.Etag, p.LastModified, p.InitializationVector, p.CompressedSize, bucketname, objectname, version}
+	return sql, args
+}
+
+func (p *Part) GetCreateFreezerSql(bucketname, objectname string, version interface{}) (string, []interface{}) {
+	sql := "insert into restoreobjectpart(partnumber,size,objectid,offset,etag,lastmodified,initializationvector,compressedsize,bucketname,objectname,version) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?)"
+	args := []interface{}{p.PartNumber, p.Size, p.ObjectId, p.Offset, p.Etag, p.LastModified, p.InitializationVector, p.CompressedSize, bucketname, objectname, version}
 	return sql, args
 }
 
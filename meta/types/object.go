@@ -24,28 +24,44 @@ type Object struct {
 	Location         string // which Ceph cluster this object locates
 	Pool             string // which Ceph pool this object locates
 	OwnerId          string
-	Size             int64     // file size
+	Size             int64     // file size, before compression
 	ObjectId         string    // object name in Ceph
 	LastModifiedTime time.Time // in format "2006-01-02T15:04:05.000Z"
 	Etag             string
 	ContentType      string
 	CustomAttributes map[string]string
+	Tags             map[string]string // object tagging
 	Parts            map[int]*Part
 	PartsIndex       *SimpleIndex
 	ACL              datatype.Acl
 	NullVersion      bool   // if this entry has `null` version
 	DeleteMarker     bool   // if this entry is a delete marker
 	VersionId        string // version cache
-	// type of Server Side Encryption, could be "SSE-KMS", "SSE-S3", "SSE-C"(custom), or ""(none),
-	// KMS is not implemented yet
+	// type of Server Side Encryption, could be "SSE-KMS", "SSE-S3", "SSE-C"(custom), or ""(none)
 	SseType string
-	// encryption key for SSE-S3, the key itself is encrypted with SSE_S3_MASTER_KEY,
+	// encryption key for SSE-S3 and SSE-KMS, the key itself is sealed by KMS,
 	// in AES256-GCM
-	EncryptionKey        []byte
+	EncryptionKey []byte
+	// KMS key ID and encryption context(JSON) the key of SSE-KMS is sealed with
+	SseKmsKeyId          string
+	SseContext           string
 	InitializationVector []byte
 	// ObjectType include `Normal`, `Appendable`, 'Multipart'
 	Type         ObjectType
 	StorageClass StorageClass
+	// if data in Ceph is compressed by the compression plugin,
+	// CompressedSize is the size actually stored in Ceph
+	Compressed     bool
+	CompressedSize int64
+	// cross-cluster replication status of this version, could be "PENDING", "COMPLETED",
+	// "FAILED", "REPLICA" or ""(not replicated)
+	ReplicationStatus string
+	// object lock(WORM) retention mode of this version, could be "GOVERNANCE",
+	// "COMPLIANCE" or ""(no retention), version could not be removed or
+	// overwritten before RetainUntilDate, nor during LegalHold
+	LockMode        string
+	RetainUntilDate time.Time
+	LegalHold       bool
 }
 
 type ObjectType int
@@ -78,6 +94,9 @@ func (o *Object) String() (s string) {
 	s += "Version: " + o.VersionId + "\t"
 	s += "Type: " + o.ObjectTypeToString() + "\t"
 	s += "StorageClass: " + o.StorageClass.ToString() + "\t"
+	if o.Compressed {
+		s += fmt.Sprintf("Compressed Size: %d\t", o.CompressedSize)
+	}
 	for n, part := range o.Parts {
 		s += fmt.Sprintf("Part %d ObjectID: %s\t", n, part.ObjectId)
 	}
@@ -144,29 +163,33 @@ func (o *Object) GetCreateSql() (string, []interface{}) {
 	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
 	customAttributes, _ := json.Marshal(o.CustomAttributes)
 	acl, _ := json.Marshal(o.ACL)
+	tags, _ := json.Marshal(o.Tags)
 	lastModifiedTime := o.LastModifiedTime.Format(TIME_LAYOUT_TIDB)
 	sql := "insert into objects(bucketname,name,version,location,pool,ownerid,size,objectid,lastmodifiedtime,etag," +
-		"contenttype,customattributes,acl,nullversion,deletemarker,ssetype,encryptionkey,initializationvector,type,storageclass) " +
-		"values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
+		"contenttype,customattributes,acl,nullversion,deletemarker,ssetype,encryptionkey,initializationvector,type,storageclass," +
+		"compressed,compressedsize,tags,replicationstatus,lockmode,retainuntildate,legalhold,ssekmskeyid,ssecontext) " +
+		"values(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)"
 	args := []interface{}{o.BucketName, o.Name, version, o.Location, o.Pool, o.OwnerId, o.Size, o.ObjectId,
 		lastModifiedTime, o.Etag, o.ContentType, customAttributes, acl, o.NullVersion, o.DeleteMarker,
-		o.SseType, o.EncryptionKey, o.InitializationVector, o.Type, o.StorageClass}
+		o.SseType, o.EncryptionKey, o.InitializationVector, o.Type, o.StorageClass, o.Compressed, o.CompressedSize, tags, o.ReplicationStatus,
+		o.LockMode, o.retainUntilDate(), o.LegalHold, o.SseKmsKeyId, o.SseContext}
 	return sql, args
 }
 
 func (o *Object) GetAppendSql() (string, []interface{}) {
 	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
 	lastModifiedTime := o.LastModifiedTime.Format(TIME_LAYOUT_TIDB)
-	sql := "update objects set lastmodifiedtime=?, size=?, version=? where bucketname=? and name=?"
-	args := []interface{}{lastModifiedTime, o.Size, version, o.BucketName, o.Name}
+	sql := "update objects set lastmodifiedtime=?, size=?, compressedsize=?, version=? where bucketname=? and name=?"
+	args := []interface{}{lastModifiedTime, o.Size, o.CompressedSize, version, o.BucketName, o.Name}
 	return sql, args
 }
 
 func (o *Object) GetUpdateSql() (string, []interface{}) {
 	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
 	sql := "update objects set location=?,pool=?," +
-		"size=?,objectid=?,etag=?,initializationvector=?,storageclass=? where bucketname=? and name=? and version=?"
-	args := []interface{}{o.Location, o.Pool, o.Size, o.ObjectId, o.Etag, o.InitializationVector, o.StorageClass, o.BucketName, o.Name, version}
+		"size=?,objectid=?,etag=?,initializationvector=?,storageclass=?,compressed=?,compressedsize=? where bucketname=? and name=? and version=?"
+	args := []interface{}{o.Location, o.Pool, o.Size, o.ObjectId, o.Etag, o.InitializationVector, o.StorageClass,
+		o.Compressed, o.CompressedSize, o.BucketName, o.Name, version}
 	return sql, args
 }
 
@@ -178,6 +201,42 @@ func (o *Object) GetUpdateAclSql() (string, []interface{}) {
 	return sql, args
 }
 
+func (o *Object) GetUpdateTagsSql() (string, []interface{}) {
+	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
+	tags, _ := json.Marshal(o.Tags)
+	sql := "update objects set tags=? where bucketname=? and name=? and version=?"
+	args := []interface{}{tags, o.BucketName, o.Name, version}
+	return sql, args
+}
+
+func (o *Object) GetUpdateReplicationStatusSql() (string, []interface{}) {
+	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
+	sql := "update objects set replicationstatus=? where bucketname=? and name=? and version=?"
+	args := []interface{}{o.ReplicationStatus, o.BucketName, o.Name, version}
+	return sql, args
+}
+
+func (o *Object) retainUntilDate() interface{} {
+	if o.RetainUntilDate.IsZero() {
+		return nil
+	}
+	return o.RetainUntilDate.UTC().Format(TIME_LAYOUT_TIDB)
+}
+
+func (o *Object) GetUpdateObjectLockSql() (string, []interface{}) {
+	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
+	sql := "update objects set lockmode=?,retainuntildate=?,legalhold=? where bucketname=? and name=? and version=?"
+	args := []interface{}{o.LockMode, o.retainUntilDate(), o.LegalHold, o.BucketName, o.Name, version}
+	return sql, args
+}
+
+func (o *Object) GetUpdateEncryptionKeySql() (string, []interface{}) {
+	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
+	sql := "update objects set encryptionkey=? where bucketname=? and name=? and version=?"
+	args := []interface{}{o.EncryptionKey, o.BucketName, o.Name, version}
+	return sql, args
+}
+
 func (o *Object) GetUpdateAttrsSql() (string, []interface{}) {
 	customAttributes, _ := json.Marshal(o.CustomAttributes)
 	sql := "update objects set customattributes=? where bucketname=? and name=?"
@@ -204,10 +263,12 @@ func (o *Object) GetSubUsageSql() (string, []interface{}) {
 	return sql, args
 }
 
-// TODO : with Version
+// Only the version of LastModifiedTime is replaced
 func (o *Object) GetReplaceObjectMetasSql() (string, []interface{}) {
+	version := math.MaxUint64 - uint64(o.LastModifiedTime.UnixNano())
 	customAttributes, _ := json.Marshal(o.CustomAttributes)
-	sql := "update objects set contenttype=?,customattributes=?,storageclass=? where bucketname=? and name=?"
-	args := []interface{}{o.ContentType, customAttributes, o.StorageClass, o.BucketName, o.Name}
+	tags, _ := json.Marshal(o.Tags)
+	sql := "update objects set contenttype=?,customattributes=?,storageclass=?,tags=? where bucketname=? and name=? and version=?"
+	args := []interface{}{o.ContentType, customAttributes, o.StorageClass, tags, o.BucketName, o.Name, version}
 	return sql, args
 }
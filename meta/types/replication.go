@@ -0,0 +1,23 @@
+package types
+
+import (
+	"time"
+)
+
+// Replication status of an object version
+const (
+	ReplicationStatusPending   = "PENDING"
+	ReplicationStatusCompleted = "COMPLETED"
+	ReplicationStatusFailed    = "FAILED"
+	// object version written by the replication worker, never replicated again
+	ReplicationStatusReplica = "REPLICA"
+)
+
+// An object version waiting to be replicated to the destination cluster
+type ReplicationTask struct {
+	BucketName string
+	ObjectName string
+	Version    uint64 // version column of `objects` table
+	TriedTimes int
+	NextTime   time.Time // the task would not be scanned before NextTime
+}
@@ -3,6 +3,7 @@ package meta
 import (
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
 	"github.com/journeymidnight/yig/redis"
 )
 
@@ -46,3 +47,17 @@ func (m *Meta) AddBucketForUser(bucketName string, userId string) (err error) {
 func (m *Meta) RemoveBucketForUser(bucketName string, userId string) (err error) {
 	return m.Client.RemoveBucketForUser(bucketName, userId)
 }
+
+// Total usage of buckets owned by user, read from database since usage in
+// cached buckets is not updated on write
+func (m *Meta) GetUserUsage(userId string) (usage, objects int64, err error) {
+	return m.Client.GetUserUsage(userId)
+}
+
+func (m *Meta) GetUserQuota(userId string) (quota common.Quota, err error) {
+	return m.Client.GetUserQuota(userId)
+}
+
+func (m *Meta) PutUserQuota(userId string, quota common.Quota) error {
+	return m.Client.PutUserQuota(userId, quota)
+}
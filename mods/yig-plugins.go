@@ -27,6 +27,7 @@ const (
 	MQ_PLUGIN
 	KMS_PLUGIN
 	COMPRESS_PLUGIN
+	BACKEND_PLUGIN //backend.Plugin interface
 	NUMS_PLUGIN
 )
 
@@ -8,6 +8,9 @@ import (
 type MessageSender interface {
 	// send the message async
 	AsyncSend(value []byte) error
+	// send the message of a bucket notification event async, events are
+	// sent apart from access logs, and events with the same key are kept in order
+	AsyncSendEvent(key string, value []byte) error
 	// flush all the messages, timeout is in ms.
 	Flush(timeout int) error
 	// free this instance.
@@ -4,6 +4,8 @@ const (
 	KAFKA_CFG_BROKER_LIST       = "broker_list"
 	KAFKA_CFG_AUTO_OFFSET_STORE = "auto_offset_store"
 	KAFKA_CFG_MSG_TOPIC         = "topic"
+	// topic of bucket notification events, access logs are sent to "topic"
+	KAFKA_CFG_NOTIFICATION_TOPIC = "notification_topic"
 )
 
 const (
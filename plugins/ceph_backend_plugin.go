@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/ceph"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/mods"
+)
+
+const pluginName = "ceph_backend"
+
+//The variable MUST be named as Exported.
+//the code in yig-plugin will lookup this symbol
+var Exported = mods.YigPlugin{
+	Name:       pluginName,
+	PluginType: mods.BACKEND_PLUGIN,
+	Create:     GetCephBackend,
+}
+
+func GetCephBackend(config map[string]interface{}) (interface{}, error) {
+	helper.Logger.Info("Get ceph backend plugin config:", config)
+	configPattern, _ := config["config_pattern"].(string)
+	return &CephBackend{ConfigPattern: configPattern}, nil
+}
+
+type CephBackend struct {
+	// glob pattern of ceph config files, one cluster per file,
+	// ceph_config_pattern in yig config is used if empty
+	ConfigPattern string
+}
+
+func (b *CephBackend) Initialize(logger *log.Logger,
+	config helper.Config) map[string]backend.Cluster {
+
+	if b.ConfigPattern != "" {
+		config.CephConfigPattern = b.ConfigPattern
+	}
+	clusters := ceph.Initialize(config)
+	for id := range clusters {
+		logger.Info("Ceph backend cluster", id)
+	}
+	return clusters
+}
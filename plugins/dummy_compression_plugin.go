@@ -26,8 +26,14 @@ func (d DummyCompress) CompressReader(reader io.Reader) io.Reader {
 	return reader
 }
 
-func (d DummyCompress) CompressWriter(writer io.Writer) io.Writer {
-	return writer
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func (d DummyCompress) CompressWriter(writer io.Writer) io.WriteCloser {
+	return nopCloser{writer}
 }
 
 func (d DummyCompress) IsCompressible(objectName, mtype string) bool {
@@ -46,3 +46,6 @@ func (d DebugIamClient) GetCredential(accessKey string) (c common.Credential, er
 	}, nil // For test now
 }
 
+func (d DebugIamClient) GetIdentityPolicies(userId string) (policies []string, err error) {
+	return
+}
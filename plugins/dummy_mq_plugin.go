@@ -20,13 +20,18 @@ func GetDummyMsgQueueClient(config map[string]interface{}) (interface{}, error)
 		Url:   config["url"].(string),
 		Topic: config["topic"].(string),
 	}
+	msgQueue.NotificationTopic = msgQueue.Topic
+	if topic, ok := config["notification_topic"]; ok {
+		msgQueue.NotificationTopic = topic.(string)
+	}
 	msgQueue.Start()
 	return interface{}(msgQueue), nil
 }
 
 type dummyMsgQueue struct {
-	Url   string
-	Topic string
+	Url               string
+	Topic             string
+	NotificationTopic string
 }
 
 func (mb *dummyMsgQueue) Start() error {
@@ -47,3 +52,9 @@ func (mb *dummyMsgQueue) AsyncSend(value []byte) error {
 	fmt.Println("Send message succeed! url is:", mb.Url, "topic is:", mb.Topic, "value is：", value)
 	return nil
 }
+
+func (mb *dummyMsgQueue) AsyncSendEvent(key string, value []byte) error {
+	fmt.Println("Send event succeed! url is:", mb.Url, "topic is:", mb.NotificationTopic,
+		"key is:", key, "value is：", value)
+	return nil
+}
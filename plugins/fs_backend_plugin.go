@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/filesystem"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/mods"
+)
+
+const (
+	pluginName       = "fs_backend"
+	defaultClusterId = "fs"
+)
+
+//The variable MUST be named as Exported.
+//the code in yig-plugin will lookup this symbol
+var Exported = mods.YigPlugin{
+	Name:       pluginName,
+	PluginType: mods.BACKEND_PLUGIN,
+	Create:     GetFsBackend,
+}
+
+func GetFsBackend(config map[string]interface{}) (interface{}, error) {
+	helper.Logger.Info("Get fs backend plugin config:", config)
+	root, _ := config["root"].(string)
+	if root == "" {
+		return nil, errors.New("root directory of fs backend is not set")
+	}
+	id, _ := config["id"].(string)
+	if id == "" {
+		id = defaultClusterId
+	}
+	return &FsBackend{Id: id, Root: root}, nil
+}
+
+type FsBackend struct {
+	Id   string // cluster ID, saved as object location in metadata
+	Root string
+}
+
+func (b *FsBackend) Initialize(logger *log.Logger,
+	config helper.Config) map[string]backend.Cluster {
+
+	cluster, err := filesystem.NewFsCluster(b.Id, b.Root)
+	if err != nil {
+		panic("Failed to initialize fs backend at " + b.Root + ": " + err.Error())
+	}
+	logger.Info("Fs backend cluster", b.Id, "at", b.Root)
+	return map[string]backend.Cluster{
+		cluster.ID(): cluster,
+	}
+}
@@ -60,7 +60,13 @@ func GetKafkaClient(config map[string]interface{}) (interface{}, error) {
 	kafka := &Kafka{
 		producer: p,
 		doneChan: make(chan int),
-		Topic:    config["topic"].(string),
+		Topic:    config[types.KAFKA_CFG_MSG_TOPIC].(string),
+	}
+	// events are sent to the topic of access logs if no topic is specified,
+	// they could be told apart by their keys
+	kafka.NotificationTopic = kafka.Topic
+	if v, ok = params[types.KAFKA_CFG_NOTIFICATION_TOPIC]; ok {
+		kafka.NotificationTopic = v.(string)
 	}
 
 	kafka.Start()
@@ -69,9 +75,10 @@ func GetKafkaClient(config map[string]interface{}) (interface{}, error) {
 }
 
 type Kafka struct {
-	producer *kafka.Producer
-	doneChan chan int
-	Topic    string
+	producer          *kafka.Producer
+	doneChan          chan int
+	Topic             string
+	NotificationTopic string
 }
 
 func (kf *Kafka) Start() error {
@@ -131,12 +138,20 @@ func (kf *Kafka) Close() {
 }
 
 func (kf *Kafka) AsyncSend(value []byte) error {
+	return kf.send(kf.Topic, "", value)
+}
+
+func (kf *Kafka) AsyncSendEvent(key string, value []byte) error {
+	return kf.send(kf.NotificationTopic, key, value)
+}
+
+func (kf *Kafka) send(topic string, key string, value []byte) error {
 	if nil == kf.producer {
 		return errors.New("Kafka is not created correctly yet.")
 	}
-	if nil == value || "" == kf.Topic {
+	if nil == value || "" == topic {
 		return errors.New(fmt.Sprintf("input message[%v] is invalid.", value))
 	}
-	kf.producer.ProduceChannel() <- &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &kf.Topic, Partition: kafka.PartitionAny}, Key: []byte(""), Value: value, Opaque: nil}
+	kf.producer.ProduceChannel() <- &kafka.Message{TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny}, Key: []byte(key), Value: value, Opaque: nil}
 	return nil
 }
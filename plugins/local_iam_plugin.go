@@ -0,0 +1,408 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam"
+	"github.com/journeymidnight/yig/iam/common"
+	"github.com/journeymidnight/yig/mods"
+)
+
+const (
+	pluginName = "local_iam"
+
+	accessKeyLength  = 20
+	secretKeyLength  = 40
+	keyCharacters    = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	secretCharacters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+)
+
+//The variable MUST be named as Exported.
+//the code in yig-plugin will lookup this symbol
+var Exported = mods.YigPlugin{
+	Name:       pluginName,
+	PluginType: mods.IAM_PLUGIN,
+	Create:     GetIamClient,
+}
+
+var (
+	errNoSuchUser    = errors.New("user does not exist")
+	errUserExists    = errors.New("user already exists")
+	errKeyNotActive  = errors.New("access key is not active")
+	errInvalidUserId = errors.New("invalid user id")
+)
+
+type localUser struct {
+	DisplayName string            `json:"displayName"`
+	Policies    []json.RawMessage `json:"policies,omitempty"` // identity policies
+	CreateTime  time.Time         `json:"createTime"`
+}
+
+type localKey struct {
+	UserId string `json:"userId"`
+	// Secret key is needed to verify signatures so it can't be hashed,
+	// it's encrypted with master key if one is configured
+	SecretKey  string    `json:"secretKey"`
+	Encrypted  bool      `json:"encrypted,omitempty"`
+	Status     string    `json:"status"`
+	CreateTime time.Time `json:"createTime"`
+}
+
+type localIamData struct {
+	Users map[string]*localUser `json:"users"`
+	Keys  map[string]*localKey  `json:"keys"`
+}
+
+func GetIamClient(config map[string]interface{}) (interface{}, error) {
+	helper.Logger.Info("Get local IAM plugin config path:", config["path"])
+	path, _ := config["path"].(string)
+	if path == "" {
+		return nil, errors.New("path of local IAM file is not set")
+	}
+	c := &LocalIamClient{path: path}
+	if masterKey, _ := config["master_key"].(string); masterKey != "" {
+		key := sha256.Sum256([]byte(masterKey))
+		block, err := aes.NewCipher(key[:])
+		if err != nil {
+			return nil, err
+		}
+		c.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		helper.Logger.Warn("master_key of local IAM is not set, secret keys are stored in plain text")
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+	c.lockFile, err = os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = c.read(func() error { return nil })
+	if err != nil {
+		return nil, err
+	}
+	return interface{}(c), nil
+}
+
+// Users and keys are kept in a JSON file, which could be shared by yig and
+// tools on the same host: writers are serialized by flock on a lock file and
+// the file is reloaded whenever it's replaced by others
+type LocalIamClient struct {
+	mutex    sync.Mutex
+	path     string
+	lockFile *os.File
+	aead     cipher.AEAD // encrypts secret keys, nil if master key is not set
+	fileInfo os.FileInfo // of the loaded file, nil forces reload
+	data     localIamData
+}
+
+func (c *LocalIamClient) load() error {
+	info, err := os.Stat(c.path)
+	if os.IsNotExist(err) {
+		c.fileInfo = nil
+		c.data = localIamData{
+			Users: make(map[string]*localUser),
+			Keys:  make(map[string]*localKey),
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if c.fileInfo != nil && os.SameFile(info, c.fileInfo) &&
+		info.ModTime().Equal(c.fileInfo.ModTime()) {
+		return nil
+	}
+	content, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var data localIamData
+	err = json.Unmarshal(content, &data)
+	if err != nil {
+		return errors.New("corrupted local IAM file " + c.path + ": " + err.Error())
+	}
+	if data.Users == nil {
+		data.Users = make(map[string]*localUser)
+	}
+	if data.Keys == nil {
+		data.Keys = make(map[string]*localKey)
+	}
+	c.data = data
+	c.fileInfo = info
+	return nil
+}
+
+// Replace the file atomically, so readers never see a partial one
+func (c *LocalIamClient) save() (err error) {
+	content, err := json.MarshalIndent(c.data, "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := c.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, content, 0600)
+	if err != nil {
+		return
+	}
+	err = os.Rename(tmpPath, c.path)
+	if err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+	c.fileInfo, err = os.Stat(c.path)
+	return
+}
+
+func (c *LocalIamClient) withLock(how int, fn func() error) (err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	err = syscall.Flock(int(c.lockFile.Fd()), how)
+	if err != nil {
+		return
+	}
+	defer syscall.Flock(int(c.lockFile.Fd()), syscall.LOCK_UN)
+	err = c.load()
+	if err != nil {
+		return
+	}
+	return fn()
+}
+
+func (c *LocalIamClient) read(fn func() error) error {
+	return c.withLock(syscall.LOCK_SH, fn)
+}
+
+// Run fn and save changes it made, changes are discarded if fn fails
+func (c *LocalIamClient) update(fn func() error) error {
+	return c.withLock(syscall.LOCK_EX, func() error {
+		err := fn()
+		if err != nil {
+			// force reload since data may be partially changed
+			c.fileInfo = nil
+			return err
+		}
+		return c.save()
+	})
+}
+
+func (c *LocalIamClient) encryptSecret(secret string) (string, bool, error) {
+	if c.aead == nil {
+		return secret, false, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", false, err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), true, nil
+}
+
+func (c *LocalIamClient) decryptSecret(key *localKey) (string, error) {
+	if !key.Encrypted {
+		return key.SecretKey, nil
+	}
+	if c.aead == nil {
+		return "", errors.New("master_key of local IAM is needed to decrypt secret keys")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(key.SecretKey)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("invalid encrypted secret key")
+	}
+	secret, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+func (c *LocalIamClient) credentialOf(accessKey string, key *localKey) (credential common.Credential, err error) {
+	secret, err := c.decryptSecret(key)
+	if err != nil {
+		return
+	}
+	credential = common.Credential{
+		UserId:          key.UserId,
+		DisplayName:     key.UserId,
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secret,
+	}
+	if user, ok := c.data.Users[key.UserId]; ok && user.DisplayName != "" {
+		credential.DisplayName = user.DisplayName
+	}
+	return credential, nil
+}
+
+// Active keys of user
+func (c *LocalIamClient) GetKeysByUid(uid string) (credentials []common.Credential, err error) {
+	err = c.read(func() error {
+		for accessKey, key := range c.data.Keys {
+			if key.UserId != uid || key.Status != iam.KEY_STATUS_ACTIVE {
+				continue
+			}
+			credential, err := c.credentialOf(accessKey, key)
+			if err != nil {
+				return err
+			}
+			credentials = append(credentials, credential)
+		}
+		return nil
+	})
+	return
+}
+
+func (c *LocalIamClient) GetCredential(accessKey string) (credential common.Credential, err error) {
+	err = c.read(func() error {
+		key, ok := c.data.Keys[accessKey]
+		if !ok {
+			return common.ErrAccessKeyNotExist
+		}
+		if key.Status != iam.KEY_STATUS_ACTIVE {
+			return errKeyNotActive
+		}
+		credential, err = c.credentialOf(accessKey, key)
+		return err
+	})
+	return
+}
+
+func (c *LocalIamClient) GetIdentityPolicies(userId string) (policies []string, err error) {
+	err = c.read(func() error {
+		if user, ok := c.data.Users[userId]; ok {
+			for _, p := range user.Policies {
+				policies = append(policies, string(p))
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (c *LocalIamClient) CreateUser(userId, displayName string) error {
+	if userId == "" {
+		return errInvalidUserId
+	}
+	return c.update(func() error {
+		if _, ok := c.data.Users[userId]; ok {
+			return errUserExists
+		}
+		c.data.Users[userId] = &localUser{
+			DisplayName: displayName,
+			CreateTime:  time.Now().UTC(),
+		}
+		return nil
+	})
+}
+
+func (c *LocalIamClient) DeleteUser(userId string) error {
+	return c.update(func() error {
+		if _, ok := c.data.Users[userId]; !ok {
+			return errNoSuchUser
+		}
+		delete(c.data.Users, userId)
+		for accessKey, key := range c.data.Keys {
+			if key.UserId == userId {
+				delete(c.data.Keys, accessKey)
+			}
+		}
+		return nil
+	})
+}
+
+func randomString(n int, characters string) (string, error) {
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(characters)))
+	for i := range b {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = characters[r.Int64()]
+	}
+	return string(b), nil
+}
+
+func (c *LocalIamClient) CreateKey(userId string) (credential common.Credential, err error) {
+	err = c.update(func() error {
+		user, ok := c.data.Users[userId]
+		if !ok {
+			return errNoSuchUser
+		}
+		var accessKey string
+		for {
+			accessKey, err = randomString(accessKeyLength, keyCharacters)
+			if err != nil {
+				return err
+			}
+			if _, ok := c.data.Keys[accessKey]; !ok {
+				break
+			}
+		}
+		secret, err := randomString(secretKeyLength, secretCharacters)
+		if err != nil {
+			return err
+		}
+		stored, encrypted, err := c.encryptSecret(secret)
+		if err != nil {
+			return err
+		}
+		c.data.Keys[accessKey] = &localKey{
+			UserId:     userId,
+			SecretKey:  stored,
+			Encrypted:  encrypted,
+			Status:     iam.KEY_STATUS_ACTIVE,
+			CreateTime: time.Now().UTC(),
+		}
+		credential = common.Credential{
+			UserId:          userId,
+			DisplayName:     helper.Ternary(user.DisplayName == "", userId, user.DisplayName).(string),
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secret,
+		}
+		return nil
+	})
+	return
+}
+
+func (c *LocalIamClient) SetKeyStatus(accessKey, status string) error {
+	return c.update(func() error {
+		key, ok := c.data.Keys[accessKey]
+		if !ok {
+			return common.ErrAccessKeyNotExist
+		}
+		key.Status = status
+		return nil
+	})
+}
+
+func (c *LocalIamClient) DeleteKey(accessKey string) error {
+	return c.update(func() error {
+		if _, ok := c.data.Keys[accessKey]; !ok {
+			return common.ErrAccessKeyNotExist
+		}
+		delete(c.data.Keys, accessKey)
+		return nil
+	})
+}
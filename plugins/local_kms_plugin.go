@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/journeymidnight/yig/crypto"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/mods"
+)
+
+const (
+	pluginName   = "local_kms"
+	defaultKeyID = "yig"
+)
+
+//The variable MUST be named as Exported.
+//the code in yig-plugin will lookup this symbol
+var Exported = mods.YigPlugin{
+	Name:       pluginName,
+	PluginType: mods.KMS_PLUGIN,
+	Create:     GetLocalKMSClient,
+}
+
+// Master keys are kept in a local keyring file instead of Vault, keys could be
+// rotated and existing data keys re-sealed by tools/rewrap.go
+func GetLocalKMSClient(config map[string]interface{}) (interface{}, error) {
+	helper.Logger.Info("Get local KMS plugin config path:", config["path"])
+	path, _ := config["path"].(string)
+	if path == "" {
+		return nil, errors.New("path of local KMS keyring is not set")
+	}
+	keyID, _ := config["key_id"].(string)
+	if keyID == "" {
+		keyID = defaultKeyID
+	}
+	autoCreate, _ := config["auto_create"].(bool)
+	kms, err := crypto.NewLocalKMS(path, keyID, autoCreate)
+	if err != nil {
+		return nil, err
+	}
+	return kms, nil
+}
@@ -21,6 +21,12 @@ var Exported = YigPlugin{
 
 var downloadBufPool sync.Pool
 
+func init() {
+	downloadBufPool.New = func() interface{} {
+		return make([]byte, helper.CONFIG.DownloadBufPoolSize)
+	}
+}
+
 func GetCompressClient(config map[string]interface{}) (interface{}, error) {
 	snappy := SnappyCompress{}
 	return snappy, nil
@@ -31,39 +37,54 @@ type SnappyCompress struct{}
 func (s SnappyCompress) CompressReader(reader io.Reader) io.Reader {
 	pipeReader, pipeWriter := io.Pipe()
 	go func() {
-		defer pipeWriter.Close()
-		downloadBufPool.New = func() interface{} {
-			return make([]byte, helper.CONFIG.DownloadBufPoolSize)
-		}
 		buffer := downloadBufPool.Get().([]byte)
-		_, err := io.CopyBuffer(snappy.NewBufferedWriter(pipeWriter), reader, buffer)
-		downloadBufPool.Put(buffer)
+		defer downloadBufPool.Put(buffer)
+		snappyWriter := snappy.NewBufferedWriter(pipeWriter)
+		_, err := io.CopyBuffer(snappyWriter, reader, buffer)
+		if err == nil {
+			err = snappyWriter.Close()
+		}
 		if err != nil {
 			helper.Logger.Error("Unable to read an object need compress:", err)
-			pipeWriter.CloseWithError(err)
-			return
 		}
+		pipeWriter.CloseWithError(err)
 	}()
 	return pipeReader
 }
 
-func (s SnappyCompress) CompressWriter(writer io.Writer) io.Writer {
+// decompressWriter decompresses the snappy stream written to it into
+// the underlying writer
+type decompressWriter struct {
+	pipeWriter *io.PipeWriter
+	done       chan error
+}
+
+func (d *decompressWriter) Write(p []byte) (int, error) {
+	return d.pipeWriter.Write(p)
+}
+
+func (d *decompressWriter) Close() error {
+	d.pipeWriter.Close()
+	return <-d.done
+}
+
+func (s SnappyCompress) CompressWriter(writer io.Writer) io.WriteCloser {
 	pipeReader, pipeWriter := io.Pipe()
+	d := &decompressWriter{
+		pipeWriter: pipeWriter,
+		done:       make(chan error, 1),
+	}
 	go func() {
-		defer pipeReader.Close()
-		downloadBufPool.New = func() interface{} {
-			return make([]byte, helper.CONFIG.DownloadBufPoolSize)
-		}
 		buffer := downloadBufPool.Get().([]byte)
-		_, err := io.CopyBuffer(writer, pipeReader, buffer)
-		downloadBufPool.Put(buffer)
+		defer downloadBufPool.Put(buffer)
+		_, err := io.CopyBuffer(writer, snappy.NewReader(pipeReader), buffer)
 		if err != nil {
-			helper.Logger.Error("Unable to read an object need compress:", err)
-			pipeWriter.CloseWithError(err)
-			return
+			helper.Logger.Error("Unable to decompress an object:", err)
 		}
+		pipeReader.CloseWithError(err)
+		d.done <- err
 	}()
-	return pipeWriter
+	return d
 }
 
 func (s SnappyCompress) IsCompressible(objectName, mtype string) bool {
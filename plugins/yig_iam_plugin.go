@@ -172,3 +172,9 @@ func (c YigIamClient) GetCredential(accessKey string) (credential common.Credent
 
 	return
 }
+
+// Identity policies are not provided by yig IAM yet, only bucket ACLs and
+// bucket policies apply to its users
+func (c YigIamClient) GetIdentityPolicies(userId string) (policies []string, err error) {
+	return
+}
@@ -0,0 +1,472 @@
+package s3select
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/journeymidnight/yig/error"
+)
+
+// Values are nil(NULL or missing), int64, float64, string, bool,
+// and *jsonObject or []interface{} from JSON input
+type value interface{}
+
+type expr interface {
+	// r is nil when aggregates are finally evaluated
+	eval(r record) (value, error)
+}
+
+func toString(v value) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// Numbers in CSV are strings, so strings are parsed when numbers are needed
+func toNumber(v value) (value, bool) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+func toFloat(v value) float64 {
+	if i, ok := v.(int64); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+func toBool(v value) (value, bool) {
+	switch v := v.(type) {
+	case nil:
+		return nil, true
+	case bool:
+		return v, true
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func compareNumbers(a, b value) int {
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			switch {
+			case ai < bi:
+				return -1
+			case ai > bi:
+				return 1
+			}
+			return 0
+		}
+	}
+	af, bf := toFloat(a), toFloat(b)
+	switch {
+	case af < bf:
+		return -1
+	case af > bf:
+		return 1
+	}
+	return 0
+}
+
+// Compare two values, ok is false if they are not comparable, e.g. one of
+// them is NULL
+func compareValues(a, b value) (result int, ok bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	_, aIsString := a.(string)
+	_, bIsString := b.(string)
+	if aIsString && bIsString {
+		return strings.Compare(a.(string), b.(string)), true
+	}
+	if an, ok := toNumber(a); ok {
+		if bn, ok := toNumber(b); ok {
+			return compareNumbers(an, bn), true
+		}
+		return 0, false
+	}
+	if ab, ok := toBool(a); ok && ab != nil {
+		if bb, ok := toBool(b); ok && bb != nil {
+			switch {
+			case ab == bb:
+				return 0, true
+			case bb.(bool):
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+type literal struct {
+	value value
+}
+
+func (l *literal) eval(r record) (value, error) {
+	return l.value, nil
+}
+
+type columnRef struct {
+	path []pathElement
+}
+
+func (c *columnRef) eval(r record) (value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return r.get(c.path), nil
+}
+
+type compareExpr struct {
+	op          string
+	left, right expr
+}
+
+func (c *compareExpr) eval(r record) (value, error) {
+	left, err := c.left.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.right.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := compareValues(left, right)
+	if !ok {
+		return nil, nil
+	}
+	switch c.op {
+	case "=":
+		return result == 0, nil
+	case "!=", "<>":
+		return result != 0, nil
+	case "<":
+		return result < 0, nil
+	case "<=":
+		return result <= 0, nil
+	case ">":
+		return result > 0, nil
+	default: // >=
+		return result >= 0, nil
+	}
+}
+
+// AND and OR in three-valued logic, where NULL means unknown
+type logicalExpr struct {
+	or          bool
+	left, right expr
+}
+
+func (l *logicalExpr) operand(e expr, r record) (value, error) {
+	v, err := e.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	v, _ = toBool(v)
+	return v, nil
+}
+
+func (l *logicalExpr) eval(r record) (value, error) {
+	left, err := l.operand(l.left, r)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit: false AND x, true OR x
+	if left != nil && left.(bool) == l.or {
+		return l.or, nil
+	}
+	right, err := l.operand(l.right, r)
+	if err != nil {
+		return nil, err
+	}
+	if right != nil && right.(bool) == l.or {
+		return l.or, nil
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	return !l.or, nil
+}
+
+type notExpr struct {
+	expr expr
+}
+
+func (n *notExpr) eval(r record) (value, error) {
+	v, err := n.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	v, _ = toBool(v)
+	if v == nil {
+		return nil, nil
+	}
+	return !v.(bool), nil
+}
+
+type negateExpr struct {
+	expr expr
+}
+
+func (n *negateExpr) eval(r record) (value, error) {
+	v, err := n.expr.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	number, ok := toNumber(v)
+	if !ok {
+		return nil, ErrIllegalSelectArgument
+	}
+	if i, ok := number.(int64); ok {
+		return -i, nil
+	}
+	return -number.(float64), nil
+}
+
+type isNullExpr struct {
+	expr   expr
+	negate bool
+}
+
+func (i *isNullExpr) eval(r record) (value, error) {
+	v, err := i.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	return (v == nil) != i.negate, nil
+}
+
+type likeExpr struct {
+	expr, pattern, escape expr
+	negate                bool
+	regexp                *regexp.Regexp // compiled if pattern is constant
+}
+
+func (l *likeExpr) eval(r record) (value, error) {
+	v, err := l.expr.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	re := l.regexp
+	if re == nil {
+		pattern, err := l.pattern.eval(r)
+		if err != nil || pattern == nil {
+			return nil, err
+		}
+		var escape value
+		if l.escape != nil {
+			escape, err = l.escape.eval(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		re, err = likeToRegexp(toString(pattern), toString(escape))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return re.MatchString(toString(v)) != l.negate, nil
+}
+
+type betweenExpr struct {
+	expr, low, high expr
+	negate          bool
+}
+
+func (b *betweenExpr) eval(r record) (value, error) {
+	v, err := b.expr.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	low, err := b.low.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	high, err := b.high.eval(r)
+	if err != nil {
+		return nil, err
+	}
+	lowResult, ok := compareValues(v, low)
+	if !ok {
+		return nil, nil
+	}
+	highResult, ok := compareValues(v, high)
+	if !ok {
+		return nil, nil
+	}
+	return (lowResult >= 0 && highResult <= 0) != b.negate, nil
+}
+
+type inExpr struct {
+	expr   expr
+	list   []expr
+	negate bool
+}
+
+func (in *inExpr) eval(r record) (value, error) {
+	v, err := in.expr.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	for _, e := range in.list {
+		item, err := e.eval(r)
+		if err != nil {
+			return nil, err
+		}
+		if result, ok := compareValues(v, item); ok && result == 0 {
+			return !in.negate, nil
+		}
+	}
+	return in.negate, nil
+}
+
+type castExpr struct {
+	expr     expr
+	typeName string
+}
+
+func (c *castExpr) eval(r record) (value, error) {
+	v, err := c.expr.eval(r)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	switch c.typeName {
+	case "INT", "INTEGER", "BIGINT":
+		if b, ok := v.(bool); ok {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		number, ok := toNumber(v)
+		if !ok {
+			return nil, ErrSelectCastFailed
+		}
+		if f, ok := number.(float64); ok {
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, ErrSelectCastFailed
+			}
+			return int64(f), nil
+		}
+		return number, nil
+	case "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
+		number, ok := toNumber(v)
+		if !ok {
+			return nil, ErrSelectCastFailed
+		}
+		return toFloat(number), nil
+	case "BOOL", "BOOLEAN":
+		if number, ok := toNumber(v); ok {
+			return toFloat(number) != 0, nil
+		}
+		b, ok := toBool(v)
+		if !ok {
+			return nil, ErrSelectCastFailed
+		}
+		return b, nil
+	default: // STRING, VARCHAR, CHAR
+		return toString(v), nil
+	}
+}
+
+type aggregate struct {
+	function string
+	arg      expr // nil for COUNT(*)
+
+	count int64
+	sum   value // int64 or float64
+	best  value // of MIN or MAX
+}
+
+// Fold value of a matched record into the aggregate
+func (a *aggregate) accumulate(r record) error {
+	if a.arg == nil {
+		a.count++
+		return nil
+	}
+	v, err := a.arg.eval(r)
+	if err != nil || v == nil {
+		return err
+	}
+	a.count++
+	switch a.function {
+	case "SUM", "AVG":
+		number, ok := toNumber(v)
+		if !ok {
+			return ErrIllegalSelectArgument
+		}
+		sumInt, sumIsInt := a.sum.(int64)
+		numberInt, numberIsInt := number.(int64)
+		switch {
+		case a.sum == nil:
+			a.sum = number
+		case sumIsInt && numberIsInt:
+			a.sum = sumInt + numberInt
+		default:
+			a.sum = toFloat(a.sum) + toFloat(number)
+		}
+	case "MIN", "MAX":
+		// compare numerically if possible, CSV values are strings
+		if number, ok := toNumber(v); ok {
+			v = number
+		}
+		if a.best == nil {
+			a.best = v
+			return nil
+		}
+		result, ok := compareValues(v, a.best)
+		if !ok {
+			return ErrIllegalSelectArgument
+		}
+		if (a.function == "MIN" && result < 0) || (a.function == "MAX" && result > 0) {
+			a.best = v
+		}
+	}
+	return nil
+}
+
+func (a *aggregate) eval(r record) (value, error) {
+	switch a.function {
+	case "COUNT":
+		return a.count, nil
+	case "SUM":
+		return a.sum, nil
+	case "AVG":
+		if a.count == 0 {
+			return nil, nil
+		}
+		return toFloat(a.sum) / float64(a.count), nil
+	default: // MIN, MAX
+		return a.best, nil
+	}
+}
@@ -0,0 +1,97 @@
+package s3select
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Messages are in the binary event stream encoding:
+//
+//	total length(4) | headers length(4) | prelude CRC(4) |
+//	headers | payload | message CRC(4)
+//
+// where every header is name length(1) | name | type(1, 7 for string) |
+// value length(2) | value, and CRCs are CRC32 of everything before them.
+// Reference: https://docs.aws.amazon.com/AmazonS3/latest/API/RESTSelectObjectAppendix.html
+
+const (
+	preludeLength     = 12
+	headerTypeString  = 7
+	messageCRCLength  = 4
+	messageTypeEvent  = "event"
+	messageTypeError  = "error"
+	contentTypeBinary = "application/octet-stream"
+	contentTypeXml    = "text/xml"
+)
+
+type messageHeader struct {
+	name, value string
+}
+
+func encodeMessage(headers []messageHeader, payload []byte) []byte {
+	var encodedHeaders bytes.Buffer
+	for _, h := range headers {
+		encodedHeaders.WriteByte(byte(len(h.name)))
+		encodedHeaders.WriteString(h.name)
+		encodedHeaders.WriteByte(headerTypeString)
+		binary.Write(&encodedHeaders, binary.BigEndian, uint16(len(h.value)))
+		encodedHeaders.WriteString(h.value)
+	}
+
+	totalLength := preludeLength + encodedHeaders.Len() + len(payload) + messageCRCLength
+	message := make([]byte, 0, totalLength)
+	message = appendUint32(message, uint32(totalLength))
+	message = appendUint32(message, uint32(encodedHeaders.Len()))
+	message = appendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, encodedHeaders.Bytes()...)
+	message = append(message, payload...)
+	return appendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var encoded [4]byte
+	binary.BigEndian.PutUint32(encoded[:], v)
+	return append(b, encoded[:]...)
+}
+
+func eventHeaders(eventType, contentType string) []messageHeader {
+	headers := []messageHeader{{":event-type", eventType}}
+	if contentType != "" {
+		headers = append(headers, messageHeader{":content-type", contentType})
+	}
+	return append(headers, messageHeader{":message-type", messageTypeEvent})
+}
+
+func writeRecordsMessage(w io.Writer, records []byte) error {
+	_, err := w.Write(encodeMessage(eventHeaders("Records", contentTypeBinary), records))
+	return err
+}
+
+func writeStatsMessage(w io.Writer, bytesScanned, bytesProcessed, bytesReturned int64) error {
+	var payload bytes.Buffer
+	payload.WriteString(`<?xml version="1.0" encoding="UTF-8"?><Stats><BytesScanned>`)
+	payload.WriteString(toString(bytesScanned))
+	payload.WriteString("</BytesScanned><BytesProcessed>")
+	payload.WriteString(toString(bytesProcessed))
+	payload.WriteString("</BytesProcessed><BytesReturned>")
+	payload.WriteString(toString(bytesReturned))
+	payload.WriteString("</BytesReturned></Stats>")
+	_, err := w.Write(encodeMessage(eventHeaders("Stats", contentTypeXml), payload.Bytes()))
+	return err
+}
+
+func writeEndMessage(w io.Writer) error {
+	_, err := w.Write(encodeMessage(eventHeaders("End", ""), nil))
+	return err
+}
+
+func writeErrorMessage(w io.Writer, code, message string) error {
+	_, err := w.Write(encodeMessage([]messageHeader{
+		{":error-code", code},
+		{":error-message", message},
+		{":message-type", messageTypeError},
+	}, nil))
+	return err
+}
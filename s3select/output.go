@@ -0,0 +1,73 @@
+package s3select
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/journeymidnight/yig/api/datatype"
+)
+
+type recordWriter interface {
+	// Append a record to buffer
+	write(buffer *bytes.Buffer, names []string, values []value) error
+}
+
+type csvWriter struct {
+	config *datatype.CsvOutput
+	// characters that make a field quoted when QuoteFields is ASNEEDED
+	specialCharacters string
+}
+
+func newCsvWriter(config *datatype.CsvOutput) *csvWriter {
+	return &csvWriter{
+		config: config,
+		specialCharacters: config.FieldDelimiter + config.RecordDelimiter +
+			config.QuoteCharacter + config.QuoteEscapeCharacter + "\r\n",
+	}
+}
+
+func (w *csvWriter) write(buffer *bytes.Buffer, names []string, values []value) error {
+	for i, v := range values {
+		if i > 0 {
+			buffer.WriteString(w.config.FieldDelimiter)
+		}
+		field := toString(v)
+		if w.config.QuoteFields == datatype.CsvQuoteFieldsAlways ||
+			strings.ContainsAny(field, w.specialCharacters) {
+			quote := w.config.QuoteCharacter
+			buffer.WriteString(quote)
+			buffer.WriteString(strings.Replace(field, quote, w.config.QuoteEscapeCharacter+quote, -1))
+			buffer.WriteString(quote)
+		} else {
+			buffer.WriteString(field)
+		}
+	}
+	buffer.WriteString(w.config.RecordDelimiter)
+	return nil
+}
+
+type jsonWriter struct {
+	config *datatype.JsonOutput
+}
+
+// Missing and NULL values are left out
+func (w *jsonWriter) write(buffer *bytes.Buffer, names []string, values []value) error {
+	o := &jsonObject{values: make(map[string]value)}
+	for i, name := range names {
+		if values[i] == nil {
+			continue
+		}
+		if _, ok := o.values[name]; !ok {
+			o.keys = append(o.keys, name)
+		}
+		o.values[name] = values[i]
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	buffer.Write(b)
+	buffer.WriteString(w.config.RecordDelimiter)
+	return nil
+}
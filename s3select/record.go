@@ -0,0 +1,461 @@
+package s3select
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+)
+
+type record interface {
+	// Value of the column at path, nil if it's missing
+	get(path []pathElement) value
+	// Names and values of all columns, for SELECT *
+	columns() (names []string, values []value)
+}
+
+type recordReader interface {
+	// Returns io.EOF when there are no more records
+	read() (record, error)
+}
+
+// Column name used to reference CSV columns by position, e.g. _1
+func positionalName(i int) string {
+	return "_" + strconv.Itoa(i+1)
+}
+
+type csvRecord struct {
+	header []string // nil unless FileHeaderInfo is USE
+	fields []string
+}
+
+func (c *csvRecord) get(path []pathElement) value {
+	if len(path) != 1 || path[0].index >= 0 {
+		return nil
+	}
+	name := path[0].name
+	for i, h := range c.header {
+		if h == name && i < len(c.fields) {
+			return c.fields[i]
+		}
+	}
+	if !path[0].quoted {
+		for i, h := range c.header {
+			if strings.EqualFold(h, name) && i < len(c.fields) {
+				return c.fields[i]
+			}
+		}
+	}
+	if strings.HasPrefix(name, "_") {
+		i, err := strconv.Atoi(name[1:])
+		if err == nil && i > 0 && i <= len(c.fields) {
+			return c.fields[i-1]
+		}
+	}
+	return nil
+}
+
+func (c *csvRecord) columns() (names []string, values []value) {
+	for i, field := range c.fields {
+		if i < len(c.header) {
+			names = append(names, c.header[i])
+		} else {
+			names = append(names, positionalName(i))
+		}
+		values = append(values, field)
+	}
+	return
+}
+
+// Reads CSV with arbitrary delimiters and quote characters, which
+// encoding/csv doesn't support. Quoted fields could contain delimiters,
+// a quote character in a quoted field is escaped by the escape character.
+type csvReader struct {
+	reader          *bufio.Reader
+	recordDelimiter []byte
+	// "\r\n" is also accepted if record delimiter is "\n"
+	altRecordDelimiter []byte
+	fieldDelimiter     []byte
+	quote              []byte
+	escape             []byte
+	comment            []byte
+	// first bytes of delimiters, quote and escape characters
+	special [256]bool
+
+	header []string
+}
+
+func newCsvReader(reader io.Reader, config *datatype.CsvInput) (*csvReader, error) {
+	r := &csvReader{
+		reader:          bufio.NewReader(reader),
+		recordDelimiter: []byte(config.RecordDelimiter),
+		fieldDelimiter:  []byte(config.FieldDelimiter),
+		quote:           []byte(config.QuoteCharacter),
+		escape:          []byte(config.QuoteEscapeCharacter),
+		comment:         []byte(config.Comments),
+	}
+	if config.RecordDelimiter == "\n" {
+		r.altRecordDelimiter = []byte("\r\n")
+	}
+	for _, s := range [][]byte{r.recordDelimiter, r.altRecordDelimiter, r.fieldDelimiter,
+		r.quote, r.escape} {
+		if len(s) > 0 {
+			r.special[s[0]] = true
+		}
+	}
+	switch config.FileHeaderInfo {
+	case datatype.CsvFileHeaderUse:
+		header, err := r.readFields()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		r.header = header
+	case datatype.CsvFileHeaderIgnore:
+		_, err := r.readFields()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Consume prefix if the input starts with it
+func (r *csvReader) consume(prefix []byte) (bool, error) {
+	if len(prefix) == 0 {
+		return false, nil
+	}
+	b, err := r.reader.Peek(len(prefix))
+	if len(b) < len(prefix) {
+		if err == io.EOF {
+			err = nil
+		}
+		return false, err
+	}
+	if !bytes.Equal(b, prefix) {
+		return false, nil
+	}
+	_, err = r.reader.Discard(len(prefix))
+	return true, err
+}
+
+func (r *csvReader) consumeRecordDelimiter() (bool, error) {
+	ok, err := r.consume(r.recordDelimiter)
+	if ok || err != nil {
+		return ok, err
+	}
+	return r.consume(r.altRecordDelimiter)
+}
+
+func (r *csvReader) skipLine() error {
+	for {
+		ok, err := r.consumeRecordDelimiter()
+		if ok || err != nil {
+			return err
+		}
+		_, err = r.reader.ReadByte()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *csvReader) readFields() ([]string, error) {
+	for {
+		fields, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		// skip comments and empty lines
+		if fields != nil {
+			return fields, nil
+		}
+	}
+}
+
+// Returns nil fields for comments and empty lines
+func (r *csvReader) readLine() (fields []string, err error) {
+	if ok, err := r.consume(r.comment); ok || err != nil {
+		if err == nil {
+			err = r.skipLine()
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var field bytes.Buffer
+	started := false  // anything of the line is read
+	quoted := false   // the current field is quoted
+	inQuotes := false // between quote characters
+	escapeIsQuote := bytes.Equal(r.escape, r.quote)
+	for {
+		b, err := r.reader.Peek(1)
+		if err == io.EOF {
+			if inQuotes {
+				return nil, ErrCSVParsing
+			}
+			if !started {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !r.special[b[0]] {
+			r.reader.ReadByte()
+			field.WriteByte(b[0])
+			started = true
+			continue
+		}
+
+		if inQuotes {
+			if !escapeIsQuote {
+				if ok, err := r.consume(r.escape); err != nil {
+					return nil, err
+				} else if ok {
+					// the escape character escapes quote and itself only
+					if ok, err := r.consume(r.quote); err != nil {
+						return nil, err
+					} else if ok {
+						field.Write(r.quote)
+					} else if ok, err := r.consume(r.escape); err != nil {
+						return nil, err
+					} else if ok {
+						field.Write(r.escape)
+					} else {
+						field.Write(r.escape)
+					}
+					continue
+				}
+			}
+			if ok, err := r.consume(r.quote); err != nil {
+				return nil, err
+			} else if ok {
+				if escapeIsQuote {
+					if ok, err := r.consume(r.quote); err != nil {
+						return nil, err
+					} else if ok {
+						field.Write(r.quote)
+						continue
+					}
+				}
+				inQuotes = false
+				continue
+			}
+		} else {
+			if ok, err := r.consumeRecordDelimiter(); err != nil {
+				return nil, err
+			} else if ok {
+				if !started {
+					return nil, nil
+				}
+				break
+			}
+			if ok, err := r.consume(r.fieldDelimiter); err != nil {
+				return nil, err
+			} else if ok {
+				fields = append(fields, field.String())
+				field.Reset()
+				quoted = false
+				started = true
+				continue
+			}
+			if field.Len() == 0 && !quoted {
+				if ok, err := r.consume(r.quote); err != nil {
+					return nil, err
+				} else if ok {
+					quoted = true
+					inQuotes = true
+					started = true
+					continue
+				}
+			}
+		}
+		r.reader.ReadByte()
+		field.WriteByte(b[0])
+		started = true
+	}
+	return append(fields, field.String()), nil
+}
+
+func (r *csvReader) read() (record, error) {
+	fields, err := r.readFields()
+	if err != nil {
+		return nil, err
+	}
+	return &csvRecord{header: r.header, fields: fields}, nil
+}
+
+// JSON object which keeps the order of keys
+type jsonObject struct {
+	keys   []string
+	values map[string]value
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (o *jsonObject) lookup(element pathElement) value {
+	if v, ok := o.values[element.name]; ok {
+		return v
+	}
+	if !element.quoted {
+		for _, key := range o.keys {
+			if strings.EqualFold(key, element.name) {
+				return o.values[key]
+			}
+		}
+	}
+	return nil
+}
+
+func (o *jsonObject) get(path []pathElement) value {
+	var v value = o
+	for _, element := range path {
+		switch current := v.(type) {
+		case *jsonObject:
+			if element.index >= 0 {
+				return nil
+			}
+			v = current.lookup(element)
+		case []interface{}:
+			if element.index < 0 || element.index >= len(current) {
+				return nil
+			}
+			v = current[element.index]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func (o *jsonObject) columns() (names []string, values []value) {
+	for _, key := range o.keys {
+		names = append(names, key)
+		values = append(values, o.values[key])
+	}
+	return
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+	pending []interface{} // records of a top level array
+}
+
+func newJsonReader(reader io.Reader) *jsonReader {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	return &jsonReader{decoder: decoder}
+}
+
+func (r *jsonReader) decodeValue() (value, error) {
+	t, err := r.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := &jsonObject{values: make(map[string]value)}
+			for r.decoder.More() {
+				key, err := r.decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := r.decodeValue()
+				if err != nil {
+					return nil, err
+				}
+				if _, ok := o.values[key.(string)]; !ok {
+					o.keys = append(o.keys, key.(string))
+				}
+				o.values[key.(string)] = v
+			}
+			_, err = r.decoder.Token() // }
+			return o, err
+		case '[':
+			array := make([]interface{}, 0)
+			for r.decoder.More() {
+				v, err := r.decodeValue()
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, v)
+			}
+			_, err = r.decoder.Token() // ]
+			return array, err
+		}
+		return nil, ErrJSONParsing
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, ErrJSONParsing
+		}
+		return f, nil
+	default: // string, bool or nil
+		return t, nil
+	}
+}
+
+// Records are top level objects, or objects in top level arrays
+func (r *jsonReader) read() (record, error) {
+	for len(r.pending) == 0 {
+		v, err := r.decodeValue()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			if _, ok := err.(ApiError); ok {
+				return nil, err
+			}
+			if _, ok := err.(*json.SyntaxError); ok || err == io.ErrUnexpectedEOF {
+				return nil, ErrJSONParsing
+			}
+			return nil, err
+		}
+		if array, ok := v.([]interface{}); ok {
+			r.pending = array
+			continue
+		}
+		r.pending = []interface{}{v}
+	}
+	v := r.pending[0]
+	r.pending = r.pending[1:]
+	o, ok := v.(*jsonObject)
+	if !ok {
+		return nil, ErrJSONParsing
+	}
+	return o, nil
+}
@@ -0,0 +1,201 @@
+package s3select
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+
+	"github.com/dustin/go-humanize"
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+)
+
+// Records are sent once this much output is buffered
+const recordsMessageSize = 64 * humanize.KiByte
+
+type countingReader struct {
+	reader io.Reader
+	n      int64
+	err    error // last error other than io.EOF
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.reader.Read(p)
+	c.n += int64(n)
+	if err != nil && err != io.EOF {
+		c.err = err
+	}
+	return
+}
+
+// Reports errors of the decompressor as invalid compression format, errors
+// of the compressed source are passed on
+type decompressReader struct {
+	reader io.Reader
+	source *countingReader
+}
+
+func (d decompressReader) Read(p []byte) (n int, err error) {
+	n, err = d.reader.Read(p)
+	if err != nil && err != io.EOF && err != d.source.err {
+		err = ErrInvalidCompressionFormat
+	}
+	return
+}
+
+// A parsed SelectObjectContent request, which could run over content of
+// the object
+type Selector struct {
+	request *datatype.SelectObjectContentRequest
+	query   *query
+	writer  recordWriter
+}
+
+// The request should be validated already
+func NewSelector(request *datatype.SelectObjectContentRequest) (*Selector, error) {
+	q, err := parseQuery(request.Expression)
+	if err != nil {
+		return nil, err
+	}
+	s := &Selector{request: request, query: q}
+	if request.OutputSerialization.CSV != nil {
+		s.writer = newCsvWriter(request.OutputSerialization.CSV)
+	} else {
+		s.writer = &jsonWriter{config: request.OutputSerialization.JSON}
+	}
+	return s, nil
+}
+
+// Run the query over object content read from reader, and write results to w
+// in Records messages followed by Stats and End messages. Once anything is
+// written, errors could only be reported by an error message, so the error is
+// also returned for logging.
+func (s *Selector) Run(reader io.Reader, w io.Writer) error {
+	err := s.run(reader, w)
+	if err != nil {
+		code, message := "InternalError", "We encountered an internal error, please try again."
+		if apiErr, ok := err.(ApiError); ok {
+			code, message = apiErr.AwsErrorCode(), apiErr.Description()
+		}
+		writeErrorMessage(w, code, message)
+	}
+	return err
+}
+
+func (s *Selector) records(reader io.Reader) (recordReader, error) {
+	input := s.request.InputSerialization
+	if input.CSV != nil {
+		return newCsvReader(reader, input.CSV)
+	}
+	return newJsonReader(reader), nil
+}
+
+func (s *Selector) project(r record) (names []string, values []value, err error) {
+	if s.query.projections == nil {
+		names, values = r.columns()
+		return
+	}
+	for _, p := range s.query.projections {
+		v, err := p.expr.eval(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, p.name)
+		values = append(values, v)
+	}
+	return
+}
+
+func (s *Selector) run(reader io.Reader, w io.Writer) (err error) {
+	scanned := &countingReader{reader: reader}
+	var input io.Reader = scanned
+	switch s.request.InputSerialization.CompressionType {
+	case datatype.SelectCompressionGzip:
+		gzipReader, err := gzip.NewReader(scanned)
+		if err != nil {
+			if err == scanned.err {
+				return err
+			}
+			return ErrInvalidCompressionFormat
+		}
+		defer gzipReader.Close()
+		input = decompressReader{reader: gzipReader, source: scanned}
+	case datatype.SelectCompressionBzip2:
+		input = decompressReader{reader: bzip2.NewReader(scanned), source: scanned}
+	}
+	processed := &countingReader{reader: input}
+	records, err := s.records(processed)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	var returned int64
+	flush := func() error {
+		if buffer.Len() == 0 {
+			return nil
+		}
+		returned += int64(buffer.Len())
+		err := writeRecordsMessage(w, buffer.Bytes())
+		buffer.Reset()
+		return err
+	}
+
+	q := s.query
+	for matched := int64(0); q.limit < 0 || matched < q.limit; {
+		r, err := records.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if q.where != nil {
+			v, err := q.where.eval(r)
+			if err != nil {
+				return err
+			}
+			if v, _ = toBool(v); v != true {
+				continue
+			}
+		}
+		matched++
+		if len(q.aggregates) > 0 {
+			for _, a := range q.aggregates {
+				if err = a.accumulate(r); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		names, values, err := s.project(r)
+		if err != nil {
+			return err
+		}
+		if err = s.writer.write(&buffer, names, values); err != nil {
+			return err
+		}
+		if buffer.Len() >= recordsMessageSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(q.aggregates) > 0 {
+		names, values, err := s.project(nil)
+		if err != nil {
+			return err
+		}
+		if err = s.writer.write(&buffer, names, values); err != nil {
+			return err
+		}
+	}
+	if err = flush(); err != nil {
+		return err
+	}
+	if err = writeStatsMessage(w, scanned.n, processed.n, returned); err != nil {
+		return err
+	}
+	return writeEndMessage(w)
+}
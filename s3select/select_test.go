@@ -0,0 +1,198 @@
+package s3select
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/stretchr/testify/assert"
+)
+
+type message struct {
+	headers map[string]string
+	payload string
+}
+
+func decodeMessages(t *testing.T, data []byte) (messages []message) {
+	for len(data) > 0 {
+		totalLength := binary.BigEndian.Uint32(data[0:4])
+		headersLength := binary.BigEndian.Uint32(data[4:8])
+		assert.Equal(t, crc32.ChecksumIEEE(data[0:8]), binary.BigEndian.Uint32(data[8:12]))
+		assert.Equal(t, crc32.ChecksumIEEE(data[:totalLength-4]),
+			binary.BigEndian.Uint32(data[totalLength-4:totalLength]))
+		m := message{headers: make(map[string]string)}
+		headers := data[12 : 12+headersLength]
+		for len(headers) > 0 {
+			nameLength := int(headers[0])
+			name := string(headers[1 : 1+nameLength])
+			assert.Equal(t, byte(headerTypeString), headers[1+nameLength])
+			valueLength := int(binary.BigEndian.Uint16(headers[2+nameLength:]))
+			m.headers[name] = string(headers[4+nameLength : 4+nameLength+valueLength])
+			headers = headers[4+nameLength+valueLength:]
+		}
+		m.payload = string(data[12+headersLength : totalLength-4])
+		messages = append(messages, m)
+		data = data[totalLength:]
+	}
+	return
+}
+
+func runSelect(t *testing.T, request *datatype.SelectObjectContentRequest, input []byte) (records string, messages []message, err error) {
+	request.ExpressionType = "SQL"
+	err = request.Validate()
+	if err != nil {
+		return
+	}
+	selector, err := NewSelector(request)
+	if err != nil {
+		return
+	}
+	var output bytes.Buffer
+	err = selector.Run(bytes.NewReader(input), &output)
+	messages = decodeMessages(t, output.Bytes())
+	for _, m := range messages {
+		if m.headers[":event-type"] == "Records" {
+			records += m.payload
+		}
+	}
+	return
+}
+
+const csvInput = `name,age,city
+Alice,30,"New York, NY"
+Bob,25,Boston
+"Carol ""C""",41,Chicago
+Dave,19,
+`
+
+func csvRequest(expression string) *datatype.SelectObjectContentRequest {
+	return &datatype.SelectObjectContentRequest{
+		Expression: expression,
+		InputSerialization: datatype.InputSerialization{
+			CSV: &datatype.CsvInput{FileHeaderInfo: "USE"},
+		},
+		OutputSerialization: datatype.OutputSerialization{CSV: &datatype.CsvOutput{}},
+	}
+}
+
+func TestSelect_Csv(t *testing.T) {
+	records, messages, err := runSelect(t,
+		csvRequest("SELECT s.name, s.city FROM S3Object s WHERE CAST(s.age AS INT) > 24 LIMIT 2"),
+		[]byte(csvInput))
+	assert.Nil(t, err)
+	assert.Equal(t, "Alice,\"New York, NY\"\nBob,Boston\n", records)
+	last := messages[len(messages)-1]
+	assert.Equal(t, "End", last.headers[":event-type"])
+	stats := messages[len(messages)-2]
+	assert.Equal(t, "Stats", stats.headers[":event-type"])
+	assert.Contains(t, stats.payload, "<BytesReturned>32</BytesReturned>")
+
+	records, _, err = runSelect(t,
+		csvRequest("SELECT * FROM S3Object WHERE name LIKE 'C%' OR city IS NULL OR city = ''"),
+		[]byte(csvInput))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"Carol \"\"C\"\"\",41,Chicago\nDave,19,\n", records)
+
+	records, _, err = runSelect(t,
+		csvRequest("SELECT COUNT(*), SUM(s.age), AVG(age), MIN(s.age), MAX(name) FROM S3Object s "+
+			"WHERE s._3 NOT IN ('Boston')"),
+		[]byte(csvInput))
+	assert.Nil(t, err)
+	assert.Equal(t, "3,90,30,19,Dave\n", records)
+}
+
+func TestSelect_CsvDelimiters(t *testing.T) {
+	request := csvRequest("SELECT _2, _1 FROM S3Object WHERE _1 BETWEEN 'a' AND 'az'")
+	request.InputSerialization.CSV = &datatype.CsvInput{
+		FieldDelimiter:       "|",
+		RecordDelimiter:      ";",
+		QuoteCharacter:       "'",
+		QuoteEscapeCharacter: "\\",
+		Comments:             "#",
+	}
+	request.OutputSerialization.CSV.QuoteFields = "ALWAYS"
+	records, _, err := runSelect(t, request, []byte(`#comment;a|'x|\'y';b|z;c|w`))
+	assert.Nil(t, err)
+	assert.Equal(t, "\"x|'y\",\"a\"\n", records)
+}
+
+func TestSelect_JsonLines(t *testing.T) {
+	input := `{"id": 1, "user": {"name": "alice", "tags": ["a", "b"]}, "score": 1.5}
+{"id": 2, "user": {"name": "bob"}, "score": 3}
+{"id": 3, "user": {"name": "carol", "tags": ["c"]}}
+`
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(input))
+	gz.Close()
+
+	request := &datatype.SelectObjectContentRequest{
+		Expression: "SELECT s.id, s.user.name AS who, s.user.tags[0] FROM S3Object[*] s " +
+			"WHERE s.user.name != 'bob'",
+		InputSerialization: datatype.InputSerialization{
+			CompressionType: "GZIP",
+			JSON:            &datatype.JsonInput{Type: "LINES"},
+		},
+		OutputSerialization: datatype.OutputSerialization{JSON: &datatype.JsonOutput{}},
+	}
+	records, messages, err := runSelect(t, request, compressed.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"id":1,"who":"alice","_3":"a"}`+"\n"+`{"id":3,"who":"carol","_3":"c"}`+"\n", records)
+	stats := messages[len(messages)-2].payload
+	assert.Contains(t, stats, "<BytesProcessed>"+toString(int64(len(input)))+"</BytesProcessed>")
+
+	request.Expression = "SELECT SUM(s.score), COUNT(s.score) AS n FROM S3Object s"
+	records, _, err = runSelect(t, request, compressed.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, `{"_1":4.5,"n":2}`+"\n", records)
+
+	request.Expression = "SELECT * FROM S3Object s LIMIT 1"
+	request.OutputSerialization = datatype.OutputSerialization{CSV: &datatype.CsvOutput{}}
+	records, _, err = runSelect(t, request, compressed.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, "1,\"{\"\"name\"\":\"\"alice\"\",\"\"tags\"\":[\"\"a\"\",\"\"b\"\"]}\",1.5\n", records)
+}
+
+func TestSelect_Errors(t *testing.T) {
+	for _, expression := range []string{
+		"SELECT",
+		"SELECT * FROM table",
+		"SELECT name, COUNT(*) FROM S3Object",
+		"SELECT * FROM S3Object WHERE COUNT(*) > 1",
+		"SELECT * FROM S3Object LIMIT -1",
+		"SELECT UNKNOWN(name) FROM S3Object",
+		"SELECT 'unterminated FROM S3Object",
+	} {
+		_, err := parseQuery(expression)
+		assert.Equal(t, ErrInvalidSelectQuery, err, expression)
+	}
+
+	_, messages, err := runSelect(t, csvRequest("SELECT SUM(name) FROM S3Object"), []byte(csvInput))
+	assert.Equal(t, ErrIllegalSelectArgument, err)
+	last := messages[len(messages)-1]
+	assert.Equal(t, "error", last.headers[":message-type"])
+	assert.Equal(t, "IllegalSqlFunctionArgument", last.headers[":error-code"])
+
+	request := csvRequest("SELECT * FROM S3Object")
+	request.InputSerialization.CompressionType = "GZIP"
+	_, _, err = runSelect(t, request, []byte(csvInput))
+	assert.Equal(t, ErrInvalidCompressionFormat, err)
+
+	_, _, err = runSelect(t, csvRequest("SELECT * FROM S3Object"), []byte(`a,"b`))
+	assert.Equal(t, ErrCSVParsing, err)
+
+	request.InputSerialization = datatype.InputSerialization{JSON: &datatype.JsonInput{}}
+	_, _, err = runSelect(t, request, []byte(`{"a": 1} [2]`))
+	assert.Equal(t, ErrJSONParsing, err)
+
+	_, err = datatype.ParseSelectRequest(strings.NewReader(
+		"<SelectObjectContentRequest><Expression>SELECT * FROM S3Object</Expression>" +
+			"<ExpressionType>SQL</ExpressionType><InputSerialization><Parquet/></InputSerialization>" +
+			"</SelectObjectContentRequest>"))
+	assert.Equal(t, ErrInvalidDataSource, err)
+}
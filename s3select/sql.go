@@ -0,0 +1,660 @@
+package s3select
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	. "github.com/journeymidnight/yig/error"
+)
+
+// The SQL subset supported:
+//
+//	SELECT * | expression [[AS] alias], ...
+//	FROM S3Object[[*]] [[AS] alias]
+//	[WHERE condition]
+//	[LIMIT number]
+//
+// where expressions are column references(_1, s._1, s.name, s."Name", s.a.b[0]),
+// literals, comparisons, [NOT] LIKE, IS [NOT] NULL, [NOT] BETWEEN, [NOT] IN,
+// AND, OR, NOT, CAST(expression AS type) and aggregates COUNT, SUM, AVG, MIN
+// and MAX, which could not be mixed with columns outside aggregates.
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+var reservedWords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "LIMIT": true, "AS": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "ESCAPE": true,
+	"IS": true, "NULL": true, "TRUE": true, "FALSE": true, "BETWEEN": true,
+	"IN": true, "CAST": true,
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isIdentPart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// Read a string quoted by quote, which is escaped by doubling it
+func readQuoted(s []rune, i int, quote rune) (text string, next int, err error) {
+	var b strings.Builder
+	for i++; i < len(s); i++ {
+		if s[i] == quote {
+			if i+1 < len(s) && s[i+1] == quote {
+				b.WriteRune(quote)
+				i++
+				continue
+			}
+			return b.String(), i + 1, nil
+		}
+		b.WriteRune(s[i])
+	}
+	return "", 0, ErrInvalidSelectQuery
+}
+
+func tokenize(expression string) (tokens []token, err error) {
+	s := []rune(expression)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && isIdentPart(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(s[start:i])})
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(s) && unicode.IsDigit(s[i+1])):
+			start := i
+			for i < len(s) && (unicode.IsDigit(s[i]) || s[i] == '.') {
+				i++
+			}
+			if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+				i++
+				if i < len(s) && (s[i] == '+' || s[i] == '-') {
+					i++
+				}
+				for i < len(s) && unicode.IsDigit(s[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, string(s[start:i])})
+		case c == '\'':
+			var text string
+			text, i, err = readQuoted(s, i, '\'')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenString, text})
+		case c == '"':
+			var text string
+			text, i, err = readQuoted(s, i, '"')
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{tokenQuotedIdent, text})
+		default:
+			if i+1 < len(s) {
+				switch op := string(s[i : i+2]); op {
+				case "<=", ">=", "<>", "!=":
+					tokens = append(tokens, token{tokenOperator, op})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("=<>*,().[]-+", c) {
+				return nil, ErrInvalidSelectQuery
+			}
+			tokens = append(tokens, token{tokenOperator, string(c)})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// One step of a column path, either a name or an array index
+type pathElement struct {
+	name   string
+	quoted bool // quoted names are case sensitive
+	index  int  // -1 if it's a name
+}
+
+type projection struct {
+	expr expr
+	name string // name of the column in JSON output
+}
+
+type query struct {
+	projections []projection // nil for SELECT *
+	where       expr         // nil if there is no WHERE clause
+	limit       int64        // -1 if there is no LIMIT clause
+	aggregates  []*aggregate
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+
+	columns    []*columnRef
+	aggregates []*aggregate
+	// column references outside aggregates in projections
+	columnsOutsideAggregate int
+	inAggregate             bool
+	inWhere                 bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenIdent && strings.EqualFold(t.text, keyword)
+}
+
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return ErrInvalidSelectQuery
+	}
+	return nil
+}
+
+func (p *parser) accept(operator string) bool {
+	t := p.peek()
+	if t.kind == tokenOperator && t.text == operator {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(operator string) error {
+	if !p.accept(operator) {
+		return ErrInvalidSelectQuery
+	}
+	return nil
+}
+
+// An identifier which is not a reserved word, or a quoted one
+func (p *parser) isName() bool {
+	t := p.peek()
+	return t.kind == tokenQuotedIdent ||
+		(t.kind == tokenIdent && !reservedWords[strings.ToUpper(t.text)])
+}
+
+func (p *parser) parseAlias() (alias string, err error) {
+	if p.acceptKeyword("AS") {
+		if !p.isName() {
+			return "", ErrInvalidSelectQuery
+		}
+		return p.next().text, nil
+	}
+	if p.isName() {
+		return p.next().text, nil
+	}
+	return "", nil
+}
+
+func parseQuery(expression string) (*query, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q := &query{limit: -1}
+
+	if err = p.expectKeyword("SELECT"); err != nil {
+		return nil, err
+	}
+	if !p.accept("*") {
+		for {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			name, err := p.parseAlias()
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				if column, ok := e.(*columnRef); ok && column.path[len(column.path)-1].index < 0 {
+					name = column.path[len(column.path)-1].name
+				} else {
+					name = "_" + strconv.Itoa(len(q.projections)+1)
+				}
+			}
+			q.projections = append(q.projections, projection{expr: e, name: name})
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if err = p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenIdent || !strings.EqualFold(t.text, "S3Object") {
+		return nil, ErrInvalidSelectQuery
+	}
+	if p.accept("[") {
+		if !p.accept("*") || !p.accept("]") {
+			return nil, ErrInvalidSelectQuery
+		}
+	}
+	alias, err := p.parseAlias()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.acceptKeyword("WHERE") {
+		p.inWhere = true
+		q.where, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		p.inWhere = false
+	}
+	if p.acceptKeyword("LIMIT") {
+		t := p.next()
+		if t.kind != tokenNumber {
+			return nil, ErrInvalidSelectQuery
+		}
+		q.limit, err = strconv.ParseInt(t.text, 10, 64)
+		if err != nil || q.limit < 0 {
+			return nil, ErrInvalidSelectQuery
+		}
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, ErrInvalidSelectQuery
+	}
+
+	q.aggregates = p.aggregates
+	if len(q.aggregates) > 0 && (q.projections == nil || p.columnsOutsideAggregate > 0) {
+		return nil, ErrInvalidSelectQuery
+	}
+	// s._1 and S3Object._1 are the same as _1
+	for _, column := range p.columns {
+		if len(column.path) < 2 || column.path[0].index >= 0 {
+			continue
+		}
+		first := column.path[0]
+		if (alias != "" && first.name == alias) ||
+			(!first.quoted && (strings.EqualFold(first.name, alias) ||
+				strings.EqualFold(first.name, "S3Object"))) {
+			column.path = column.path[1:]
+		}
+	}
+	return q, nil
+}
+
+func (p *parser) parseExpr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{or: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.acceptKeyword("NOT") {
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{e}, nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenOperator {
+		switch t.text {
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			p.next()
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &compareExpr{op: t.text, left: left, right: right}, nil
+		}
+	}
+	if p.acceptKeyword("IS") {
+		negate := p.acceptKeyword("NOT")
+		if err = p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNullExpr{expr: left, negate: negate}, nil
+	}
+	negate := p.acceptKeyword("NOT")
+	switch {
+	case p.acceptKeyword("LIKE"):
+		return p.parseLike(left, negate)
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &betweenExpr{expr: left, low: low, high: high, negate: negate}, nil
+	case p.acceptKeyword("IN"):
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		e := &inExpr{expr: left, negate: negate}
+		for {
+			item, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			e.list = append(e.list, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	if negate {
+		return nil, ErrInvalidSelectQuery
+	}
+	return left, nil
+}
+
+func (p *parser) parseLike(left expr, negate bool) (expr, error) {
+	pattern, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	e := &likeExpr{expr: left, pattern: pattern, negate: negate}
+	if p.acceptKeyword("ESCAPE") {
+		e.escape, err = p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+	}
+	// compile constant patterns once
+	patternLiteral, ok := pattern.(*literal)
+	if !ok {
+		return e, nil
+	}
+	escape := ""
+	if e.escape != nil {
+		escapeLiteral, ok := e.escape.(*literal)
+		if !ok {
+			return e, nil
+		}
+		escape = toString(escapeLiteral.value)
+	}
+	e.regexp, err = likeToRegexp(toString(patternLiteral.value), escape)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Translate LIKE pattern, where % matches any string and _ matches any
+// character, to an anchored regular expression
+func likeToRegexp(pattern, escape string) (*regexp.Regexp, error) {
+	escapeRunes := []rune(escape)
+	if len(escapeRunes) > 1 {
+		return nil, ErrIllegalSelectArgument
+	}
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case len(escapeRunes) == 1 && c == escapeRunes[0]:
+			if i+1 == len(runes) {
+				return nil, ErrIllegalSelectArgument
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func (p *parser) parseOperand() (expr, error) {
+	if p.accept("-") {
+		e, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if l, ok := e.(*literal); ok {
+			switch v := l.value.(type) {
+			case int64:
+				return &literal{-v}, nil
+			case float64:
+				return &literal{-v}, nil
+			}
+		}
+		return &negateExpr{e}, nil
+	}
+	p.accept("+")
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next()
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &literal{i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, ErrInvalidSelectQuery
+		}
+		return &literal{f}, nil
+	case tokenString:
+		p.next()
+		return &literal{t.text}, nil
+	case tokenOperator:
+		if !p.accept("(") {
+			return nil, ErrInvalidSelectQuery
+		}
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	case tokenQuotedIdent:
+		return p.parseColumn()
+	case tokenIdent:
+		switch strings.ToUpper(t.text) {
+		case "NULL":
+			p.next()
+			return &literal{nil}, nil
+		case "TRUE":
+			p.next()
+			return &literal{true}, nil
+		case "FALSE":
+			p.next()
+			return &literal{false}, nil
+		case "CAST":
+			p.next()
+			return p.parseCast()
+		}
+		if reservedWords[strings.ToUpper(t.text)] {
+			return nil, ErrInvalidSelectQuery
+		}
+		if next := p.tokens[p.pos+1]; next.kind == tokenOperator && next.text == "(" {
+			return p.parseAggregate()
+		}
+		return p.parseColumn()
+	}
+	return nil, ErrInvalidSelectQuery
+}
+
+func (p *parser) parseCast() (expr, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err = p.expectKeyword("AS"); err != nil {
+		return nil, err
+	}
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, ErrInvalidSelectQuery
+	}
+	typeName := strings.ToUpper(t.text)
+	switch typeName {
+	case "INT", "INTEGER", "BIGINT", "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC",
+		"STRING", "VARCHAR", "CHAR", "BOOL", "BOOLEAN":
+	default:
+		return nil, ErrInvalidSelectQuery
+	}
+	if err = p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &castExpr{expr: e, typeName: typeName}, nil
+}
+
+func (p *parser) parseAggregate() (expr, error) {
+	function := strings.ToUpper(p.next().text)
+	switch function {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+	default:
+		return nil, ErrInvalidSelectQuery
+	}
+	if p.inWhere || p.inAggregate {
+		return nil, ErrInvalidSelectQuery
+	}
+	p.next() // (
+	a := &aggregate{function: function}
+	if function == "COUNT" && p.accept("*") {
+		// COUNT(*) counts all records, arg is nil
+	} else {
+		p.inAggregate = true
+		arg, err := p.parseExpr()
+		p.inAggregate = false
+		if err != nil {
+			return nil, err
+		}
+		a.arg = arg
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	p.aggregates = append(p.aggregates, a)
+	return a, nil
+}
+
+func (p *parser) parseColumn() (expr, error) {
+	column := &columnRef{}
+	t := p.next()
+	column.path = append(column.path,
+		pathElement{name: t.text, quoted: t.kind == tokenQuotedIdent, index: -1})
+	for {
+		if p.accept(".") {
+			if !p.isName() {
+				return nil, ErrInvalidSelectQuery
+			}
+			t := p.next()
+			column.path = append(column.path,
+				pathElement{name: t.text, quoted: t.kind == tokenQuotedIdent, index: -1})
+		} else if p.accept("[") {
+			t := p.next()
+			index, err := strconv.Atoi(t.text)
+			if t.kind != tokenNumber || err != nil || index < 0 {
+				return nil, ErrInvalidSelectQuery
+			}
+			if err = p.expect("]"); err != nil {
+				return nil, err
+			}
+			column.path = append(column.path, pathElement{index: index})
+		} else {
+			break
+		}
+	}
+	p.columns = append(p.columns, column)
+	if !p.inAggregate && !p.inWhere {
+		p.columnsOutsideAggregate++
+	}
+	return column, nil
+}
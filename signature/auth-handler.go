@@ -18,6 +18,7 @@ package signature
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"encoding/base64"
@@ -27,6 +28,7 @@ import (
 	"strings"
 
 	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
 )
 
@@ -122,8 +124,92 @@ func sumMD5(data []byte) []byte {
 	return hash.Sum(nil)
 }
 
+// getSessionToken - returns session token of temporary credentials in request,
+// either in header or in query string of presigned url
+func getSessionToken(r *http.Request) string {
+	if token := r.Header.Get("X-Amz-Security-Token"); token != "" {
+		return token
+	}
+	query := r.URL.Query()
+	if token := query.Get("X-Amz-Security-Token"); token != "" {
+		return token
+	}
+	return query.Get("x-amz-security-token")
+}
+
+// GetRequestAccessKey - returns access key and session token claimed by request
+// without verifying its signature, access key is empty for anonymous requests
+// and POST policy requests whose credential is in form
+func GetRequestAccessKey(r *http.Request) (accessKey, sessionToken string) {
+	switch GetRequestAuthType(r) {
+	case AuthTypeSignedV4:
+		signV4Values, err := parseSignV4(r.Header.Get("Authorization"), r.Header)
+		if err != nil {
+			return "", ""
+		}
+		accessKey = signV4Values.Credential.accessKey
+	case AuthTypePresignedV4:
+		credential, err := parseCredential(r.URL.Query().Get("X-Amz-Credential"))
+		if err != nil {
+			return "", ""
+		}
+		accessKey = credential.accessKey
+	case AuthTypeSignedV2:
+		// Authorization: AWS AccessKeyId:Signature
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), SignV2Algorithm+" ")
+		accessKey = strings.Split(auth, ":")[0]
+	case AuthTypePresignedV2:
+		accessKey = r.URL.Query().Get("AWSAccessKeyId")
+	}
+	if accessKey == "" {
+		return "", ""
+	}
+	return accessKey, getSessionToken(r)
+}
+
+// getCredential - gets credential of access key, session token is validated
+// for temporary credentials
+func getCredential(accessKey, sessionToken string) (credential common.Credential, err error) {
+	credential, err = iam.GetCredential(accessKey, sessionToken)
+	if err == ErrInvalidToken || err == ErrExpiredToken {
+		return credential, err
+	}
+	if err != nil {
+		return credential, ErrInvalidAccessKeyID
+	}
+	return credential, nil
+}
+
+type authCallbackKeyType string
+
+const authCallbackKey authCallbackKeyType = "AuthCallback"
+
+// AuthCallback is called with the credential once signature of a request is verified
+type AuthCallback func(credential common.Credential)
+
+// WithAuthCallback - returns request whose verified credential is passed to callback,
+// e.g. to charge limits of the access key only when it's proved
+func WithAuthCallback(r *http.Request, callback AuthCallback) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), authCallbackKey, callback))
+}
+
+// Authenticated - notifies callback of request that credential is verified
+func Authenticated(r *http.Request, credential common.Credential) {
+	if callback, ok := r.Context().Value(authCallbackKey).(AuthCallback); ok {
+		callback(credential)
+	}
+}
+
 // A helper function to verify if request has valid AWS Signature
 func IsReqAuthenticated(r *http.Request) (c common.Credential, e error) {
+	c, e = isReqAuthenticated(r)
+	if e == nil {
+		Authenticated(r, c)
+	}
+	return
+}
+
+func isReqAuthenticated(r *http.Request) (c common.Credential, e error) {
 	payload, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		return c, ErrInternalError
@@ -33,7 +33,6 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/journeymidnight/yig/api/datatype"
 	. "github.com/journeymidnight/yig/error"
-	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
 )
 
@@ -50,13 +49,13 @@ func getChunkSignature(cred common.Credential, seedSignature string, region stri
 	// Calculate string to sign.
 	stringToSign := signV4ChunkedAlgorithm + "\n" +
 		date.Format(datatype.Iso8601Format) + "\n" +
-		getScope(date, region) + "\n" +
+		getScope(date, region, serviceS3) + "\n" +
 		seedSignature + "\n" +
 		emptySHA256 + "\n" +
 		hashedChunk
 
 	// Get hmac signing key.
-	signingKey := getSigningKey(cred.SecretAccessKey, date, region)
+	signingKey := getSigningKey(cred.SecretAccessKey, date, region, serviceS3)
 
 	// Calculate signature.
 	newSignature := getSignature(signingKey, stringToSign)
@@ -95,9 +94,9 @@ func CalculateSeedSignature(r *http.Request) (credential common.Credential, sign
 		return
 	}
 
-	credential, e := iam.GetCredential(signV4Values.Credential.accessKey)
+	credential, e := getCredential(signV4Values.Credential.accessKey, getSessionToken(r))
 	if e != nil {
-		return credential, "", "", time.Time{}, ErrInvalidAccessKeyID
+		return credential, "", "", time.Time{}, e
 	}
 
 	// Verify if region is valid.
@@ -123,10 +122,12 @@ func CalculateSeedSignature(r *http.Request) (credential common.Credential, sign
 	canonicalRequest := getCanonicalRequest(extractedSignedHeaders, payload, queryStr, req.URL.Path, req.Method)
 
 	// Get string to sign from canonical request.
-	stringToSign := getStringToSign(canonicalRequest, date, signV4Values.Credential.scope.region)
+	stringToSign := getStringToSign(canonicalRequest, date, signV4Values.Credential.scope.region,
+		signV4Values.Credential.scope.service)
 
 	// Get hmac signing key.
-	signingKey := getSigningKey(credential.SecretAccessKey, signV4Values.Credential.scope.date, region)
+	signingKey := getSigningKey(credential.SecretAccessKey, signV4Values.Credential.scope.date, region,
+		signV4Values.Credential.scope.service)
 
 	// Calculate signature.
 	newSignature := getSignature(signingKey, stringToSign)
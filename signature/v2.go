@@ -14,7 +14,6 @@ import (
 	"github.com/journeymidnight/yig/api/datatype"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
-	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
 	//	"net"
 	"strconv"
@@ -137,10 +136,10 @@ func DoesSignatureMatchV2(r *http.Request) (credential common.Credential, err er
 		return credential, ErrMissingSignTag
 	}
 	accessKey := splitSignature[0]
-	credential, e := iam.GetCredential(accessKey)
+	credential, e := getCredential(accessKey, getSessionToken(r))
 	helper.Logger.Info(fmt.Sprintf("credential: %+v", credential))
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 	signature, e := base64.StdEncoding.DecodeString(splitSignature[1])
 	if e != nil {
@@ -193,9 +192,9 @@ func DoesPresignedSignatureMatchV2(r *http.Request) (credential common.Credentia
 	expires := query.Get("Expires")
 	signatureString := query.Get("Signature")
 
-	credential, e := iam.GetCredential(accessKey)
+	credential, e := getCredential(accessKey, getSessionToken(r))
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 	signature, e := base64.StdEncoding.DecodeString(signatureString)
 	if e != nil {
@@ -227,9 +226,9 @@ func DoesPolicySignatureMatchV2(formValues map[string]string) (credential common
 	err error) {
 
 	if accessKey, ok := formValues["Awsaccesskeyid"]; ok {
-		credential, err = iam.GetCredential(accessKey)
+		credential, err = getCredential(accessKey, formValues["X-Amz-Security-Token"])
 		if err != nil {
-			return credential, ErrInvalidAccessKeyID
+			return credential, err
 		}
 	} else {
 		return credential, ErrMissingFields
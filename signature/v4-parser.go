@@ -62,7 +62,7 @@ func parseCredential(credentialValue string) (credentialHeader, error) {
 		return credentialHeader{}, ErrInvalidRegion
 	}
 	cred.scope.region = credElements[2]
-	if credElements[3] != "s3" {
+	if credElements[3] != serviceS3 && credElements[3] != serviceSTS {
 		return credentialHeader{}, ErrInvalidService
 	}
 	cred.scope.service = credElements[3]
@@ -75,7 +75,7 @@ func parseCredential(credentialValue string) (credentialHeader, error) {
 
 // parse credentialHeader string into its structured form.
 // Credential=<your-access-key-id>/<date>/<aws-region>/<aws-service>/aws4_request
-// <aws-service> is always "s3" for us
+// <aws-service> is "s3", or "sts" for requests to STS endpoint
 func parseCredentialHeader(credElement string) (credentialHeader, error) {
 	creds := strings.Split(strings.TrimSpace(credElement), "=")
 	if len(creds) != 2 {
@@ -36,13 +36,14 @@ import (
 
 	. "github.com/journeymidnight/yig/api/datatype"
 	. "github.com/journeymidnight/yig/error"
-	"github.com/journeymidnight/yig/iam"
 	"github.com/journeymidnight/yig/iam/common"
 )
 
 // AWS Signature Version '4' constants.
 const (
 	signV4Algorithm = "AWS4-HMAC-SHA256"
+	serviceS3       = "s3"
+	serviceSTS      = "sts" // requests to STS endpoint are signed for "sts" service
 )
 
 // getSignedHeaders generate a string i.e alphabetically sorted,
@@ -107,31 +108,31 @@ func getCanonicalHeaders(signedHeaders http.Header) string {
 }
 
 // getScope generate a string of a specific date, an AWS region, and a service.
-func getScope(t time.Time, region string) string {
+func getScope(t time.Time, region, service string) string {
 	scope := strings.Join([]string{
 		t.Format(YYYYMMDD),
 		region,
-		"s3",
+		service,
 		"aws4_request",
 	}, "/")
 	return scope
 }
 
 // getStringToSign a string based on selected query values.
-func getStringToSign(canonicalRequest string, t time.Time, region string) string {
+func getStringToSign(canonicalRequest string, t time.Time, region, service string) string {
 	stringToSign := signV4Algorithm + "\n" + t.Format(Iso8601Format) + "\n"
-	stringToSign = stringToSign + getScope(t, region) + "\n"
+	stringToSign = stringToSign + getScope(t, region, service) + "\n"
 	canonicalRequestBytes := sum256([]byte(canonicalRequest))
 	stringToSign = stringToSign + hex.EncodeToString(canonicalRequestBytes[:])
 	return stringToSign
 }
 
 // getSigningKey hmac seed to calculate final signature.
-func getSigningKey(secretKey string, t time.Time, region string) []byte {
+func getSigningKey(secretKey string, t time.Time, region, service string) []byte {
 	date := sumHMAC([]byte("AWS4"+secretKey), []byte(t.Format(YYYYMMDD)))
 	regionBytes := sumHMAC(date, []byte(region))
-	service := sumHMAC(regionBytes, []byte("s3"))
-	signingKey := sumHMAC(service, []byte("aws4_request"))
+	serviceBytes := sumHMAC(regionBytes, []byte(service))
+	signingKey := sumHMAC(serviceBytes, []byte("aws4_request"))
 	return signingKey
 }
 
@@ -162,12 +163,12 @@ func DoesPolicySignatureMatchV4(formValues map[string]string) (credential common
 		return credential, ErrMalformedDate
 	}
 
-	credential, e = iam.GetCredential(credHeader.accessKey)
+	credential, e = getCredential(credHeader.accessKey, formValues["X-Amz-Security-Token"])
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 	// Get signing key.
-	signingKey := getSigningKey(credential.SecretAccessKey, t, region)
+	signingKey := getSigningKey(credential.SecretAccessKey, t, region, credHeader.scope.service)
 
 	// Get signature.
 	newSignature := getSignature(signingKey, formValues["Policy"])
@@ -190,9 +191,9 @@ func DoesPresignedSignatureMatchV4(r *http.Request,
 		return credential, err
 	}
 
-	credential, e := iam.GetCredential(preSignValues.Credential.accessKey)
+	credential, e := getCredential(preSignValues.Credential.accessKey, getSessionToken(r))
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 
 	if preSignValues.Expires > PresignedUrlExpireLimit {
@@ -237,10 +238,12 @@ func DoesPresignedSignatureMatchV4(r *http.Request,
 		query.Encode(), r.URL.Path, r.Method)
 
 	// Get string to sign from canonical request.
-	presignedStringToSign := getStringToSign(presignedCanonicalReq, preSignValues.Date, region)
+	presignedStringToSign := getStringToSign(presignedCanonicalReq, preSignValues.Date, region,
+		preSignValues.Credential.scope.service)
 
 	// Get hmac presigned signing key.
-	presignedSigningKey := getSigningKey(credential.SecretAccessKey, preSignValues.Date, region)
+	presignedSigningKey := getSigningKey(credential.SecretAccessKey, preSignValues.Date, region,
+		preSignValues.Credential.scope.service)
 
 	// Get new signature.
 	newSignature := getSignature(presignedSigningKey, presignedStringToSign)
@@ -261,9 +264,9 @@ func getCredentialUnverified(r *http.Request) (credential common.Credential, err
 		return credential, err
 	}
 
-	credential, e := iam.GetCredential(signV4Values.Credential.accessKey)
+	credential, e := getCredential(signV4Values.Credential.accessKey, getSessionToken(r))
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 
 	return credential, nil
@@ -289,6 +292,10 @@ func DoesSignatureMatchV4(hashedPayload string, r *http.Request,
 	// It provides a hash of the request payload. If there is no payload, you must provide
 	// the hash of an empty string.
 	hashedPayloadReceived := r.Header.Get("X-Amz-Content-Sha256")
+	// the header is not required by STS
+	if hashedPayloadReceived == "" && signV4Values.Credential.scope.service == serviceSTS {
+		hashedPayloadReceived = hashedPayload
+	}
 	if hashedPayloadReceived != "UNSIGNED-PAYLOAD" && hashedPayloadReceived != hashedPayload {
 		return credential, ErrContentSHA256Mismatch
 	}
@@ -332,14 +339,14 @@ func DoesSignatureMatchV4(hashedPayload string, r *http.Request,
 		r.URL.Path, r.Method)
 
 	// Get string to sign from canonical request.
-	stringToSign := getStringToSign(canonicalRequest, t, region)
+	stringToSign := getStringToSign(canonicalRequest, t, region, signV4Values.Credential.scope.service)
 
-	credential, e := iam.GetCredential(signV4Values.Credential.accessKey)
+	credential, e := getCredential(signV4Values.Credential.accessKey, getSessionToken(r))
 	if e != nil {
-		return credential, ErrInvalidAccessKeyID
+		return credential, e
 	}
 	// Get hmac signing key.
-	signingKey := getSigningKey(credential.SecretAccessKey, t, region)
+	signingKey := getSigningKey(credential.SecretAccessKey, t, region, signV4Values.Credential.scope.service)
 
 	// Calculate signature.
 	newSignature := getSignature(signingKey, stringToSign)
@@ -6,12 +6,14 @@ import (
 	"github.com/journeymidnight/yig/api/datatype"
 	"github.com/journeymidnight/yig/backend"
 	"github.com/journeymidnight/yig/ceph"
+	"github.com/journeymidnight/yig/compression"
 	"github.com/journeymidnight/yig/crypto"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/iam/common"
 	"github.com/journeymidnight/yig/meta"
 	"github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/mods"
 	"github.com/journeymidnight/yig/redis"
 	"github.com/journeymidnight/yig/signature"
 	"io"
@@ -19,7 +21,9 @@ import (
 	"time"
 )
 
-func New(metaCacheType int, enableDataCache bool, kms crypto.KMS) *YigStorage {
+func New(metaCacheType int, enableDataCache bool, kms crypto.KMS,
+	plugins map[string]*mods.YigPlugin) *YigStorage {
+
 	yig := YigStorage{
 		DataStorage: make(map[string]backend.Cluster),
 		DataCache:   newDataCache(enableDataCache),
@@ -29,10 +33,21 @@ func New(metaCacheType int, enableDataCache bool, kms crypto.KMS) *YigStorage {
 		WaitGroup:   new(sync.WaitGroup),
 	}
 
-	yig.DataStorage = ceph.Initialize(helper.CONFIG)
+	yig.DataStorage, yig.DataBackends = backend.InitializePlugins(plugins)
 	if len(yig.DataStorage) == 0 {
-		panic("No data storage can be used!")
+		// configs before backend plugins have no [plugins.ceph_backend]
+		helper.Logger.Warn("No backend plugin enabled, fall back to ceph clusters of",
+			helper.CONFIG.CephConfigPattern, "please enable ceph_backend plugin instead")
+		yig.DataStorage = ceph.Initialize(helper.CONFIG)
+		for id := range yig.DataStorage {
+			yig.DataBackends[id] = "ceph_backend"
+		}
 	}
+	if len(yig.DataStorage) == 0 {
+		panic("No data storage can be used! Enable at least one backend plugin, e.g. ceph_backend")
+	}
+	yig.checkClusterBackends()
+	yig.loadStorageClassPolicies()
 
 	initializeRecycler(&yig)
 	return &yig
@@ -41,10 +56,11 @@ func New(metaCacheType int, enableDataCache bool, kms crypto.KMS) *YigStorage {
 //TODO: Append Support Encryption
 func (yig *YigStorage) AppendObject(bucketName string, objectName string, credential common.Credential,
 	offset uint64, size int64, data io.ReadCloser, metadata map[string]string, acl datatype.Acl,
-	sseRequest datatype.SseRequest, storageClass types.StorageClass, objInfo *types.Object) (result datatype.AppendObjectResult, err error) {
+	sseRequest datatype.SseRequest, storageClass types.StorageClass, objInfo *types.Object,
+	bypassGovernance bool) (result datatype.AppendObjectResult, err error) {
 
 	defer data.Close()
-	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(sseRequest, bucketName, objectName)
+	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(&sseRequest, bucketName, objectName)
 	helper.Logger.Println(10, "get encryptionKey:", encryptionKey, "cipherKey:", cipherKey, "err:", err)
 	if err != nil {
 		return
@@ -53,6 +69,26 @@ func (yig *YigStorage) AppendObject(bucketName string, objectName string, creden
 	//TODO: Append Support Encryption
 	encryptionKey = nil
 
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	// appending modifies the version in place, which object lock should prevent
+	if bucket.ObjectLock.IsEnabled() {
+		return result, ErrAppendObjectLockEnabled
+	}
+	if objInfo != nil {
+		err = checkObjectLock(objInfo, bypassGovernance)
+		if err != nil {
+			return
+		}
+	}
+	err = yig.checkQuota(bucket, "", helper.Ternary(size > 0, size, int64(0)).(int64),
+		helper.Ternary(objInfo == nil, int64(1), int64(0)).(int64))
+	if err != nil {
+		return
+	}
+
 	md5Writer := md5.New()
 
 	// Limit the reader to its provided size if specified.
@@ -66,20 +102,23 @@ func (yig *YigStorage) AppendObject(bucketName string, objectName string, creden
 	var cephCluster backend.Cluster
 	var poolName, oid string
 	var initializationVector []byte
-	var objSize int64
+	var objSize, storedSize int64
+	var compressed bool
 	if objInfo != nil {
 		cephCluster = yig.DataStorage[objInfo.Location]
-		// Every appendable file must be treated as a big file
-		poolName = backend.BIG_FILE_POOLNAME
+		// appended to the pool it's stored in, which is big pool of its storage class
+		poolName = objInfo.Pool
 		oid = objInfo.ObjectId
 		initializationVector = objInfo.InitializationVector
 		objSize = objInfo.Size
 		storageClass = objInfo.StorageClass
+		compressed = objInfo.Compressed
+		storedSize = objInfo.CompressedSize
 		helper.Logger.Println(20, "request append oid:", oid, "iv:", initializationVector, "size:", objSize)
 	} else {
 		// New appendable object
 		cephCluster, poolName = yig.pickClusterAndPool(bucketName, objectName, storageClass, size, true)
-		if cephCluster == nil || poolName != backend.BIG_FILE_POOLNAME {
+		if cephCluster == nil {
 			helper.Logger.Warn("PickOneClusterAndPool error")
 			return result, ErrInternalError
 		}
@@ -89,22 +128,30 @@ func (yig *YigStorage) AppendObject(bucketName string, objectName string, creden
 				return
 			}
 		}
+		compressed = compression.ShouldCompress(objectName, metadata["Content-Type"])
 		helper.Logger.Println(20, "request first append oid:", oid, "iv:", initializationVector, "size:", objSize)
 	}
 
-	dataReader := io.TeeReader(limitedDataReader, md5Writer)
+	dataReader := &countingReader{reader: io.TeeReader(limitedDataReader, md5Writer)}
 
-	storageReader, err := wrapEncryptionReader(dataReader, encryptionKey, initializationVector)
+	// compressed streams are appended one after another, so data
+	// is appended at the end of what is actually stored in Ceph
+	storageOffset := int64(offset)
+	if compressed {
+		storageOffset = storedSize
+	}
+	storageReader, err := wrapEncryptionReader(wrapCompressionReader(dataReader, compressed),
+		encryptionKey, initializationVector)
 	if err != nil {
 		return
 	}
-	oid, bytesWritten, err := cephCluster.Append(poolName, oid, storageReader, int64(offset))
+	oid, bytesWritten, err := cephCluster.Append(poolName, oid, storageReader, storageOffset)
 	if err != nil {
-		helper.Logger.Error("cephCluster.Append err:", err, poolName, oid, offset)
+		helper.Logger.Error("cephCluster.Append err:", err, poolName, oid, storageOffset)
 		return
 	}
 
-	if int64(bytesWritten) < size {
+	if dataReader.count < size {
 		return result, ErrIncompleteBody
 	}
 
@@ -131,7 +178,7 @@ func (yig *YigStorage) AppendObject(bucketName string, objectName string, creden
 		Location:             cephCluster.ID(),
 		Pool:                 poolName,
 		OwnerId:              credential.UserId,
-		Size:                 objSize + int64(bytesWritten),
+		Size:                 objSize + dataReader.count,
 		ObjectId:             oid,
 		LastModifiedTime:     time.Now().UTC(),
 		Etag:                 calculatedMd5,
@@ -145,6 +192,8 @@ func (yig *YigStorage) AppendObject(bucketName string, objectName string, creden
 		CustomAttributes:     metadata,
 		Type:                 types.ObjectTypeAppendable,
 		StorageClass:         storageClass,
+		Compressed:           compressed,
+		CompressedSize:       helper.Ternary(compressed, storedSize+int64(bytesWritten), int64(0)).(int64),
 	}
 
 	result.LastModified = object.LastModifiedTime
@@ -99,6 +99,19 @@ func (yig *YigStorage) SetBucketLogging(bucketName string, bl datatype.BucketLog
 	if err != nil {
 		return err
 	}
+	// logs are delivered on behalf of bucket owner, so the target bucket must be owned by the same one
+	if target := bl.LoggingEnabled.TargetBucket; target != "" {
+		targetBucket, err := yig.MetaStorage.GetBucket(target, true)
+		if err == ErrNoSuchBucket {
+			return ErrInvalidTargetBucketForLogging
+		}
+		if err != nil {
+			return err
+		}
+		if targetBucket.OwnerId != bucket.OwnerId {
+			return ErrInvalidTargetBucketForLogging
+		}
+	}
 	bucket.BucketLogging = bl
 	err = yig.MetaStorage.Client.PutBucket(*bucket)
 	if err != nil {
@@ -246,6 +259,47 @@ func (yig *YigStorage) GetBucketCors(bucketName string,
 	return bucket.CORS, nil
 }
 
+func (yig *YigStorage) SetBucketTagging(bucketName string, tags map[string]string,
+	credential common.Credential) error {
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, false)
+	if err != nil {
+		return err
+	}
+	if bucket.OwnerId != credential.UserId {
+		return ErrBucketAccessForbidden
+	}
+	bucket.Tags = tags
+	err = yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucketName)
+	return nil
+}
+
+func (yig *YigStorage) DeleteBucketTagging(bucketName string, credential common.Credential) error {
+	return yig.SetBucketTagging(bucketName, nil, credential)
+}
+
+func (yig *YigStorage) GetBucketTagging(bucketName string,
+	credential common.Credential) (tagging datatype.Tagging, err error) {
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return tagging, err
+	}
+	if bucket.OwnerId != credential.UserId {
+		err = ErrBucketAccessForbidden
+		return
+	}
+	if len(bucket.Tags) == 0 {
+		err = ErrNoSuchTagSet
+		return
+	}
+	return datatype.TaggingFromMap(bucket.Tags), nil
+}
+
 func (yig *YigStorage) SetBucketVersioning(bucketName string, versioning datatype.Versioning,
 	credential common.Credential) error {
 
@@ -256,6 +310,14 @@ func (yig *YigStorage) SetBucketVersioning(bucketName string, versioning datatyp
 	if bucket.OwnerId != credential.UserId {
 		return ErrBucketAccessForbidden
 	}
+	// replication works on object versions, so versioning could not be
+	// suspended once the bucket has replication configuration
+	if !bucket.Replication.IsEmpty() && versioning.Status != meta.VersionEnabled {
+		return ErrReplicationRequiresVersioning
+	}
+	if bucket.ObjectLock.IsEnabled() && versioning.Status != meta.VersionEnabled {
+		return ErrObjectLockRequiresVersioning
+	}
 	bucket.Versioning = versioning.Status
 	err = yig.MetaStorage.Client.PutBucket(*bucket)
 	if err != nil {
@@ -450,6 +512,58 @@ func (yig *YigStorage) DeleteBucketWebsite(bucket *meta.Bucket) error {
 	return nil
 }
 
+func (yig *YigStorage) SetBucketNotification(bucket *meta.Bucket, config datatype.NotificationConfiguration) (err error) {
+	bucket.Notification = config
+	err = yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucket.Name)
+	return nil
+}
+
+func (yig *YigStorage) GetBucketNotification(bucketName string) (config datatype.NotificationConfiguration, err error) {
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	return bucket.Notification, nil
+}
+
+func (yig *YigStorage) SetBucketReplication(bucket *meta.Bucket, config datatype.ReplicationConfiguration) (err error) {
+	if bucket.Versioning != meta.VersionEnabled {
+		return ErrReplicationRequiresVersioning
+	}
+	bucket.Replication = config
+	err = yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucket.Name)
+	return nil
+}
+
+func (yig *YigStorage) GetBucketReplication(bucketName string) (config datatype.ReplicationConfiguration, err error) {
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	if bucket.Replication.IsEmpty() {
+		return config, ErrNoSuchReplicationConfiguration
+	}
+	return bucket.Replication, nil
+}
+
+func (yig *YigStorage) DeleteBucketReplication(bucket *meta.Bucket) error {
+	bucket.Replication = datatype.ReplicationConfiguration{}
+	err := yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucket.Name)
+	return nil
+}
+
 func (yig *YigStorage) SetBucketEncryption(bucket *meta.Bucket, config datatype.EncryptionConfiguration) (err error) {
 	bucket.Encryption = config
 	err = yig.MetaStorage.Client.PutBucket(*bucket)
@@ -491,12 +605,14 @@ func (yig *YigStorage) CheckBucketEncryption(bucketName string) (*datatype.Apply
 		return nil, false
 	}
 	configuration := bucketEncryption.Rules[0].ApplyServerSideEncryptionByDefault
-	if configuration.SSEAlgorithm == crypto.SSEAlgorithmAES256 {
+	switch configuration.SSEAlgorithm {
+	case crypto.SSEAlgorithmAES256, crypto.SSEAlgorithmKMS:
 		return configuration, true
+	case "": // KMSMasterKeyID alone implies SSE-KMS
+		if configuration.KMSMasterKeyID != "" {
+			return configuration, true
+		}
 	}
-	//if bucketEncryption.Rules[0].ApplyServerSideEncryptionByDefault.SSEAlgorithm == crypto.SSEAlgorithmKMS {
-	//	return configuration, nil
-	//}
 	return nil, false
 }
 
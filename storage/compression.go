@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"errors"
+	"io"
+
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/compression"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+// countingReader records how many bytes have been read from the client,
+// i.e. the object size before compression
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.count += int64(n)
+	return
+}
+
+// Wraps reader with compression if compressed is true
+func wrapCompressionReader(reader io.Reader, compressed bool) io.Reader {
+	if !compressed {
+		return reader
+	}
+	return compression.Compress.CompressReader(reader)
+}
+
+// rangeWriter drops the first `skip` bytes written to it and
+// passes at most `length` bytes after them to writer
+type rangeWriter struct {
+	writer io.Writer
+	skip   int64
+	length int64
+}
+
+func newRangeWriter(writer io.Writer, skip, length int64) *rangeWriter {
+	return &rangeWriter{
+		writer: writer,
+		skip:   skip,
+		length: length,
+	}
+}
+
+func (w *rangeWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.skip >= int64(len(p)) {
+		w.skip -= int64(len(p))
+		return n, nil
+	}
+	p = p[w.skip:]
+	w.skip = 0
+	if int64(len(p)) > w.length {
+		p = p[:w.length]
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	written, err := w.writer.Write(p)
+	w.length -= int64(written)
+	if err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// Compressed data could not be read from an arbitrary offset, so the whole
+// ceph object is read, decrypted and decompressed into writer
+func copyCompressedData(cluster backend.Cluster, pool, objectId string, storedSize int64,
+	encryptionKey []byte, initializationVector []byte, writer io.Writer) (err error) {
+
+	reader, err := cluster.GetReader(pool, objectId, 0, uint64(storedSize))
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decryptedReader, err := wrapEncryptionReader(reader, encryptionKey, initializationVector)
+	if err != nil {
+		return err
+	}
+	decompressWriter := compression.Compress.CompressWriter(writer)
+	buffer := downloadBufPool.Get().([]byte)
+	_, err = io.CopyBuffer(decompressWriter, decryptedReader, buffer)
+	downloadBufPool.Put(buffer)
+	closeErr := decompressWriter.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func (yig *YigStorage) getCompressedObject(object *meta.Object, startOffset int64,
+	length int64, writer io.Writer, encryptionKey []byte) (err error) {
+
+	if compression.Compress == nil {
+		return errors.New("Compression plugin is not configured for compressed object: " +
+			object.BucketName + "/" + object.Name)
+	}
+	cluster, ok := yig.DataStorage[object.Location]
+	if !ok {
+		return errors.New("Cannot find specified ceph cluster: " + object.Location)
+	}
+
+	if len(object.Parts) == 0 { // this object has only one part
+		transWholeObjectWriter := func(w io.Writer) error {
+			return copyCompressedData(cluster, object.Pool, object.ObjectId, object.CompressedSize,
+				encryptionKey, object.InitializationVector, w)
+		}
+		transRangeWriter := func(w io.Writer) error {
+			return transWholeObjectWriter(newRangeWriter(w, startOffset, length))
+		}
+		if object.SseType == "" { // unencrypted object
+			return yig.DataCache.WriteFromCache(object, startOffset, length, writer,
+				transRangeWriter, transWholeObjectWriter)
+		}
+		// never put decrypted data into cache
+		return transRangeWriter(writer)
+	}
+
+	// multipart uploaded object, every part is compressed separately
+	for i := 1; i <= len(object.Parts); i++ {
+		p := object.Parts[i]
+		if p.Offset+p.Size <= startOffset {
+			continue
+		}
+		if p.Offset >= startOffset+length {
+			return
+		}
+		var readOffset, readLength int64
+		if startOffset > p.Offset {
+			readOffset = startOffset - p.Offset
+		}
+		if p.Offset+p.Size <= startOffset+length {
+			readLength = p.Size - readOffset
+		} else {
+			readLength = startOffset + length - (p.Offset + readOffset)
+		}
+		err = copyCompressedData(cluster, object.Pool, p.ObjectId, p.CompressedSize,
+			encryptionKey, p.InitializationVector, newRangeWriter(writer, readOffset, readLength))
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
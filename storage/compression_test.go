@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/compression"
+	"github.com/journeymidnight/yig/crypto"
+	"github.com/journeymidnight/yig/helper"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// gzipCompression compresses with gzip, whose concatenated streams are
+// decompressed as a whole like compression plugins
+type gzipCompression struct{}
+
+func (gzipCompression) CompressReader(reader io.Reader) io.Reader {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		gzipWriter := gzip.NewWriter(pipeWriter)
+		_, err := io.Copy(gzipWriter, reader)
+		if err == nil {
+			err = gzipWriter.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+	return pipeReader
+}
+
+type gzipDecompressWriter struct {
+	pipeWriter *io.PipeWriter
+	done       chan error
+}
+
+func (w *gzipDecompressWriter) Write(p []byte) (int, error) {
+	return w.pipeWriter.Write(p)
+}
+
+func (w *gzipDecompressWriter) Close() error {
+	w.pipeWriter.Close()
+	return <-w.done
+}
+
+func (gzipCompression) CompressWriter(writer io.Writer) io.WriteCloser {
+	pipeReader, pipeWriter := io.Pipe()
+	w := &gzipDecompressWriter{pipeWriter: pipeWriter, done: make(chan error, 1)}
+	go func() {
+		gzipReader, err := gzip.NewReader(pipeReader)
+		if err == nil {
+			_, err = io.Copy(writer, gzipReader)
+		}
+		pipeReader.CloseWithError(err)
+		w.done <- err
+	}()
+	return w
+}
+
+func (gzipCompression) IsCompressible(objectName, mtype string) bool {
+	return true
+}
+
+func setupCompression(t *testing.T) func() {
+	helper.CONFIG.DownloadBufPoolSize = 1024
+	compression.Compress = gzipCompression{}
+	return func() {
+		compression.Compress = nil
+	}
+}
+
+var (
+	testEncryptionKey        = []byte("0123456789abcdef0123456789abcdef")
+	testInitializationVector = []byte("fedcba9876543210")
+)
+
+// Put data like PutObject does, returns size of data stored
+func putTestData(t *testing.T, cluster backend.Cluster, data string, compressed bool,
+	encryptionKey []byte) (oid string, storedSize int64) {
+
+	reader, err := wrapEncryptionReader(
+		wrapCompressionReader(strings.NewReader(data), compressed), encryptionKey,
+		testInitializationVector)
+	if err != nil {
+		t.Fatal("wrapEncryptionReader error:", err)
+	}
+	oid, size, err := cluster.Put("tiger", reader)
+	if err != nil {
+		t.Fatal("Put error:", err)
+	}
+	return oid, int64(size)
+}
+
+func TestCountingReader(t *testing.T) {
+	reader := &countingReader{reader: strings.NewReader("hello world")}
+	buffer := make([]byte, 4)
+	n, _ := reader.Read(buffer)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, int64(4), reader.count)
+	var out bytes.Buffer
+	io.Copy(&out, reader)
+	assert.Equal(t, "o world", out.String())
+	assert.Equal(t, int64(11), reader.count)
+}
+
+func TestRangeWriter(t *testing.T) {
+	var testcase = [...]struct {
+		chunk    int
+		skip     int64
+		length   int64
+		expected string
+	}{
+		{100, 0, 11, "hello world"},
+		{100, 6, 5, "world"},
+		{100, 2, 3, "llo"},
+		{1, 2, 3, "llo"},
+		{3, 2, 3, "llo"},
+		{3, 3, 3, "lo "},
+		{5, 5, 100, " world"},
+		{4, 11, 5, ""},
+		{4, 0, 0, ""},
+	}
+	data := []byte("hello world")
+	for _, c := range testcase {
+		var out bytes.Buffer
+		w := newRangeWriter(&out, c.skip, c.length)
+		for i := 0; i < len(data); i += c.chunk {
+			end := i + c.chunk
+			if end > len(data) {
+				end = len(data)
+			}
+			// all bytes are consumed even if they are dropped
+			n, err := w.Write(data[i:end])
+			assert.Nil(t, err)
+			assert.Equal(t, end-i, n)
+		}
+		assert.Equal(t, c.expected, out.String(), c)
+	}
+}
+
+func TestCopyCompressedData(t *testing.T) {
+	defer setupCompression(t)()
+	cluster := newMemoryCluster("c")
+	data := strings.Repeat("hello world ", 1000)
+	for _, key := range [][]byte{nil, testEncryptionKey} {
+		oid, storedSize := putTestData(t, cluster, data, true, key)
+		assert.True(t, storedSize < int64(len(data)))
+
+		var out bytes.Buffer
+		err := copyCompressedData(cluster, "tiger", oid, storedSize, key,
+			testInitializationVector, &out)
+		assert.Nil(t, err)
+		assert.Equal(t, data, out.String())
+
+		out.Reset()
+		err = copyCompressedData(cluster, "tiger", oid, storedSize, key,
+			testInitializationVector, newRangeWriter(&out, 6, 5))
+		assert.Nil(t, err)
+		assert.Equal(t, "world", out.String())
+	}
+}
+
+// Ranges of objects stored in different ways, every object holds data
+func testGetObjectRanges(t *testing.T, yig *YigStorage, object *meta.Object,
+	sseRequest datatype.SseRequest, data string) {
+
+	var ranges = [...]struct {
+		offset int64
+		length int64
+	}{
+		{0, int64(len(data))},
+		{0, 1},
+		{1, 20},
+		{15, 17},
+		{int64(len(data)) - 7, 7},
+		{1500, 3000},
+	}
+	for _, r := range ranges {
+		var out bytes.Buffer
+		err := yig.GetObject(object, r.offset, r.length, &out, sseRequest)
+		assert.Nil(t, err)
+		assert.Equal(t, data[r.offset:r.offset+r.length], out.String(),
+			"compressed: %v, sse: %v, parts: %d, range: %v", object.Compressed,
+			object.SseType, len(object.Parts), r)
+	}
+}
+
+func TestGetObject_Ranges(t *testing.T) {
+	defer setupCompression(t)()
+	cluster := newMemoryCluster("c")
+	yig := &YigStorage{
+		DataStorage: map[string]backend.Cluster{"c": cluster},
+		DataCache:   &disabledDataCache{},
+	}
+	var data string
+	for i := 0; len(data) < 5000; i++ {
+		data += strings.Repeat(string(rune('a'+i%26)), i%7+1)
+	}
+	ssec := datatype.SseRequest{Type: crypto.SSEC.String(), SseCustomerKey: testEncryptionKey}
+
+	for _, compressed := range []bool{false, true} {
+		for _, sseRequest := range []datatype.SseRequest{{}, ssec} {
+			object := &meta.Object{Location: "c", Pool: "tiger", Size: int64(len(data)),
+				Compressed: compressed, SseType: sseRequest.Type,
+				InitializationVector: testInitializationVector}
+			object.ObjectId, object.CompressedSize = putTestData(t, cluster, data, compressed,
+				sseRequest.SseCustomerKey)
+			testGetObjectRanges(t, yig, object, sseRequest, data)
+
+			// multipart uploaded, every part is compressed and encrypted separately
+			object = &meta.Object{Location: "c", Pool: "tiger", Size: int64(len(data)),
+				Compressed: compressed, SseType: sseRequest.Type,
+				Parts: map[int]*meta.Part{}}
+			var partsIndex []int64
+			for i, offset := 1, 0; offset < len(data); i++ {
+				end := offset + 1024
+				if end > len(data) {
+					end = len(data)
+				}
+				part := &meta.Part{PartNumber: i, Size: int64(end - offset), Offset: int64(offset),
+					InitializationVector: testInitializationVector}
+				part.ObjectId, part.CompressedSize = putTestData(t, cluster, data[offset:end],
+					compressed, sseRequest.SseCustomerKey)
+				object.Parts[i] = part
+				partsIndex = append(partsIndex, part.Offset)
+				offset = end
+			}
+			object.PartsIndex = &meta.SimpleIndex{Index: partsIndex}
+			testGetObjectRanges(t, yig, object, sseRequest, data)
+		}
+	}
+}
+
+func TestOffsetInitializationVector(t *testing.T) {
+	iv := []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xfe}
+	assert.Equal(t, iv, offsetInitializationVector(iv, 0))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff},
+		offsetInitializationVector(iv, 1))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0},
+		offsetInitializationVector(iv, 2))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0xff, 0xfd},
+		offsetInitializationVector(iv, 0xffffff))
+	// the counter wraps around
+	assert.Equal(t, make([]byte, 16), offsetInitializationVector(bytes.Repeat([]byte{0xff}, 16), 1))
+	// the argument is left untouched
+	assert.Equal(t, byte(0xfe), iv[15])
+}
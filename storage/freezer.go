@@ -1,6 +1,12 @@
 package storage
 
 import (
+	"time"
+
+	"github.com/journeymidnight/yig/api"
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
 	meta "github.com/journeymidnight/yig/meta/types"
 )
 
@@ -36,3 +42,98 @@ func (yig *YigStorage) UpdateFreezerDate(freezer *meta.Freezer, date int, isIncr
 	freezer.LifeTime = lifeTime
 	return yig.MetaStorage.UpdateFreezerDate(freezer)
 }
+
+// Copy data of a glacier object version to big pool of STANDARD, which could be read
+// until the restored copy expires. Freezers of objects no longer in glacier are removed.
+// ObjectRestore:Completed event is sent once restored.
+func (yig *YigStorage) RestoreObject(freezer *meta.Freezer) (err error) {
+	var object *meta.Object
+	if freezer.VersionId == "" {
+		object, err = yig.MetaStorage.GetObject(freezer.BucketName, freezer.Name, false)
+	} else {
+		object, err = yig.MetaStorage.GetObjectVersion(freezer.BucketName, freezer.Name,
+			freezer.VersionId, false)
+	}
+	if err == ErrNoSuchKey || (err == nil &&
+		(object.DeleteMarker || object.StorageClass != meta.ObjectStorageClassGlacier)) {
+		helper.Logger.Info("No glacier object to restore:", freezer.BucketName, freezer.Name)
+		return yig.MetaStorage.DeleteFreezer(freezer)
+	}
+	if err != nil {
+		return err
+	}
+	if freezer.Status == meta.ObjectNeedRestore {
+		err = yig.MetaStorage.UpdateFreezer(freezer, meta.ObjectRestoring)
+		if err != nil {
+			return err
+		}
+		freezer.Status = meta.ObjectRestoring
+	}
+
+	source, ok := yig.DataStorage[object.Location]
+	if !ok {
+		helper.Logger.Error("Cannot find cluster", object.Location, "for",
+			object.BucketName, object.Name)
+		return ErrInternalError
+	}
+	policy := yig.storageClassPolicy(meta.ObjectStorageClassStandard)
+	cluster := yig.pickCluster(policy.BigPool, policy.Clusters)
+	if cluster == nil {
+		return ErrInternalError
+	}
+	target, written, err := copyObjectData(object, source, cluster, policy.BigPool)
+	if err != nil {
+		return err
+	}
+	restored := *freezer
+	restored.Location = target.Location
+	restored.Pool = target.Pool
+	restored.ObjectId = target.ObjectId
+	restored.Parts = target.Parts
+	restored.OwnerId = object.OwnerId
+	restored.Size = object.Size
+	restored.Etag = object.Etag
+	restored.LastModifiedTime = time.Now()
+	err = yig.MetaStorage.UpdateFreezer(&restored, meta.ObjectHasRestored)
+	if err != nil {
+		recycleObjects(written)
+		return err
+	}
+	*freezer = restored
+	freezer.Status = meta.ObjectHasRestored
+	yig.sendRestoreCompletedEvent(object)
+	return nil
+}
+
+func (yig *YigStorage) sendRestoreCompletedEvent(object *meta.Object) {
+	bucket, err := yig.MetaStorage.GetBucket(object.BucketName, true)
+	if err != nil {
+		helper.Logger.Error("Unable to get bucket", object.BucketName,
+			"to send restore event, error:", err)
+		return
+	}
+	api.SendEventNotification(bucket, datatype.EventObjectRestoreCompleted, "", datatype.EventObject{
+		Key:       object.Name,
+		Size:      object.Size,
+		ETag:      object.Etag,
+		VersionId: object.VersionId,
+	})
+}
+
+// Move the restored copy to garbage collection if it's expired
+func (yig *YigStorage) ExpireRestoredObject(freezer *meta.Freezer) (expired bool, err error) {
+	// with parts and location
+	freezer, err = yig.MetaStorage.GetFreezer(freezer.BucketName, freezer.Name, freezer.VersionId)
+	if err != nil {
+		return false, err
+	}
+	// restore could be extended meanwhile
+	if !freezer.Expired(time.Now()) {
+		return false, nil
+	}
+	err = yig.MetaStorage.DeleteFreezer(freezer)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/journeymidnight/yig/backend"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/redis"
+)
+
+// Copy raw data(maybe compressed and encrypted) of an object or part to another pool,
+// size is the length of data stored in backend. Ceph readers return nothing for
+// length 0, so the length is always passed and checked against bytes written
+func copyRawData(source backend.Cluster, sourcePool, objectId string, size int64,
+	target backend.Cluster, targetPool string) (oid string, err error) {
+
+	reader, err := source.GetReader(sourcePool, objectId, 0, uint64(size))
+	if err != nil {
+		return
+	}
+	defer reader.Close()
+	oid, written, err := target.Put(targetPool, reader)
+	if err != nil {
+		return
+	}
+	if int64(written) != size {
+		target.Remove(targetPool, oid)
+		return "", fmt.Errorf("copied %d bytes of %s/%s, expected %d",
+			written, sourcePool, objectId, size)
+	}
+	return
+}
+
+// Length of data stored in backend, compressed size if the object is compressed
+func storedSize(object *meta.Object, part *meta.Part) int64 {
+	if part != nil {
+		if object.Compressed {
+			return part.CompressedSize
+		}
+		return part.Size
+	}
+	if object.Compressed {
+		return object.CompressedSize
+	}
+	return object.Size
+}
+
+// Copy raw data of an object version and its parts to another cluster and pool,
+// returns the object with new location and data written, which should be
+// recycled if the object is not updated at last
+func copyObjectData(object *meta.Object, source backend.Cluster, cluster backend.Cluster,
+	poolName string) (target meta.Object, written []objectToRecycle, err error) {
+
+	defer func() {
+		if err != nil {
+			recycleObjects(written)
+			written = nil
+		}
+	}()
+	target = *object
+	target.Location = cluster.ID()
+	target.Pool = poolName
+	if len(object.Parts) == 0 {
+		target.ObjectId, err = copyRawData(source, object.Pool, object.ObjectId,
+			storedSize(object, nil), cluster, poolName)
+		if err != nil {
+			return
+		}
+		written = append(written, objectToRecycle{
+			location: cluster.ID(),
+			pool:     poolName,
+			objectId: target.ObjectId,
+		})
+		return
+	}
+	target.Parts = make(map[int]*meta.Part, len(object.Parts))
+	for n, part := range object.Parts {
+		p := *part
+		p.ObjectId, err = copyRawData(source, object.Pool, part.ObjectId,
+			storedSize(object, part), cluster, poolName)
+		if err != nil {
+			return
+		}
+		written = append(written, objectToRecycle{
+			location: cluster.ID(),
+			pool:     poolName,
+			objectId: p.ObjectId,
+		})
+		target.Parts[n] = &p
+	}
+	return
+}
+
+func recycleObjects(objects []objectToRecycle) {
+	for _, o := range objects {
+		RecycleQueue <- o
+	}
+}
+
+// Change storage class of an object version in place, used by lifecycle transitions
+// and copying an object to itself. Data is moved to the pool of new storage class if
+// the pool changes, e.g. to GLACIER, otherwise only metadata is updated. Version,
+// ACL and encryption keys are kept. Returns ErrNoSuchKey if the object is changed
+// or deleted during moving.
+func (yig *YigStorage) TransitObject(object *meta.Object, storageClass meta.StorageClass) (err error) {
+	cluster, poolName := yig.pickClusterAndPool(object.BucketName, object.Name, storageClass,
+		object.Size, object.Type == meta.ObjectTypeAppendable)
+	if poolName == object.Pool &&
+		clusterAllowed(object.Location, yig.storageClassPolicy(storageClass).Clusters) {
+		target := *object
+		target.StorageClass = storageClass
+		err = yig.MetaStorage.Client.UpdateObject(&target, nil)
+	} else {
+		source, ok := yig.DataStorage[object.Location]
+		if !ok {
+			helper.Logger.Error("Cannot find cluster", object.Location, "for",
+				object.BucketName, object.Name)
+			return ErrInternalError
+		}
+		var written []objectToRecycle
+		var target meta.Object
+		target, written, err = copyObjectData(object, source, cluster, poolName)
+		if err != nil {
+			return err
+		}
+		target.StorageClass = storageClass
+		defer func() {
+			if err != nil {
+				recycleObjects(written)
+			}
+		}()
+		err = yig.MetaStorage.TransitObject(&target, object)
+	}
+	if err != nil {
+		return err
+	}
+	yig.removeObjectCache(object)
+	return nil
+}
+
+func (yig *YigStorage) removeObjectCache(object *meta.Object) {
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, object.BucketName+":"+object.Name+":")
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, object.BucketName+":"+object.Name+":"+object.GetVersionId())
+	yig.DataCache.Remove(object.BucketName + ":" + object.Name + ":" + object.GetVersionId())
+}
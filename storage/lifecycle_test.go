@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/journeymidnight/yig/backend"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCluster keeps objects in memory, its readers honour length like ceph,
+// i.e. length 0 reads nothing
+type memoryCluster struct {
+	id      string
+	mutex   sync.Mutex
+	objects map[string][]byte // pool/oid -> data
+	counter int
+}
+
+func newMemoryCluster(id string) *memoryCluster {
+	return &memoryCluster{id: id, objects: make(map[string][]byte)}
+}
+
+func (c *memoryCluster) ID() string {
+	return c.id
+}
+
+func (c *memoryCluster) GetUsage() (backend.Usage, error) {
+	return backend.Usage{}, nil
+}
+
+func (c *memoryCluster) Put(pool string, data io.Reader) (oid string, size uint64, err error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", 0, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.counter++
+	oid = c.id + ":" + strconv.Itoa(c.counter)
+	c.objects[pool+"/"+oid] = b
+	return oid, uint64(len(b)), nil
+}
+
+func (c *memoryCluster) Append(pool, existName string, data io.Reader,
+	offset int64) (oid string, size uint64, err error) {
+
+	if existName == "" {
+		return c.Put(pool, data)
+	}
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", 0, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	old := c.objects[pool+"/"+existName]
+	if int64(len(old)) != offset {
+		return "", 0, errors.New("bad offset")
+	}
+	c.objects[pool+"/"+existName] = append(old, b...)
+	return existName, uint64(len(b)), nil
+}
+
+func (c *memoryCluster) GetReader(pool, oid string, offset int64,
+	length uint64) (io.ReadCloser, error) {
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	data, ok := c.objects[pool+"/"+oid]
+	if !ok {
+		return nil, errors.New("no such object")
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	end := offset + int64(length)
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+	return ioutil.NopCloser(bytes.NewReader(data[offset:end])), nil
+}
+
+func (c *memoryCluster) Remove(pool, oid string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.objects, pool+"/"+oid)
+	return nil
+}
+
+func (c *memoryCluster) get(pool, oid string) []byte {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.objects[pool+"/"+oid]
+}
+
+func TestCopyObjectData(t *testing.T) {
+	source := newMemoryCluster("source")
+	target := newMemoryCluster("target")
+	oid, _, _ := source.Put("tiger", bytes.NewBufferString("hello world"))
+	object := &meta.Object{Pool: "tiger", ObjectId: oid, Size: 11}
+
+	copied, written, err := copyObjectData(object, source, target, "turtle")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(written))
+	assert.Equal(t, "target", copied.Location)
+	assert.Equal(t, "turtle", copied.Pool)
+	assert.Equal(t, "hello world", string(target.get("turtle", copied.ObjectId)))
+}
+
+func TestCopyObjectData_Compressed(t *testing.T) {
+	source := newMemoryCluster("source")
+	target := newMemoryCluster("target")
+	oid, _, _ := source.Put("tiger", bytes.NewBufferString("zipped"))
+	object := &meta.Object{Pool: "tiger", ObjectId: oid, Size: 100,
+		Compressed: true, CompressedSize: 6}
+
+	copied, _, err := copyObjectData(object, source, target, "turtle")
+	assert.Nil(t, err)
+	assert.Equal(t, "zipped", string(target.get("turtle", copied.ObjectId)))
+}
+
+func TestCopyObjectData_Parts(t *testing.T) {
+	source := newMemoryCluster("source")
+	target := newMemoryCluster("target")
+	oid1, _, _ := source.Put("tiger", bytes.NewBufferString("hello "))
+	oid2, _, _ := source.Put("tiger", bytes.NewBufferString("world"))
+	object := &meta.Object{Pool: "tiger", Size: 11, Parts: map[int]*meta.Part{
+		1: {PartNumber: 1, ObjectId: oid1, Size: 6},
+		2: {PartNumber: 2, ObjectId: oid2, Size: 5, Offset: 6},
+	}}
+
+	copied, written, err := copyObjectData(object, source, target, "turtle")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(written))
+	assert.Equal(t, "hello ", string(target.get("turtle", copied.Parts[1].ObjectId)))
+	assert.Equal(t, "world", string(target.get("turtle", copied.Parts[2].ObjectId)))
+	assert.Equal(t, oid1, object.Parts[1].ObjectId)
+}
+
+func TestCopyObjectData_Truncated(t *testing.T) {
+	source := newMemoryCluster("source")
+	target := newMemoryCluster("target")
+	oid, _, _ := source.Put("tiger", bytes.NewBufferString("hello"))
+	object := &meta.Object{Pool: "tiger", ObjectId: oid, Size: 11}
+
+	_, _, err := copyObjectData(object, source, target, "turtle")
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(target.objects))
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/journeymidnight/yig/backend"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+const (
+	MigrationRunning   = "Running"
+	MigrationCompleted = "Completed"
+	MigrationCancelled = "Cancelled"
+	MigrationFailed    = "Failed"
+
+	MIGRATION_SCAN_LIMIT = 1000
+)
+
+var errMigrationCancelled = errors.New("migration cancelled")
+
+// Progress of a migration job, reported by admin server
+type MigrationProgress struct {
+	SourceFsid string
+	SourcePool string
+	TargetFsid string // empty means target clusters are picked by weight
+	TargetPool string
+	Status     string
+	Error      string `json:",omitempty"`
+	StartTime  time.Time
+	EndTime    time.Time
+
+	CurrentBucket   string
+	ScannedObjects  int64
+	MigratedObjects int64
+	MigratedBytes   int64
+	SkippedObjects  int64 // changed or deleted during migration
+	FailedObjects   int64
+}
+
+type migrationJob struct {
+	mutex    sync.Mutex
+	progress MigrationProgress
+	cancel   chan struct{}
+}
+
+func (job *migrationJob) update(fn func(p *MigrationProgress)) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	fn(&job.progress)
+}
+
+func (job *migrationJob) getProgress() MigrationProgress {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	return job.progress
+}
+
+// Start a job in background to move data of all objects located on
+// sourceFsid/sourcePool to targetFsid/targetPool. Empty targetFsid means
+// spreading data to other clusters with the same pool by weight, so setting
+// weight of a cluster to 0 and migrating from it drains the cluster.
+// Empty targetPool means the same pool as source.
+// Only one job could be running at a time.
+func (yig *YigStorage) StartMigration(sourceFsid, sourcePool, targetFsid, targetPool string) error {
+	if targetPool == "" {
+		targetPool = sourcePool
+	}
+	if _, ok := yig.DataStorage[sourceFsid]; !ok || sourcePool == "" {
+		return ErrInvalidMigration
+	}
+	if targetFsid != "" {
+		if _, ok := yig.DataStorage[targetFsid]; !ok {
+			return ErrInvalidMigration
+		}
+		if targetFsid == sourceFsid && targetPool == sourcePool {
+			return ErrInvalidMigration
+		}
+	}
+
+	yig.migrationMutex.Lock()
+	defer yig.migrationMutex.Unlock()
+	if yig.migration != nil && yig.migration.getProgress().Status == MigrationRunning {
+		return ErrMigrationInProgress
+	}
+	if yig.Stopping {
+		return ErrMaintenance
+	}
+	job := &migrationJob{
+		progress: MigrationProgress{
+			SourceFsid: sourceFsid,
+			SourcePool: sourcePool,
+			TargetFsid: targetFsid,
+			TargetPool: targetPool,
+			Status:     MigrationRunning,
+			StartTime:  time.Now().UTC(),
+		},
+		cancel: make(chan struct{}),
+	}
+	yig.migration = job
+	yig.WaitGroup.Add(1)
+	go yig.runMigration(job)
+	helper.Logger.Info("Migration started from", sourceFsid, sourcePool,
+		"to", targetFsid, targetPool)
+	return nil
+}
+
+// Get progress of the latest migration job
+func (yig *YigStorage) GetMigrationProgress() (progress MigrationProgress, err error) {
+	yig.migrationMutex.Lock()
+	defer yig.migrationMutex.Unlock()
+	if yig.migration == nil {
+		return progress, ErrNoSuchMigration
+	}
+	return yig.migration.getProgress(), nil
+}
+
+// Cancel the running migration job, objects already migrated are kept
+func (yig *YigStorage) CancelMigration() error {
+	yig.migrationMutex.Lock()
+	defer yig.migrationMutex.Unlock()
+	if yig.migration == nil || yig.migration.getProgress().Status != MigrationRunning {
+		return ErrNoSuchMigration
+	}
+	select {
+	case <-yig.migration.cancel:
+	default:
+		close(yig.migration.cancel)
+	}
+	return nil
+}
+
+func (yig *YigStorage) runMigration(job *migrationJob) {
+	defer yig.WaitGroup.Done()
+	err := yig.migrate(job)
+	job.update(func(p *MigrationProgress) {
+		p.EndTime = time.Now().UTC()
+		switch err {
+		case nil:
+			p.Status = MigrationCompleted
+		case errMigrationCancelled:
+			p.Status = MigrationCancelled
+		default:
+			p.Status = MigrationFailed
+			p.Error = err.Error()
+		}
+	})
+	progress := job.getProgress()
+	helper.Logger.Info("Migration from", progress.SourceFsid, progress.SourcePool,
+		"finished:", progress.Status, "migrated:", progress.MigratedObjects,
+		"skipped:", progress.SkippedObjects, "failed:", progress.FailedObjects, progress.Error)
+}
+
+func (yig *YigStorage) migrationStopped(job *migrationJob) bool {
+	if yig.Stopping {
+		return true
+	}
+	select {
+	case <-job.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// Walk all object versions of all buckets, and migrate those on source cluster and pool.
+// Multipart uploads in progress are not migrated, they should be completed
+// or aborted before draining the cluster.
+func (yig *YigStorage) migrate(job *migrationJob) error {
+	progress := job.getProgress()
+	buckets, err := yig.MetaStorage.Client.GetBuckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		job.update(func(p *MigrationProgress) {
+			p.CurrentBucket = bucket.Name
+		})
+		var keyMarker string
+		var versionMarker uint64
+		for {
+			if yig.migrationStopped(job) {
+				return errMigrationCancelled
+			}
+			objects, nextKeyMarker, nextVersionMarker, truncated, err :=
+				yig.MetaStorage.ScanObjectVersions(bucket.Name, keyMarker, versionMarker, MIGRATION_SCAN_LIMIT)
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				if yig.migrationStopped(job) {
+					return errMigrationCancelled
+				}
+				job.update(func(p *MigrationProgress) {
+					p.ScannedObjects++
+				})
+				if object.DeleteMarker || object.Location != progress.SourceFsid ||
+					object.Pool != progress.SourcePool {
+					continue
+				}
+				migrated, err := yig.migrateObject(object, progress.TargetFsid, progress.TargetPool)
+				if err != nil {
+					helper.Logger.Error("Migrate object", object.BucketName, object.Name,
+						object.GetVersionId(), "error:", err)
+				}
+				job.update(func(p *MigrationProgress) {
+					if err != nil {
+						p.FailedObjects++
+					} else if migrated {
+						p.MigratedObjects++
+						p.MigratedBytes += object.Size
+					} else {
+						p.SkippedObjects++
+					}
+				})
+			}
+			if !truncated {
+				break
+			}
+			keyMarker, versionMarker = nextKeyMarker, nextVersionMarker
+		}
+	}
+	return nil
+}
+
+// Pick a cluster with pool by weight, except the excluded one
+func (yig *YigStorage) pickMigrationTarget(poolName, exclude string) (cluster backend.Cluster, err error) {
+	clusters, err := yig.MetaStorage.GetClusters()
+	if err != nil {
+		return nil, err
+	}
+	var totalWeight int
+	clusterWeights := make(map[string]int)
+	for _, c := range clusters {
+		if c.Weight == 0 || c.Pool != poolName || c.Fsid == exclude {
+			continue
+		}
+		if !yig.clusterUsable(c) {
+			continue
+		}
+		totalWeight += c.Weight
+		clusterWeights[c.Fsid] = c.Weight
+	}
+	if totalWeight == 0 {
+		return nil, ErrInvalidMigration
+	}
+	N := rand.Intn(totalWeight)
+	n := 0
+	for fsid, weight := range clusterWeights {
+		n += weight
+		if n > N {
+			return yig.DataStorage[fsid], nil
+		}
+	}
+	return nil, ErrInvalidMigration
+}
+
+// Copy data of an object version to target cluster and pool, then update its
+// location and put the old data to garbage collection in one transaction.
+// Returns false if the object is changed or deleted during copying,
+// in which case the copied data is recycled.
+func (yig *YigStorage) migrateObject(object *meta.Object, targetFsid, targetPool string) (
+	migrated bool, err error) {
+
+	if object.Type == meta.ObjectTypeAppendable && targetPool == backend.SMALL_FILE_POOLNAME {
+		return false, errors.New("appendable object cannot be stored in pool " +
+			backend.SMALL_FILE_POOLNAME)
+	}
+	source := yig.DataStorage[object.Location]
+	var cluster backend.Cluster
+	if targetFsid == "" {
+		cluster, err = yig.pickMigrationTarget(targetPool, object.Location)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		cluster = yig.DataStorage[targetFsid]
+	}
+
+	target, written, err := copyObjectData(object, source, cluster, targetPool)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if !migrated {
+			recycleObjects(written)
+		}
+	}()
+
+	err = yig.MetaStorage.TransitObject(&target, object)
+	if err == ErrNoSuchKey {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	yig.removeObjectCache(object)
+	return true, nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/journeymidnight/yig/api"
 	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/compression"
 	"github.com/journeymidnight/yig/crypto"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
@@ -84,7 +85,8 @@ func (yig *YigStorage) ListMultipartUploads(credential common.Credential, bucket
 
 func (yig *YigStorage) NewMultipartUpload(credential common.Credential, bucketName, objectName string,
 	metadata map[string]string, acl datatype.Acl,
-	sseRequest datatype.SseRequest, storageClass meta.StorageClass) (uploadId string, err error) {
+	sseRequest datatype.SseRequest, storageClass meta.StorageClass,
+	tags map[string]string) (uploadId string, err error) {
 
 	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
 	if err != nil {
@@ -115,13 +117,17 @@ func (yig *YigStorage) NewMultipartUpload(credential common.Credential, bucketNa
 		Acl:          acl,
 		SseRequest:   sseRequest,
 		Attrs:        metadata,
+		Tags:         tags,
 		StorageClass: storageClass,
+		Compressed:   compression.ShouldCompress(objectName, contentType),
 	}
-	if sseRequest.Type == crypto.S3.String() {
-		multipartMetadata.EncryptionKey, multipartMetadata.CipherKey, err = yig.encryptionKeyFromSseRequest(sseRequest, bucketName, objectName)
+	if sseRequest.Type == crypto.S3.String() || sseRequest.Type == crypto.S3KMS.String() {
+		multipartMetadata.EncryptionKey, multipartMetadata.CipherKey, err = yig.encryptionKeyFromSseRequest(&sseRequest, bucketName, objectName)
 		if err != nil {
 			return
 		}
+		// with the resolved KMS key ID and encryption context
+		multipartMetadata.SseRequest = sseRequest
 	} else {
 		multipartMetadata.EncryptionKey = nil
 	}
@@ -166,10 +172,17 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 			return
 		}
 		encryptionKey = sseRequest.SseCustomerKey
-	case crypto.S3.String():
+	case crypto.S3.String(), crypto.S3KMS.String():
 		encryptionKey = multipart.Metadata.EncryptionKey
-	case crypto.S3KMS.String():
-		err = ErrNotImplemented
+	}
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	// check before writing data, an uploaded part is replaced
+	err = yig.checkQuota(bucket, "", size-uploadedPartSize(multipart, partId), 0)
+	if err != nil {
 		return
 	}
 
@@ -180,7 +193,7 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 	if err != nil {
 		return
 	}
-	dataReader := io.TeeReader(limitedDataReader, md5Writer)
+	dataReader := &countingReader{reader: io.TeeReader(limitedDataReader, md5Writer)}
 
 	var initializationVector []byte
 	if len(encryptionKey) != 0 {
@@ -189,7 +202,8 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 			return
 		}
 	}
-	storageReader, err := wrapEncryptionReader(dataReader, encryptionKey,
+	storageReader, err := wrapEncryptionReader(
+		wrapCompressionReader(dataReader, multipart.Metadata.Compressed), encryptionKey,
 		initializationVector)
 	if err != nil {
 		return
@@ -205,7 +219,7 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 		pool:     poolName,
 		objectId: objectId,
 	}
-	if int64(bytesWritten) < size {
+	if dataReader.count < size {
 		RecycleQueue <- maybeObjectToRecycle
 		err = ErrIncompleteBody
 		return
@@ -226,11 +240,6 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 		}
 	}
 
-	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
-	if err != nil {
-		RecycleQueue <- maybeObjectToRecycle
-		return
-	}
 	switch bucket.ACL.CannedAcl {
 	case "public-read-write":
 		break
@@ -248,6 +257,8 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 		Etag:                 calculatedMd5,
 		LastModified:         time.Now().UTC().Format(meta.CREATE_TIME_LAYOUT),
 		InitializationVector: initializationVector,
+		CompressedSize: helper.Ternary(multipart.Metadata.Compressed,
+			int64(bytesWritten), int64(0)).(int64),
 	}
 	err = yig.MetaStorage.PutObjectPart(multipart, part)
 	if err != nil {
@@ -264,10 +275,10 @@ func (yig *YigStorage) PutObjectPart(bucketName, objectName string, credential c
 	}
 
 	result.ETag = calculatedMd5
-	result.SseType = sseRequest.Type
-	result.SseAwsKmsKeyIdBase64 = base64.StdEncoding.EncodeToString([]byte(sseRequest.SseAwsKmsKeyId))
+	result.SseType = multipart.Metadata.SseRequest.Type
+	result.SseAwsKmsKeyId = multipart.Metadata.SseRequest.SseAwsKmsKeyId
 	result.SseCustomerAlgorithm = sseRequest.SseCustomerAlgorithm
-	result.SseCustomerKeyMd5Base64 = base64.StdEncoding.EncodeToString(sseRequest.SseCustomerKey)
+	result.SseCustomerKeyMd5Base64 = customerKeyMd5Base64(sseRequest.SseCustomerKey)
 	return result, nil
 }
 
@@ -295,10 +306,26 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 			return
 		}
 		encryptionKey = sseRequest.SseCustomerKey
-	case crypto.S3.String():
+	case crypto.S3.String(), crypto.S3KMS.String():
 		encryptionKey = multipart.Metadata.EncryptionKey
-	case crypto.S3KMS.String():
-		err = ErrNotImplemented
+	}
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	switch bucket.ACL.CannedAcl {
+	case "public-read-write":
+		break
+	default:
+		if bucket.OwnerId != credential.UserId {
+			err = ErrBucketAccessForbidden
+			return
+		}
+	} // TODO policy and fancy ACL
+	// check before writing data, an uploaded part is replaced
+	err = yig.checkQuota(bucket, "", size-uploadedPartSize(multipart, partId), 0)
+	if err != nil {
 		return
 	}
 
@@ -309,7 +336,7 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 	if err != nil {
 		return
 	}
-	dataReader := io.TeeReader(limitedDataReader, md5Writer)
+	dataReader := &countingReader{reader: io.TeeReader(limitedDataReader, md5Writer)}
 
 	var initializationVector []byte
 	if len(encryptionKey) != 0 {
@@ -318,7 +345,8 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 			return
 		}
 	}
-	storageReader, err := wrapEncryptionReader(dataReader, encryptionKey,
+	storageReader, err := wrapEncryptionReader(
+		wrapCompressionReader(dataReader, multipart.Metadata.Compressed), encryptionKey,
 		initializationVector)
 	if err != nil {
 		return
@@ -335,7 +363,7 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 		objectId: objectId,
 	}
 
-	if int64(bytesWritten) < size {
+	if dataReader.count < size {
 		RecycleQueue <- maybeObjectToRecycle
 		err = ErrIncompleteBody
 		return
@@ -343,22 +371,6 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 
 	result.Md5 = hex.EncodeToString(md5Writer.Sum(nil))
 
-	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
-	if err != nil {
-		RecycleQueue <- maybeObjectToRecycle
-		return
-	}
-	switch bucket.ACL.CannedAcl {
-	case "public-read-write":
-		break
-	default:
-		if bucket.OwnerId != credential.UserId {
-			RecycleQueue <- maybeObjectToRecycle
-			err = ErrBucketAccessForbidden
-			return
-		}
-	} // TODO policy and fancy ACL
-
 	if initializationVector == nil {
 		initializationVector = []byte{}
 	}
@@ -370,8 +382,12 @@ func (yig *YigStorage) CopyObjectPart(bucketName, objectName, uploadId string, p
 		Etag:                 result.Md5,
 		LastModified:         now.Format(meta.CREATE_TIME_LAYOUT),
 		InitializationVector: initializationVector,
+		CompressedSize: helper.Ternary(multipart.Metadata.Compressed,
+			int64(bytesWritten), int64(0)).(int64),
 	}
 	result.LastModified = now
+	result.SseType = multipart.Metadata.SseRequest.Type
+	result.SseAwsKmsKeyId = multipart.Metadata.SseRequest.SseAwsKmsKeyId
 
 	err = yig.MetaStorage.PutObjectPart(multipart, part)
 	if err != nil {
@@ -532,6 +548,11 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 		}
 	}
 	// TODO policy and fancy ACL
+	// parts are counted in usage when uploaded
+	err = yig.checkQuota(bucket, objectName, 0, 1)
+	if err != nil {
+		return
+	}
 
 	multipart, err := yig.MetaStorage.GetMultipart(bucketName, objectName, uploadId)
 	if err != nil {
@@ -539,7 +560,7 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 	}
 
 	md5Writer := md5.New()
-	var totalSize int64 = 0
+	var totalSize, compressedSize int64
 	helper.Logger.Info("Upload parts:", uploadedParts, "uploadId:", uploadId)
 	for i := 0; i < len(uploadedParts); i++ {
 		if uploadedParts[i].PartNumber != i+1 {
@@ -580,6 +601,7 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 		}
 		part.Offset = totalSize
 		totalSize += part.Size
+		compressedSize += part.CompressedSize
 		md5Writer.Write(etagBytes)
 	}
 	result.ETag = hex.EncodeToString(md5Writer.Sum(nil))
@@ -605,10 +627,17 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 		DeleteMarker:     false,
 		SseType:          multipart.Metadata.SseRequest.Type,
 		EncryptionKey:    multipart.Metadata.CipherKey,
+		SseKmsKeyId:      multipart.Metadata.SseRequest.SseAwsKmsKeyId,
+		SseContext:       multipart.Metadata.SseRequest.SseContext,
 		CustomAttributes: multipart.Metadata.Attrs,
+		Tags:             multipart.Metadata.Tags,
 		Type:             meta.ObjectTypeMultipart,
 		StorageClass:     multipart.Metadata.StorageClass,
+		Compressed:       multipart.Metadata.Compressed,
+		CompressedSize:   compressedSize,
 	}
+	object.ReplicationStatus = replicationStatus(bucket, objectName, object.CustomAttributes, credential)
+	applyDefaultRetention(bucket, object)
 
 	var nullVerNum uint64
 	nullVerNum, err = yig.checkOldObject(bucketName, objectName, bucket.Versioning)
@@ -629,7 +658,7 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 	}
 
 	if object.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := yig.MetaStorage.GetFreezer(object.BucketName, object.Name, object.VersionId)
+		freezer, err := yig.MetaStorage.GetFreezer(object.BucketName, object.Name, meta.FreezerVersion(object))
 		if err == nil {
 			err = yig.MetaStorage.DeleteFreezer(freezer)
 			if err != nil {
@@ -648,9 +677,9 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 
 	sseRequest := multipart.Metadata.SseRequest
 	result.SseType = sseRequest.Type
-	result.SseAwsKmsKeyIdBase64 = base64.StdEncoding.EncodeToString([]byte(sseRequest.SseAwsKmsKeyId))
+	result.SseAwsKmsKeyId = sseRequest.SseAwsKmsKeyId
 	result.SseCustomerAlgorithm = sseRequest.SseCustomerAlgorithm
-	result.SseCustomerKeyMd5Base64 = base64.StdEncoding.EncodeToString(sseRequest.SseCustomerKey)
+	result.SseCustomerKeyMd5Base64 = customerKeyMd5Base64(sseRequest.SseCustomerKey)
 
 	if err == nil {
 		yig.MetaStorage.Cache.Remove(redis.ObjectTable, bucketName+":"+objectName+":")
@@ -659,3 +688,12 @@ func (yig *YigStorage) CompleteMultipartUpload(credential common.Credential, buc
 
 	return
 }
+
+// MD5 of the SSE-C key in base64, as the key itself should never be returned
+func customerKeyMd5Base64(key []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	sum := md5.Sum(key)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/redis"
+)
+
+// Whether an object version could be removed or overwritten.
+// Legal hold protects the version until it's turned off, GOVERNANCE retention could be
+// bypassed by users allowed to, while COMPLIANCE retention could never be.
+func checkObjectLock(object *meta.Object, bypassGovernance bool) error {
+	if object.LegalHold {
+		return ErrObjectLocked
+	}
+	if object.LockMode == "" || !time.Now().Before(object.RetainUntilDate) {
+		return nil
+	}
+	if object.LockMode == datatype.ObjectLockModeGovernance && bypassGovernance {
+		return nil
+	}
+	return ErrObjectLocked
+}
+
+// Retention could always be extended, but shortened, removed or changed from COMPLIANCE
+// to GOVERNANCE only if current retention expires, or it's in GOVERNANCE mode and bypassed
+func checkRetentionChange(object *meta.Object, mode string, retainUntilDate time.Time,
+	bypassGovernance bool) error {
+
+	if object.LockMode == "" || !time.Now().Before(object.RetainUntilDate) {
+		return nil
+	}
+	weaken := retainUntilDate.Before(object.RetainUntilDate) ||
+		(object.LockMode == datatype.ObjectLockModeCompliance && mode != datatype.ObjectLockModeCompliance)
+	if !weaken {
+		return nil
+	}
+	if object.LockMode == datatype.ObjectLockModeGovernance && bypassGovernance {
+		return nil
+	}
+	return ErrObjectLocked
+}
+
+// Apply default retention of the bucket to a new object version
+func applyDefaultRetention(bucket *meta.Bucket, object *meta.Object) {
+	mode, retainUntilDate := bucket.ObjectLock.DefaultRetention(object.LastModifiedTime)
+	object.LockMode = mode
+	object.RetainUntilDate = helper.Ternary(mode == "", time.Time{},
+		retainUntilDate.UTC().Truncate(time.Second)).(time.Time)
+	object.LegalHold = false
+}
+
+func (yig *YigStorage) SetBucketObjectLock(bucket *meta.Bucket, config datatype.ObjectLockConfiguration) (err error) {
+	if bucket.Versioning != meta.VersionEnabled {
+		return ErrObjectLockRequiresVersioning
+	}
+	bucket.ObjectLock = config
+	err = yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucket.Name)
+	return nil
+}
+
+func (yig *YigStorage) GetBucketObjectLock(bucketName string) (config datatype.ObjectLockConfiguration, err error) {
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	if !bucket.ObjectLock.IsEnabled() {
+		return config, ErrNoSuchObjectLockConfiguration
+	}
+	return bucket.ObjectLock, nil
+}
+
+func (yig *YigStorage) getLockableObject(bucketName, objectName, version string,
+	credential common.Credential) (object *meta.Object, err error) {
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	if !bucket.ObjectLock.IsEnabled() {
+		return nil, ErrMissingObjectLockConfiguration
+	}
+	return yig.getObjectForSubResource(bucketName, objectName, version, credential)
+}
+
+func (yig *YigStorage) updateObjectLock(object *meta.Object) error {
+	err := yig.MetaStorage.UpdateObjectLock(object)
+	if err != nil {
+		helper.Logger.Error("Update object lock, sql fails:", err)
+		return ErrInternalError
+	}
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, object.BucketName+":"+object.Name+":")
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, object.BucketName+":"+object.Name+":"+object.GetVersionId())
+	return nil
+}
+
+func (yig *YigStorage) GetObjectRetention(bucketName, objectName, version string,
+	credential common.Credential) (retention datatype.ObjectRetention, err error) {
+
+	object, err := yig.getLockableObject(bucketName, objectName, version, credential)
+	if err != nil {
+		return
+	}
+	return datatype.ObjectRetentionFromMeta(object.LockMode, object.RetainUntilDate), nil
+}
+
+func (yig *YigStorage) SetObjectRetention(bucketName, objectName, version string,
+	retention datatype.ObjectRetention, bypassGovernance bool, credential common.Credential) error {
+
+	mode, retainUntilDate, err := retention.Parse()
+	if err != nil {
+		return err
+	}
+	object, err := yig.getLockableObject(bucketName, objectName, version, credential)
+	if err != nil {
+		return err
+	}
+	err = checkRetentionChange(object, mode, retainUntilDate, bypassGovernance)
+	if err != nil {
+		return err
+	}
+	object.LockMode = mode
+	object.RetainUntilDate = retainUntilDate.Truncate(time.Second)
+	return yig.updateObjectLock(object)
+}
+
+func (yig *YigStorage) GetObjectLegalHold(bucketName, objectName, version string,
+	credential common.Credential) (legalHold datatype.ObjectLegalHold, err error) {
+
+	object, err := yig.getLockableObject(bucketName, objectName, version, credential)
+	if err != nil {
+		return
+	}
+	return datatype.ObjectLegalHoldFromMeta(object.LegalHold), nil
+}
+
+func (yig *YigStorage) SetObjectLegalHold(bucketName, objectName, version string,
+	legalHold bool, credential common.Credential) error {
+
+	object, err := yig.getLockableObject(bucketName, objectName, version, credential)
+	if err != nil {
+		return err
+	}
+	object.LegalHold = legalHold
+	return yig.updateObjectLock(object)
+}
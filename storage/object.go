@@ -6,13 +6,13 @@ import (
 	"errors"
 	"io"
 	"math/rand"
-	"path"
 	"sync"
 	"time"
 
 	"github.com/journeymidnight/yig/api"
 	"github.com/journeymidnight/yig/api/datatype"
 	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/compression"
 	"github.com/journeymidnight/yig/crypto"
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
@@ -23,55 +23,89 @@ import (
 	"github.com/journeymidnight/yig/signature"
 )
 
-var latestQueryTime [3]time.Time // 0 is for SMALL_FILE_POOLNAME, 1 is for BIG_FILE_POOLNAME, 2 is for GLACIER_FILE_POOLNAME
+var (
+	latestQueryTime      = make(map[string]time.Time) // pool name -> last time of checking used space
+	latestQueryTimeMutex sync.Mutex
+)
 const (
 	CLUSTER_MAX_USED_SPACE_PERCENT = 85
-	BIG_FILE_THRESHOLD             = 128 << 10 /* 128K */
 )
 
-func (yig *YigStorage) pickRandomCluster() (cluster backend.Cluster) {
+func (yig *YigStorage) pickRandomCluster(clusters []string) (cluster backend.Cluster) {
 	helper.Logger.Warn("Error picking cluster from table cluster in DB, " +
 		"use first cluster in config to write.")
-	for _, c := range yig.DataStorage {
+	for id, c := range yig.DataStorage {
+		if !clusterAllowed(id, clusters) {
+			continue
+		}
 		cluster = c
 		break
 	}
 	return
 }
 
+// A cluster row in DB is usable only if the cluster is initialized, and by the
+// backend plugin it names, if any
+func (yig *YigStorage) clusterUsable(c meta.Cluster) bool {
+	if _, ok := yig.DataStorage[c.Fsid]; !ok {
+		return false
+	}
+	return c.Backend == "" || c.Backend == yig.DataBackends[c.Fsid]
+}
+
+// Warn about cluster rows in DB which could not be written to
+func (yig *YigStorage) checkClusterBackends() {
+	clusters, err := yig.MetaStorage.GetClusters()
+	if err != nil {
+		helper.Logger.Warn("Error getting clusters from DB:", err)
+		return
+	}
+	for _, c := range clusters {
+		if yig.clusterUsable(c) {
+			continue
+		}
+		if _, ok := yig.DataStorage[c.Fsid]; !ok {
+			helper.Logger.Warn("Cluster", c.Fsid, "of pool", c.Pool,
+				"is not initialized by any backend plugin, expected backend:", c.Backend)
+		} else {
+			helper.Logger.Warn("Cluster", c.Fsid, "of pool", c.Pool, "is owned by backend",
+				c.Backend, "but initialized by", yig.DataBackends[c.Fsid])
+		}
+	}
+}
+
+// Pool is decided by the pool policy of storage class, see [storage_class] in config file
 func (yig *YigStorage) pickClusterAndPool(bucket string, object string, storageClass meta.StorageClass,
 	size int64, isAppend bool) (cluster backend.Cluster, poolName string) {
 
-	var idx int
-	if storageClass == meta.ObjectStorageClassGlacier {
-		poolName = backend.GLACIER_FILE_POOLNAME
-		idx = 2
+	policy := yig.storageClassPolicy(storageClass)
+	if isAppend {
+		poolName = policy.BigPool
+	} else if size < 0 { // request.ContentLength is -1 if length is unknown
+		poolName = policy.BigPool
+	} else if size < policy.Threshold {
+		poolName = policy.SmallPool
 	} else {
-		if isAppend {
-			poolName = backend.BIG_FILE_POOLNAME
-			idx = 1
-		} else if size < 0 { // request.ContentLength is -1 if length is unknown
-			poolName = backend.BIG_FILE_POOLNAME
-			idx = 1
-		} else if size < BIG_FILE_THRESHOLD {
-			poolName = backend.SMALL_FILE_POOLNAME
-			idx = 0
-		} else {
-			poolName = backend.BIG_FILE_POOLNAME
-			idx = 1
-		}
+		poolName = policy.BigPool
 	}
+	return yig.pickCluster(poolName, policy.Clusters), poolName
+}
+
+// Pick a cluster having the pool by weights in table cluster,
+// only from clusters in the list if it's not empty
+func (yig *YigStorage) pickCluster(poolName string, clusters []string) (cluster backend.Cluster) {
 	var needCheck bool
-	queryTime := latestQueryTime[idx]
-	if time.Since(queryTime).Hours() > 24 { // check used space every 24 hours
-		latestQueryTime[idx] = time.Now()
+	latestQueryTimeMutex.Lock()
+	if time.Since(latestQueryTime[poolName]).Hours() > 24 { // check used space every 24 hours
+		latestQueryTime[poolName] = time.Now()
 		needCheck = true
 	}
+	latestQueryTimeMutex.Unlock()
 	var totalWeight int
 	clusterWeights := make(map[string]int, len(yig.DataStorage))
 	metaClusters, err := yig.MetaStorage.GetClusters()
 	if err != nil {
-		cluster = yig.pickRandomCluster()
+		cluster = yig.pickRandomCluster(clusters)
 		return
 	}
 	for _, cluster := range metaClusters {
@@ -81,6 +115,9 @@ func (yig *YigStorage) pickClusterAndPool(bucket string, object string, storageC
 		if cluster.Pool != poolName {
 			continue
 		}
+		if !yig.clusterUsable(cluster) || !clusterAllowed(cluster.Fsid, clusters) {
+			continue
+		}
 		if needCheck {
 			usage, err := yig.DataStorage[cluster.Fsid].GetUsage()
 			if err != nil {
@@ -98,7 +135,7 @@ func (yig *YigStorage) pickClusterAndPool(bucket string, object string, storageC
 		clusterWeights[cluster.Fsid] = cluster.Weight
 	}
 	if len(clusterWeights) == 0 || totalWeight == 0 {
-		cluster = yig.pickRandomCluster()
+		cluster = yig.pickRandomCluster(clusters)
 		return
 	}
 	N := rand.Intn(totalWeight)
@@ -183,19 +220,34 @@ func getAlignedReader(cluster backend.Cluster, poolName, objectName string,
 	return cluster.GetReader(poolName, objectName, alignedOffset, length)
 }
 
+// Unseals the data key of an SSE-S3 or SSE-KMS object
+func (yig *YigStorage) unsealObjectKey(object *meta.Object) (key []byte, err error) {
+	if yig.KMS == nil {
+		return nil, ErrKMSNotConfigured
+	}
+	keyID, context, err := SealingContext(object.SseType, object.SseKmsKeyId, object.SseContext,
+		object.BucketName, object.Name)
+	if err != nil {
+		return nil, err
+	}
+	if keyID == "" {
+		keyID = yig.KMS.GetKeyID()
+	}
+	unsealedKey, err := yig.KMS.UnsealKey(keyID, object.EncryptionKey, context)
+	if err != nil {
+		return nil, err
+	}
+	return unsealedKey[:], nil
+}
+
 func (yig *YigStorage) GetObject(object *meta.Object, startOffset int64,
 	length int64, writer io.Writer, sseRequest datatype.SseRequest) (err error) {
 	var encryptionKey []byte
-	if object.SseType == crypto.S3.String() {
-		if yig.KMS == nil {
-			return ErrKMSNotConfigured
-		}
-		key, err := yig.KMS.UnsealKey(yig.KMS.GetKeyID(), object.EncryptionKey,
-			crypto.Context{object.BucketName: path.Join(object.BucketName, object.Name)})
+	if object.SseType == crypto.S3.String() || object.SseType == crypto.S3KMS.String() {
+		encryptionKey, err = yig.unsealObjectKey(object)
 		if err != nil {
 			return err
 		}
-		encryptionKey = key[:]
 	} else { // SSE-C
 		if len(sseRequest.CopySourceSseCustomerKey) != 0 {
 			encryptionKey = sseRequest.CopySourceSseCustomerKey
@@ -204,6 +256,10 @@ func (yig *YigStorage) GetObject(object *meta.Object, startOffset int64,
 		}
 	}
 
+	if object.Compressed {
+		return yig.getCompressedObject(object, startOffset, length, writer, encryptionKey)
+	}
+
 	if len(object.Parts) == 0 { // this object has only one part
 		cephCluster, ok := yig.DataStorage[object.Location]
 		if !ok {
@@ -493,6 +549,65 @@ func (yig *YigStorage) SetObjectAcl(bucketName string, objectName string, versio
 	return nil
 }
 
+func (yig *YigStorage) getObjectForSubResource(bucketName, objectName, version string,
+	credential common.Credential) (object *meta.Object, err error) {
+
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	if version == "" {
+		object, err = yig.MetaStorage.GetObject(bucketName, objectName, false)
+	} else {
+		object, err = yig.getObjWithVersion(bucketName, objectName, version)
+	}
+	if err != nil {
+		return
+	}
+	if object.DeleteMarker {
+		return nil, ErrNoSuchKey
+	}
+	if !credential.AllowOtherUserAccess && bucket.OwnerId != credential.UserId &&
+		object.OwnerId != credential.UserId {
+		return nil, ErrAccessDenied
+	}
+	return object, nil
+}
+
+func (yig *YigStorage) GetObjectTagging(bucketName, objectName, version string,
+	credential common.Credential) (tagging datatype.Tagging, err error) {
+
+	object, err := yig.getObjectForSubResource(bucketName, objectName, version, credential)
+	if err != nil {
+		return
+	}
+	return datatype.TaggingFromMap(object.Tags), nil
+}
+
+func (yig *YigStorage) SetObjectTagging(bucketName, objectName, version string,
+	tags map[string]string, credential common.Credential) error {
+
+	object, err := yig.getObjectForSubResource(bucketName, objectName, version, credential)
+	if err != nil {
+		return err
+	}
+	object.Tags = tags
+	err = yig.MetaStorage.UpdateObjectTags(object)
+	if err != nil {
+		helper.Logger.Error("Update object tags, sql fails:", err)
+		return ErrInternalError
+	}
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, bucketName+":"+objectName+":")
+	yig.MetaStorage.Cache.Remove(redis.ObjectTable, bucketName+":"+objectName+":"+version)
+	return nil
+}
+
+func (yig *YigStorage) DeleteObjectTagging(bucketName, objectName, version string,
+	credential common.Credential) error {
+
+	return yig.SetObjectTagging(bucketName, objectName, version, nil, credential)
+}
+
 // Write path:
 //                                           +-----------+
 // PUT object/part                           |           |   Ceph
@@ -506,10 +621,11 @@ func (yig *YigStorage) SetObjectAcl(bucketName string, objectName string, versio
 // Encryptor is enabled when user set SSE headers
 func (yig *YigStorage) PutObject(bucketName string, objectName string, credential common.Credential,
 	size int64, data io.ReadCloser, metadata map[string]string, acl datatype.Acl,
-	sseRequest datatype.SseRequest, storageClass meta.StorageClass) (result datatype.PutObjectResult, err error) {
+	sseRequest datatype.SseRequest, storageClass meta.StorageClass,
+	tags map[string]string) (result datatype.PutObjectResult, err error) {
 
 	defer data.Close()
-	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(sseRequest, bucketName, objectName)
+	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(&sseRequest, bucketName, objectName)
 	helper.Logger.Info("get encryptionKey:", encryptionKey, "cipherKey:", cipherKey, "err:", err)
 	if err != nil {
 		return
@@ -529,6 +645,11 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 			return result, ErrBucketAccessForbidden
 		}
 	}
+	// size is -1 for POST and chunked uploads, checked again once written
+	err = yig.checkQuota(bucket, objectName, helper.Ternary(size > 0, size, int64(0)).(int64), 1)
+	if err != nil {
+		return
+	}
 
 	md5Writer := md5.New()
 
@@ -545,7 +666,8 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 		return result, ErrInternalError
 	}
 
-	dataReader := io.TeeReader(limitedDataReader, md5Writer)
+	dataReader := &countingReader{reader: io.TeeReader(limitedDataReader, md5Writer)}
+	compressed := compression.ShouldCompress(objectName, metadata["Content-Type"])
 
 	var initializationVector []byte
 	if len(encryptionKey) != 0 {
@@ -555,7 +677,8 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 		}
 	}
 	// Not support now
-	storageReader, err := wrapEncryptionReader(dataReader, encryptionKey, initializationVector)
+	storageReader, err := wrapEncryptionReader(wrapCompressionReader(dataReader, compressed),
+		encryptionKey, initializationVector)
 	if err != nil {
 		return
 	}
@@ -570,12 +693,19 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 		pool:     poolName,
 		objectId: objectId,
 	}
-	if int64(bytesWritten) < size {
+	if dataReader.count < size {
 		RecycleQueue <- maybeObjectToRecycle
 		helper.Logger.Error("Failed to write objects, already written",
-			bytesWritten, "total size", size)
+			dataReader.count, "total size", size)
 		return result, ErrIncompleteBody
 	}
+	if size <= 0 {
+		err = yig.checkQuota(bucket, objectName, dataReader.count, 1)
+		if err != nil {
+			RecycleQueue <- maybeObjectToRecycle
+			return
+		}
+	}
 
 	calculatedMd5 := hex.EncodeToString(md5Writer.Sum(nil))
 	helper.Logger.Info("CalculatedMd5:", calculatedMd5, "userMd5:", metadata["md5Sum"])
@@ -602,7 +732,7 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 		Location:         cluster.ID(),
 		Pool:             poolName,
 		OwnerId:          credential.UserId,
-		Size:             int64(bytesWritten),
+		Size:             dataReader.count,
 		ObjectId:         objectId,
 		LastModifiedTime: time.Now().UTC(),
 		Etag:             calculatedMd5,
@@ -611,15 +741,24 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 		NullVersion:      helper.Ternary(bucket.Versioning == "Enabled", false, true).(bool),
 		DeleteMarker:     false,
 		SseType:          sseRequest.Type,
-		EncryptionKey: helper.Ternary(sseRequest.Type == crypto.S3.String(),
+		EncryptionKey: helper.Ternary(sseRequest.Type == crypto.S3.String() || sseRequest.Type == crypto.S3KMS.String(),
 			cipherKey, []byte("")).([]byte),
+		SseKmsKeyId:          sseRequest.SseAwsKmsKeyId,
+		SseContext:           sseRequest.SseContext,
 		InitializationVector: initializationVector,
 		CustomAttributes:     metadata,
+		Tags:                 tags,
 		Type:                 meta.ObjectTypeNormal,
 		StorageClass:         storageClass,
+		Compressed:           compressed,
+		CompressedSize:       helper.Ternary(compressed, int64(bytesWritten), int64(0)).(int64),
 	}
+	object.ReplicationStatus = replicationStatus(bucket, objectName, object.CustomAttributes, credential)
+	applyDefaultRetention(bucket, object)
 
 	result.LastModified = object.LastModifiedTime
+	result.SseType = object.SseType
+	result.SseAwsKmsKeyId = object.SseKmsKeyId
 	var nullVerNum uint64
 	nullVerNum, err = yig.checkOldObject(bucketName, objectName, bucket.Versioning)
 	if err != nil {
@@ -635,7 +774,7 @@ func (yig *YigStorage) PutObject(bucketName string, objectName string, credentia
 	}
 
 	if object.StorageClass == meta.ObjectStorageClassGlacier {
-		freezer, err := yig.MetaStorage.GetFreezer(object.BucketName, object.Name, object.VersionId)
+		freezer, err := yig.MetaStorage.GetFreezer(object.BucketName, object.Name, meta.FreezerVersion(object))
 		if err == nil {
 			err = yig.MetaStorage.DeleteFreezer(freezer)
 			if err != nil {
@@ -690,7 +829,8 @@ func (yig *YigStorage) PutObjectMeta(bucket *meta.Bucket, targetObject *meta.Obj
 	return nil
 }
 
-func (yig *YigStorage) RenameObject(targetObject *meta.Object, sourceObject string, credential common.Credential) (result datatype.RenameObjectResult, err error) {
+func (yig *YigStorage) RenameObject(targetObject *meta.Object, sourceObject string, bypassGovernance bool,
+	credential common.Credential) (result datatype.RenameObjectResult, err error) {
 
 	bucket, err := yig.MetaStorage.GetBucket(targetObject.BucketName, true)
 	if err != nil {
@@ -704,6 +844,11 @@ func (yig *YigStorage) RenameObject(targetObject *meta.Object, sourceObject stri
 			return result, ErrBucketAccessForbidden
 		}
 	}
+	// the source object is removed by renaming, lock of it is carried by targetObject
+	err = checkObjectLock(targetObject, bypassGovernance)
+	if err != nil {
+		return
+	}
 
 	if len(targetObject.Parts) != 0 {
 		err = yig.MetaStorage.RenameObjectPart(targetObject, sourceObject)
@@ -732,7 +877,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 	var oid string
 	var maybeObjectToRecycle objectToRecycle
 	var encryptionKey []byte
-	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(sseRequest, targetObject.BucketName, targetObject.Name)
+	encryptionKey, cipherKey, err := yig.encryptionKeyFromSseRequest(&sseRequest, targetObject.BucketName, targetObject.Name)
 	if err != nil {
 		return
 	}
@@ -750,8 +895,24 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 			return result, ErrBucketAccessForbidden
 		}
 	}
+	targetObject.ReplicationStatus = replicationStatus(bucket, targetObject.Name, targetObject.CustomAttributes,
+		credential)
+
+	// like S3, changing storage class in a versioning-enabled bucket creates a new version
+	if isMetadataOnly && bucket.Versioning == "Enabled" &&
+		sourceObject.StorageClass != meta.ObjectStorageClassGlacier &&
+		targetObject.StorageClass != sourceObject.StorageClass {
+		isMetadataOnly = false
+	}
 
 	if isMetadataOnly {
+		// metadata of the source version is replaced in place
+		result.SseType = sourceObject.SseType
+		result.SseAwsKmsKeyId = sourceObject.SseKmsKeyId
+		err = checkObjectLock(sourceObject, false)
+		if err != nil {
+			return
+		}
 		if sourceObject.StorageClass == meta.ObjectStorageClassGlacier {
 			targetObject.LastModifiedTime = sourceObject.LastModifiedTime
 			err = yig.MetaStorage.UpdateGlacierObject(targetObject, sourceObject, true)
@@ -767,6 +928,16 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 			yig.DataCache.Remove(targetObject.BucketName + ":" + targetObject.Name + ":" + targetObject.GetVersionId())
 			return result, nil
 		}
+		if targetObject.StorageClass != sourceObject.StorageClass {
+			// data is moved to the pool of new storage class first
+			err = yig.TransitObject(sourceObject, targetObject.StorageClass)
+			if err != nil {
+				helper.Logger.Error("Copy Object with same source and target, change storage class",
+					"to", targetObject.StorageClass.ToString(), "fails:", err)
+				return
+			}
+		}
+		targetObject.LastModifiedTime = sourceObject.LastModifiedTime
 		err = yig.MetaStorage.ReplaceObjectMetas(targetObject)
 		if err != nil {
 			helper.Logger.Error("Copy Object with same source and target, sql fails:", err)
@@ -782,12 +953,18 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 		return result, nil
 	}
 
+	err = yig.checkQuota(bucket, targetObject.Name, targetObject.Size, 1)
+	if err != nil {
+		return
+	}
+
 	// Limit the reader to its provided size if specified.
 	var limitedDataReader io.Reader
 	limitedDataReader = io.LimitReader(source, targetObject.Size)
 
 	cephCluster, poolName := yig.pickClusterAndPool(targetObject.BucketName,
 		targetObject.Name, targetObject.StorageClass, targetObject.Size, false)
+	compressed := compression.ShouldCompress(targetObject.Name, targetObject.ContentType)
 
 	if len(targetObject.Parts) != 0 {
 		var targetParts map[int]*meta.Part = make(map[int]*meta.Part, len(targetObject.Parts))
@@ -807,7 +984,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 					pw.Close()
 				}()
 				md5Writer := md5.New()
-				dataReader := io.TeeReader(pr, md5Writer)
+				dataReader := &countingReader{reader: io.TeeReader(pr, md5Writer)}
 				var bytesW uint64
 				var storageReader io.Reader
 				var initializationVector []byte
@@ -817,14 +994,15 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 						return
 					}
 				}
-				storageReader, err = wrapEncryptionReader(dataReader, encryptionKey, initializationVector)
+				storageReader, err = wrapEncryptionReader(wrapCompressionReader(dataReader, compressed),
+					encryptionKey, initializationVector)
 				oid, bytesW, err = cephCluster.Put(poolName, storageReader)
 				maybeObjectToRecycle = objectToRecycle{
 					location: cephCluster.ID(),
 					pool:     poolName,
 					objectId: oid,
 				}
-				if bytesW < uint64(part.Size) {
+				if dataReader.count < part.Size {
 					RecycleQueue <- maybeObjectToRecycle
 					return result, ErrIncompleteBody
 				}
@@ -840,6 +1018,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 				}
 				part.LastModified = time.Now().UTC().Format(meta.CREATE_TIME_LAYOUT)
 				part.ObjectId = oid
+				part.CompressedSize = helper.Ternary(compressed, int64(bytesW), int64(0)).(int64)
 
 				part.InitializationVector = initializationVector
 				return result, nil
@@ -855,7 +1034,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 		md5Writer := md5.New()
 
 		// Mapping a shorter name for the object
-		dataReader := io.TeeReader(limitedDataReader, md5Writer)
+		dataReader := &countingReader{reader: io.TeeReader(limitedDataReader, md5Writer)}
 		var storageReader io.Reader
 		var initializationVector []byte
 		if len(encryptionKey) != 0 {
@@ -864,7 +1043,8 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 				return
 			}
 		}
-		storageReader, err = wrapEncryptionReader(dataReader, encryptionKey, initializationVector)
+		storageReader, err = wrapEncryptionReader(wrapCompressionReader(dataReader, compressed),
+			encryptionKey, initializationVector)
 		if err != nil {
 			return
 		}
@@ -880,7 +1060,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 			pool:     poolName,
 			objectId: oid,
 		}
-		if int64(bytesWritten) < targetObject.Size {
+		if dataReader.count < targetObject.Size {
 			RecycleQueue <- maybeObjectToRecycle
 			return result, ErrIncompleteBody
 		}
@@ -893,6 +1073,7 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 		result.Md5 = calculatedMd5
 		targetObject.ObjectId = oid
 		targetObject.InitializationVector = initializationVector
+		targetObject.CompressedSize = helper.Ternary(compressed, int64(bytesWritten), int64(0)).(int64)
 	}
 	// TODO validate bucket policy and fancy ACL
 
@@ -904,9 +1085,15 @@ func (yig *YigStorage) CopyObject(targetObject *meta.Object, sourceObject *meta.
 	targetObject.LastModifiedTime = time.Now().UTC()
 	targetObject.NullVersion = helper.Ternary(bucket.Versioning == "Enabled", false, true).(bool)
 	targetObject.DeleteMarker = false
+	targetObject.Compressed = compressed
 	targetObject.SseType = sseRequest.Type
-	targetObject.EncryptionKey = helper.Ternary(sseRequest.Type == crypto.S3.String(),
+	targetObject.EncryptionKey = helper.Ternary(sseRequest.Type == crypto.S3.String() || sseRequest.Type == crypto.S3KMS.String(),
 		cipherKey, []byte("")).([]byte)
+	targetObject.SseKmsKeyId = sseRequest.SseAwsKmsKeyId
+	targetObject.SseContext = sseRequest.SseContext
+	result.SseType = targetObject.SseType
+	result.SseAwsKmsKeyId = targetObject.SseKmsKeyId
+	applyDefaultRetention(bucket, targetObject)
 
 	result.LastModified = targetObject.LastModifiedTime
 
@@ -974,6 +1161,26 @@ func (yig *YigStorage) getObjWithVersion(bucketName, objectName, version string)
 
 }
 
+// The null version of objectName, which is replaced by writes in a versioning
+// suspended bucket
+func (yig *YigStorage) getNullVersion(bucketName, objectName string) (object *meta.Object, err error) {
+	objMap, err := yig.MetaStorage.GetObjectMap(bucketName, objectName)
+	if err == nil {
+		return yig.MetaStorage.GetObjectVersion(bucketName, objectName, objMap.NullVerId, false)
+	}
+	if err != ErrNoSuchKey {
+		return nil, err
+	}
+	object, err = yig.MetaStorage.GetObject(bucketName, objectName, false)
+	if err != nil {
+		return nil, err
+	}
+	if !object.NullVersion {
+		return nil, ErrNoSuchKey
+	}
+	return object, nil
+}
+
 func (yig *YigStorage) removeAllObjectsEntryByName(bucketName, objectName string) (err error) {
 
 	objs, err := yig.MetaStorage.GetAllObject(bucketName, objectName)
@@ -985,7 +1192,7 @@ func (yig *YigStorage) removeAllObjectsEntryByName(bucketName, objectName string
 	}
 	for _, obj := range objs {
 		if obj.StorageClass == meta.ObjectStorageClassGlacier {
-			freezer, err := yig.GetFreezer(bucketName, objectName, "")
+			freezer, err := yig.GetFreezer(bucketName, objectName, meta.FreezerVersion(obj))
 			if err == nil {
 				if freezer.Name == objectName {
 					err = yig.MetaStorage.DeleteFreezer(freezer)
@@ -1056,6 +1263,10 @@ func (yig *YigStorage) checkOldObject(bucketName, objectName, versioning string)
 		} else {
 			helper.Logger.Info("object.NullVersion:", object.NullVersion)
 			if objectExist && object.NullVersion {
+				err = checkObjectLock(object, false)
+				if err != nil {
+					return
+				}
 				err = yig.MetaStorage.DeleteObject(object, object.DeleteMarker, nil)
 				if err != nil {
 					return
@@ -1068,7 +1279,11 @@ func (yig *YigStorage) checkOldObject(bucketName, objectName, versioning string)
 	return 0, errors.New("No Such versioning status!")
 }
 
-func (yig *YigStorage) removeObjectVersion(bucketName, objectName, version string) error {
+// Remove the specified version, either "null" or any other version, the object
+// map is only dropped along with the null version
+func (yig *YigStorage) removeObjectVersion(bucketName, objectName, version string,
+	bypassGovernance bool) error {
+
 	object, err := yig.getObjWithVersion(bucketName, objectName, version)
 	if err == ErrNoSuchKey {
 		return nil
@@ -1076,18 +1291,19 @@ func (yig *YigStorage) removeObjectVersion(bucketName, objectName, version strin
 	if err != nil {
 		return err
 	}
+	err = checkObjectLock(object, bypassGovernance)
+	if err != nil {
+		return err
+	}
 
+	var objMap *meta.ObjMap
 	if version == "null" {
-		objMap := &meta.ObjMap{
+		objMap = &meta.ObjMap{
 			Name:       objectName,
 			BucketName: bucketName,
 		}
-		err = yig.removeByObject(object, objMap)
-		if err != nil {
-			return err
-		}
 	}
-	return nil
+	return yig.removeByObject(object, objMap)
 }
 
 func (yig *YigStorage) addDeleteMarker(bucket meta.Bucket, objectName string,
@@ -1126,9 +1342,12 @@ func (yig *YigStorage) addDeleteMarker(bucket meta.Bucket, objectName string,
 // | Suspended | remove corresponding version | remove null version object(if exists) and add a        |
 // |           |                              | null version delete marker                             |
 //
+// Removing a version protected by object lock fails with ErrObjectLocked, unless it's
+// under GOVERNANCE retention and bypassGovernance is set.
+//
 // See http://docs.aws.amazon.com/AmazonS3/latest/dev/Versioning.html
 func (yig *YigStorage) DeleteObject(bucketName string, objectName string, version string,
-	credential common.Credential) (result datatype.DeleteObjectResult, err error) {
+	bypassGovernance bool, credential common.Credential) (result datatype.DeleteObjectResult, err error) {
 
 	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
 	if err != nil {
@@ -1160,7 +1379,7 @@ func (yig *YigStorage) DeleteObject(bucketName string, objectName string, versio
 			}
 			result.DeleteMarker = true
 		} else {
-			err = yig.removeObjectVersion(bucketName, objectName, version)
+			err = yig.removeObjectVersion(bucketName, objectName, version, bypassGovernance)
 			if err != nil {
 				return
 			}
@@ -1168,7 +1387,7 @@ func (yig *YigStorage) DeleteObject(bucketName string, objectName string, versio
 		}
 	case meta.VersionSuspended:
 		if version == "" {
-			err = yig.removeObjectVersion(bucketName, objectName, "null")
+			err = yig.removeObjectVersion(bucketName, objectName, "null", bypassGovernance)
 			if err != nil {
 				return
 			}
@@ -1178,7 +1397,7 @@ func (yig *YigStorage) DeleteObject(bucketName string, objectName string, versio
 			}
 			result.DeleteMarker = true
 		} else {
-			err = yig.removeObjectVersion(bucketName, objectName, version)
+			err = yig.removeObjectVersion(bucketName, objectName, version, bypassGovernance)
 			if err != nil {
 				return
 			}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/redis"
+)
+
+func exceedsQuota(quota common.Quota, usage, objects, addedBytes, addedObjects int64) bool {
+	if quota.MaxBytes > 0 && usage+addedBytes > quota.MaxBytes {
+		return true
+	}
+	if quota.MaxObjects > 0 && objects+addedObjects > quota.MaxObjects {
+		return true
+	}
+	return false
+}
+
+// Size of the part already uploaded, which is replaced by uploading it again
+func uploadedPartSize(multipart meta.Multipart, partId int) int64 {
+	if part, ok := multipart.Parts[partId]; ok {
+		return part.Size
+	}
+	return 0
+}
+
+// Size and number of existing objects replaced by writing objectName into bucket,
+// they are removed along with the write unless versioning is enabled, see checkOldObject
+func (yig *YigStorage) replacedUsage(bucket *meta.Bucket, objectName string) (bytes, objects int64, err error) {
+	var replaced []*meta.Object
+	switch bucket.Versioning {
+	case meta.VersionEnabled:
+		return 0, 0, nil
+	case meta.VersionDisabled:
+		replaced, err = yig.MetaStorage.GetAllObject(bucket.Name, objectName)
+	default:
+		var object *meta.Object
+		object, err = yig.getNullVersion(bucket.Name, objectName)
+		replaced = []*meta.Object{object}
+	}
+	if err == ErrNoSuchKey {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, object := range replaced {
+		// delete markers are not counted in usage
+		if object.DeleteMarker {
+			continue
+		}
+		bytes += object.Size
+		objects += 1
+	}
+	return bytes, objects, nil
+}
+
+// Check whether writing addedBytes bytes and addedObjects objects into bucket
+// would exceed quota of the bucket or its owner. Existing objects replaced by
+// writing objectName are subtracted, objectName is empty for parts. Quotas
+// could be overrun by concurrent writes a little since usage is not locked.
+func (yig *YigStorage) checkQuota(bucket *meta.Bucket, objectName string, addedBytes, addedObjects int64) error {
+	userQuota, err := yig.MetaStorage.GetUserQuota(bucket.OwnerId)
+	if err != nil {
+		return err
+	}
+	bucketLimited := bucket.Quota.MaxBytes > 0 || bucket.Quota.MaxObjects > 0
+	userLimited := userQuota.MaxBytes > 0 || userQuota.MaxObjects > 0
+	if !bucketLimited && !userLimited {
+		return nil
+	}
+	if objectName != "" {
+		replacedBytes, replacedObjects, err := yig.replacedUsage(bucket, objectName)
+		if err != nil {
+			return err
+		}
+		addedBytes -= replacedBytes
+		addedObjects -= replacedObjects
+	}
+
+	if bucketLimited {
+		// usage of cached bucket is not updated on write
+		b, err := yig.MetaStorage.Client.GetBucket(bucket.Name)
+		if err != nil {
+			return err
+		}
+		if exceedsQuota(bucket.Quota, b.Usage, b.ObjectCount, addedBytes, addedObjects) {
+			return ErrQuotaExceeded
+		}
+	}
+	if userLimited {
+		usage, objects, err := yig.MetaStorage.GetUserUsage(bucket.OwnerId)
+		if err != nil {
+			return err
+		}
+		if exceedsQuota(userQuota, usage, objects, addedBytes, addedObjects) {
+			return ErrQuotaExceeded
+		}
+	}
+	return nil
+}
+
+func (yig *YigStorage) SetBucketQuota(bucketName string, quota common.Quota) error {
+	if quota.MaxBytes < 0 || quota.MaxObjects < 0 {
+		return ErrInvalidQuota
+	}
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return err
+	}
+	bucket.Quota = quota
+	err = yig.MetaStorage.Client.PutBucket(*bucket)
+	if err != nil {
+		return err
+	}
+	yig.MetaStorage.Cache.Remove(redis.BucketTable, bucketName)
+	return nil
+}
+
+func (yig *YigStorage) GetBucketQuota(bucketName string) (quota common.Quota, err error) {
+	bucket, err := yig.MetaStorage.GetBucket(bucketName, true)
+	if err != nil {
+		return
+	}
+	return bucket.Quota, nil
+}
+
+func (yig *YigStorage) SetUserQuota(userId string, quota common.Quota) error {
+	if quota.MaxBytes < 0 || quota.MaxObjects < 0 {
+		return ErrInvalidQuota
+	}
+	return yig.MetaStorage.PutUserQuota(userId, quota)
+}
+
+func (yig *YigStorage) GetUserQuota(userId string) (quota common.Quota, err error) {
+	return yig.MetaStorage.GetUserQuota(userId)
+}
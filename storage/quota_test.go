@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExceedsQuota(t *testing.T) {
+	var testcase = [...]struct {
+		quota        common.Quota
+		usage        int64
+		objects      int64
+		addedBytes   int64
+		addedObjects int64
+		expected     bool
+	}{
+		{common.Quota{}, 1 << 40, 1 << 20, 100, 1, false},
+		{common.Quota{MaxBytes: 1000}, 900, 10, 100, 1, false},
+		{common.Quota{MaxBytes: 1000}, 900, 10, 101, 1, true},
+		{common.Quota{MaxObjects: 10}, 900, 9, 100, 1, false},
+		{common.Quota{MaxObjects: 10}, 900, 10, 100, 1, true},
+		// parts add no objects
+		{common.Quota{MaxObjects: 10}, 900, 10, 100, 0, false},
+		{common.Quota{MaxBytes: 1000, MaxObjects: 10}, 1000, 0, 1, 0, true},
+		// overwriting objects replaces their size and count
+		{common.Quota{MaxBytes: 1000, MaxObjects: 10}, 1000, 10, 0, 0, false},
+		{common.Quota{MaxBytes: 1000, MaxObjects: 10}, 1000, 10, -50, 0, false},
+		{common.Quota{MaxBytes: 1000, MaxObjects: 10}, 1000, 10, 1, 0, true},
+	}
+	for _, c := range testcase {
+		assert.Equal(t, c.expected,
+			exceedsQuota(c.quota, c.usage, c.objects, c.addedBytes, c.addedObjects), c)
+	}
+}
+
+func TestUploadedPartSize(t *testing.T) {
+	multipart := meta.Multipart{Parts: map[int]*meta.Part{
+		1: {PartNumber: 1, Size: 100},
+	}}
+	assert.Equal(t, int64(100), uploadedPartSize(multipart, 1))
+	assert.Equal(t, int64(0), uploadedPartSize(multipart, 2))
+}
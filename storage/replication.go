@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+// Replication status of a new object version, which is PENDING if it matches
+// replication rules of the bucket.
+// Replicas written by the replication worker carry `X-Amz-Replication-Status: REPLICA`
+// in metadata and are never replicated again, the header itself is not saved as
+// a custom attribute. The header is only honoured for replication access keys,
+// otherwise any user could skip replication of its objects.
+func replicationStatus(bucket *meta.Bucket, objectName string, metadata map[string]string,
+	credential common.Credential) string {
+
+	replica := metadata[datatype.ReplicationStatusHeader] == meta.ReplicationStatusReplica &&
+		isReplicationAccessKey(credential.AccessKeyID)
+	delete(metadata, datatype.ReplicationStatusHeader)
+	if replica {
+		return meta.ReplicationStatusReplica
+	}
+	if bucket.Versioning != meta.VersionEnabled || bucket.Replication.Match(objectName) == nil {
+		return ""
+	}
+	return meta.ReplicationStatusPending
+}
+
+func isReplicationAccessKey(accessKey string) bool {
+	for _, key := range helper.CONFIG.ReplicationAccessKeys {
+		if accessKey != "" && key == accessKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/iam/common"
+	meta "github.com/journeymidnight/yig/meta/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicationStatus(t *testing.T) {
+	helper.CONFIG.ReplicationAccessKeys = []string{"replicator"}
+	defer func() {
+		helper.CONFIG.ReplicationAccessKeys = nil
+	}()
+	bucket := &meta.Bucket{
+		Versioning: meta.VersionEnabled,
+		Replication: datatype.ReplicationConfiguration{
+			Rules: []datatype.ReplicationRule{
+				{Status: datatype.ReplicationRuleEnabled, Prefix: "backup/"},
+			},
+		},
+	}
+	var testcase = [...]struct {
+		objectName string
+		header     string
+		accessKey  string
+		expected   string
+	}{
+		{"backup/a", "", "hehehehe", meta.ReplicationStatusPending},
+		{"backup/a", meta.ReplicationStatusReplica, "replicator", meta.ReplicationStatusReplica},
+		// only replication workers could skip replication
+		{"backup/a", meta.ReplicationStatusReplica, "hehehehe", meta.ReplicationStatusPending},
+		{"backup/a", meta.ReplicationStatusReplica, "", meta.ReplicationStatusPending},
+		{"other/a", "", "hehehehe", ""},
+		{"other/a", meta.ReplicationStatusReplica, "hehehehe", ""},
+	}
+	for _, c := range testcase {
+		metadata := map[string]string{"Content-Type": "text/plain"}
+		if c.header != "" {
+			metadata[datatype.ReplicationStatusHeader] = c.header
+		}
+		status := replicationStatus(bucket, c.objectName, metadata,
+			common.Credential{AccessKeyID: c.accessKey})
+		assert.Equal(t, c.expected, status, c.objectName, c.header, c.accessKey)
+		_, ok := metadata[datatype.ReplicationStatusHeader]
+		assert.False(t, ok)
+		assert.Equal(t, 1, len(metadata))
+	}
+}
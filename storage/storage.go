@@ -5,6 +5,7 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"encoding/json"
 	"github.com/journeymidnight/yig/api/datatype"
 	"github.com/journeymidnight/yig/backend"
 	"github.com/journeymidnight/yig/circuitbreak"
@@ -12,6 +13,7 @@ import (
 	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/meta"
+	"github.com/journeymidnight/yig/meta/types"
 	"github.com/journeymidnight/yig/redis"
 	"io"
 	"path"
@@ -27,12 +29,22 @@ const (
 
 // *YigStorage implements api.ObjectLayer
 type YigStorage struct {
-	DataStorage map[string]backend.Cluster
-	DataCache   DataCache
-	MetaStorage *meta.Meta
-	KMS         crypto.KMS
-	Stopping    bool
-	WaitGroup   *sync.WaitGroup
+	DataStorage  map[string]backend.Cluster
+	DataBackends map[string]string // cluster ID -> name of the backend plugin
+	DataCache    DataCache
+	MetaStorage  *meta.Meta
+	KMS          crypto.KMS
+	Stopping     bool
+	WaitGroup    *sync.WaitGroup
+
+	// pool policies of all storage classes, loaded from config at startup
+	storageClassPolicies map[types.StorageClass]helper.StorageClassPolicy
+
+	migrationMutex sync.Mutex
+	migration      *migrationJob // the latest migration job
+
+	storageClassJobMutex sync.Mutex
+	storageClassJob      *storageClassJob // the latest job changing storage class
 }
 
 func (y *YigStorage) Stop() {
@@ -72,13 +84,33 @@ func (y *YigStorage) PingCache(interval time.Duration) {
 	}
 }
 
-func (yig *YigStorage) encryptionKeyFromSseRequest(sseRequest datatype.SseRequest, bucket, object string) (key []byte, encKey []byte, err error) {
+// For SSE-KMS, the KMS key ID and the encryption context the key is sealed with are
+// saved back to sseRequest, so they could be stored with the object.
+func (yig *YigStorage) encryptionKeyFromSseRequest(sseRequest *datatype.SseRequest, bucket, object string) (key []byte, encKey []byte, err error) {
 	switch sseRequest.Type {
 	case "": // no encryption
 		return nil, nil, nil
-	// not implemented yet
 	case crypto.S3KMS.String():
-		return nil, nil, ErrNotImplemented
+		if yig.KMS == nil {
+			return nil, nil, ErrKMSNotConfigured
+		}
+		if sseRequest.SseAwsKmsKeyId == "" {
+			sseRequest.SseAwsKmsKeyId = yig.KMS.GetKeyID()
+		}
+		context, err := kmsContext(sseRequest.SseContext, bucket, object)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, encKey, err := yig.KMS.GenerateKey(sseRequest.SseAwsKmsKeyId, context)
+		if err == crypto.ErrKMSKeyNotFound {
+			return nil, nil, ErrKMSKeyNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		encodedContext, _ := json.Marshal(context)
+		sseRequest.SseContext = string(encodedContext)
+		return key[:], encKey, nil
 	case crypto.S3.String():
 		if yig.KMS == nil {
 			return nil, nil, ErrKMSNotConfigured
@@ -96,6 +128,37 @@ func (yig *YigStorage) encryptionKeyFromSseRequest(sseRequest datatype.SseReques
 	}
 }
 
+// Encryption context of SSE-KMS keys is the user provided context in JSON,
+// plus the object path as SSE-S3 does
+func kmsContext(userContext, bucket, object string) (context crypto.Context, err error) {
+	context = make(crypto.Context)
+	if userContext != "" {
+		err = json.Unmarshal([]byte(userContext), &context)
+		if err != nil {
+			return nil, ErrInvalidEncryptionContext
+		}
+	}
+	context[bucket] = path.Join(bucket, object)
+	return context, nil
+}
+
+// SealingContext returns the master key ID and encryption context the data key
+// of an SSE-S3 or SSE-KMS object or multipart upload is sealed with, an empty
+// key ID stands for the default key of KMS
+func SealingContext(sseType, kmsKeyId, sseContext, bucketName, objectName string) (
+	keyID string, context crypto.Context, err error) {
+
+	if sseType == crypto.S3KMS.String() {
+		context = make(crypto.Context)
+		err = json.Unmarshal([]byte(sseContext), &context)
+		if err != nil {
+			return "", nil, err
+		}
+		return kmsKeyId, context, nil
+	}
+	return "", crypto.Context{bucketName: path.Join(bucketName, objectName)}, nil
+}
+
 func newInitializationVector() (initializationVector []byte, err error) {
 
 	initializationVector = make([]byte, INITIALIZATION_VECTOR_LENGTH)
@@ -146,6 +209,18 @@ func (r *alignedReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// In CTR mode the counter block of the n-th block is IV+n, taken as a big-endian integer
+func offsetInitializationVector(initializationVector []byte, blocks int64) []byte {
+	counter := make([]byte, len(initializationVector))
+	copy(counter, initializationVector)
+	for i := len(counter) - 1; i >= 0 && blocks > 0; i-- {
+		sum := int64(counter[i]) + blocks&0xff
+		counter[i] = byte(sum)
+		blocks = blocks>>8 + sum>>8
+	}
+	return counter
+}
+
 // AES is a block cipher with block size of 16 bytes, i.e. the basic unit of encryption/decryption
 // is 16 bytes. As an HTTP range request could start from any byte, we need to read one more
 // block if necessary.
@@ -167,7 +242,8 @@ func wrapAlignedEncryptionReader(reader io.Reader, startOffset int64, encryption
 	}
 
 	alignedOffset := startOffset / AES_BLOCK_SIZE * AES_BLOCK_SIZE
-	newReader, err := wrapEncryptionReader(reader, encryptionKey, initializationVector)
+	newReader, err := wrapEncryptionReader(reader, encryptionKey,
+		offsetInitializationVector(initializationVector, alignedOffset/AES_BLOCK_SIZE))
 	if err != nil {
 		return
 	}
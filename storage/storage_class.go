@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"github.com/journeymidnight/yig/backend"
+	"github.com/journeymidnight/yig/helper"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+const BIG_FILE_THRESHOLD = 128 << 10 /* 128K */
+
+// Pools of storage classes not set in [storage_class] of config file
+var defaultStorageClassPolicies = map[meta.StorageClass]helper.StorageClassPolicy{
+	meta.ObjectStorageClassStandard: {
+		SmallPool: backend.SMALL_FILE_POOLNAME,
+		BigPool:   backend.BIG_FILE_POOLNAME,
+		Threshold: BIG_FILE_THRESHOLD,
+	},
+	meta.ObjectStorageClassGlacier: {
+		SmallPool: backend.GLACIER_FILE_POOLNAME,
+		BigPool:   backend.GLACIER_FILE_POOLNAME,
+	},
+}
+
+// Build pool policies of all storage classes from config, panic on invalid ones
+func (yig *YigStorage) loadStorageClassPolicies() {
+	policies := make(map[meta.StorageClass]helper.StorageClassPolicy)
+	for class, policy := range defaultStorageClassPolicies {
+		policies[class] = policy
+	}
+	for name, policy := range helper.CONFIG.StorageClasses {
+		class, err := meta.MatchStorageClassIndex(name)
+		if err != nil {
+			panic("Invalid storage class in config: " + name)
+		}
+		if policy.BigPool == "" {
+			panic("big_pool of storage class " + name + " is not set")
+		}
+		// appendable objects are written to big pool, which is not supported by small pool
+		if policy.BigPool == backend.SMALL_FILE_POOLNAME {
+			panic("big_pool of storage class " + name + " cannot be " + backend.SMALL_FILE_POOLNAME)
+		}
+		if policy.SmallPool == "" {
+			policy.SmallPool = policy.BigPool
+		}
+		if policy.Threshold < 0 {
+			panic("threshold of storage class " + name + " cannot be negative")
+		}
+		for _, id := range policy.Clusters {
+			if _, ok := yig.DataStorage[id]; !ok {
+				panic("Cluster " + id + " of storage class " + name + " is not initialized")
+			}
+		}
+		policies[class] = policy
+	}
+	for class := range meta.StorageClassIndexMap {
+		if _, ok := policies[class]; !ok {
+			policies[class] = policies[meta.ObjectStorageClassStandard]
+		}
+	}
+	yig.storageClassPolicies = policies
+	yig.checkStorageClassPools()
+}
+
+// Warn about pools of storage classes which have no cluster to write in table cluster
+func (yig *YigStorage) checkStorageClassPools() {
+	clusters, err := yig.MetaStorage.GetClusters()
+	if err != nil {
+		helper.Logger.Warn("Error getting clusters from DB:", err)
+		return
+	}
+	for class, policy := range yig.storageClassPolicies {
+		for _, pool := range []string{policy.SmallPool, policy.BigPool} {
+			found := false
+			for _, c := range clusters {
+				if c.Pool == pool && c.Weight != 0 && yig.clusterUsable(c) &&
+					clusterAllowed(c.Fsid, policy.Clusters) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				helper.Logger.Warn("No cluster in DB to write pool", pool,
+					"of storage class", class.ToString())
+			}
+		}
+	}
+}
+
+func (yig *YigStorage) storageClassPolicy(storageClass meta.StorageClass) helper.StorageClassPolicy {
+	if policy, ok := yig.storageClassPolicies[storageClass]; ok {
+		return policy
+	}
+	return yig.storageClassPolicies[meta.ObjectStorageClassStandard]
+}
+
+// Empty list of clusters allows all
+func clusterAllowed(fsid string, clusters []string) bool {
+	if len(clusters) == 0 {
+		return true
+	}
+	for _, c := range clusters {
+		if c == fsid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	meta "github.com/journeymidnight/yig/meta/types"
+)
+
+// Progress of a job changing storage class, reported by admin server.
+// Status is one of the migration statuses
+type StorageClassJobProgress struct {
+	Bucket       string
+	Prefix       string
+	StorageClass string
+	Status       string
+	Error        string `json:",omitempty"`
+	StartTime    time.Time
+	EndTime      time.Time
+
+	CurrentObject  string
+	ScannedObjects int64
+	ChangedObjects int64
+	ChangedBytes   int64
+	SkippedObjects int64 // already in the storage class, changed or deleted during the job
+	FailedObjects  int64
+}
+
+type storageClassJob struct {
+	mutex    sync.Mutex
+	progress StorageClassJobProgress
+	cancel   chan struct{}
+}
+
+func (job *storageClassJob) update(fn func(p *StorageClassJobProgress)) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	fn(&job.progress)
+}
+
+func (job *storageClassJob) getProgress() StorageClassJobProgress {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+	return job.progress
+}
+
+// Start a job in background to change storage class of all object versions
+// in bucket with names starting with prefix, data is moved to the pool of new
+// storage class in place like lifecycle transitions.
+// Only one job could be running at a time.
+func (yig *YigStorage) StartStorageClassJob(bucketName, prefix, storageClass string) error {
+	class, err := meta.MatchStorageClassIndex(storageClass)
+	if err != nil {
+		return err
+	}
+	_, err = yig.MetaStorage.GetBucket(bucketName, false)
+	if err != nil {
+		return err
+	}
+
+	yig.storageClassJobMutex.Lock()
+	defer yig.storageClassJobMutex.Unlock()
+	if yig.storageClassJob != nil && yig.storageClassJob.getProgress().Status == MigrationRunning {
+		return ErrStorageClassJobInProgress
+	}
+	if yig.Stopping {
+		return ErrMaintenance
+	}
+	job := &storageClassJob{
+		progress: StorageClassJobProgress{
+			Bucket:       bucketName,
+			Prefix:       prefix,
+			StorageClass: class.ToString(),
+			Status:       MigrationRunning,
+			StartTime:    time.Now().UTC(),
+		},
+		cancel: make(chan struct{}),
+	}
+	yig.storageClassJob = job
+	yig.WaitGroup.Add(1)
+	go yig.runStorageClassJob(job, class)
+	helper.Logger.Info("Storage class job started for", bucketName, prefix, "to", class.ToString())
+	return nil
+}
+
+// Get progress of the latest job changing storage class
+func (yig *YigStorage) GetStorageClassJobProgress() (progress StorageClassJobProgress, err error) {
+	yig.storageClassJobMutex.Lock()
+	defer yig.storageClassJobMutex.Unlock()
+	if yig.storageClassJob == nil {
+		return progress, ErrNoSuchStorageClassJob
+	}
+	return yig.storageClassJob.getProgress(), nil
+}
+
+// Cancel the running job, objects already changed are kept
+func (yig *YigStorage) CancelStorageClassJob() error {
+	yig.storageClassJobMutex.Lock()
+	defer yig.storageClassJobMutex.Unlock()
+	if yig.storageClassJob == nil || yig.storageClassJob.getProgress().Status != MigrationRunning {
+		return ErrNoSuchStorageClassJob
+	}
+	select {
+	case <-yig.storageClassJob.cancel:
+	default:
+		close(yig.storageClassJob.cancel)
+	}
+	return nil
+}
+
+func (yig *YigStorage) runStorageClassJob(job *storageClassJob, class meta.StorageClass) {
+	defer yig.WaitGroup.Done()
+	err := yig.changeStorageClass(job, class)
+	job.update(func(p *StorageClassJobProgress) {
+		p.EndTime = time.Now().UTC()
+		switch err {
+		case nil:
+			p.Status = MigrationCompleted
+		case errMigrationCancelled:
+			p.Status = MigrationCancelled
+		default:
+			p.Status = MigrationFailed
+			p.Error = err.Error()
+		}
+	})
+	progress := job.getProgress()
+	helper.Logger.Info("Storage class job for", progress.Bucket, progress.Prefix,
+		"finished:", progress.Status, "changed:", progress.ChangedObjects,
+		"skipped:", progress.SkippedObjects, "failed:", progress.FailedObjects, progress.Error)
+}
+
+func (yig *YigStorage) storageClassJobStopped(job *storageClassJob) bool {
+	if yig.Stopping {
+		return true
+	}
+	select {
+	case <-job.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// Walk object versions under prefix in order, and transit those not in the storage class
+func (yig *YigStorage) changeStorageClass(job *storageClassJob, class meta.StorageClass) error {
+	progress := job.getProgress()
+	keyMarker := progress.Prefix
+	var versionMarker uint64
+	for {
+		if yig.storageClassJobStopped(job) {
+			return errMigrationCancelled
+		}
+		objects, nextKeyMarker, nextVersionMarker, truncated, err :=
+			yig.MetaStorage.ScanObjectVersions(progress.Bucket, keyMarker, versionMarker, MIGRATION_SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if !strings.HasPrefix(object.Name, progress.Prefix) {
+				return nil
+			}
+			if yig.storageClassJobStopped(job) {
+				return errMigrationCancelled
+			}
+			job.update(func(p *StorageClassJobProgress) {
+				p.CurrentObject = object.Name
+				p.ScannedObjects++
+			})
+			if object.DeleteMarker || object.StorageClass == class {
+				job.update(func(p *StorageClassJobProgress) {
+					p.SkippedObjects++
+				})
+				continue
+			}
+			err := yig.TransitObject(object, class)
+			if err != nil && err != ErrNoSuchKey {
+				helper.Logger.Error("Change storage class of", object.BucketName, object.Name,
+					object.GetVersionId(), "to", class.ToString(), "error:", err)
+			}
+			job.update(func(p *StorageClassJobProgress) {
+				if err == ErrNoSuchKey {
+					p.SkippedObjects++
+				} else if err != nil {
+					p.FailedObjects++
+				} else {
+					p.ChangedObjects++
+					p.ChangedBytes += object.Size
+				}
+			})
+		}
+		if !truncated {
+			return nil
+		}
+		keyMarker, versionMarker = nextKeyMarker, nextVersionMarker
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 )
 
+const TEST_LOGGING_TARGET_BUCKET = "mylogbucket"
+
 func Test_BucketLogging_Prepare(t *testing.T) {
 	sc := NewS3()
 	err := sc.MakeBucket(TEST_BUCKET)
@@ -13,13 +15,18 @@ func Test_BucketLogging_Prepare(t *testing.T) {
 		t.Fatal("MakeBucket err:", err)
 		panic(err)
 	}
+	err = sc.MakeBucket(TEST_LOGGING_TARGET_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+		panic(err)
+	}
 	t.Log("MakeBucket Success.")
 }
 
 func Test_PutBucketLogging(t *testing.T) {
 	sc := NewS3()
 	rules := &s3.LoggingEnabled{
-		TargetBucket: aws.String("testTargetBucket"),
+		TargetBucket: aws.String(TEST_LOGGING_TARGET_BUCKET),
 		TargetPrefix: aws.String("testTargetPrefix"),
 	}
 	err := sc.PutBucketLogging(TEST_BUCKET, rules)
@@ -30,6 +37,19 @@ func Test_PutBucketLogging(t *testing.T) {
 	t.Log("PutBucketLogging Success.")
 }
 
+func Test_PutBucketLoggingWithNoSuchTargetBucket(t *testing.T) {
+	sc := NewS3()
+	rules := &s3.LoggingEnabled{
+		TargetBucket: aws.String("testTargetBucket"),
+		TargetPrefix: aws.String("testTargetPrefix"),
+	}
+	err := sc.PutBucketLogging(TEST_BUCKET, rules)
+	if err == nil {
+		t.Fatal("PutBucketLogging with no such target bucket should fail")
+	}
+	t.Log("PutBucketLogging with no such target bucket failed as expected:", err)
+}
+
 func Test_GetBucketLogging(t *testing.T) {
 	sc := NewS3()
 	out,err := sc.GetBucketLogging(TEST_BUCKET)
@@ -60,5 +80,10 @@ func Test_BucketLogging_End(t *testing.T) {
 		t.Fatal("DeleteBucket err:", err)
 		panic(err)
 	}
+	err = sc.DeleteBucket(TEST_LOGGING_TARGET_BUCKET)
+	if err != nil {
+		t.Fatal("DeleteBucket err:", err)
+		panic(err)
+	}
 
 }
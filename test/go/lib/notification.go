@@ -0,0 +1,22 @@
+package lib
+
+import (
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+)
+
+func (s3client *S3Client) PutBucketNotification(bucketName string, conf *s3.NotificationConfiguration) (err error) {
+	params := &s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(bucketName),
+		NotificationConfiguration: conf,
+	}
+	_, err = s3client.Client.PutBucketNotificationConfiguration(params)
+	return err
+}
+
+func (s3client *S3Client) GetBucketNotification(bucketName string) (conf *s3.NotificationConfiguration, err error) {
+	params := &s3.GetBucketNotificationConfigurationRequest{
+		Bucket: aws.String(bucketName),
+	}
+	return s3client.Client.GetBucketNotificationConfiguration(params)
+}
@@ -0,0 +1,45 @@
+package lib
+
+import (
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+)
+
+func (s3client *S3Client) PutBucketVersioning(bucketName string, status string) (err error) {
+	params := &s3.PutBucketVersioningInput{
+		Bucket: aws.String(bucketName),
+		VersioningConfiguration: &s3.VersioningConfiguration{
+			Status: aws.String(status),
+		},
+	}
+	_, err = s3client.Client.PutBucketVersioning(params)
+	return err
+}
+
+func (s3client *S3Client) PutBucketReplication(bucketName string, conf *s3.ReplicationConfiguration) (err error) {
+	params := &s3.PutBucketReplicationInput{
+		Bucket:                   aws.String(bucketName),
+		ReplicationConfiguration: conf,
+	}
+	_, err = s3client.Client.PutBucketReplication(params)
+	return err
+}
+
+func (s3client *S3Client) GetBucketReplication(bucketName string) (conf *s3.ReplicationConfiguration, err error) {
+	params := &s3.GetBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+	}
+	out, err := s3client.Client.GetBucketReplication(params)
+	if err != nil {
+		return nil, err
+	}
+	return out.ReplicationConfiguration, nil
+}
+
+func (s3client *S3Client) DeleteBucketReplication(bucketName string) (err error) {
+	params := &s3.DeleteBucketReplicationInput{
+		Bucket: aws.String(bucketName),
+	}
+	_, err = s3client.Client.DeleteBucketReplication(params)
+	return err
+}
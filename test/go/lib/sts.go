@@ -0,0 +1,53 @@
+package lib
+
+import (
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/aws/credentials"
+	"github.com/journeymidnight/aws-sdk-go/aws/session"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+	"github.com/journeymidnight/aws-sdk-go/service/sts"
+)
+
+const (
+	TEST_ROLE_ARN     = "arn:aws:iam::hehehehe:role/ci"
+	TEST_ROLE_SESSION = "ci-runner"
+	// session policy which only allows to get objects of TEST_BUCKET
+	GetObjectSessionPolicy = `{
+	"Version": "2012-10-17",
+	"Statement": [{
+		"Effect": "Allow",
+		"Action": ["s3:GetObject"],
+		"Resource": ["arn:aws:s3:::` + TEST_BUCKET + `/*"]
+	}]
+}`
+)
+
+func NewSTS() *sts.STS {
+	creds := credentials.NewStaticCredentials(AccessKey, SecretKey, "")
+	return sts.New(session.Must(session.NewSession(
+		&aws.Config{
+			Credentials: creds,
+			DisableSSL:  aws.Bool(true),
+			Endpoint:    aws.String(Endpoint),
+			Region:      aws.String(Region),
+		},
+	),
+	),
+	)
+}
+
+func NewS3WithSessionCredentials(c *sts.Credentials) *S3Client {
+	creds := credentials.NewStaticCredentials(aws.StringValue(c.AccessKeyId),
+		aws.StringValue(c.SecretAccessKey), aws.StringValue(c.SessionToken))
+	s3client := s3.New(session.Must(session.NewSession(
+		&aws.Config{
+			Credentials: creds,
+			DisableSSL:  aws.Bool(true),
+			Endpoint:    aws.String(Endpoint),
+			Region:      aws.String(Region),
+		},
+	),
+	),
+	)
+	return &S3Client{s3client}
+}
@@ -112,6 +112,97 @@ func Test_LifeCycle(t *testing.T) {
 
 }
 
+func Test_LifeCycleRules(t *testing.T) {
+	sc := NewS3()
+
+	rules := []*s3.LifecycleRule{
+		{
+			ID: aws.String("transition"),
+			Filter: &s3.LifecycleRuleFilter{
+				And: &s3.LifecycleRuleAndOperator{
+					Prefix: aws.String("logs/"),
+					Tags: []*s3.Tag{
+						{Key: aws.String("type"), Value: aws.String("log")},
+					},
+				},
+			},
+			Status: aws.String("Enabled"),
+			Transitions: []*s3.Transition{
+				{Days: aws.Int64(30), StorageClass: aws.String("STANDARD_IA")},
+				{Days: aws.Int64(90), StorageClass: aws.String("GLACIER")},
+			},
+			Expiration: &s3.LifecycleExpiration{
+				Days: aws.Int64(365),
+			},
+		},
+		{
+			ID: aws.String("noncurrent"),
+			Filter: &s3.LifecycleRuleFilter{
+				Prefix: aws.String("data/"),
+			},
+			Status: aws.String("Enabled"),
+			NoncurrentVersionTransitions: []*s3.NoncurrentVersionTransition{
+				{NoncurrentDays: aws.Int64(7), StorageClass: aws.String("GLACIER")},
+			},
+			NoncurrentVersionExpiration: &s3.NoncurrentVersionExpiration{
+				NoncurrentDays: aws.Int64(30),
+			},
+			AbortIncompleteMultipartUpload: &s3.AbortIncompleteMultipartUpload{
+				DaysAfterInitiation: aws.Int64(7),
+			},
+		},
+	}
+	_, err := sc.Client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(TEST_BUCKET),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		t.Fatal("PutBucketLifecycle err:", err)
+	}
+	t.Log("PutBucketLifecycle Success!")
+
+	out, err := sc.Client.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(TEST_BUCKET),
+	})
+	if err != nil {
+		t.Fatal("GetBucketLifecycle err:", err)
+	}
+	if len(out.Rules) != 2 || len(out.Rules[0].Transitions) != 2 ||
+		out.Rules[1].NoncurrentVersionExpiration == nil ||
+		out.Rules[1].AbortIncompleteMultipartUpload == nil {
+		t.Fatal("GetBucketLifecycle err: unexpected rules", out.Rules)
+	}
+	t.Log("GetBucketLifecycle Success!")
+
+	// Transition to an unsupported storage class should be rejected
+	_, err = sc.Client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(TEST_BUCKET),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{
+			Rules: []*s3.LifecycleRule{
+				{
+					ID:     aws.String("invalid"),
+					Filter: &s3.LifecycleRuleFilter{Prefix: aws.String("")},
+					Status: aws.String("Enabled"),
+					Transitions: []*s3.Transition{
+						{Days: aws.Int64(30), StorageClass: aws.String("STANDARD")},
+					},
+				},
+			},
+		},
+	})
+	if err == nil {
+		t.Fatal("PutBucketLifecycle with invalid transition should fail")
+	}
+	t.Log("PutBucketLifecycle with invalid transition failed as expected:", err)
+
+	_, err = sc.Client.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(TEST_BUCKET),
+	})
+	if err != nil {
+		t.Fatal("DeleteBucketLifecycle err:", err)
+	}
+}
+
 func Test_LC_End(t *testing.T) {
 	sc := NewS3()
 	err := sc.DeleteObject(TEST_BUCKET, TEST_KEY)
@@ -0,0 +1,69 @@
+package _go
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_BucketNotification(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	conf := &s3.NotificationConfiguration{
+		QueueConfigurations: []*s3.QueueConfiguration{
+			{
+				Id:       aws.String("index"),
+				QueueArn: aws.String("arn:yig:kafka:::index"),
+				Events:   []*string{aws.String("s3:ObjectCreated:*"), aws.String("s3:ObjectRemoved:*")},
+				Filter: &s3.NotificationConfigurationFilter{
+					Key: &s3.KeyFilter{
+						FilterRules: []*s3.FilterRule{
+							{Name: aws.String("prefix"), Value: aws.String("images/")},
+							{Name: aws.String("suffix"), Value: aws.String(".jpg")},
+						},
+					},
+				},
+			},
+		},
+	}
+	err = sc.PutBucketNotification(TEST_BUCKET, conf)
+	if err != nil {
+		t.Fatal("PutBucketNotification err:", err)
+	}
+	out, err := sc.GetBucketNotification(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("GetBucketNotification err:", err)
+	}
+	if len(out.QueueConfigurations) != 1 || aws.StringValue(out.QueueConfigurations[0].Id) != "index" ||
+		len(out.QueueConfigurations[0].Events) != 2 {
+		t.Fatal("Notification configuration not match:", out)
+	}
+
+	// events should still be published while objects are written and deleted
+	err = sc.PutObject(TEST_BUCKET, "images/"+TEST_KEY+".jpg", TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+	err = sc.DeleteObject(TEST_BUCKET, "images/"+TEST_KEY+".jpg")
+	if err != nil {
+		t.Fatal("DeleteObject err:", err)
+	}
+
+	conf.QueueConfigurations[0].Events = []*string{aws.String("s3:ObjectAccessed:*")}
+	err = sc.PutBucketNotification(TEST_BUCKET, conf)
+	if err == nil {
+		t.Fatal("PutBucketNotification with unsupported event should fail")
+	}
+
+	err = sc.PutBucketNotification(TEST_BUCKET, &s3.NotificationConfiguration{})
+	if err != nil {
+		t.Fatal("PutBucketNotification with empty configuration err:", err)
+	}
+}
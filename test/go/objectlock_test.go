@@ -0,0 +1,152 @@
+package _go
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_ObjectLock(t *testing.T) {
+	sc := NewS3()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+	defer sc.DeleteBucket(TEST_BUCKET)
+
+	conf := &s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String("Enabled"),
+		Rule: &s3.ObjectLockRule{
+			DefaultRetention: &s3.DefaultRetention{
+				Mode: aws.String("GOVERNANCE"),
+				Days: aws.Int64(1),
+			},
+		},
+	}
+	// object lock requires versioning enabled
+	err = sc.PutObjectLockConfiguration(TEST_BUCKET, conf)
+	if err == nil {
+		t.Fatal("PutObjectLockConfiguration should fail without versioning")
+	}
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Enabled")
+	if err != nil {
+		t.Fatal("PutBucketVersioning err:", err)
+	}
+	err = sc.PutObjectLockConfiguration(TEST_BUCKET, conf)
+	if err != nil {
+		t.Fatal("PutObjectLockConfiguration err:", err)
+	}
+	out, err := sc.GetObjectLockConfiguration(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("GetObjectLockConfiguration err:", err)
+	}
+	if aws.StringValue(out.Rule.DefaultRetention.Mode) != "GOVERNANCE" ||
+		aws.Int64Value(out.Rule.DefaultRetention.Days) != 1 {
+		t.Fatal("Object lock configuration not match:", out)
+	}
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Suspended")
+	if err == nil {
+		t.Fatal("PutBucketVersioning should fail with object lock enabled")
+	}
+
+	versionId, err := sc.PutObjectWithVersion(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+	retention, err := sc.GetObjectRetention(TEST_BUCKET, TEST_KEY, versionId)
+	if err != nil {
+		t.Fatal("GetObjectRetention err:", err)
+	}
+	if aws.StringValue(retention.Mode) != "GOVERNANCE" {
+		t.Fatal("Default retention not applied:", retention)
+	}
+
+	err = sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, versionId, false)
+	if err == nil {
+		t.Fatal("DeleteObject should fail under retention")
+	}
+	err = sc.PutObjectLegalHold(TEST_BUCKET, TEST_KEY, versionId, "ON")
+	if err != nil {
+		t.Fatal("PutObjectLegalHold err:", err)
+	}
+	err = sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, versionId, true)
+	if err == nil {
+		t.Fatal("DeleteObject should fail under legal hold")
+	}
+	err = sc.PutObjectLegalHold(TEST_BUCKET, TEST_KEY, versionId, "OFF")
+	if err != nil {
+		t.Fatal("PutObjectLegalHold err:", err)
+	}
+	// bucket owner could bypass GOVERNANCE retention
+	err = sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, versionId, true)
+	if err != nil {
+		t.Fatal("DeleteObject with governance bypass err:", err)
+	}
+}
+
+func makeObjectLockBucket(t *testing.T, sc *S3Client) {
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Enabled")
+	if err != nil {
+		t.Fatal("PutBucketVersioning err:", err)
+	}
+	err = sc.PutObjectLockConfiguration(TEST_BUCKET, &s3.ObjectLockConfiguration{
+		ObjectLockEnabled: aws.String("Enabled"),
+		Rule: &s3.ObjectLockRule{
+			DefaultRetention: &s3.DefaultRetention{
+				Mode: aws.String("GOVERNANCE"),
+				Days: aws.Int64(1),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("PutObjectLockConfiguration err:", err)
+	}
+}
+
+func Test_ObjectLockAppend(t *testing.T) {
+	sc := NewS3()
+	makeObjectLockBucket(t, sc)
+	defer sc.DeleteBucket(TEST_BUCKET)
+
+	_, err := sc.AppendObject(TEST_BUCKET, TEST_KEY, TEST_VALUE, 0)
+	if err == nil {
+		sc.DeleteObject(TEST_BUCKET, TEST_KEY)
+		t.Fatal("AppendObject should fail with object lock enabled")
+	}
+}
+
+func Test_ObjectLockRename(t *testing.T) {
+	sc := NewS3()
+	makeObjectLockBucket(t, sc)
+	defer sc.DeleteBucket(TEST_BUCKET)
+
+	versionId, err := sc.PutObjectWithVersion(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+	defer sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, versionId, true)
+
+	TEST_RENAME_KEY := "RENAME:" + TEST_KEY
+	_, err = sc.Client.RenameObject(&s3.RenameObjectInput{
+		Bucket:          aws.String(TEST_BUCKET),
+		RenameSourceKey: aws.String(TEST_KEY),
+		Key:             aws.String(TEST_RENAME_KEY),
+	})
+	if err == nil {
+		sc.DeleteObject(TEST_BUCKET, TEST_RENAME_KEY)
+		t.Fatal("RenameObject should fail under retention")
+	}
+	v, err := sc.GetObject(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("GetObject err:", err)
+	}
+	if v != TEST_VALUE {
+		t.Fatal("Locked object changed by rename:", v)
+	}
+}
@@ -0,0 +1,73 @@
+package _go
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_BucketReplication(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	conf := &s3.ReplicationConfiguration{
+		Role: aws.String("arn:aws:iam:::role/replication"),
+		Rules: []*s3.ReplicationRule{
+			{
+				ID:     aws.String("backup"),
+				Status: aws.String("Enabled"),
+				Prefix: aws.String("backup/"),
+				Destination: &s3.Destination{
+					Bucket: aws.String("arn:aws:s3:cn-sh-1::" + TEST_COPY_BUCKET),
+				},
+			},
+		},
+	}
+	// replication requires versioning enabled
+	err = sc.PutBucketReplication(TEST_BUCKET, conf)
+	if err == nil {
+		t.Fatal("PutBucketReplication should fail without versioning")
+	}
+
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Enabled")
+	if err != nil {
+		t.Fatal("PutBucketVersioning err:", err)
+	}
+	err = sc.PutBucketReplication(TEST_BUCKET, conf)
+	if err != nil {
+		t.Fatal("PutBucketReplication err:", err)
+	}
+	out, err := sc.GetBucketReplication(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("GetBucketReplication err:", err)
+	}
+	if len(out.Rules) != 1 || aws.StringValue(out.Rules[0].ID) != "backup" ||
+		aws.StringValue(out.Rules[0].Destination.Bucket) != "arn:aws:s3:cn-sh-1::"+TEST_COPY_BUCKET {
+		t.Fatal("Replication configuration not match:", out)
+	}
+
+	// versioning could not be suspended while replication is configured
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Suspended")
+	if err == nil {
+		t.Fatal("PutBucketVersioning should fail with replication configured")
+	}
+
+	err = sc.DeleteBucketReplication(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("DeleteBucketReplication err:", err)
+	}
+	_, err = sc.GetBucketReplication(TEST_BUCKET)
+	if err == nil {
+		t.Fatal("GetBucketReplication should fail after delete")
+	}
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Suspended")
+	if err != nil {
+		t.Fatal("PutBucketVersioning err:", err)
+	}
+}
@@ -0,0 +1,113 @@
+package _go
+
+import (
+	"testing"
+
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/aws/awserr"
+	"github.com/journeymidnight/aws-sdk-go/service/sts"
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_GetSessionToken(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	out, err := NewSTS().GetSessionToken(&sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int64(900),
+	})
+	if err != nil {
+		t.Fatal("GetSessionToken err:", err)
+	}
+	session := NewS3WithSessionCredentials(out.Credentials)
+	err = session.PutObject(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject with session credentials err:", err)
+	}
+	value, err := session.GetObject(TEST_BUCKET, TEST_KEY)
+	if err != nil || value != TEST_VALUE {
+		t.Fatal("GetObject with session credentials err:", err, value)
+	}
+	err = session.DeleteObject(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("DeleteObject with session credentials err:", err)
+	}
+}
+
+func Test_AssumeRoleWithSessionPolicy(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+	err = sc.PutObject(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+
+	out, err := NewSTS().AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         aws.String(TEST_ROLE_ARN),
+		RoleSessionName: aws.String(TEST_ROLE_SESSION),
+		Policy:          aws.String(GetObjectSessionPolicy),
+	})
+	if err != nil {
+		t.Fatal("AssumeRole err:", err)
+	}
+	session := NewS3WithSessionCredentials(out.Credentials)
+	value, err := session.GetObject(TEST_BUCKET, TEST_KEY)
+	if err != nil || value != TEST_VALUE {
+		t.Fatal("GetObject with session credentials err:", err, value)
+	}
+	// not allowed by session policy
+	err = session.PutObject(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err == nil {
+		t.Fatal("PutObject should be denied by session policy")
+	}
+
+	// roles of other accounts could not be assumed
+	_, err = NewSTS().AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws:iam::hahahaha:role/ci"),
+		RoleSessionName: aws.String(TEST_ROLE_SESSION),
+	})
+	if err == nil {
+		t.Fatal("AssumeRole of other account should fail")
+	}
+}
+
+func Test_SessionPolicyOnBucketAPIs(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	out, err := NewSTS().AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         aws.String(TEST_ROLE_ARN),
+		RoleSessionName: aws.String(TEST_ROLE_SESSION),
+		Policy:          aws.String(GetObjectSessionPolicy),
+	})
+	if err != nil {
+		t.Fatal("AssumeRole err:", err)
+	}
+	session := NewS3WithSessionCredentials(out.Credentials)
+	// bucket APIs are not allowed by session policy either, though the session
+	// acts as owner of the bucket
+	err = session.DeleteBucket(TEST_BUCKET)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "AccessDenied" {
+		t.Fatal("DeleteBucket should be denied by session policy, err:", err)
+	}
+	err = sc.HeadBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("Bucket should not be deleted, HeadBucket err:", err)
+	}
+	_, err = session.ListObjects(TEST_BUCKET)
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "AccessDenied" {
+		t.Fatal("ListObjects should be denied by session policy, err:", err)
+	}
+}
@@ -0,0 +1,96 @@
+package _go
+
+import (
+	"testing"
+
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_ObjectTagging(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	err = sc.PutObjectWithTagging(TEST_BUCKET, TEST_KEY, TEST_VALUE, "k1=v1&k2=v2")
+	if err != nil {
+		t.Fatal("PutObjectWithTagging err:", err)
+	}
+	tags, err := sc.GetObjectTagging(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("GetObjectTagging err:", err)
+	}
+	if len(tags) != 2 || tags["k1"] != "v1" || tags["k2"] != "v2" {
+		t.Fatal("Tags from x-amz-tagging not match:", tags)
+	}
+
+	err = sc.PutObjectTagging(TEST_BUCKET, TEST_KEY, map[string]string{"k3": "v3"})
+	if err != nil {
+		t.Fatal("PutObjectTagging err:", err)
+	}
+	out, err := sc.GetObjectOutPut(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("GetObject err:", err)
+	}
+	if out.TagCount == nil || *out.TagCount != 1 {
+		t.Fatal("Tag count not match:", out.TagCount)
+	}
+
+	err = sc.DeleteObjectTagging(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("DeleteObjectTagging err:", err)
+	}
+	tags, err = sc.GetObjectTagging(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("GetObjectTagging err:", err)
+	}
+	if len(tags) != 0 {
+		t.Fatal("Tags should be empty after DeleteObjectTagging:", tags)
+	}
+
+	tooMany := make(map[string]string)
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"} {
+		tooMany[k] = k
+	}
+	err = sc.PutObjectTagging(TEST_BUCKET, TEST_KEY, tooMany)
+	if err == nil {
+		t.Fatal("PutObjectTagging with 11 tags should fail")
+	}
+}
+
+func Test_BucketTagging(t *testing.T) {
+	sc := NewS3()
+	defer sc.CleanEnv()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+
+	_, err = sc.GetBucketTagging(TEST_BUCKET)
+	if err == nil {
+		t.Fatal("GetBucketTagging without tags should fail")
+	}
+
+	err = sc.PutBucketTagging(TEST_BUCKET, map[string]string{"project": "yig", "team": "storage"})
+	if err != nil {
+		t.Fatal("PutBucketTagging err:", err)
+	}
+	tags, err := sc.GetBucketTagging(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("GetBucketTagging err:", err)
+	}
+	if len(tags) != 2 || tags["project"] != "yig" || tags["team"] != "storage" {
+		t.Fatal("Bucket tags not match:", tags)
+	}
+
+	err = sc.DeleteBucketTagging(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("DeleteBucketTagging err:", err)
+	}
+	_, err = sc.GetBucketTagging(TEST_BUCKET)
+	if err == nil {
+		t.Fatal("GetBucketTagging after DeleteBucketTagging should fail")
+	}
+}
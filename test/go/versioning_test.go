@@ -0,0 +1,63 @@
+package _go
+
+import (
+	"testing"
+
+	. "github.com/journeymidnight/yig/test/go/lib"
+)
+
+func Test_DeleteObjectVersion(t *testing.T) {
+	sc := NewS3()
+	err := sc.MakeBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("MakeBucket err:", err)
+	}
+	defer sc.DeleteBucket(TEST_BUCKET)
+	err = sc.PutBucketVersioning(TEST_BUCKET, "Enabled")
+	if err != nil {
+		t.Fatal("PutBucketVersioning err:", err)
+	}
+
+	oldVersion, err := sc.PutObjectWithVersion(TEST_BUCKET, TEST_KEY, "old value")
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+	newVersion, err := sc.PutObjectWithVersion(TEST_BUCKET, TEST_KEY, TEST_VALUE)
+	if err != nil {
+		t.Fatal("PutObject err:", err)
+	}
+	if oldVersion == "" || oldVersion == newVersion {
+		t.Fatal("Unexpected versions:", oldVersion, newVersion)
+	}
+
+	// deleting a specific version removes only that version, without a delete marker
+	err = sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, oldVersion, false)
+	if err != nil {
+		t.Fatal("DeleteObjectVersion err:", err)
+	}
+	_, err = sc.GetObjectVersion(TEST_BUCKET, TEST_KEY, oldVersion)
+	if err == nil {
+		t.Fatal("GetObjectVersion should fail for deleted version", oldVersion)
+	}
+	v, err := sc.GetObject(TEST_BUCKET, TEST_KEY)
+	if err != nil {
+		t.Fatal("GetObject err:", err)
+	}
+	if v != TEST_VALUE {
+		t.Fatal("GetObject value is:", v, ", but should be:", TEST_VALUE)
+	}
+
+	// so the bucket is empty after deleting the last version
+	err = sc.DeleteObjectVersion(TEST_BUCKET, TEST_KEY, newVersion, false)
+	if err != nil {
+		t.Fatal("DeleteObjectVersion err:", err)
+	}
+	err = sc.HeadObject(TEST_BUCKET, TEST_KEY)
+	if err == nil {
+		t.Fatal("HeadObject should fail after all versions are deleted")
+	}
+	err = sc.DeleteBucket(TEST_BUCKET)
+	if err != nil {
+		t.Fatal("DeleteBucket err:", err)
+	}
+}
@@ -21,11 +21,18 @@ var config Config
 
 func printHelp() {
 	fmt.Println("Usage: admin <commands> [options...] ")
-	fmt.Println("Commands: usage|bucket|object|user|cachehit")
+	fmt.Println("Commands: usage|bucket|object|user|cachehit|migrate|migration|cancelmigration|" +
+		"changeclass|classjob|cancelclassjob")
 	fmt.Println("Options:")
 	fmt.Println(" -b, --bucket   Specify bucket to operate")
 	fmt.Println(" -u, --uid      Specify user name to operate")
 	fmt.Println(" -o, --object   Specify object to operate")
+	fmt.Println(" -fsid          Specify cluster to migrate data from")
+	fmt.Println(" -pool          Specify pool to migrate data from")
+	fmt.Println(" -target-fsid   Specify cluster to migrate data to, clusters are picked by weight if not set")
+	fmt.Println(" -target-pool   Specify pool to migrate data to, same as -pool if not set")
+	fmt.Println(" -prefix        Specify prefix of objects in bucket to change storage class")
+	fmt.Println(" -class         Specify storage class to change objects to, e.g STANDARD_IA")
 }
 
 func isParaEmpty(p string) bool {
@@ -212,6 +219,85 @@ func getCacheHit() {
 
 }
 
+// method is POST to start a migration, GET to get its progress and DELETE to cancel it
+func migration(method, fsid, pool, targetFsid, targetPool string) {
+	if method == "POST" && (isParaEmpty(fsid) || isParaEmpty(pool)) {
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"fsid":        fsid,
+		"pool":        pool,
+		"target_fsid": targetFsid,
+		"target_pool": targetPool,
+	})
+
+	tokenString, err := token.SignedString([]byte(config.AdminKey))
+
+	if err == nil {
+		//go use token
+		fmt.Printf("\nHS256 = %v\n", tokenString)
+	} else {
+		fmt.Println("internal error", err)
+		return
+	}
+
+	url := config.RequestUrl + "/admin/migration"
+	request, _ := http.NewRequest(method, url, nil)
+	request.Header.Set("Authorization", "Bearer "+tokenString)
+	response, err := client.Do(request)
+	if err != nil {
+		fmt.Println("migration failed error:", err.Error())
+		return
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != 200 {
+		fmt.Println("migration failed as status != 200", response.StatusCode, string(body))
+		return
+	}
+	fmt.Println(string(body))
+}
+
+// method is POST to start a job changing storage class, GET to get its progress and DELETE to cancel it
+func storageClassJob(method, bucket, prefix, storageClass string) {
+	if method == "POST" && (isParaEmpty(bucket) || isParaEmpty(storageClass)) {
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"bucket":        bucket,
+		"prefix":        prefix,
+		"storage_class": storageClass,
+	})
+
+	tokenString, err := token.SignedString([]byte(config.AdminKey))
+
+	if err == nil {
+		//go use token
+		fmt.Printf("\nHS256 = %v\n", tokenString)
+	} else {
+		fmt.Println("internal error", err)
+		return
+	}
+
+	url := config.RequestUrl + "/admin/storageclass"
+	request, _ := http.NewRequest(method, url, nil)
+	request.Header.Set("Authorization", "Bearer "+tokenString)
+	response, err := client.Do(request)
+	if err != nil {
+		fmt.Println("storage class job failed error:", err.Error())
+		return
+	}
+	defer response.Body.Close()
+	body, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode != 200 {
+		fmt.Println("storage class job failed as status != 200", response.StatusCode, string(body))
+		return
+	}
+	fmt.Println(string(body))
+}
+
 func main() {
 	f, err := os.Open("./admin.json")
 	if err != nil {
@@ -231,6 +317,12 @@ func main() {
 	bucket := mySet.String("b", "", "bucket name")
 	uid := mySet.String("u", "", "user name")
 	object := mySet.String("o", "", "object name")
+	fsid := mySet.String("fsid", "", "source cluster of migration")
+	pool := mySet.String("pool", "", "source pool of migration")
+	targetFsid := mySet.String("target-fsid", "", "target cluster of migration")
+	targetPool := mySet.String("target-pool", "", "target pool of migration")
+	prefix := mySet.String("prefix", "", "prefix of objects to change storage class")
+	storageClass := mySet.String("class", "", "storage class to change objects to")
 	mySet.Parse(os.Args[2:])
 	fmt.Println("command:", os.Args[1], "bucket:", *bucket, "user:", *uid, "object:", *object)
 	switch os.Args[1] {
@@ -244,6 +336,18 @@ func main() {
 		getObjectInfo(*bucket, *object)
 	case "cachehit":
 		getCacheHit()
+	case "migrate":
+		migration("POST", *fsid, *pool, *targetFsid, *targetPool)
+	case "migration":
+		migration("GET", *fsid, *pool, *targetFsid, *targetPool)
+	case "cancelmigration":
+		migration("DELETE", *fsid, *pool, *targetFsid, *targetPool)
+	case "changeclass":
+		storageClassJob("POST", *bucket, *prefix, *storageClass)
+	case "classjob":
+		storageClassJob("GET", *bucket, *prefix, *storageClass)
+	case "cancelclassjob":
+		storageClassJob("DELETE", *bucket, *prefix, *storageClass)
 	default:
 		printHelp()
 		return
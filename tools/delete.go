@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"github.com/journeymidnight/yig/coordinator"
 	"github.com/journeymidnight/yig/crypto"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/log"
@@ -30,6 +31,8 @@ var (
 	gcTaskQ     chan types.GarbageCollection
 	gcWaitgroup sync.WaitGroup
 	gcStop      bool
+	// garbage is split between running gc instances by rowkey
+	gcCoordinator *coordinator.Coordinator
 )
 
 func deleteFromCeph(index int) {
@@ -44,6 +47,11 @@ func deleteFromCeph(index int) {
 		)
 		garbage := <-gcTaskQ
 		gcWaitgroup.Add(1)
+		if !gcCoordinator.Owns(garbage.Rowkey) {
+			// taken over by another instance after queued
+			gcWaitgroup.Done()
+			continue
+		}
 		if len(garbage.Parts) == 0 {
 			err = yigs[index].DataStorage[garbage.Location].
 				Remove(garbage.Pool, garbage.ObjectId)
@@ -107,7 +115,9 @@ func removeDeleted() {
 			continue
 		} else if len(garbages) == 1 {
 			for _, garbage := range garbages {
-				gcTaskQ <- garbage
+				if gcCoordinator.Owns(garbage.Rowkey) {
+					gcTaskQ <- garbage
+				}
 			}
 			startRowKey = ""
 			time.Sleep(time.Duration(5000) * time.Millisecond)
@@ -116,7 +126,9 @@ func removeDeleted() {
 			startRowKey = garbages[len(garbages)-1].Rowkey
 			garbages = garbages[:len(garbages)-1]
 			for _, garbage := range garbages {
-				gcTaskQ <- garbage
+				if gcCoordinator.Owns(garbage.Rowkey) {
+					gcTaskQ <- garbage
+				}
 			}
 		}
 	}
@@ -140,10 +152,13 @@ func main() {
 
 	numOfWorkers := helper.CONFIG.GcThread
 	yigs = make([]*storage.YigStorage, helper.CONFIG.GcThread+1)
-	yigs[0] = storage.New(int(meta.NoCache), false, kms)
+	yigs[0] = storage.New(int(meta.NoCache), false, kms, allPluginMap)
+	gcCoordinator = coordinator.New("gc", yigs[0].MetaStorage, helper.CONFIG.CoordinatorPartitions,
+		time.Duration(helper.CONFIG.CoordinatorLeaseTTL)*time.Second)
+	gcCoordinator.Start()
 	helper.Logger.Info("start gc thread:", numOfWorkers)
 	for i := 0; i < numOfWorkers; i++ {
-		yigs[i+1] = storage.New(int(meta.NoCache), false, kms)
+		yigs[i+1] = storage.New(int(meta.NoCache), false, kms, allPluginMap)
 		go deleteFromCeph(i + 1)
 	}
 	go removeDeleted()
@@ -159,6 +174,7 @@ func main() {
 			// gcStop YIG server, order matters
 			gcStop = true
 			gcWaitgroup.Wait()
+			gcCoordinator.Stop()
 			return
 		}
 	}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/coordinator"
 	"github.com/journeymidnight/yig/crypto"
+	. "github.com/journeymidnight/yig/error"
 	"github.com/journeymidnight/yig/helper"
 	"github.com/journeymidnight/yig/iam/common"
 	"github.com/journeymidnight/yig/log"
@@ -15,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -22,6 +27,9 @@ import (
 const (
 	SCAN_LIMIT          = 50
 	DEFAULT_LC_LOG_PATH = "/var/log/yig/lc.log"
+	// leases of runs, apart from leases of lc coordinator
+	LC_RUN_LEASE  = "lc-run"
+	LC_DONE_LEASE = "lc-done/"
 )
 
 var (
@@ -31,10 +39,124 @@ var (
 	waitgroup   sync.WaitGroup
 	empty       bool
 	stop        bool
+	// buckets are split between running lc instances by name
+	lcCoordinator *coordinator.Coordinator
+	// instances running at the same time share a run, until all of them exit
+	runId   string
+	pending sync.WaitGroup // queued buckets of the partition being scanned
+	skipped int32          // buckets of the partition not handled since it's lost
 )
 
-func getLifeCycles() {
+// Join the run of other lc instances, or start a new one if there is none
+func joinRun(ttl time.Duration) error {
+	hostname, _ := os.Hostname()
+	newId := fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano())
+	for i := 0; i < 3; i++ {
+		leases, err := yig.MetaStorage.ListLeases(LC_RUN_LEASE)
+		if err != nil {
+			return err
+		}
+		id := newId
+		for _, lease := range leases {
+			if lease.Name == LC_RUN_LEASE && lease.Expire.After(time.Now()) {
+				id = lease.Owner
+			}
+		}
+		// fails if the run expires meanwhile and another one is started
+		acquired, err := yig.MetaStorage.AcquireLease(LC_RUN_LEASE, id, time.Now().Add(ttl))
+		if err != nil {
+			return err
+		}
+		if acquired {
+			runId = id
+			return nil
+		}
+	}
+	return errors.New("run lease is taken by others")
+}
+
+// The run lease is renewed by every instance of the run, and expires after all exit
+func renewRun(ttl time.Duration) {
+	for !stop {
+		time.Sleep(ttl / 3)
+		acquired, err := yig.MetaStorage.AcquireLease(LC_RUN_LEASE, runId, time.Now().Add(ttl))
+		if err != nil || !acquired {
+			helper.Logger.Warn("Renew lease of lc run", runId, "failed:", err)
+		}
+	}
+}
+
+func doneLease(partition int) string {
+	return LC_DONE_LEASE + strconv.Itoa(partition)
+}
+
+// Partitions done in this run. They are recorded by leases owned by the run,
+// which expire at once so that the next run could take them over.
+func donePartitions() (map[int]bool, error) {
+	leases, err := yig.MetaStorage.ListLeases(LC_DONE_LEASE)
+	if err != nil {
+		return nil, err
+	}
+	done := make(map[int]bool)
+	for _, lease := range leases {
+		if lease.Owner != runId {
+			continue
+		}
+		partition, err := strconv.Atoi(strings.TrimPrefix(lease.Name, LC_DONE_LEASE))
+		if err == nil && partition < helper.CONFIG.CoordinatorPartitions {
+			done[partition] = true
+		}
+	}
+	return done, nil
+}
+
+func markDone(partition int) error {
+	_, err := yig.MetaStorage.AcquireLease(doneLease(partition), runId, time.Now())
+	return err
+}
+
+// Queue buckets of the partition, it's done in this run only if all of them are
+// handled while it's owned, otherwise it's scanned again by its next owner
+func scanPartition(partition int) error {
 	var marker string
+	atomic.StoreInt32(&skipped, 0)
+	for !stop && lcCoordinator.OwnsPartition(partition) {
+		result, err := yig.MetaStorage.ScanLifeCycle(SCAN_LIMIT, marker)
+		if err != nil {
+			pending.Wait()
+			return err
+		}
+		for _, entry := range result.Lcs {
+			marker = entry.BucketName
+			if coordinator.Partition(entry.BucketName, helper.CONFIG.CoordinatorPartitions) != partition {
+				continue
+			}
+			pending.Add(1)
+			taskQ <- entry
+		}
+
+		if result.Truncated == false {
+			pending.Wait()
+			if atomic.LoadInt32(&skipped) != 0 || !lcCoordinator.OwnsPartition(partition) {
+				break
+			}
+			helper.Logger.Info("Lifecycle partition done:", partition)
+			return markDone(partition)
+		}
+	}
+	if stop {
+		return nil
+	}
+	pending.Wait()
+	helper.Logger.Warn("Lost lifecycle partition", partition, "after bucket", marker)
+	return nil
+}
+
+// Scan owned partitions one by one until every partition is done in this run.
+// Partitions being scanned are held, so they are not handed over to instances
+// joined later, while partitions of dead instances are taken over after their
+// leases expire.
+func getLifeCycles() {
 	helper.Logger.Info("all bucket lifecycle handle start")
 	waitgroup.Add(1)
 	defer waitgroup.Done()
@@ -44,24 +166,37 @@ func getLifeCycles() {
 			return
 		}
 
-		result, err := yig.MetaStorage.ScanLifeCycle(SCAN_LIMIT, marker)
+		done, err := donePartitions()
 		if err != nil {
-			helper.Logger.Error("ScanLifeCycle failed:", err)
+			helper.Logger.Error("List done lifecycle partitions failed:", err)
 			signalQueue <- syscall.SIGQUIT
 			return
 		}
-		for _, entry := range result.Lcs {
-			taskQ <- entry
-			marker = entry.BucketName
-		}
-
-		if result.Truncated == false {
+		if len(done) == helper.CONFIG.CoordinatorPartitions {
 			empty = true
 			return
 		}
-
+		scanned := false
+		for _, partition := range lcCoordinator.Partitions() {
+			if done[partition] || !lcCoordinator.Hold(partition) {
+				continue
+			}
+			err = scanPartition(partition)
+			lcCoordinator.Unhold(partition)
+			if err != nil {
+				helper.Logger.Error("ScanLifeCycle failed:", err)
+				signalQueue <- syscall.SIGQUIT
+				return
+			}
+			// others may finish some partitions meanwhile
+			scanned = true
+			break
+		}
+		if !scanned {
+			// wait for partitions owned by others
+			time.Sleep(time.Duration(helper.CONFIG.CoordinatorLeaseTTL) * time.Second / 3)
+		}
 	}
-
 }
 
 func checkIfExpiration(updateTime time.Time, days int) bool {
@@ -72,129 +207,255 @@ func checkIfExpiration(updateTime time.Time, days int) bool {
 	}
 }
 
-// If a rule has an empty prefix, the days in it will be consider as a default days for all objects that not specified in
-// other rules. For this reason, we have two conditions to check if a object has expired and should be deleted
-//  if defaultConfig == true
-//                    for each object           check if object name has a prifix
-//  list all objects --------------->loop rules---------------------------------->
-//                                                                      |     NO
-//                                                                      |--------> days = default days ---
-//                                                                      |     YES                         |->delete object if expired
-//                                                                      |--------> days = specify days ---
-//
-//  if defaultConfig == false
-//                 for each rule get objects by prefix
-//  iterator rules ----------------------------------> loop objects-------->delete object if expired
-func retrieveBucket(lc types.LifeCycle) error {
-	defaultConfig := false
-	defaultDays := 0
-	bucket, err := yig.MetaStorage.GetBucket(lc.BucketName, false)
+func checkIfDateArrived(date string) bool {
+	t, err := datatype.ParseLifecycleDate(date)
+	return err == nil && !time.Now().Before(t)
+}
+
+// Actions are due after `days` since updateTime, or at `date` if it is set
+func isDue(updateTime time.Time, days int, date string) bool {
+	if date != "" {
+		return checkIfDateArrived(date)
+	}
+	return checkIfExpiration(updateTime, days)
+}
+
+// Returns the storage class if it is colder than current one,
+// objects could only transit STANDARD -> STANDARD_IA -> GLACIER
+func colderStorageClass(current types.StorageClass, storageClass string) (types.StorageClass, bool) {
+	target, err := types.MatchStorageClassIndex(storageClass)
 	if err != nil {
-		return err
+		return current, false
 	}
-	rules := bucket.Lifecycle.Rule
-	for _, rule := range rules {
-		if rule.Prefix == "" {
-			defaultConfig = true
-			defaultDays, err = strconv.Atoi(rule.Expiration)
-			if err != nil {
-				return err
+	switch target {
+	case types.ObjectStorageClassGlacier:
+		return target, current != types.ObjectStorageClassGlacier
+	case types.ObjectStorageClassStandardIa:
+		return target, current == types.ObjectStorageClassStandard
+	}
+	return current, false
+}
+
+// Versions protected by object lock are never removed by lifecycle,
+// GOVERNANCE retention is not bypassed either
+func deleteObject(object *types.Object, version string) {
+	_, err := yig.DeleteObject(object.BucketName, object.Name, version, false, common.Credential{})
+	if err == ErrObjectLocked {
+		helper.Logger.Info(object.BucketName, object.Name, version, "is locked, skip deleting")
+		return
+	}
+	if err != nil {
+		helper.Logger.Error(object.BucketName, object.Name, version, "delete failed:", err)
+		return
+	}
+	helper.Logger.Info("Deleted:", object.BucketName, object.Name, version)
+}
+
+func transitObject(object *types.Object, storageClass types.StorageClass) {
+	err := yig.TransitObject(object, storageClass)
+	if err != nil {
+		helper.Logger.Error(object.BucketName, object.Name, object.GetVersionId(),
+			"transit to", storageClass.ToString(), "failed:", err)
+		return
+	}
+	helper.Logger.Info("Transited:", object.BucketName, object.Name, object.GetVersionId(),
+		"to", storageClass.ToString())
+}
+
+// Expiration and Transition actions on current version of an object,
+// a lone delete marker is removed if ExpiredObjectDeleteMarker is set
+func processCurrentVersion(rules []datatype.LifecycleRule, object *types.Object, onlyVersion bool) {
+	if object.DeleteMarker {
+		if !onlyVersion {
+			return
+		}
+		for _, rule := range rules {
+			if rule.Expiration != nil && rule.Expiration.ExpiredObjectDeleteMarker {
+				deleteObject(object, object.GetVersionId())
+				return
 			}
 		}
+		return
 	}
-	var request datatype.ListObjectsRequest
-	request.Versioned = false
-	request.MaxKeys = 1000
-	if defaultConfig == true {
-		for {
-			retObjects, _, truncated, nextMarker, nextVerIdMarker, err := yig.ListObjectsInternal(bucket.Name, request)
-			if err != nil {
-				return err
+	for _, rule := range rules {
+		e := rule.Expiration
+		if e != nil && (e.Days != 0 || e.Date != "") && isDue(object.LastModifiedTime, e.Days, e.Date) {
+			// delete marker is created instead if versioning is enabled
+			deleteObject(object, "")
+			return
+		}
+	}
+	target, transit := object.StorageClass, false
+	for _, rule := range rules {
+		for _, t := range rule.Transitions {
+			if !isDue(object.LastModifiedTime, t.Days, t.Date) {
+				continue
+			}
+			if class, ok := colderStorageClass(target, t.StorageClass); ok {
+				target, transit = class, true
 			}
+		}
+	}
+	if transit {
+		transitObject(object, target)
+	}
+}
 
-			for _, object := range retObjects {
-				prefixMatch := false
-				matchDays := 0
-				for _, rule := range rules {
-					if rule.Prefix == "" {
-						continue
-					}
-					if strings.HasPrefix(object.Name, rule.Prefix) == false {
-						continue
-					}
-					prefixMatch = true
-					matchDays, err = strconv.Atoi(rule.Expiration)
-					if err != nil {
-						return err
-					}
-				}
-				days := 0
-				if prefixMatch == true {
-					days = matchDays
-				} else {
-					days = defaultDays
-				}
-				helper.Logger.Info("inteval:", time.Since(object.LastModifiedTime).Seconds())
-				if checkIfExpiration(object.LastModifiedTime, days) {
-					helper.Logger.Info("come here")
-					if object.NullVersion {
-						object.VersionId = ""
-					}
-					_, err = yig.DeleteObject(object.BucketName, object.Name, object.VersionId, common.Credential{})
-					if err != nil {
-						helper.Logger.Error(object.BucketName, object.Name, object.VersionId, err)
-						continue
-					}
-					helper.Logger.Info("Deleted:", object.BucketName, object.Name, object.VersionId)
-				}
+// NoncurrentVersionExpiration and NoncurrentVersionTransition actions,
+// days are counted from noncurrentTime, when the version became noncurrent
+func processNoncurrentVersion(rules []datatype.LifecycleRule, object *types.Object, noncurrentTime time.Time) {
+	for _, rule := range rules {
+		e := rule.NoncurrentVersionExpiration
+		if e != nil && checkIfExpiration(noncurrentTime, e.NoncurrentDays) {
+			deleteObject(object, object.GetVersionId())
+			return
+		}
+	}
+	if object.DeleteMarker {
+		return
+	}
+	target, transit := object.StorageClass, false
+	for _, rule := range rules {
+		for _, t := range rule.NoncurrentVersionTransitions {
+			if !checkIfExpiration(noncurrentTime, t.NoncurrentDays) {
+				continue
 			}
-			if truncated == true {
-				request.KeyMarker = nextMarker
-				request.VersionIdMarker = nextVerIdMarker
-			} else {
-				break
+			if class, ok := colderStorageClass(target, t.StorageClass); ok {
+				target, transit = class, true
 			}
 		}
-	} else {
+	}
+	if transit {
+		transitObject(object, target)
+	}
+}
+
+// versions are all versions of an object, newest first
+func processObject(rules []datatype.LifecycleRule, versions []*types.Object) {
+	for i, object := range versions {
+		var matched []datatype.LifecycleRule
 		for _, rule := range rules {
-			if rule.Prefix == "" {
-				continue
+			if rule.Match(object.Name, object.Tags) {
+				matched = append(matched, rule)
 			}
-			days, _ := strconv.Atoi(rule.Expiration)
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		if i == 0 {
+			processCurrentVersion(matched, object, len(versions) == 1)
+		} else {
+			processNoncurrentVersion(matched, object, versions[i-1].LastModifiedTime)
+		}
+	}
+}
+
+func abortIncompleteUploads(bucket *types.Bucket, rules []datatype.LifecycleRule) error {
+	var keyMarker, uploadIdMarker string
+	credential := common.Credential{UserId: bucket.OwnerId}
+	for {
+		uploads, _, truncated, nextKeyMarker, nextUploadIdMarker, err :=
+			yig.MetaStorage.Client.ListMultipartUploads(bucket.Name, keyMarker, uploadIdMarker,
+				"", "", "", SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, upload := range uploads {
+			initiated, err := time.Parse(types.CREATE_TIME_LAYOUT, upload.Initiated)
 			if err != nil {
-				return err
+				continue
 			}
-			request.Prefix = rule.Prefix
-			for {
-
-				retObjects, _, truncated, nextMarker, nextVerIdMarker, err := yig.ListObjectsInternal(bucket.Name, request)
-				if err != nil {
-					return err
-				}
-				for _, object := range retObjects {
-					if checkIfExpiration(object.LastModifiedTime, days) {
-						_, err = yig.DeleteObject(object.BucketName, object.Name, object.VersionId, common.Credential{})
-						if err != nil {
-							helper.Logger.Error(object.BucketName, object.Name, object.VersionId, "failed:", err)
-							continue
-						}
-						helper.Logger.Info("Deleted:", object.BucketName, object.Name, object.VersionId)
-					}
+			for _, rule := range rules {
+				a := rule.AbortIncompleteMultipartUpload
+				if a == nil || !rule.MatchUpload(upload.Key) ||
+					!checkIfExpiration(initiated, a.DaysAfterInitiation) {
+					continue
 				}
-				if truncated == true {
-					request.KeyMarker = nextMarker
-					request.VersionIdMarker = nextVerIdMarker
+				err = yig.AbortMultipartUpload(credential, bucket.Name, upload.Key, upload.UploadId)
+				if err != nil {
+					helper.Logger.Error(bucket.Name, upload.Key, upload.UploadId, "abort failed:", err)
 				} else {
-					break
+					helper.Logger.Info("Aborted:", bucket.Name, upload.Key, upload.UploadId)
 				}
-
+				break
 			}
 		}
+		if !truncated {
+			return nil
+		}
+		keyMarker, uploadIdMarker = nextKeyMarker, nextUploadIdMarker
+	}
+}
+
+// Scan all object versions of the bucket, and execute actions of enabled rules
+// matching each version:
+//  current version:    Expiration, Transition
+//  noncurrent version: NoncurrentVersionExpiration, NoncurrentVersionTransition
+//  multipart upload:   AbortIncompleteMultipartUpload
+func retrieveBucket(lc types.LifeCycle) error {
+	bucket, err := yig.MetaStorage.GetBucket(lc.BucketName, false)
+	if err != nil {
+		return err
+	}
+	var rules []datatype.LifecycleRule
+	var abortUploads bool
+	for _, rule := range bucket.Lifecycle.Rule {
+		if rule.Enabled() {
+			rules = append(rules, rule)
+			abortUploads = abortUploads || rule.AbortIncompleteMultipartUpload != nil
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	if abortUploads {
+		err = abortIncompleteUploads(bucket, rules)
+		if err != nil {
+			return err
+		}
+	}
 
+	var keyMarker string
+	var versionMarker uint64
+	var versions []*types.Object
+	for {
+		objects, nextKeyMarker, nextVersionMarker, truncated, err :=
+			yig.MetaStorage.ScanObjectVersions(bucket.Name, keyMarker, versionMarker, SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if len(versions) != 0 && versions[0].Name != object.Name {
+				processObject(rules, versions)
+				versions = nil
+			}
+			versions = append(versions, object)
+		}
+		if !truncated {
+			break
+		}
+		keyMarker, versionMarker = nextKeyMarker, nextVersionMarker
+	}
+	if len(versions) != 0 {
+		processObject(rules, versions)
 	}
 	return nil
 }
 
+func handleBucket(item types.LifeCycle) {
+	if !lcCoordinator.Owns(item.BucketName) {
+		// taken over by another instance after queued
+		atomic.AddInt32(&skipped, 1)
+		return
+	}
+	err := retrieveBucket(item)
+	if err != nil {
+		helper.Logger.Error("Bucket", item.BucketName, "retrieve error:", err)
+		return
+	}
+	helper.Logger.Info("Bucket lifecycle done:", item.BucketName)
+}
+
 func processLifecycle() {
 	time.Sleep(time.Second * 1)
 	for {
@@ -205,13 +466,8 @@ func processLifecycle() {
 		waitgroup.Add(1)
 		select {
 		case item := <-taskQ:
-			err := retrieveBucket(item)
-			if err != nil {
-				helper.Logger.Error("Bucket", item.BucketName, "retrieve error:", err)
-				waitgroup.Done()
-				continue
-			}
-			helper.Logger.Info("Bucket lifecycle done:", item.BucketName)
+			handleBucket(item)
+			pending.Done()
 		default:
 			if empty == true {
 				helper.Logger.Info("All bucket lifecycle handle complete. QUIT")
@@ -241,7 +497,17 @@ func main() {
 	allPluginMap := mods.InitialPlugins()
 	kms := crypto.NewKMS(allPluginMap)
 
-	yig = storage.New(helper.CONFIG.MetaCacheType, helper.CONFIG.EnableDataCache, kms)
+	yig = storage.New(helper.CONFIG.MetaCacheType, helper.CONFIG.EnableDataCache, kms, allPluginMap)
+	ttl := time.Duration(helper.CONFIG.CoordinatorLeaseTTL) * time.Second
+	err := joinRun(ttl)
+	if err != nil {
+		helper.Logger.Error("Join lc run failed:", err)
+		return
+	}
+	helper.Logger.Info("Joined lc run", runId)
+	go renewRun(ttl)
+	lcCoordinator = coordinator.New("lc", yig.MetaStorage, helper.CONFIG.CoordinatorPartitions, ttl)
+	lcCoordinator.Start()
 	taskQ = make(chan types.LifeCycle, SCAN_LIMIT)
 	signal.Ignore()
 	signalQueue = make(chan os.Signal)
@@ -265,6 +531,7 @@ func main() {
 			// stop YIG server, order matters
 			stop = true
 			waitgroup.Wait()
+			lcCoordinator.Stop()
 			return
 		}
 	}
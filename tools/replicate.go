@@ -0,0 +1,304 @@
+package main
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/journeymidnight/aws-sdk-go/aws"
+	"github.com/journeymidnight/aws-sdk-go/aws/credentials"
+	"github.com/journeymidnight/aws-sdk-go/aws/request"
+	"github.com/journeymidnight/aws-sdk-go/aws/session"
+	"github.com/journeymidnight/aws-sdk-go/service/s3"
+	"github.com/journeymidnight/aws-sdk-go/service/s3/s3manager"
+	"github.com/journeymidnight/yig/api/datatype"
+	"github.com/journeymidnight/yig/crypto"
+	. "github.com/journeymidnight/yig/error"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/mods"
+	"github.com/journeymidnight/yig/redis"
+	"github.com/journeymidnight/yig/storage"
+)
+
+const (
+	SCAN_LIMIT                   = 50
+	SCAN_INTERVAL                = 10 * time.Second
+	RETRY_BASE_INTERVAL          = 10 * time.Second
+	RETRY_MAX_INTERVAL           = time.Hour
+	DEFAULT_REPLICATION_LOG_PATH = "/var/log/yig/replicate.log"
+)
+
+var (
+	yig         *storage.YigStorage
+	taskQ       chan types.ReplicationTask
+	signalQueue chan os.Signal
+	waitgroup   sync.WaitGroup
+	batch       sync.WaitGroup // tasks of current scan batch
+	stop        bool
+
+	uploaders     = make(map[string]*s3manager.Uploader) // keyed by destination region
+	uploadersLock sync.Mutex
+)
+
+// Scan due tasks from `replication` table batch by batch, a batch is scanned only after
+// the previous one is done, so a task would never be processed by two workers
+func scanReplication() {
+	helper.Logger.Info("replication scan start")
+	defer waitgroup.Done()
+	for {
+		if stop {
+			helper.Logger.Info("shutting down...")
+			return
+		}
+
+		tasks, err := yig.MetaStorage.ScanReplication(SCAN_LIMIT, time.Now())
+		if err != nil {
+			helper.Logger.Error("ScanReplication failed:", err)
+			signalQueue <- syscall.SIGQUIT
+			return
+		}
+		if len(tasks) == 0 {
+			time.Sleep(SCAN_INTERVAL)
+			continue
+		}
+		batch.Add(len(tasks))
+		for _, task := range tasks {
+			taskQ <- task
+		}
+		batch.Wait()
+	}
+}
+
+func getUploader(region string) (*s3manager.Uploader, error) {
+	uploadersLock.Lock()
+	defer uploadersLock.Unlock()
+	if uploader, ok := uploaders[region]; ok {
+		return uploader, nil
+	}
+	target, ok := helper.CONFIG.ReplicationTargets[region]
+	if !ok {
+		return nil, ErrInvalidReplicationConfiguration
+	}
+	sess, err := session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials(target.AccessKey, target.SecretKey, ""),
+		Endpoint:         aws.String(target.Endpoint),
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	uploader := s3manager.NewUploaderWithClient(s3.New(sess))
+	uploaders[region] = uploader
+	return uploader, nil
+}
+
+// Mark the request as a replica, so destination cluster would not replicate it again
+func withReplicaHeader(r *request.Request) {
+	r.HTTPRequest.Header.Set(datatype.ReplicationStatusHeader, types.ReplicationStatusReplica)
+}
+
+func uploadInput(object *types.Object, bucketName, storageClass string) *s3manager.UploadInput {
+	input := &s3manager.UploadInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(object.Name),
+		ContentType: aws.String(object.ContentType),
+		Metadata:    make(map[string]*string),
+	}
+	for key, value := range object.CustomAttributes {
+		v := aws.String(value)
+		switch strings.ToLower(key) {
+		case "cache-control":
+			input.CacheControl = v
+		case "content-disposition":
+			input.ContentDisposition = v
+		case "content-encoding":
+			input.ContentEncoding = v
+		case "content-language":
+			input.ContentLanguage = v
+		case "website-redirect-location":
+			input.WebsiteRedirectLocation = v
+		default:
+			if strings.HasPrefix(strings.ToLower(key), "x-amz-meta-") {
+				input.Metadata[key[len("x-amz-meta-"):]] = v
+			}
+		}
+	}
+	if len(object.Tags) != 0 {
+		tags := url.Values{}
+		for key, value := range object.Tags {
+			tags.Set(key, value)
+		}
+		input.Tagging = aws.String(tags.Encode())
+	}
+	if storageClass != "" {
+		input.StorageClass = aws.String(storageClass)
+	}
+	return input
+}
+
+func replicate(object *types.Object, rule *datatype.ReplicationRule) error {
+	region, bucketName, err := datatype.ParseReplicationDestination(rule.Destination.Bucket)
+	if err != nil {
+		return err
+	}
+	uploader, err := getUploader(region)
+	if err != nil {
+		return err
+	}
+	storageClass := rule.Destination.StorageClass
+	if storageClass == "" {
+		storageClass = object.StorageClass.ToString()
+	}
+	input := uploadInput(object, bucketName, storageClass)
+
+	reader, writer := io.Pipe()
+	go func() {
+		err := yig.GetObject(object, 0, object.Size, writer, datatype.SseRequest{})
+		writer.CloseWithError(err)
+	}()
+	input.Body = reader
+	_, err = uploader.Upload(input, s3manager.WithUploaderRequestOptions(withReplicaHeader))
+	reader.Close()
+	return err
+}
+
+func processTask(task types.ReplicationTask) error {
+	version := strconv.FormatUint(task.Version, 10)
+	object, err := yig.MetaStorage.Client.GetObject(task.BucketName, task.ObjectName, version)
+	if err == ErrNoSuchKey {
+		helper.Logger.Info("Object", task.BucketName, task.ObjectName, version,
+			"no longer exists, skip replication")
+		return yig.MetaStorage.RemoveReplicationTask(task)
+	}
+	if err != nil {
+		return err
+	}
+	bucket, err := yig.MetaStorage.GetBucket(task.BucketName, false)
+	if err != nil && err != ErrNoSuchBucket {
+		return err
+	}
+	var rule *datatype.ReplicationRule
+	if bucket != nil {
+		rule = bucket.Replication.Match(object.Name)
+	}
+	// SSE-C objects could never be decrypted without the customer key
+	if rule == nil || object.SseType == crypto.SSEC.String() {
+		helper.Logger.Warn("Object", task.BucketName, task.ObjectName, version,
+			"could not be replicated")
+		object.ReplicationStatus = types.ReplicationStatusFailed
+		err = yig.MetaStorage.UpdateObjectReplicationStatus(object)
+		if err != nil {
+			return err
+		}
+		return yig.MetaStorage.RemoveReplicationTask(task)
+	}
+
+	err = replicate(object, rule)
+	if err != nil {
+		helper.Logger.Error("Replicate", task.BucketName, task.ObjectName, version,
+			"failed, tried times:", task.TriedTimes+1, "error:", err)
+		if object.ReplicationStatus != types.ReplicationStatusFailed {
+			object.ReplicationStatus = types.ReplicationStatusFailed
+			yig.MetaStorage.UpdateObjectReplicationStatus(object)
+		}
+		// retry with exponential backoff
+		interval := RETRY_MAX_INTERVAL
+		if task.TriedTimes < 10 {
+			interval = RETRY_BASE_INTERVAL << uint(task.TriedTimes)
+			if interval > RETRY_MAX_INTERVAL {
+				interval = RETRY_MAX_INTERVAL
+			}
+		}
+		task.TriedTimes += 1
+		task.NextTime = time.Now().Add(interval)
+		return yig.MetaStorage.UpdateReplicationTask(task)
+	}
+
+	helper.Logger.Info("Replicate", task.BucketName, task.ObjectName, version, "done")
+	object.ReplicationStatus = types.ReplicationStatusCompleted
+	err = yig.MetaStorage.UpdateObjectReplicationStatus(object)
+	if err != nil {
+		return err
+	}
+	return yig.MetaStorage.RemoveReplicationTask(task)
+}
+
+// Workers drain the task queue before quitting, so the scanner waiting for
+// current batch could always return
+func processReplication() {
+	for {
+		select {
+		case task := <-taskQ:
+			err := processTask(task)
+			if err != nil {
+				helper.Logger.Error("Replication task", task.BucketName, task.ObjectName, task.Version,
+					"error:", err)
+			}
+			batch.Done()
+		case <-time.After(time.Second):
+			if stop {
+				helper.Logger.Info("Shutting down...")
+				return
+			}
+		}
+	}
+}
+
+func main() {
+	stop = false
+
+	helper.SetupConfig()
+	logLevel := log.ParseLevel(helper.CONFIG.LogLevel)
+
+	helper.Logger = log.NewFileLogger(DEFAULT_REPLICATION_LOG_PATH, logLevel)
+	defer helper.Logger.Close()
+	if helper.CONFIG.MetaCacheType > 0 {
+		redis.Initialize()
+		defer redis.Close()
+	}
+
+	// Read all *.so from plugins directory, and fill the variable allPlugins
+	allPluginMap := mods.InitialPlugins()
+	kms := crypto.NewKMS(allPluginMap)
+
+	// every object version is read only once, no need for data cache
+	yig = storage.New(helper.CONFIG.MetaCacheType, false, kms, allPluginMap)
+	taskQ = make(chan types.ReplicationTask, SCAN_LIMIT)
+	signal.Ignore()
+	signalQueue = make(chan os.Signal, 1)
+
+	numOfWorkers := helper.CONFIG.ReplicationThread
+	helper.Logger.Info("start replication thread:", numOfWorkers)
+	for i := 0; i < numOfWorkers; i++ {
+		go processReplication()
+	}
+	waitgroup.Add(1)
+	go scanReplication()
+	signal.Notify(signalQueue, syscall.SIGINT, syscall.SIGTERM,
+		syscall.SIGQUIT, syscall.SIGHUP)
+	for {
+		s := <-signalQueue
+		switch s {
+		case syscall.SIGHUP:
+			// reload config file
+			helper.SetupConfig()
+			uploadersLock.Lock()
+			uploaders = make(map[string]*s3manager.Uploader)
+			uploadersLock.Unlock()
+		default:
+			// stop replication, order matters
+			stop = true
+			waitgroup.Wait()
+			return
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/journeymidnight/yig/coordinator"
+	"github.com/journeymidnight/yig/crypto"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/meta/types"
+	"github.com/journeymidnight/yig/mods"
+	bus "github.com/journeymidnight/yig/mq"
+	"github.com/journeymidnight/yig/redis"
+	"github.com/journeymidnight/yig/storage"
+)
+
+const (
+	SCAN_LIMIT               = 100
+	SCAN_INTERVAL            = 10 * time.Second
+	DEFAULT_RESTORE_LOG_PATH = "/var/log/yig/restore.log"
+)
+
+var (
+	yig         *storage.YigStorage
+	signalQueue chan os.Signal
+	waitgroup   sync.WaitGroup
+	stop        bool
+	// pending restore requests of each tier
+	queues = map[types.RestoreTier]chan types.Freezer{
+		types.RestoreTierExpedited: make(chan types.Freezer, SCAN_LIMIT),
+		types.RestoreTierStandard:  make(chan types.Freezer, SCAN_LIMIT),
+		types.RestoreTierBulk:      make(chan types.Freezer, SCAN_LIMIT),
+	}
+	// workers take Expedited requests first, then Standard, then Bulk
+	tierPriority = []types.RestoreTier{
+		types.RestoreTierExpedited,
+		types.RestoreTierStandard,
+		types.RestoreTierBulk,
+	}
+	// objects queued or being restored, so they are not queued again by next scan
+	queued      = make(map[string]bool)
+	queuedMutex sync.Mutex
+	// objects are split between running restore instances by bucket, object name and version
+	restoreCoordinator *coordinator.Coordinator
+)
+
+func freezerKey(freezer types.Freezer) string {
+	return freezer.BucketName + "/" + freezer.Name + "/" + freezer.VersionId
+}
+
+func enqueue(freezer types.Freezer) {
+	key := freezerKey(freezer)
+	queue, ok := queues[freezer.Tier]
+	if !ok {
+		queue = queues[types.RestoreTierStandard]
+	}
+	queuedMutex.Lock()
+	defer queuedMutex.Unlock()
+	if queued[key] {
+		return
+	}
+	select {
+	case queue <- freezer:
+		queued[key] = true
+	default:
+		// queue is full, the request is picked up again by next scan
+	}
+}
+
+func dequeue() (freezer types.Freezer, ok bool) {
+	for _, tier := range tierPriority {
+		select {
+		case freezer = <-queues[tier]:
+			return freezer, true
+		default:
+		}
+	}
+	return freezer, false
+}
+
+func done(freezer types.Freezer) {
+	queuedMutex.Lock()
+	delete(queued, freezerKey(freezer))
+	queuedMutex.Unlock()
+}
+
+func restoreObject(freezer types.Freezer) {
+	defer done(freezer)
+	if !restoreCoordinator.Owns(freezerKey(freezer)) {
+		return
+	}
+	start := time.Now()
+	err := yig.RestoreObject(&freezer)
+	if err != nil {
+		helper.Logger.Error("Restore", freezer.BucketName, freezer.Name,
+			"tier:", freezer.Tier.ToString(), "failed:", err)
+		return
+	}
+	if freezer.Status == types.ObjectHasRestored {
+		helper.Logger.Info("Restored:", freezer.BucketName, freezer.Name,
+			"tier:", freezer.Tier.ToString(), "in", time.Since(start),
+			"expires at", freezer.ExpireTime())
+	}
+}
+
+func processRestore() {
+	defer waitgroup.Done()
+	for !stop {
+		freezer, ok := dequeue()
+		if !ok {
+			time.Sleep(time.Second)
+			continue
+		}
+		restoreObject(freezer)
+	}
+}
+
+func expireRestoredObject(freezer types.Freezer) {
+	expired, err := yig.ExpireRestoredObject(&freezer)
+	if err != nil {
+		helper.Logger.Error("Expire restored", freezer.BucketName, freezer.Name, "failed:", err)
+		return
+	}
+	if expired {
+		helper.Logger.Info("Expired restored:", freezer.BucketName, freezer.Name)
+	}
+}
+
+// Queue requests of one status owned by this instance, or expire restored
+// copies for ObjectHasRestored
+func scanFreezers(status types.Status) error {
+	var bucketMarker, objectMarker, versionMarker string
+	for !stop {
+		freezers, err := yig.MetaStorage.ScanFreezers(status, bucketMarker, objectMarker, versionMarker,
+			SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, freezer := range freezers {
+			if !restoreCoordinator.Owns(freezerKey(freezer)) {
+				continue
+			}
+			if status != types.ObjectHasRestored {
+				enqueue(freezer)
+			} else if freezer.Expired(now) {
+				expireRestoredObject(freezer)
+			}
+		}
+		if len(freezers) < SCAN_LIMIT {
+			return nil
+		}
+		last := freezers[len(freezers)-1]
+		bucketMarker, objectMarker, versionMarker = last.BucketName, last.Name, last.VersionId
+	}
+	return nil
+}
+
+// Requests left in ObjectRestoring by a dead instance are restored again
+func scanRestores() {
+	defer waitgroup.Done()
+	for !stop {
+		for _, status := range []types.Status{types.ObjectNeedRestore,
+			types.ObjectRestoring, types.ObjectHasRestored} {
+			err := scanFreezers(status)
+			if err != nil {
+				helper.Logger.Error("ScanFreezers failed:", err)
+			}
+		}
+		for i := time.Duration(0); i < SCAN_INTERVAL && !stop; i += time.Second {
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func main() {
+	stop = false
+
+	helper.SetupConfig()
+	logLevel := log.ParseLevel(helper.CONFIG.LogLevel)
+
+	helper.Logger = log.NewFileLogger(DEFAULT_RESTORE_LOG_PATH, logLevel)
+	defer helper.Logger.Close()
+	if helper.CONFIG.MetaCacheType > 0 || helper.CONFIG.EnableDataCache {
+		redis.Initialize()
+		defer redis.Close()
+	}
+
+	// Read all *.so from plugins directory, and fill the variable allPlugins
+	allPluginMap := mods.InitialPlugins()
+	kms := crypto.NewKMS(allPluginMap)
+
+	// ObjectRestore:Completed events are sent by this daemon
+	mqSender, err := bus.InitMessageSender(allPluginMap)
+	if err != nil {
+		helper.Logger.Error("Failed to create message queue sender, err:", err)
+		panic("failed to create message bus sender")
+	}
+	defer mqSender.Close()
+
+	yig = storage.New(helper.CONFIG.MetaCacheType, helper.CONFIG.EnableDataCache, kms, allPluginMap)
+	restoreCoordinator = coordinator.New("restore", yig.MetaStorage, helper.CONFIG.CoordinatorPartitions,
+		time.Duration(helper.CONFIG.CoordinatorLeaseTTL)*time.Second)
+	restoreCoordinator.Start()
+	signal.Ignore()
+	signalQueue = make(chan os.Signal, 1)
+
+	numOfWorkers := helper.CONFIG.RestoreThread
+	helper.Logger.Info("start restore thread:", numOfWorkers)
+	for i := 0; i < numOfWorkers; i++ {
+		waitgroup.Add(1)
+		go processRestore()
+	}
+	waitgroup.Add(1)
+	go scanRestores()
+	signal.Notify(signalQueue, syscall.SIGINT, syscall.SIGTERM,
+		syscall.SIGQUIT, syscall.SIGHUP)
+	for {
+		s := <-signalQueue
+		switch s {
+		case syscall.SIGHUP:
+			// reload config file
+			helper.SetupConfig()
+		default:
+			// stop restore daemon, wait for restores in progress
+			stop = true
+			waitgroup.Wait()
+			restoreCoordinator.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/journeymidnight/yig/crypto"
+	"github.com/journeymidnight/yig/helper"
+	"github.com/journeymidnight/yig/log"
+	"github.com/journeymidnight/yig/meta"
+	"github.com/journeymidnight/yig/storage"
+)
+
+const (
+	SCAN_LIMIT              = 1000
+	DEFAULT_REWRAP_LOG_PATH = "/var/log/yig/rewrap.log"
+	LOCAL_KMS_PLUGIN_NAME   = "local_kms"
+)
+
+var (
+	kms      *crypto.LocalKMS
+	metadata *meta.Meta
+	dryRun   bool
+
+	scanned, rewrapped, failed int
+)
+
+// Re-seal a data key with the latest master key version, returns the new sealed key
+// or nil if it's already up to date
+func rewrapKey(sseType, kmsKeyId, sseContext, bucketName, objectName string,
+	sealedKey []byte) (newSealedKey []byte, err error) {
+
+	scanned++
+	keyID, context, err := storage.SealingContext(sseType, kmsKeyId, sseContext, bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	newSealedKey, ok, err := kms.RewrapKey(keyID, sealedKey, context)
+	if err != nil || !ok {
+		return nil, err
+	}
+	rewrapped++
+	return newSealedKey, nil
+}
+
+func isSealed(sseType string, sealedKey []byte) bool {
+	return (sseType == crypto.S3.String() || sseType == crypto.S3KMS.String()) &&
+		len(sealedKey) != 0
+}
+
+func rewrapObjects(bucketName string) error {
+	var keyMarker string
+	var versionMarker uint64
+	for {
+		objects, nextKeyMarker, nextVersionMarker, truncated, err :=
+			metadata.ScanObjectVersions(bucketName, keyMarker, versionMarker, SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if !isSealed(object.SseType, object.EncryptionKey) {
+				continue
+			}
+			sealedKey, err := rewrapKey(object.SseType, object.SseKmsKeyId, object.SseContext,
+				object.BucketName, object.Name, object.EncryptionKey)
+			if err == nil && sealedKey != nil && !dryRun {
+				object.EncryptionKey = sealedKey
+				err = metadata.UpdateObjectEncryptionKey(object)
+			}
+			if err != nil {
+				failed++
+				helper.Logger.Error("Rewrap key of object", object.BucketName, object.Name,
+					object.GetVersionId(), "error:", err)
+			}
+		}
+		if !truncated {
+			return nil
+		}
+		keyMarker, versionMarker = nextKeyMarker, nextVersionMarker
+	}
+}
+
+// Multipart uploads in progress keep the sealed key to be saved with the object
+// when completed, parts themselves hold no keys
+func rewrapMultiparts(bucketName string) error {
+	var keyMarker, uploadIdMarker string
+	for {
+		uploads, _, truncated, nextKeyMarker, nextUploadIdMarker, err :=
+			metadata.Client.ListMultipartUploads(bucketName, keyMarker, uploadIdMarker,
+				"", "", "", SCAN_LIMIT)
+		if err != nil {
+			return err
+		}
+		for _, upload := range uploads {
+			multipart, err := metadata.GetMultipart(bucketName, upload.Key, upload.UploadId)
+			if err != nil {
+				helper.Logger.Error("Get multipart upload", bucketName, upload.Key,
+					upload.UploadId, "error:", err)
+				continue
+			}
+			sseRequest := multipart.Metadata.SseRequest
+			if !isSealed(sseRequest.Type, multipart.Metadata.CipherKey) {
+				continue
+			}
+			sealedKey, err := rewrapKey(sseRequest.Type, sseRequest.SseAwsKmsKeyId,
+				sseRequest.SseContext, bucketName, upload.Key, multipart.Metadata.CipherKey)
+			if err == nil && sealedKey != nil && !dryRun {
+				multipart.Metadata.CipherKey = sealedKey
+				err = metadata.UpdateMultipartCipherKey(multipart)
+			}
+			if err != nil {
+				failed++
+				helper.Logger.Error("Rewrap key of multipart upload", bucketName, upload.Key,
+					upload.UploadId, "error:", err)
+			}
+		}
+		if !truncated {
+			return nil
+		}
+		keyMarker, uploadIdMarker = nextKeyMarker, nextUploadIdMarker
+	}
+}
+
+func localKMSArg(name string) string {
+	if plugin, ok := helper.CONFIG.Plugins[LOCAL_KMS_PLUGIN_NAME]; ok {
+		value, _ := plugin.Args[name].(string)
+		return value
+	}
+	return ""
+}
+
+// Re-seal data keys of all SSE-S3 and SSE-KMS objects and multipart uploads with the
+// latest master key versions of the local KMS keyring, object data is not touched.
+// Previous master key versions are kept in the keyring, so yig could read objects
+// during and after the rewrap, including those in metadata cache.
+func main() {
+	helper.SetupConfig()
+
+	defaultKeyID := localKMSArg("key_id")
+	if defaultKeyID == "" {
+		defaultKeyID = "yig"
+	}
+	keyringPath := flag.String("keyring", localKMSArg("path"), "path of local KMS keyring file")
+	keyID := flag.String("key-id", defaultKeyID, "default key ID, used by SSE-S3")
+	rotate := flag.Bool("rotate", false, "add a new version to every master key before rewrapping")
+	flag.BoolVar(&dryRun, "dry-run", false, "count keys to rewrap without updating them")
+	flag.Parse()
+
+	logLevel := log.ParseLevel(helper.CONFIG.LogLevel)
+	helper.Logger = log.NewFileLogger(DEFAULT_REWRAP_LOG_PATH, logLevel)
+	defer helper.Logger.Close()
+
+	var err error
+	kms, err = crypto.NewLocalKMS(*keyringPath, *keyID, false)
+	if err != nil {
+		fmt.Println("Load KMS keyring failed:", err)
+		os.Exit(1)
+	}
+	if *rotate && !dryRun {
+		keyIDs, err := kms.KeyIDs()
+		if err != nil {
+			fmt.Println("Read KMS keyring failed:", err)
+			os.Exit(1)
+		}
+		for _, id := range keyIDs {
+			version, err := kms.RotateKey(id)
+			if err != nil {
+				fmt.Println("Rotate key", id, "failed:", err)
+				os.Exit(1)
+			}
+			fmt.Println("Rotated key", id, "to version", version)
+		}
+	}
+
+	metadata = meta.New(meta.NoCache)
+	buckets, err := metadata.GetBuckets()
+	if err != nil {
+		fmt.Println("List buckets failed:", err)
+		os.Exit(1)
+	}
+	for _, bucket := range buckets {
+		for _, rewrap := range []func(string) error{rewrapObjects, rewrapMultiparts} {
+			err = rewrap(bucket.Name)
+			if err != nil {
+				fmt.Println("Rewrap keys of bucket", bucket.Name, "failed:", err)
+				os.Exit(1)
+			}
+		}
+	}
+	if dryRun {
+		fmt.Println("Dry run, no key is updated")
+	}
+	fmt.Println("Scanned:", scanned, "rewrapped:", rewrapped, "failed:", failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}